@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/validation"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+func GetApprovalPolicy(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	policy, err := service.GetApprovalPolicy(ctx, user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, policy)
+	return
+}
+
+func UpdateApprovalPolicy(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil || user.UserType != constants.UserTypeAdminStr {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ApprovalPolicyRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	policy := &model.ApprovalPolicy{
+		OrgId:          user.OrgId,
+		Enabled:        req.Enabled,
+		MaxExpandCount: req.MaxExpandCount,
+		MaxMonthlyCost: req.MaxMonthlyCost,
+		ExpireHours:    req.ExpireHours,
+		UpdateBy:       user.Name,
+	}
+	if err := service.SaveApprovalPolicy(ctx, policy, user.UserId); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func ListTaskApprovals(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ListApprovalRequest{}
+	if err := ctx.BindQuery(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	approvals, total, err := service.ListTaskApprovals(ctx, model.ApprovalSearchCond{
+		OrgId:       user.OrgId,
+		ClusterName: req.ClusterName,
+		Status:      req.Status,
+		PageNumber:  req.PageNumber,
+		PageSize:    req.PageSize,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	uids := make([]int64, 0, 2*len(approvals))
+	for _, approval := range approvals {
+		uids = append(uids, approval.Applicant, approval.Approver)
+	}
+	resp := &response.TaskApprovalListResponse{
+		ApprovalList: helper.ConvertToTaskApprovalList(approvals, service.UserMapByIDs(ctx, uids)),
+		Pager: response.Pager{
+			PageNumber: req.PageNumber,
+			PageSize:   req.PageSize,
+			Total:      int(total),
+		},
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
+	return
+}
+
+func ApproveTask(ctx *gin.Context) {
+	handleReviewTask(ctx, service.ApproveTask)
+}
+
+func RejectTask(ctx *gin.Context) {
+	handleReviewTask(ctx, service.RejectTask)
+}
+
+func handleReviewTask(ctx *gin.Context, review func(ctx context.Context, orgId, uid, taskId int64, comment string) error) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil || user.UserType != constants.UserTypeAdminStr {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ReviewTaskRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if err := review(ctx, user.OrgId, user.UserId, cast.ToInt64(req.TaskId), req.Comment); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -17,6 +16,7 @@ import (
 	"github.com/galaxy-future/BridgX/internal/service"
 	"github.com/galaxy-future/BridgX/internal/types"
 	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
 	"github.com/gin-gonic/gin"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/spf13/cast"
@@ -239,7 +239,7 @@ func CreateCluster(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	m, err := convertToClusterModel(&clusterInput)
+	m, err := service.ConvertToClusterModel(&clusterInput)
 	if err != nil {
 		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), err)
 		return
@@ -375,7 +375,7 @@ func EditCluster(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
-	m, err := convertToClusterModel(&clusterInput)
+	m, err := service.ConvertToClusterModel(&clusterInput)
 	if err != nil {
 		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), err)
 		return
@@ -436,49 +436,6 @@ func convertCustomClusterInstances(instanceList []model.CustomClusterInstance, c
 	return ret, nil
 }
 
-func convertToClusterModel(clusterInput *types.ClusterInfo) (*model.Cluster, error) {
-	ic := ""
-	if clusterInput.ImageConfig != nil {
-		ic, _ = jsoniter.MarshalToString(clusterInput.ImageConfig)
-	}
-	ec := ""
-	if clusterInput.ExtendConfig != nil {
-		ec, _ = jsoniter.MarshalToString(clusterInput.ExtendConfig)
-	}
-	if clusterInput.NetworkConfig == nil {
-		return nil, errors.New("missing network config")
-	}
-	if clusterInput.StorageConfig == nil {
-		return nil, errors.New("missing storage config")
-	}
-	if clusterInput.ChargeConfig == nil {
-		return nil, errors.New("missing charge config")
-	}
-	nc, _ := jsoniter.MarshalToString(clusterInput.NetworkConfig)
-	sc, _ := jsoniter.MarshalToString(clusterInput.StorageConfig)
-	cc, _ := jsoniter.MarshalToString(clusterInput.ChargeConfig)
-	m := model.Cluster{
-		ClusterName:   clusterInput.Name,
-		ClusterDesc:   clusterInput.Desc,
-		ClusterType:   constants.ClusterTypeStandard,
-		RegionId:      clusterInput.RegionId,
-		ZoneId:        clusterInput.ZoneId,
-		InstanceType:  clusterInput.InstanceType,
-		Image:         clusterInput.Image,
-		Password:      clusterInput.Password,
-		Provider:      clusterInput.Provider,
-		AccountKey:    clusterInput.AccountKey,
-		KeyId:         clusterInput.KeyId,
-		AuthType:      clusterInput.AuthType,
-		ImageConfig:   ic,
-		NetworkConfig: nc,
-		StorageConfig: sc,
-		ChargeConfig:  cc,
-		ExtendConfig:  ec,
-	}
-	return &m, nil
-}
-
 func AddClusterTags(ctx *gin.Context) {
 	req := request.TagRequest{}
 	err := ctx.Bind(&req)
@@ -624,6 +581,164 @@ func ExpandCluster(ctx *gin.Context) {
 	return
 }
 
+func PlanExpandCluster(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ExpandPlanRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	plan, err := service.PlanExpand(ctx, req.ClusterName, req.Count)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, plan)
+	return
+}
+
+func PreviewAdoptInstances(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.AdoptInstancesRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	preview, err := service.PreviewAdoptInstances(ctx, convertToAdoptInstancesParam(&req))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, preview)
+	return
+}
+
+func AdoptInstances(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.AdoptInstancesRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	result, err := service.AdoptInstances(ctx, convertToAdoptInstancesParam(&req))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, result)
+	return
+}
+
+func convertToAdoptInstancesParam(req *request.AdoptInstancesRequest) service.AdoptInstancesParam {
+	tags := make([]cloud.Tag, 0, len(req.Tags))
+	for k, v := range req.Tags {
+		tags = append(tags, cloud.Tag{Key: k, Value: v})
+	}
+	return service.AdoptInstancesParam{
+		ClusterName: req.ClusterName,
+		InstanceIds: req.InstanceIds,
+		Tags:        tags,
+		VpcId:       req.VpcId,
+	}
+}
+
+func ExportClusterSpecs(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ExportClusterSpecRequest{}
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	var names []string
+	if req.Names != "" {
+		names = strings.Split(req.Names, ",")
+	}
+	document, err := service.ExportClusterSpecs(ctx, accountKeys, names)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, string(document))
+	return
+}
+
+func PlanClusterSpecs(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ClusterSpecRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	changes, err := service.PlanClusterSpecs(ctx, accountKeys, []byte(req.Document))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, changes)
+	return
+}
+
+func ApplyClusterSpecs(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ClusterSpecRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	changes, err := service.ApplyClusterSpecs(ctx, accountKeys, []byte(req.Document), user.Name, user.UserId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), changes)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, changes)
+	return
+}
+
 func ShrinkCluster(ctx *gin.Context) {
 	user := helper.GetUserClaims(ctx)
 	if user == nil {
@@ -636,7 +751,35 @@ func ShrinkCluster(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
 		return
 	}
-	taskId, err := service.CreateShrinkTask(ctx, req.ClusterName, req.Count, strings.Join(req.IPs, ","), req.TaskName, user.UserId)
+	taskId, err := service.CreateShrinkTask(ctx, req.ClusterName, req.Count, strings.Join(req.IPs, ","), req.ShrinkStrategy, req.TaskName, user.UserId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, taskId)
+	return
+}
+
+func RollCluster(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.RollClusterRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	taskId, err := service.CreateRollTask(ctx, service.RollTaskParam{
+		ClusterName:    req.ClusterName,
+		Image:          req.Image,
+		InstanceType:   req.InstanceType,
+		Surge:          req.Surge,
+		MaxUnavailable: req.MaxUnavailable,
+		HealthCheck:    req.HealthCheck,
+	}, req.TaskName, user.UserId)
 	if err != nil {
 		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
 		return
@@ -713,3 +856,409 @@ func GetClusterAuthByName(ctx *gin.Context) {
 	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
 	return
 }
+
+func GetWarmPool(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.WarmPoolRequest{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	status, err := service.GetWarmPoolStatus(ctx, req.ClusterName)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToWarmPoolResponse(status))
+	return
+}
+
+func StopCluster(ctx *gin.Context) {
+	powerCluster(ctx, constants.TaskActionStop)
+}
+
+func StartCluster(ctx *gin.Context) {
+	powerCluster(ctx, constants.TaskActionStart)
+}
+
+func powerCluster(ctx *gin.Context, action string) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.PowerClusterRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	taskId, err := service.CreatePowerTask(ctx, req.ClusterName, action, req.InstanceIds, req.TaskName, user.UserId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, taskId)
+	return
+}
+
+func SaveHibernationSchedule(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.HibernationScheduleRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	schedule := convertToHibernationSchedule(&req, user.UserId)
+	if err = service.SaveHibernationSchedule(ctx, schedule); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToHibernationScheduleResponse(schedule))
+	return
+}
+
+func GetHibernationSchedule(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.HibernationClusterRequest{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	schedule, err := service.GetHibernationSchedule(ctx, req.ClusterName)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToHibernationScheduleResponse(schedule))
+	return
+}
+
+func DeleteHibernationSchedule(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.HibernationClusterRequest{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if err := service.DeleteHibernationSchedule(ctx, req.ClusterName); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func SaveRenewPolicy(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.RenewPolicyRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	err = service.SaveRenewPolicy(ctx, req.ClusterName, &types.RenewPolicy{
+		AutoRenew:       req.AutoRenew,
+		Period:          req.Period,
+		PeriodUnit:      req.PeriodUnit,
+		RenewBeforeDays: req.RenewBeforeDays,
+	}, user.Name)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func convertToHibernationSchedule(req *request.HibernationScheduleRequest, uid int64) *model.HibernationSchedule {
+	days := make([]string, 0, len(req.WorkDays))
+	for _, day := range req.WorkDays {
+		days = append(days, cast.ToString(day))
+	}
+	return &model.HibernationSchedule{
+		ClusterName: req.ClusterName,
+		WorkDays:    strings.Join(days, ","),
+		StartTime:   req.StartTime,
+		StopTime:    req.StopTime,
+		Timezone:    req.Timezone,
+		Enabled:     req.Enabled,
+		CreateBy:    uid,
+	}
+}
+
+func ListClusterRevisions(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ListClusterRevisionsRequest{}
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	pn, ps := buildPager(req.PageNumber, req.PageSize)
+	revisions, total, err := service.ListClusterRevisions(ctx, req.ClusterName, pn, ps)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	resp := &response.ClusterRevisionListResponse{
+		RevisionList: helper.ConvertToClusterRevisionList(revisions),
+		Pager: response.Pager{
+			PageNumber: pn,
+			PageSize:   ps,
+			Total:      int(total),
+		},
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
+	return
+}
+
+func CompareClusterRevisions(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.CompareClusterRevisionsRequest{}
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	diff, err := service.CompareClusterRevisions(ctx, req.ClusterName, req.From, req.To)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, diff)
+	return
+}
+
+func RollbackCluster(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.RollbackClusterRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	revision, taskId, err := service.RollbackCluster(ctx, service.RollbackClusterParam{
+		ClusterName:    req.ClusterName,
+		Revision:       req.Revision,
+		Roll:           req.Roll,
+		TaskName:       req.TaskName,
+		Surge:          req.Surge,
+		MaxUnavailable: req.MaxUnavailable,
+		HealthCheck:    req.HealthCheck,
+	}, user.Name, user.UserId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, &response.RollbackClusterResponse{Revision: revision, TaskId: cast.ToString(taskId)})
+	return
+}
+
+func PlanCloneCluster(ctx *gin.Context) {
+	param, ok := bindCloneClusterParam(ctx)
+	if !ok {
+		return
+	}
+	plan, err := service.PlanCloneCluster(ctx, param)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, plan)
+	return
+}
+
+func CloneCluster(ctx *gin.Context) {
+	param, ok := bindCloneClusterParam(ctx)
+	if !ok {
+		return
+	}
+	user := helper.GetUserClaims(ctx)
+	plan, err := service.CloneCluster(ctx, param, user.Name, user.UserId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), plan)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, plan)
+	return
+}
+
+//bindCloneClusterParam 绑定克隆参数, 目标账号必须属于当前用户的组织
+func bindCloneClusterParam(ctx *gin.Context) (service.CloneClusterParam, bool) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return service.CloneClusterParam{}, false
+	}
+	req := request.CloneClusterRequest{}
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return service.CloneClusterParam{}, false
+	}
+	if req.AccountKey != "" {
+		accountKeys, err := service.GetAksByOrgId(user.OrgId)
+		if err != nil {
+			response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return service.CloneClusterParam{}, false
+		}
+		if !utils.ContainsString(accountKeys, req.AccountKey) {
+			response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+			return service.CloneClusterParam{}, false
+		}
+	}
+	return service.CloneClusterParam{
+		SourceCluster: req.SourceCluster,
+		TargetCluster: req.TargetCluster,
+		Provider:      req.Provider,
+		AccountKey:    req.AccountKey,
+		RegionId:      req.RegionId,
+		ZoneId:        req.ZoneId,
+		InstanceType:  req.InstanceType,
+		Image:         req.Image,
+		Vpc:           req.Vpc,
+		SubnetId:      req.SubnetId,
+		SecurityGroup: req.SecurityGroup,
+		KeyId:         req.KeyId,
+		Password:      req.Password,
+	}, true
+}
+
+func GetClusterMembers(ctx *gin.Context) {
+	membership, err := service.GetClusterMembership(ctx, ctx.Param("name"))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, membership)
+	return
+}
+
+//WatchClusterMembers 长轮询集群成员变化, revision为客户端已知的成员文档版本
+func WatchClusterMembers(ctx *gin.Context) {
+	req := request.WatchClusterMembersRequest{}
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	//客户端断开时结束等待
+	membership, err := service.WatchClusterMembership(ctx.Request.Context(), ctx.Param("name"), req.Revision, time.Duration(req.Timeout)*time.Second)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, membership)
+	return
+}
+
+//ExportPrometheusTargets 直接返回target group数组, 可作为Prometheus http_sd地址或保存为file_sd文件
+func ExportPrometheusTargets(ctx *gin.Context) {
+	hosts, param, ok := getDiscoveryHosts(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, service.RenderPrometheusTargets(hosts, param.Port))
+}
+
+//ExportAnsibleInventory 直接返回Ansible动态inventory JSON
+func ExportAnsibleInventory(ctx *gin.Context) {
+	hosts, _, ok := getDiscoveryHosts(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, service.RenderAnsibleInventory(hosts))
+}
+
+func ExportSSHConfig(ctx *gin.Context) {
+	hosts, param, ok := getDiscoveryHosts(ctx)
+	if !ok {
+		return
+	}
+	config, err := service.RenderSSHConfig(hosts, param.User, param.IdentityFile)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	ctx.String(http.StatusOK, config)
+}
+
+func getDiscoveryHosts(ctx *gin.Context) ([]service.DiscoveryHost, service.DiscoveryParam, bool) {
+	param := service.DiscoveryParam{}
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return nil, param, false
+	}
+	req := request.ExportDiscoveryRequest{}
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return nil, param, false
+	}
+	param = service.DiscoveryParam{
+		UseOuterIp:   req.UseOuterIp,
+		Port:         req.Port,
+		User:         req.User,
+		IdentityFile: req.IdentityFile,
+	}
+	if req.ClusterNames != "" {
+		param.ClusterNames = strings.Split(req.ClusterNames, ",")
+	}
+	if req.Tags != "" {
+		param.Tags = make(map[string]string)
+		for _, pair := range strings.Split(req.Tags, ",") {
+			kv := strings.SplitN(pair, ":", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				response.MkResponse(ctx, http.StatusBadRequest, "invalid tags: "+pair, nil)
+				return nil, param, false
+			}
+			param.Tags[kv[0]] = kv[1]
+		}
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return nil, param, false
+	}
+	hosts, err := service.GetDiscoveryHosts(ctx, accountKeys, param)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return nil, param, false
+	}
+	return hosts, param, true
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/validation"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+func ListBackgroundJobs(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ListBackgroundJobRequest{}
+	if err := ctx.BindQuery(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	jobs, total, err := service.ListJobs(ctx, model.BackgroundJobSearchCond{
+		OrgId:      user.OrgId,
+		JobType:    req.JobType,
+		Status:     req.Status,
+		PageNumber: req.PageNumber,
+		PageSize:   req.PageSize,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	resp := &response.BackgroundJobListResponse{
+		JobList: helper.ConvertToBackgroundJobList(jobs),
+		Pager: response.Pager{
+			PageNumber: req.PageNumber,
+			PageSize:   req.PageSize,
+			Total:      int(total),
+		},
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
+	return
+}
+
+func GetBackgroundJob(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	id, err := cast.ToInt64E(ctx.Param("id"))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	job, err := service.GetJob(ctx, user.OrgId, id)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToBackgroundJob(job))
+	return
+}
+
+func RetryBackgroundJob(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	id, err := cast.ToInt64E(ctx.Param("id"))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	if err = service.RetryJob(ctx, user.OrgId, id); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
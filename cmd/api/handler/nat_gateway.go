@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/validation"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateNatGateway(ctx *gin.Context) {
+	req := request.CreateNatGatewayRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if _, ok := getOrgAccountKeys(ctx, req.AK); !ok {
+		return
+	}
+	gateway, err := service.CreateNatGateway(ctx, service.CreateNatGatewayRequest{
+		AK:            req.AK,
+		VpcId:         req.VpcId,
+		SwitchId:      req.SwitchId,
+		Name:          req.Name,
+		Bandwidth:     req.Bandwidth,
+		SnatSwitchIds: req.SnatSwitchIds,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, gateway)
+	return
+}
+
+func DescribeNatGateways(ctx *gin.Context) {
+	ak := ctx.Query("account_key")
+	provider := ctx.Query("provider")
+	regionId := ctx.Query("region_id")
+	if ak == "" || provider == "" || regionId == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	if _, ok := getOrgAccountKeys(ctx, ak); !ok {
+		return
+	}
+	gateways, err := service.DescribeNatGateways(ctx, service.DescribeNatGatewaysRequest{
+		AK:       ak,
+		Provider: provider,
+		RegionId: regionId,
+		VpcId:    ctx.Query("vpc_id"),
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, gateways)
+	return
+}
+
+func DeleteNatGateway(ctx *gin.Context) {
+	accountKeys, ok := getOrgAccountKeys(ctx, "")
+	if !ok {
+		return
+	}
+	if err := service.DeleteNatGateway(ctx, accountKeys, ctx.Param("id")); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func CreateSnatEntry(ctx *gin.Context) {
+	req := request.CreateSnatEntryRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if _, ok := getOrgAccountKeys(ctx, req.AK); !ok {
+		return
+	}
+	entry, err := service.CreateSnatEntry(ctx, service.CreateSnatEntryRequest{
+		AK:           req.AK,
+		NatGatewayId: req.NatGatewayId,
+		SwitchId:     req.SwitchId,
+		SnatIp:       req.SnatIp,
+		Name:         req.Name,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, entry)
+	return
+}
+
+func DeleteSnatEntry(ctx *gin.Context) {
+	accountKeys, ok := getOrgAccountKeys(ctx, "")
+	if !ok {
+		return
+	}
+	if err := service.DeleteSnatEntry(ctx, accountKeys, ctx.Param("id")); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+//getOrgAccountKeys 获取当前用户组织下的云账户, ak不为空时校验其属于该组织, 校验失败时已写入响应
+func getOrgAccountKeys(ctx *gin.Context, ak string) ([]string, bool) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return nil, false
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return nil, false
+	}
+	if ak != "" && !utils.ContainsString(accountKeys, ak) {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return nil, false
+	}
+	return accountKeys, true
+}
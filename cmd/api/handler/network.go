@@ -10,9 +10,11 @@ import (
 	"github.com/galaxy-future/BridgX/cmd/api/response"
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/service"
 	"github.com/galaxy-future/BridgX/internal/types"
 	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -37,6 +39,7 @@ func CreateNetworkConfig(ctx *gin.Context) {
 		SecurityGroupType: req.SecurityGroupType,
 		AK:                req.AK,
 		Rules:             req.Rules,
+		HostCount:         req.HostCount,
 	})
 	if err != nil {
 		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
@@ -46,6 +49,38 @@ func CreateNetworkConfig(ctx *gin.Context) {
 	return
 }
 
+//PlanNetworkCidr 规划与组织内已有VPC及子网都不重叠的网段, 指定vpc_id时只规划该VPC下的子网
+func PlanNetworkCidr(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.PlanNetworkCidrRequest{}
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	plan, err := service.PlanNetworkCidr(ctx, accountKeys, service.PlanCidrParam{
+		VpcId:      req.VpcId,
+		ZoneIds:    req.ZoneIds,
+		HostCount:  req.HostCount,
+		VpcMaskLen: req.VpcMaskLen,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, plan)
+	return
+}
+
 func SyncNetworkConfig(ctx *gin.Context) {
 	req := request.SyncNetworkRequest{}
 	err := ctx.Bind(&req)
@@ -54,7 +89,7 @@ func SyncNetworkConfig(ctx *gin.Context) {
 		return
 	}
 
-	err = service.SyncNetwork(ctx, service.SyncNetworkRequest{
+	recordId, err := service.SyncNetwork(ctx, service.SyncNetworkRequest{
 		Provider:   req.Provider,
 		RegionId:   req.RegionId,
 		AccountKey: req.AccountKey,
@@ -63,7 +98,34 @@ func SyncNetworkConfig(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
-	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	response.MkResponse(ctx, http.StatusOK, response.Success, recordId)
+	return
+}
+
+func GetNetworkSyncStatus(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	if accountKey := ctx.Query("account_key"); accountKey != "" {
+		if !utils.ContainsString(accountKeys, accountKey) {
+			response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+			return
+		}
+		accountKeys = []string{accountKey}
+	}
+	status, err := service.GetNetworkSyncStatus(ctx, accountKeys, ctx.Query("region_id"))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, status)
 	return
 }
 
@@ -284,6 +346,26 @@ func CreateSecurityGroupWithRules(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
 		return
 	}
+	var tpl *model.SecurityGroupTemplate
+	var orgId int64
+	if req.TemplateId > 0 || req.TemplateName != "" {
+		user := helper.GetUserClaims(ctx)
+		if user == nil {
+			response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+			return
+		}
+		orgId = user.OrgId
+		var tplRules []service.GroupRule
+		tpl, tplRules, err = service.GetSecurityGroupTemplateRules(ctx, orgId, req.TemplateId, req.TemplateName)
+		if err != nil {
+			response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if len(req.Rules) > 0 && req.Rules[0].Protocol != "" {
+			tplRules = append(tplRules, req.Rules...)
+		}
+		req.Rules = tplRules
+	}
 	groupId, err := service.CreateSecurityGroup(ctx, service.CreateSecurityGroupRequest{
 		AK:                req.AK,
 		VpcId:             req.VpcId,
@@ -309,6 +391,13 @@ func CreateSecurityGroupWithRules(ctx *gin.Context) {
 		response.MkResponse(ctx, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
+	if tpl != nil {
+		err = service.BindSecurityGroupTemplate(ctx, orgId, []string{req.AK}, groupId, tpl.Id, req.AutoRemediate)
+		if err != nil {
+			response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+			return
+		}
+	}
 	response.MkResponse(ctx, http.StatusOK, response.Success, groupId)
 }
 
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/validation"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+)
+
+func CreateNotifyChannel(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.CreateNotifyChannelRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	config, _ := jsoniter.MarshalToString(req.Config)
+	channel := &model.NotifyChannel{
+		OrgId:       user.OrgId,
+		Name:        req.Name,
+		ChannelType: req.ChannelType,
+		Config:      config,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		CreateBy:    user.Name,
+	}
+	if err := service.CreateNotifyChannel(ctx, channel); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, channel.Id)
+	return
+}
+
+func ListNotifyChannels(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	channels, err := service.ListNotifyChannels(ctx, user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToNotifyChannelList(channels))
+	return
+}
+
+func DeleteNotifyChannels(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	if err := service.DeleteNotifyChannels(ctx, user.OrgId, parseIdsParam(ctx)); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func TestNotifyChannel(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.TestNotifyChannelRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if err := service.TestNotifyChannel(ctx, user.OrgId, req.ChannelId); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func CreateNotifySubscription(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.CreateNotifySubscriptionRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	sub := &model.NotifySubscription{
+		OrgId:       user.OrgId,
+		ChannelId:   req.ChannelId,
+		ClusterName: req.ClusterName,
+		EventTypes:  strings.Join(req.EventTypes, ","),
+		MinSeverity: req.MinSeverity,
+		Template:    req.Template,
+		CreateBy:    user.Name,
+	}
+	if err := service.CreateNotifySubscription(ctx, sub); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, sub.Id)
+	return
+}
+
+func ListNotifySubscriptions(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	subs, err := service.ListNotifySubscriptions(ctx, user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, helper.ConvertToNotifySubscriptionList(subs))
+	return
+}
+
+func DeleteNotifySubscriptions(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	if err := service.DeleteNotifySubscriptions(ctx, user.OrgId, parseIdsParam(ctx)); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func ListNotifyRecords(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.ListNotifyRecordRequest{}
+	if err := ctx.BindQuery(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	records, total, err := service.ListNotifyRecords(ctx, model.NotifyRecordSearchCond{
+		OrgId:       user.OrgId,
+		ChannelId:   req.ChannelId,
+		ClusterName: req.ClusterName,
+		EventType:   req.EventType,
+		Status:      req.Status,
+		PageNumber:  req.PageNumber,
+		PageSize:    req.PageSize,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	resp := &response.NotifyRecordListResponse{
+		RecordList: helper.ConvertToNotifyRecordList(records),
+		Pager: response.Pager{
+			PageNumber: req.PageNumber,
+			PageSize:   req.PageSize,
+			Total:      int(total),
+		},
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
+	return
+}
+
+func parseIdsParam(ctx *gin.Context) []int64 {
+	ids := make([]int64, 0)
+	for _, v := range strings.Split(ctx.Param("ids"), ",") {
+		ids = append(ids, cast.ToInt64(v))
+	}
+	return ids
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/validation"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateSecurityGroupTemplate(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.SecurityGroupTemplateRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if req.Name == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	tpl := &model.SecurityGroupTemplate{
+		OrgId:       user.OrgId,
+		Name:        req.Name,
+		Description: req.Description,
+		CreateBy:    user.Name,
+	}
+	if err := service.CreateSecurityGroupTemplate(ctx, tpl, req.Rules); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, tpl.Id)
+	return
+}
+
+func UpdateSecurityGroupTemplate(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.SecurityGroupTemplateRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if req.Id <= 0 {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	if err := service.UpdateSecurityGroupTemplate(ctx, user.OrgId, req.Id, req.Description, req.Rules); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func ListSecurityGroupTemplates(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	templates, err := service.ListSecurityGroupTemplates(ctx, user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, templates)
+	return
+}
+
+func DeleteSecurityGroupTemplates(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	if err := service.DeleteSecurityGroupTemplates(ctx, user.OrgId, parseIdsParam(ctx)); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func BindSecurityGroupTemplate(ctx *gin.Context) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.BindSecurityGroupTemplateRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	accountKeys, err := service.GetAksByOrgId(user.OrgId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	err = service.BindSecurityGroupTemplate(ctx, user.OrgId, accountKeys, req.SecurityGroupId, req.TemplateId, req.AutoRemediate)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func CheckSecurityGroupDrift(ctx *gin.Context) {
+	checkSecurityGroupDrift(ctx, false)
+}
+
+func RemediateSecurityGroupDrift(ctx *gin.Context) {
+	checkSecurityGroupDrift(ctx, true)
+}
+
+func checkSecurityGroupDrift(ctx *gin.Context, remediate bool) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	drift, err := service.CheckSecurityGroupDrift(ctx, user.OrgId, ctx.Param("id"), remediate)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, drift)
+	return
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/galaxy-future/BridgX/cmd/api/helper"
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/validation"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
 	"github.com/galaxy-future/BridgX/cmd/api/response"
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/model"
@@ -169,3 +172,36 @@ func GetTaskInstances(ctx *gin.Context) {
 	response.MkResponse(ctx, http.StatusOK, response.Success, resp)
 	return
 }
+
+func PauseRollTask(ctx *gin.Context) {
+	handleRollTaskOperation(ctx, service.PauseRollTask)
+}
+
+func ResumeRollTask(ctx *gin.Context) {
+	handleRollTaskOperation(ctx, service.ResumeRollTask)
+}
+
+func RollbackRollTask(ctx *gin.Context) {
+	handleRollTaskOperation(ctx, service.RollbackRollTask)
+}
+
+func handleRollTaskOperation(ctx *gin.Context, operate func(ctx context.Context, taskId int64) error) {
+	user := helper.GetUserClaims(ctx)
+	if user == nil {
+		response.MkResponse(ctx, http.StatusBadRequest, response.PermissionDenied, nil)
+		return
+	}
+	req := request.RollTaskRequest{}
+	err := ctx.Bind(&req)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	err = operate(ctx, cast.ToInt64(req.TaskId))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/galaxy-future/BridgX/cmd/api/middleware/validation"
+	"github.com/galaxy-future/BridgX/cmd/api/request"
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+func CreateVpcPeering(ctx *gin.Context) {
+	req := request.CreateVpcPeeringRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if _, ok := getOrgAccountKeys(ctx, req.AK); !ok {
+		return
+	}
+	peering, err := service.CreateVpcPeering(ctx, service.CreateVpcPeeringRequest{
+		AK:        req.AK,
+		VpcId:     req.VpcId,
+		PeerVpcId: req.PeerVpcId,
+		Name:      req.Name,
+		AddRoutes: req.AddRoutes,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, peering)
+	return
+}
+
+func DescribeVpcPeerings(ctx *gin.Context) {
+	ak := ctx.Query("account_key")
+	provider := ctx.Query("provider")
+	regionId := ctx.Query("region_id")
+	if ak == "" || provider == "" || regionId == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	if _, ok := getOrgAccountKeys(ctx, ak); !ok {
+		return
+	}
+	peerings, err := service.DescribeVpcPeerings(ctx, ak, provider, regionId, ctx.Query("vpc_id"))
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, peerings)
+	return
+}
+
+func DeleteVpcPeering(ctx *gin.Context) {
+	accountKeys, ok := getOrgAccountKeys(ctx, "")
+	if !ok {
+		return
+	}
+	if err := service.DeleteVpcPeering(ctx, accountKeys, ctx.Param("id")); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
+
+func CreateRouteEntry(ctx *gin.Context) {
+	req := request.CreateRouteEntryRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	if !req.Check() {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	if _, ok := getOrgAccountKeys(ctx, req.AK); !ok {
+		return
+	}
+	entry, err := service.CreateRouteEntry(ctx, service.CreateRouteEntryRequest{
+		AK:              req.AK,
+		VpcId:           req.VpcId,
+		RouteTableId:    req.RouteTableId,
+		DestinationCidr: req.DestinationCidr,
+		NextHopType:     req.NextHopType,
+		NextHopId:       req.NextHopId,
+		Name:            req.Name,
+	})
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, entry)
+	return
+}
+
+func DescribeRouteEntries(ctx *gin.Context) {
+	vpcId := ctx.Query("vpc_id")
+	if vpcId == "" {
+		response.MkResponse(ctx, http.StatusBadRequest, response.ParamInvalid, nil)
+		return
+	}
+	accountKeys, ok := getOrgAccountKeys(ctx, "")
+	if !ok {
+		return
+	}
+	entries, err := service.DescribeRouteEntries(ctx, accountKeys, vpcId)
+	if err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, entries)
+	return
+}
+
+func DeleteRouteEntry(ctx *gin.Context) {
+	req := request.DeleteRouteEntryRequest{}
+	if err := ctx.Bind(&req); err != nil {
+		response.MkResponse(ctx, http.StatusBadRequest, validation.Translate2Chinese(err), nil)
+		return
+	}
+	accountKeys, ok := getOrgAccountKeys(ctx, "")
+	if !ok {
+		return
+	}
+	if err := service.DeleteRouteEntry(ctx, accountKeys, req.RouteTableId, req.DestinationCidr); err != nil {
+		response.MkResponse(ctx, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	response.MkResponse(ctx, http.StatusOK, response.Success, nil)
+	return
+}
@@ -0,0 +1,29 @@
+package helper
+
+import (
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/spf13/cast"
+)
+
+func ConvertToTaskApprovalList(approvals []model.TaskApproval, userMap map[int64]string) []response.TaskApproval {
+	ret := make([]response.TaskApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		ret = append(ret, response.TaskApproval{
+			TaskId:        cast.ToString(approval.TaskId),
+			ClusterName:   approval.ClusterName,
+			TaskAction:    approval.TaskAction,
+			Count:         approval.Count,
+			EstimatedCost: approval.EstimatedCost,
+			Reason:        approval.Reason,
+			Status:        approval.Status,
+			Applicant:     userMap[approval.Applicant],
+			Approver:      userMap[approval.Approver],
+			Comment:       approval.Comment,
+			ExpireAt:      getStringTime(approval.ExpireAt),
+			ReviewAt:      getStringTime(approval.ReviewAt),
+			CreateAt:      getStringTime(approval.CreateAt),
+		})
+	}
+	return ret
+}
@@ -0,0 +1,19 @@
+package helper
+
+import (
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func ConvertToClusterRevisionList(revisions []model.ClusterRevision) []response.ClusterRevision {
+	res := make([]response.ClusterRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		res = append(res, response.ClusterRevision{
+			Revision: revision.Revision,
+			Comment:  revision.Comment,
+			CreateBy: revision.CreateBy,
+			CreateAt: getStringTime(revision.CreateAt),
+		})
+	}
+	return res
+}
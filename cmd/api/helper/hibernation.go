@@ -0,0 +1,29 @@
+package helper
+
+import (
+	"strings"
+
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/spf13/cast"
+)
+
+func ConvertToHibernationScheduleResponse(schedule *model.HibernationSchedule) *response.HibernationScheduleResponse {
+	if schedule == nil {
+		return nil
+	}
+	days := make([]int, 0)
+	for _, day := range strings.Split(schedule.WorkDays, ",") {
+		days = append(days, cast.ToInt(day))
+	}
+	return &response.HibernationScheduleResponse{
+		ClusterName: schedule.ClusterName,
+		WorkDays:    days,
+		StartTime:   schedule.StartTime,
+		StopTime:    schedule.StopTime,
+		Timezone:    schedule.Timezone,
+		Enabled:     schedule.Enabled,
+		State:       schedule.State,
+		UpdateAt:    getStringTime(schedule.UpdateAt),
+	}
+}
@@ -46,6 +46,10 @@ func ConvertToInstanceThumbList(ctx context.Context, instances []model.Instance,
 			StartupTime:        startupTime,
 			ChargeType:         instance.ChargeType,
 			ComputingPowerType: cpuType,
+			ScaleInProtected:   instance.ScaleInProtected,
+			HealthStatus:       instance.HealthStatus,
+			ExpireAt:           getStringTime(instance.ExpireAt),
+			ReleaseAtExpiry:    instance.ReleaseAtExpiry,
 		}
 		ret = append(ret, r)
 	}
@@ -203,6 +207,10 @@ func getStringStatus(s constants.Status) string {
 		return "Deleted"
 	case constants.Deleting:
 		return "Deleting"
+	case constants.Stopping:
+		return "Stopping"
+	case constants.Stopped:
+		return "Stopped"
 	}
 	return ""
 }
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func ConvertToBackgroundJob(job *model.BackgroundJob) response.BackgroundJob {
+	return response.BackgroundJob{
+		Id:          job.Id,
+		JobType:     job.JobType,
+		Payload:     job.Payload,
+		Status:      job.Status,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		NextRunAt:   getStringTime(job.NextRunAt),
+		LastError:   job.LastError,
+		Worker:      job.Worker,
+		StartAt:     getStringTime(job.StartAt),
+		EndAt:       getStringTime(job.EndAt),
+		CreateAt:    getStringTime(job.CreateAt),
+	}
+}
+
+func ConvertToBackgroundJobList(jobs []model.BackgroundJob) []response.BackgroundJob {
+	ret := make([]response.BackgroundJob, 0, len(jobs))
+	for i := range jobs {
+		ret = append(ret, ConvertToBackgroundJob(&jobs[i]))
+	}
+	return ret
+}
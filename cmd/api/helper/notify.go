@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"strings"
+
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/notify"
+)
+
+const maskKeepLen = 4
+
+func ConvertToNotifyChannelList(channels []model.NotifyChannel) []response.NotifyChannel {
+	ret := make([]response.NotifyChannel, 0, len(channels))
+	for _, channel := range channels {
+		conf, _ := notify.ParseConfig(channel.Config)
+		ret = append(ret, response.NotifyChannel{
+			Id:          channel.Id,
+			Name:        channel.Name,
+			ChannelType: channel.ChannelType,
+			Config:      maskNotifyConfig(conf),
+			Enabled:     channel.Enabled,
+			CreateBy:    channel.CreateBy,
+			CreateAt:    getStringTime(channel.CreateAt),
+		})
+	}
+	return ret
+}
+
+//maskNotifyConfig 隐藏渠道配置中的密钥类字段, 避免在列表中泄露
+func maskNotifyConfig(conf notify.Config) notify.Config {
+	conf.HookId = maskString(conf.HookId)
+	conf.Secret = maskString(conf.Secret)
+	conf.Password = maskString(conf.Password)
+	if idx := strings.LastIndex(conf.Webhook, "/"); idx >= 0 {
+		conf.Webhook = conf.Webhook[:idx+1] + maskString(conf.Webhook[idx+1:])
+	}
+	return conf
+}
+
+func maskString(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) <= maskKeepLen {
+		return "****"
+	}
+	return s[:maskKeepLen] + "****"
+}
+
+func ConvertToNotifySubscriptionList(subs []model.NotifySubscription) []response.NotifySubscription {
+	ret := make([]response.NotifySubscription, 0, len(subs))
+	for _, sub := range subs {
+		eventTypes := make([]string, 0)
+		if sub.EventTypes != "" {
+			eventTypes = strings.Split(sub.EventTypes, ",")
+		}
+		ret = append(ret, response.NotifySubscription{
+			Id:          sub.Id,
+			ChannelId:   sub.ChannelId,
+			ClusterName: sub.ClusterName,
+			EventTypes:  eventTypes,
+			MinSeverity: sub.MinSeverity,
+			Template:    sub.Template,
+			CreateBy:    sub.CreateBy,
+			CreateAt:    getStringTime(sub.CreateAt),
+		})
+	}
+	return ret
+}
+
+func ConvertToNotifyRecordList(records []model.NotifyRecord) []response.NotifyRecord {
+	ret := make([]response.NotifyRecord, 0, len(records))
+	for _, record := range records {
+		ret = append(ret, response.NotifyRecord{
+			Id:          record.Id,
+			ChannelId:   record.ChannelId,
+			EventType:   record.EventType,
+			Severity:    record.Severity,
+			ClusterName: record.ClusterName,
+			Title:       record.Title,
+			Content:     record.Content,
+			Status:      record.Status,
+			RetryCount:  record.RetryCount,
+			ErrMsg:      record.ErrMsg,
+			CreateAt:    getStringTime(record.CreateAt),
+		})
+	}
+	return ret
+}
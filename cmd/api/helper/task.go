@@ -64,6 +64,12 @@ func ExtractTaskInfo(task *model.Task) model.TaskInfo {
 	case constants.TaskActionShrink:
 		info = &model.ShrinkTaskInfo{}
 		_ = jsoniter.UnmarshalFromString(task.TaskInfo, info)
+	case constants.TaskActionRoll:
+		info = &model.RollTaskInfo{}
+		_ = jsoniter.UnmarshalFromString(task.TaskInfo, info)
+	case constants.TaskActionStop, constants.TaskActionStart:
+		info = &model.PowerTaskInfo{}
+		_ = jsoniter.UnmarshalFromString(task.TaskInfo, info)
 	}
 	return info
 }
@@ -130,7 +136,19 @@ func getCount(task *model.Task, taskInfo model.TaskInfo, taskResult *model.TaskR
 			case constants.Starting:
 				running++
 			case constants.Running:
-				success++
+				if task.TaskAction == constants.TaskActionStop {
+					suspending++
+				} else {
+					success++
+				}
+			case constants.Stopping:
+				running++
+			case constants.Stopped:
+				if task.TaskAction == constants.TaskActionStop {
+					success++
+				} else {
+					suspending++
+				}
 			}
 		}
 	} else {
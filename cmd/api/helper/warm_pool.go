@@ -0,0 +1,29 @@
+package helper
+
+import (
+	"github.com/galaxy-future/BridgX/cmd/api/response"
+	"github.com/galaxy-future/BridgX/internal/service"
+)
+
+func ConvertToWarmPoolResponse(status *service.WarmPoolStatus) *response.WarmPoolResponse {
+	ret := &response.WarmPoolResponse{
+		ClusterName: status.ClusterName,
+		Size:        status.Size,
+		MinSize:     status.MinSize,
+		Ready:       status.Ready,
+		Preparing:   status.Preparing,
+		Instances:   make([]response.WarmPoolInstance, 0, len(status.Instances)),
+	}
+	for _, instance := range status.Instances {
+		ret.Instances = append(ret.Instances, response.WarmPoolInstance{
+			InstanceId:   instance.InstanceId,
+			Status:       instance.Status,
+			IpInner:      instance.IpInner,
+			IpOuter:      instance.IpOuter,
+			InstanceType: instance.InstanceType,
+			Image:        instance.Image,
+			CreateAt:     getStringTime(instance.CreateAt),
+		})
+	}
+	return ret
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/galaxy-future/BridgX/internal/bcc"
 	"github.com/galaxy-future/BridgX/internal/cache"
 	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/dns"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/service"
 )
@@ -26,6 +27,7 @@ func main() {
 	logs.Init()
 	clients.MustInit()
 	bcc.MustInit(config.GlobalConfig)
+	dns.MustInit(config.GlobalConfig)
 	cache.MustInit()
 	service.Init(100)
 	middleware.Init()
@@ -4,6 +4,8 @@ import (
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/service"
 	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/notify"
 )
 
 type TagRequest struct {
@@ -30,11 +32,33 @@ type ExpandClusterRequest struct {
 	Count       int    `json:"count" binding:"required,min=1,max=10000"`
 }
 
+type ExpandPlanRequest struct {
+	ClusterName string `json:"cluster_name" binding:"required"`
+	Count       int    `json:"count" binding:"required,min=1,max=10000"`
+}
+
+type AdoptInstancesRequest struct {
+	ClusterName string            `json:"cluster_name" binding:"required"`
+	InstanceIds []string          `json:"instance_ids" binding:"max=500"`
+	Tags        map[string]string `json:"tags"`
+	VpcId       string            `json:"vpc_id"`
+}
+
+type ExportClusterSpecRequest struct {
+	Names string `form:"names"` //逗号分隔, 为空时导出全部标准集群
+}
+
+type ClusterSpecRequest struct {
+	Document string `json:"document" binding:"required"` //YAML格式的集群配置文档
+}
+
 type ShrinkClusterRequest struct {
 	TaskName    string   `json:"task_name"`
 	ClusterName string   `json:"cluster_name" binding:"required"`
 	IPs         []string `json:"ips"`
 	Count       int      `json:"count" binding:"required,min=1,max=10000"`
+
+	ShrinkStrategy *types.ShrinkStrategyConfig `json:"shrink_strategy"` //为空时使用集群配置的策略
 }
 
 type ShrinkAllInstancesRequest struct {
@@ -42,6 +66,26 @@ type ShrinkAllInstancesRequest struct {
 	ClusterName string `json:"cluster_name" binding:"required"`
 }
 
+type RollClusterRequest struct {
+	TaskName       string                   `json:"task_name"`
+	ClusterName    string                   `json:"cluster_name" binding:"required"`
+	Image          string                   `json:"image"`
+	InstanceType   string                   `json:"instance_type"`
+	Surge          int                      `json:"surge" binding:"min=0,max=1000"`
+	MaxUnavailable int                      `json:"max_unavailable" binding:"min=0,max=1000"`
+	HealthCheck    *types.HealthCheckConfig `json:"health_check"`
+}
+
+type RollTaskRequest struct {
+	TaskId string `json:"task_id" binding:"required"`
+}
+
+type PowerClusterRequest struct {
+	TaskName    string   `json:"task_name"`
+	ClusterName string   `json:"cluster_name" binding:"required"`
+	InstanceIds []string `json:"instance_ids"`
+}
+
 type CreateVpcRequest struct {
 	Provider  string `json:"provider"`
 	RegionId  string `json:"region_id"`
@@ -107,20 +151,81 @@ type CreateSecurityGroupWithRuleRequest struct {
 	SecurityGroupName string              `json:"security_group_name"`
 	SecurityGroupType string              `json:"security_group_type"`
 	Rules             []service.GroupRule `json:"rules"`
+	TemplateId        int64               `json:"template_id"`   //模板规则与rules合并后添加, 并将安全组绑定到该模板
+	TemplateName      string              `json:"template_name"` //template_id为空时按名称查找模板
+	AutoRemediate     bool                `json:"auto_remediate"`
 }
 
 func (c *CreateSecurityGroupWithRuleRequest) Check() bool {
 	return c.SecurityGroupName != "" && c.RegionId != "" && c.VpcId != ""
 }
 
+type SecurityGroupTemplateRequest struct {
+	Id          int64               `json:"id"`
+	Name        string              `json:"name" binding:"max=64"`
+	Description string              `json:"description" binding:"max=255"`
+	Rules       []service.GroupRule `json:"rules" binding:"required,min=1"`
+}
+
+type BindSecurityGroupTemplateRequest struct {
+	SecurityGroupId string `json:"security_group_id" binding:"required"`
+	TemplateId      int64  `json:"template_id" binding:"required"`
+	AutoRemediate   bool   `json:"auto_remediate"`
+}
+
+type CreateNatGatewayRequest struct {
+	AK            string   `json:"account_key" binding:"required"`
+	VpcId         string   `json:"vpc_id" binding:"required"`
+	SwitchId      string   `json:"switch_id" binding:"required"`
+	Name          string   `json:"nat_gateway_name"`
+	Bandwidth     int      `json:"bandwidth" binding:"min=0"` //公网IP带宽, 单位Mbps
+	SnatSwitchIds []string `json:"snat_switch_ids"`           //需要通过NAT网关访问公网的子网
+}
+
+type CreateSnatEntryRequest struct {
+	AK           string `json:"account_key" binding:"required"`
+	NatGatewayId string `json:"nat_gateway_id" binding:"required"`
+	SwitchId     string `json:"switch_id" binding:"required"`
+	SnatIp       string `json:"snat_ip"`
+	Name         string `json:"snat_entry_name"`
+}
+
+type CreateVpcPeeringRequest struct {
+	AK        string `json:"account_key" binding:"required"`
+	VpcId     string `json:"vpc_id" binding:"required"`
+	PeerVpcId string `json:"peer_vpc_id" binding:"required"`
+	Name      string `json:"peering_name"`
+	AddRoutes bool   `json:"add_routes"`
+}
+
+type CreateRouteEntryRequest struct {
+	AK              string `json:"account_key" binding:"required"`
+	VpcId           string `json:"vpc_id" binding:"required"`
+	RouteTableId    string `json:"route_table_id"`
+	DestinationCidr string `json:"destination_cidr" binding:"required"`
+	NextHopType     string `json:"next_hop_type" binding:"required"`
+	NextHopId       string `json:"next_hop_id" binding:"required"`
+	Name            string `json:"route_entry_name"`
+}
+
+func (c *CreateRouteEntryRequest) Check() bool {
+	return c.NextHopType == cloud.RouteNextHopInstance || c.NextHopType == cloud.RouteNextHopNatGateway || c.NextHopType == cloud.RouteNextHopPeering
+}
+
+type DeleteRouteEntryRequest struct {
+	RouteTableId    string `json:"route_table_id" binding:"required"`
+	DestinationCidr string `json:"destination_cidr" binding:"required"`
+}
+
 type CreateNetworkRequest struct {
 	Provider          string              `json:"provider" binding:"required,mustIn=cloud"`
 	RegionId          string              `json:"region_id" binding:"required"`
-	CidrBlock         string              `json:"cidr_block" binding:"required"`
+	CidrBlock         string              `json:"cidr_block"` //cidr_block及switch_cidr_block为空时按host_count自动规划
 	VpcName           string              `json:"vpc_name" binding:"required"`
 	ZoneId            string              `json:"zone_id" binding:"required"`
-	SwitchCidrBlock   string              `json:"switch_cidr_block" binding:"required"`
-	GatewayIp         string              `json:"gateway_ip" binding:"required"`
+	SwitchCidrBlock   string              `json:"switch_cidr_block"`
+	GatewayIp         string              `json:"gateway_ip"`
+	HostCount         int                 `json:"host_count" binding:"min=0"`
 	SwitchName        string              `json:"switch_name" binding:"required"`
 	SecurityGroupName string              `json:"security_group_name" binding:"required"`
 	SecurityGroupType string              `json:"security_group_type"`
@@ -128,6 +233,13 @@ type CreateNetworkRequest struct {
 	Rules             []service.GroupRule `json:"rules"`
 }
 
+type PlanNetworkCidrRequest struct {
+	VpcId      string   `json:"vpc_id"`
+	ZoneIds    []string `json:"zone_ids" binding:"required,min=1"`
+	HostCount  int      `json:"host_count" binding:"required,min=1"`
+	VpcMaskLen int      `json:"vpc_mask_len" binding:"min=0,max=32"`
+}
+
 type SyncNetworkRequest struct {
 	Provider   string `json:"provider" binding:"required"`
 	RegionId   string `json:"region_id" binding:"required"`
@@ -240,3 +352,153 @@ type ListKeyPairRequest struct {
 type ClusterAuthRequest struct {
 	ClusterName string `form:"cluster_name" binding:"required"`
 }
+
+type WarmPoolRequest struct {
+	ClusterName string `form:"cluster_name" binding:"required"`
+}
+
+type HibernationScheduleRequest struct {
+	ClusterName string `json:"cluster_name" binding:"required"`
+	WorkDays    []int  `json:"work_days" binding:"dive,min=0,max=6"`
+	StartTime   string `json:"start_time" binding:"required"`
+	StopTime    string `json:"stop_time" binding:"required"`
+	Timezone    string `json:"timezone"`
+	Enabled     bool   `json:"enabled"`
+}
+
+type HibernationClusterRequest struct {
+	ClusterName string `form:"cluster_name" binding:"required"`
+}
+
+type CreateNotifyChannelRequest struct {
+	Name        string        `json:"name" binding:"required"`
+	ChannelType string        `json:"channel_type" binding:"required"`
+	Config      notify.Config `json:"config"`
+	Enabled     *bool         `json:"enabled"`
+}
+
+type TestNotifyChannelRequest struct {
+	ChannelId int64 `json:"channel_id" binding:"required"`
+}
+
+type CreateNotifySubscriptionRequest struct {
+	ChannelId   int64    `json:"channel_id" binding:"required"`
+	ClusterName string   `json:"cluster_name"`
+	EventTypes  []string `json:"event_types"`
+	MinSeverity string   `json:"min_severity"`
+	Template    string   `json:"template"`
+}
+
+type ListNotifyRecordRequest struct {
+	ChannelId   int64  `form:"channel_id"`
+	ClusterName string `form:"cluster_name"`
+	EventType   string `form:"event_type"`
+	Status      string `form:"status"`
+	PageNumber  int    `form:"page_number"`
+	PageSize    int    `form:"page_size"`
+}
+
+type ListBackgroundJobRequest struct {
+	JobType    string `form:"job_type"`
+	Status     string `form:"status"`
+	PageNumber int    `form:"page_number"`
+	PageSize   int    `form:"page_size"`
+}
+
+type ApprovalPolicyRequest struct {
+	Enabled        bool    `json:"enabled"`
+	MaxExpandCount int     `json:"max_expand_count" binding:"min=0"`
+	MaxMonthlyCost float64 `json:"max_monthly_cost" binding:"min=0"`
+	ExpireHours    int     `json:"expire_hours" binding:"min=0"`
+}
+
+type ReviewTaskRequest struct {
+	TaskId  string `json:"task_id" binding:"required"`
+	Comment string `json:"comment"`
+}
+
+type ListApprovalRequest struct {
+	ClusterName string `form:"cluster_name"`
+	Status      string `form:"status"`
+	PageNumber  int    `form:"page_number"`
+	PageSize    int    `form:"page_size"`
+}
+
+type ScaleInProtectionRequest struct {
+	ClusterName string   `json:"cluster_name" binding:"required"`
+	InstanceIds []string `json:"instance_ids" binding:"required,min=1"`
+	Protected   bool     `json:"protected"`
+}
+
+type RenewInstancesRequest struct {
+	ClusterName string   `json:"cluster_name" binding:"required"`
+	InstanceIds []string `json:"instance_ids" binding:"required,min=1"`
+	Period      int      `json:"period" binding:"required,min=1"`
+	PeriodUnit  string   `json:"period_unit" binding:"required"`
+}
+
+type ReleaseAtExpiryRequest struct {
+	ClusterName string   `json:"cluster_name" binding:"required"`
+	InstanceIds []string `json:"instance_ids" binding:"required,min=1"`
+	Release     bool     `json:"release"`
+}
+
+type RenewPolicyRequest struct {
+	ClusterName     string `json:"cluster_name" binding:"required"`
+	AutoRenew       bool   `json:"auto_renew"`
+	Period          int    `json:"period"`
+	PeriodUnit      string `json:"period_unit"`
+	RenewBeforeDays int    `json:"renew_before_days" binding:"min=0"`
+}
+
+type ListClusterRevisionsRequest struct {
+	ClusterName string `form:"cluster_name" binding:"required"`
+	PageNumber  int    `form:"page_number"`
+	PageSize    int    `form:"page_size"`
+}
+
+type CompareClusterRevisionsRequest struct {
+	ClusterName string `form:"cluster_name" binding:"required"`
+	From        int    `form:"from" binding:"required,min=1"`
+	To          int    `form:"to" binding:"min=0"` //0表示与集群当前配置比较
+}
+
+type RollbackClusterRequest struct {
+	ClusterName    string                   `json:"cluster_name" binding:"required"`
+	Revision       int                      `json:"revision" binding:"required,min=1"`
+	Roll           bool                     `json:"roll"`
+	TaskName       string                   `json:"task_name"`
+	Surge          int                      `json:"surge" binding:"min=0,max=1000"`
+	MaxUnavailable int                      `json:"max_unavailable" binding:"min=0,max=1000"`
+	HealthCheck    *types.HealthCheckConfig `json:"health_check"`
+}
+
+type CloneClusterRequest struct {
+	SourceCluster string `json:"source_cluster" binding:"required"`
+	TargetCluster string `json:"target_cluster" binding:"required,max=20"`
+	Provider      string `json:"provider" binding:"omitempty,mustIn=cloud"`
+	AccountKey    string `json:"account_key"`
+	RegionId      string `json:"region_id"`
+	ZoneId        string `json:"zone_id"`
+	InstanceType  string `json:"instance_type"`
+	Image         string `json:"image"`
+	Vpc           string `json:"vpc"`
+	SubnetId      string `json:"subnet_id"`
+	SecurityGroup string `json:"security_group"`
+	KeyId         string `json:"key_id"`
+	Password      string `json:"password"`
+}
+
+type WatchClusterMembersRequest struct {
+	Revision int64 `form:"revision"`
+	Timeout  int   `form:"timeout" binding:"min=0,max=60"` //等待秒数, 0表示使用默认值
+}
+
+type ExportDiscoveryRequest struct {
+	ClusterNames string `form:"cluster_names"` //逗号分隔, 与tags二选一
+	Tags         string `form:"tags"`          //格式为k1:v1,k2:v2
+	UseOuterIp   bool   `form:"use_outer_ip"`
+	Port         int    `form:"port" binding:"min=0,max=65535"`
+	User         string `form:"user"`
+	IdentityFile string `form:"identity_file"`
+}
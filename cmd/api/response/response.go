@@ -3,6 +3,7 @@ package response
 import (
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/notify"
 )
 
 type KeyPair struct {
@@ -137,6 +138,10 @@ type InstanceThumb struct {
 	LoginPassword      string `json:"login_password"`
 	ChargeType         string `json:"charge_type"`
 	ComputingPowerType string `json:"computing_power_type"`
+	ScaleInProtected   bool   `json:"scale_in_protected"`
+	HealthStatus       string `json:"health_status"`
+	ExpireAt           string `json:"expire_at"`
+	ReleaseAtExpiry    bool   `json:"release_at_expiry"`
 }
 
 type InstanceUsage struct {
@@ -297,3 +302,131 @@ type ClusterAuthResponse struct {
 	KeyPairName string `json:"key_pair_name"`
 	PrivateKey  string `json:"private_key"`
 }
+
+type NotifyChannel struct {
+	Id          int64         `json:"id"`
+	Name        string        `json:"name"`
+	ChannelType string        `json:"channel_type"`
+	Config      notify.Config `json:"config"`
+	Enabled     bool          `json:"enabled"`
+	CreateBy    string        `json:"create_by"`
+	CreateAt    string        `json:"create_at"`
+}
+
+type NotifySubscription struct {
+	Id          int64    `json:"id"`
+	ChannelId   int64    `json:"channel_id"`
+	ClusterName string   `json:"cluster_name"`
+	EventTypes  []string `json:"event_types"`
+	MinSeverity string   `json:"min_severity"`
+	Template    string   `json:"template"`
+	CreateBy    string   `json:"create_by"`
+	CreateAt    string   `json:"create_at"`
+}
+
+type NotifyRecord struct {
+	Id          int64  `json:"id"`
+	ChannelId   int64  `json:"channel_id"`
+	EventType   string `json:"event_type"`
+	Severity    string `json:"severity"`
+	ClusterName string `json:"cluster_name"`
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	Status      string `json:"status"`
+	RetryCount  int    `json:"retry_count"`
+	ErrMsg      string `json:"err_msg"`
+	CreateAt    string `json:"create_at"`
+}
+
+type NotifyRecordListResponse struct {
+	RecordList []NotifyRecord `json:"record_list"`
+	Pager      Pager          `json:"pager"`
+}
+
+type BackgroundJob struct {
+	Id          int64  `json:"id"`
+	JobType     string `json:"job_type"`
+	Payload     string `json:"payload"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"max_attempts"`
+	NextRunAt   string `json:"next_run_at"`
+	LastError   string `json:"last_error"`
+	Worker      string `json:"worker"`
+	StartAt     string `json:"start_at"`
+	EndAt       string `json:"end_at"`
+	CreateAt    string `json:"create_at"`
+}
+
+type BackgroundJobListResponse struct {
+	JobList []BackgroundJob `json:"job_list"`
+	Pager   Pager           `json:"pager"`
+}
+
+type TaskApproval struct {
+	TaskId        string  `json:"task_id"`
+	ClusterName   string  `json:"cluster_name"`
+	TaskAction    string  `json:"task_action"`
+	Count         int     `json:"count"`
+	EstimatedCost float64 `json:"estimated_cost"`
+	Reason        string  `json:"reason"`
+	Status        string  `json:"status"`
+	Applicant     string  `json:"applicant"`
+	Approver      string  `json:"approver"`
+	Comment       string  `json:"comment"`
+	ExpireAt      string  `json:"expire_at"`
+	ReviewAt      string  `json:"review_at"`
+	CreateAt      string  `json:"create_at"`
+}
+
+type TaskApprovalListResponse struct {
+	ApprovalList []TaskApproval `json:"approval_list"`
+	Pager        Pager          `json:"pager"`
+}
+
+type WarmPoolResponse struct {
+	ClusterName string             `json:"cluster_name"`
+	Size        int                `json:"size"`
+	MinSize     int                `json:"min_size"`
+	Ready       int                `json:"ready"`
+	Preparing   int                `json:"preparing"`
+	Instances   []WarmPoolInstance `json:"instances"`
+}
+
+type WarmPoolInstance struct {
+	InstanceId   string `json:"instance_id"`
+	Status       string `json:"status"`
+	IpInner      string `json:"ip_inner"`
+	IpOuter      string `json:"ip_outer"`
+	InstanceType string `json:"instance_type"`
+	Image        string `json:"image"`
+	CreateAt     string `json:"create_at"`
+}
+
+type HibernationScheduleResponse struct {
+	ClusterName string `json:"cluster_name"`
+	WorkDays    []int  `json:"work_days"`
+	StartTime   string `json:"start_time"`
+	StopTime    string `json:"stop_time"`
+	Timezone    string `json:"timezone"`
+	Enabled     bool   `json:"enabled"`
+	State       string `json:"state"`
+	UpdateAt    string `json:"update_at"`
+}
+
+type ClusterRevisionListResponse struct {
+	RevisionList []ClusterRevision `json:"revision_list"`
+	Pager        Pager             `json:"pager"`
+}
+
+type ClusterRevision struct {
+	Revision int    `json:"revision"`
+	Comment  string `json:"comment"`
+	CreateBy string `json:"create_by"`
+	CreateAt string `json:"create_at"`
+}
+
+type RollbackClusterResponse struct {
+	Revision int    `json:"revision"`
+	TaskId   string `json:"task_id"`
+}
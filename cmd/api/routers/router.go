@@ -63,6 +63,7 @@ func Init() *gin.Engine {
 			clusterPath.GET("describe_all", handler.ListClusters)
 			clusterPath.GET("custom/detail", handler.CustomClusterDetail)
 			clusterPath.GET("auth", handler.GetClusterAuthByName)
+			clusterPath.GET("warm_pool", handler.GetWarmPool)
 
 			clusterPath.POST("list_by_tags", handler.ListClustersByTags)
 			clusterPath.GET("get_tags", handler.GetClusterTags)
@@ -71,8 +72,31 @@ func Init() *gin.Engine {
 			clusterPath.DELETE("delete_tags", handler.DeleteClusterTags)
 
 			clusterPath.POST("expand", handler.ExpandCluster)
+			clusterPath.POST("expand/plan", handler.PlanExpandCluster)
+			clusterPath.POST("adopt/preview", handler.PreviewAdoptInstances)
+			clusterPath.POST("adopt", handler.AdoptInstances)
 			clusterPath.POST("shrink", handler.ShrinkCluster)
 			clusterPath.POST("shrink_all", handler.ShrinkAllInstances)
+			clusterPath.POST("roll", handler.RollCluster)
+			clusterPath.POST("stop", handler.StopCluster)
+			clusterPath.POST("start", handler.StartCluster)
+			clusterPath.POST("hibernation", handler.SaveHibernationSchedule)
+			clusterPath.GET("hibernation", handler.GetHibernationSchedule)
+			clusterPath.DELETE("hibernation", handler.DeleteHibernationSchedule)
+			clusterPath.POST("renew_policy", handler.SaveRenewPolicy)
+			clusterPath.GET("spec/export", handler.ExportClusterSpecs)
+			clusterPath.POST("spec/plan", handler.PlanClusterSpecs)
+			clusterPath.POST("spec/apply", handler.ApplyClusterSpecs)
+			clusterPath.GET("revisions", handler.ListClusterRevisions)
+			clusterPath.GET("revision/compare", handler.CompareClusterRevisions)
+			clusterPath.POST("revision/rollback", handler.RollbackCluster)
+			clusterPath.POST("clone/plan", handler.PlanCloneCluster)
+			clusterPath.POST("clone", handler.CloneCluster)
+			clusterPath.GET(":name/members", handler.GetClusterMembers)
+			clusterPath.GET(":name/members/watch", handler.WatchClusterMembers)
+			clusterPath.GET("discovery/prometheus", handler.ExportPrometheusTargets)
+			clusterPath.GET("discovery/ansible", handler.ExportAnsibleInventory)
+			clusterPath.GET("discovery/ssh_config", handler.ExportSSHConfig)
 
 			clusterPath.POST("instance/check", handler.CheckInstanceConnectable)
 		}
@@ -81,6 +105,23 @@ func Init() *gin.Engine {
 			vpcPath.GET("info/:id", handler.GetVpcById)
 			vpcPath.POST("create", handler.CreateVpc)
 			vpcPath.GET("describe", handler.DescribeVpc)
+			vpcPath.POST("route/create", handler.CreateRouteEntry)
+			vpcPath.GET("route/describe", handler.DescribeRouteEntries)
+			vpcPath.POST("route/delete", handler.DeleteRouteEntry)
+		}
+		natPath := v1Api.Group("nat_gateway/")
+		{
+			natPath.POST("create", handler.CreateNatGateway)
+			natPath.GET("describe", handler.DescribeNatGateways)
+			natPath.DELETE("delete/:id", handler.DeleteNatGateway)
+			natPath.POST("snat/create", handler.CreateSnatEntry)
+			natPath.DELETE("snat/delete/:id", handler.DeleteSnatEntry)
+		}
+		peeringPath := v1Api.Group("vpc_peering/")
+		{
+			peeringPath.POST("create", handler.CreateVpcPeering)
+			peeringPath.GET("describe", handler.DescribeVpcPeerings)
+			peeringPath.DELETE("delete/:id", handler.DeleteVpcPeering)
 		}
 		subnetPath := v1Api.Group("subnet/")
 		{
@@ -95,11 +136,20 @@ func Init() *gin.Engine {
 			groupPath.POST("rule/add", handler.AddSecurityGroupRule)
 			groupPath.POST("create_with_rule", handler.CreateSecurityGroupWithRules)
 			groupPath.GET(":id/rules", handler.GetSecurityGroupWithRules)
+			groupPath.GET(":id/drift", handler.CheckSecurityGroupDrift)
+			groupPath.POST(":id/drift/remediate", handler.RemediateSecurityGroupDrift)
+			groupPath.POST("template/create", handler.CreateSecurityGroupTemplate)
+			groupPath.POST("template/update", handler.UpdateSecurityGroupTemplate)
+			groupPath.GET("template/list", handler.ListSecurityGroupTemplates)
+			groupPath.DELETE("template/delete/:ids", handler.DeleteSecurityGroupTemplates)
+			groupPath.POST("template/bind", handler.BindSecurityGroupTemplate)
 		}
 		networkPath := v1Api.Group("network_config/")
 		{
 			networkPath.POST("create", handler.CreateNetworkConfig)
 			networkPath.POST("sync", handler.SyncNetworkConfig)
+			networkPath.GET("sync/status", handler.GetNetworkSyncStatus)
+			networkPath.POST("cidr/plan", handler.PlanNetworkCidr)
 			networkPath.GET("template", handler.GetNetCfgTemplate)
 		}
 		regionPath := v1Api.Group("region/")
@@ -123,6 +173,9 @@ func Init() *gin.Engine {
 			instancePath.GET("usage_total", handler.GetInstanceUsageTotal)
 			instancePath.GET("usage_statistics", handler.GetInstanceUsageStatistics)
 			instancePath.POST("sync_expire_time", handler.SyncInstanceExpireTime)
+			instancePath.POST("scale_in_protection", handler.SetInstanceScaleInProtection)
+			instancePath.POST("renew", handler.RenewInstances)
+			instancePath.POST("release_at_expiry", handler.SetInstanceReleaseAtExpiry)
 		}
 		taskPath := v1Api.Group("task/")
 		{
@@ -131,6 +184,9 @@ func Init() *gin.Engine {
 			taskPath.GET("describe", handler.GetTaskDescribe)
 			taskPath.GET("describe_all", handler.GetTaskDescribeAll)
 			taskPath.GET("instances", handler.GetTaskInstances)
+			taskPath.POST("roll/pause", handler.PauseRollTask)
+			taskPath.POST("roll/resume", handler.ResumeRollTask)
+			taskPath.POST("roll/rollback", handler.RollbackRollTask)
 		}
 		userPath := v1Api.Group("user/")
 		{
@@ -164,6 +220,31 @@ func Init() *gin.Engine {
 		{
 			logPath.GET("extract", handler.ExtractLog)
 		}
+		approvalPath := v1Api.Group("approval/")
+		{
+			approvalPath.GET("policy", handler.GetApprovalPolicy)
+			approvalPath.POST("policy/update", handler.UpdateApprovalPolicy)
+			approvalPath.GET("list", handler.ListTaskApprovals)
+			approvalPath.POST("approve", handler.ApproveTask)
+			approvalPath.POST("reject", handler.RejectTask)
+		}
+		notifyPath := v1Api.Group("notify/")
+		{
+			notifyPath.POST("channel/create", handler.CreateNotifyChannel)
+			notifyPath.GET("channel/list", handler.ListNotifyChannels)
+			notifyPath.DELETE("channel/delete/:ids", handler.DeleteNotifyChannels)
+			notifyPath.POST("channel/test", handler.TestNotifyChannel)
+			notifyPath.POST("subscription/create", handler.CreateNotifySubscription)
+			notifyPath.GET("subscription/list", handler.ListNotifySubscriptions)
+			notifyPath.DELETE("subscription/delete/:ids", handler.DeleteNotifySubscriptions)
+			notifyPath.GET("record/list", handler.ListNotifyRecords)
+		}
+		jobPath := v1Api.Group("job/")
+		{
+			jobPath.GET("list", handler.ListBackgroundJobs)
+			jobPath.GET("info/:id", handler.GetBackgroundJob)
+			jobPath.POST("retry/:id", handler.RetryBackgroundJob)
+		}
 
 		gfCluster := v1Api.Group("galaxy_cloud")
 		gf_cluster.RegisterHandler(gfCluster)
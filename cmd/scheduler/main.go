@@ -12,6 +12,7 @@ import (
 	"github.com/galaxy-future/BridgX/internal/bcc"
 	"github.com/galaxy-future/BridgX/internal/cache"
 	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/dns"
 	"github.com/galaxy-future/BridgX/internal/logs"
 )
 
@@ -21,6 +22,7 @@ func main() {
 	clients.MustInit()
 	crond.Init()
 	bcc.MustInit(config.GlobalConfig)
+	dns.MustInit(config.GlobalConfig)
 	cache.MustInit()
 
 	err := Init()
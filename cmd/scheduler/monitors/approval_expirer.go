@@ -0,0 +1,25 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+//ApprovalExpirer 负责将超过有效期仍未审批的任务置为拒绝
+type ApprovalExpirer struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m ApprovalExpirer) Run() {
+	err := m.LockerClient.SyncRun(constants.DefaultApprovalExpirerInterval, constants.ApprovalExpirerETCDLockKey, func() error {
+		return service.ExpireStaleApprovals(context.Background())
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to expire stale approvals, err: %v", err)
+	}
+}
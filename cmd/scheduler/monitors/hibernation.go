@@ -0,0 +1,25 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+//HibernationScheduler 按集群休眠计划在开机时段开始和结束时创建开机或停机任务
+type HibernationScheduler struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m HibernationScheduler) Run() {
+	err := m.LockerClient.SyncRun(constants.DefaultHibernationSchedulerInterval, constants.HibernationSchedulerETCDLockKey, func() error {
+		return service.RunHibernationSchedules(context.Background())
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to run hibernation schedules, err: %v", err)
+	}
+}
@@ -85,7 +85,7 @@ func scheduleJob(clusterName string) error {
 		if len(snapshot.ActiveInstances)-len(deleteIPs) != snapshot.Cluster.ExpectCount {
 			return fmt.Errorf("can not schedule shrink task because expect count != instance count - deleting instance count")
 		}
-		_, err := service.CreateShrinkTask(context.Background(), snapshot.Cluster.ClusterName, len(deleteIPs), strings.Join(deleteIPs, ","), "EXPECT", 0)
+		_, err := service.CreateShrinkTask(context.Background(), snapshot.Cluster.ClusterName, len(deleteIPs), strings.Join(deleteIPs, ","), nil, "EXPECT", 0)
 		if err != nil {
 			logs.Logger.Errorf("CreateShrinkTask err:%v", err)
 			return err
@@ -0,0 +1,25 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+//InstanceHealthChecker 负责周期性探测配置了健康检查的集群实例
+type InstanceHealthChecker struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m InstanceHealthChecker) Run() {
+	err := m.LockerClient.SyncRun(constants.DefaultInstanceHealthCheckInterval, constants.InstanceHealthCheckETCDLockKey, func() error {
+		return service.CheckClustersHealth(context.Background())
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to check instances health, err: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+//NetworkSyncer 增量同步所有账户在各地域下的网络资源, 每个地域记录一份变更报告
+type NetworkSyncer struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m NetworkSyncer) Run() {
+	err := m.LockerClient.SyncRun(constants.DefaultNetworkSyncInterval, constants.NetworkSyncETCDLockKey, func() error {
+		return service.RunNetworkSync(context.Background())
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to sync network, err: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+//NotifyRetrier 负责重新发送失败的通知
+type NotifyRetrier struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m NotifyRetrier) Run() {
+	err := m.LockerClient.SyncRun(constants.DefaultNotifyRetryInterval, constants.NotifyRetryETCDLockKey, func() error {
+		return service.RetryFailedNotifications(context.Background())
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to retry notifications, err: %v", err)
+	}
+}
+
+//InstanceExpireWatcher 负责包年包月实例的自动续费、到期释放, 并对即将到期的实例发送通知
+type InstanceExpireWatcher struct {
+	LockerClient *clients.EtcdClient
+	NoticeDays   int
+}
+
+func (m InstanceExpireWatcher) Run() {
+	noticeDays := m.NoticeDays
+	if noticeDays <= 0 {
+		noticeDays = constants.DefaultInstanceExpireNoticeDays
+	}
+	err := m.LockerClient.SyncRun(constants.DefaultInstanceExpireWatcherInterval, constants.InstanceExpireWatcherETCDLockKey, func() error {
+		ctx := context.Background()
+		if err := service.RenewExpiringInstances(ctx); err != nil {
+			logs.Logger.Errorf("failed to renew expiring instances, err: %v", err)
+		}
+		if err := service.ReleaseExpiredInstances(ctx); err != nil {
+			logs.Logger.Errorf("failed to release expired instances, err: %v", err)
+		}
+		return service.NotifyExpiringInstances(ctx, noticeDays)
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to notify expiring instances, err: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+//SecurityGroupDriftChecker 比较绑定了模板的安全组在云上的规则与模板及规则表, 按绑定配置自动修复
+type SecurityGroupDriftChecker struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m SecurityGroupDriftChecker) Run() {
+	err := m.LockerClient.SyncRun(constants.DefaultSecurityGroupDriftCheckInterval, constants.SecurityGroupDriftCheckETCDLockKey, func() error {
+		return service.RunSecurityGroupDriftChecks(context.Background())
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to check security group drift, err: %v", err)
+	}
+}
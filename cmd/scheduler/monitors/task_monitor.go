@@ -39,27 +39,25 @@ func (m TaskMonitor) Run() {
 }
 
 func scheduleTask(task model.Task) error {
-	//ji检查任务是否已经被执行过了
-	var newTask model.Task
-	err := model.Get(task.Id, &newTask)
+	//检查任务是否已经被执行过了, 未被执行时置为RUNNING并增加版本
+	ok, err := model.ClaimTask(&task)
 	if err != nil {
 		return err
 	}
-	if task.Status != newTask.Status {
+	if !ok {
 		return clients.ErrReviewFailed
 	}
 
 	//执行任务
-	task.Status = constants.TaskStatusRunning
-	err = model.Save(&task)
-	if err != nil {
-		return err
-	}
 	switch task.TaskAction {
 	case constants.TaskActionExpand:
 		pool.ExpandTasksChan <- &task
 	case constants.TaskActionShrink:
 		pool.ShrinkTasksChan <- &task
+	case constants.TaskActionRoll:
+		pool.RollTasksChan <- &task
+	case constants.TaskActionStop, constants.TaskActionStart:
+		pool.PowerTasksChan <- &task
 	default:
 		return fmt.Errorf("unknown task action, action : %v", task.TaskAction)
 	}
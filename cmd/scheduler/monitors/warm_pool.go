@@ -0,0 +1,25 @@
+package monitors
+
+import (
+	"context"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/service"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+//WarmPoolReplenisher 负责将预热池中的实例停机, 并按目标容量补充或释放实例
+type WarmPoolReplenisher struct {
+	LockerClient *clients.EtcdClient
+}
+
+func (m WarmPoolReplenisher) Run() {
+	err := m.LockerClient.SyncRun(constants.DefaultWarmPoolReplenishInterval, constants.WarmPoolReplenishETCDLockKey, func() error {
+		return service.ReplenishWarmPools(context.Background())
+	})
+	if err != nil && err != concurrency.ErrLocked {
+		logs.Logger.Errorf("failed to replenish warm pools, err: %v", err)
+	}
+}
@@ -35,6 +35,62 @@ func Init() error {
 			Interval: constants.DefaultKillExpireRunningTaskInterval,
 			Monitor:  &monitors.TaskKiller{},
 		},
+		{
+			//重新发送失败的通知
+			Interval: constants.DefaultNotifyRetryInterval,
+			Monitor: &monitors.NotifyRetrier{
+				LockerClient: locker,
+			},
+		},
+		{
+			//包年包月实例到期提醒
+			Interval: constants.DefaultInstanceExpireWatcherInterval,
+			Monitor: &monitors.InstanceExpireWatcher{
+				LockerClient: locker,
+			},
+		},
+		{
+			//超时未审批的任务自动拒绝
+			Interval: constants.DefaultApprovalExpirerInterval,
+			Monitor: &monitors.ApprovalExpirer{
+				LockerClient: locker,
+			},
+		},
+		{
+			//集群实例健康检查
+			Interval: constants.DefaultInstanceHealthCheckInterval,
+			Monitor: &monitors.InstanceHealthChecker{
+				LockerClient: locker,
+			},
+		},
+		{
+			//补充预热池实例
+			Interval: constants.DefaultWarmPoolReplenishInterval,
+			Monitor: &monitors.WarmPoolReplenisher{
+				LockerClient: locker,
+			},
+		},
+		{
+			//按休眠计划开关机
+			Interval: constants.DefaultHibernationSchedulerInterval,
+			Monitor: &monitors.HibernationScheduler{
+				LockerClient: locker,
+			},
+		},
+		{
+			//安全组规则偏离模板检测
+			Interval: constants.DefaultSecurityGroupDriftCheckInterval,
+			Monitor: &monitors.SecurityGroupDriftChecker{
+				LockerClient: locker,
+			},
+		},
+		{
+			//定期同步各账户的VPC, 子网, 安全组及规则
+			Interval: constants.DefaultNetworkSyncInterval,
+			Monitor: &monitors.NetworkSyncer{
+				LockerClient: locker,
+			},
+		},
 		//{
 		//	Interval: constants.DefaultQueryOrderInterval,
 		//	Monitor:  &monitors.QueryOrderJobs{},
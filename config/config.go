@@ -42,14 +42,16 @@ func composeConfigFileName(basePath string, suffix string) string {
 }
 
 type Config struct {
-	DebugMode         bool           `yaml:"DebugMode"`
-	NeedPublishConfig bool           `yaml:"NeedPublishConfig"`
-	ServerPort        int            `yaml:"ServerPort"`
-	CostCfg           CostConfig     `yaml:"CostConfig"`
-	WriteDB           DBConfig       `yaml:"WriteDB"`
-	ReadDB            DBConfig       `yaml:"ReadDB"`
-	EtcdConfig        *EtcdConfig    `yaml:"EtcdConfig"`
-	JwtToken          JwtTokenConfig `yaml:"JwtToken"`
+	DebugMode         bool                `yaml:"DebugMode"`
+	NeedPublishConfig bool                `yaml:"NeedPublishConfig"`
+	ServerPort        int                 `yaml:"ServerPort"`
+	CostCfg           CostConfig          `yaml:"CostConfig"`
+	WriteDB           DBConfig            `yaml:"WriteDB"`
+	ReadDB            DBConfig            `yaml:"ReadDB"`
+	EtcdConfig        *EtcdConfig         `yaml:"EtcdConfig"`
+	ConfigCenter      *ConfigCenterConfig `yaml:"ConfigCenter"`
+	DNS               *DNSConfig          `yaml:"DNS"`
+	JwtToken          JwtTokenConfig      `yaml:"JwtToken"`
 }
 
 type JwtTokenConfig struct {
@@ -77,6 +79,52 @@ type EtcdConfig struct {
 	DailTimeout time.Duration `yaml:"DailTimeout"`
 }
 
+//ConfigCenterConfig 配置中心配置, Type为空时使用EtcdConfig
+type ConfigCenterConfig struct {
+	Type   string        `yaml:"Type"` //etcd, consul, nacos, file
+	Consul *ConsulConfig `yaml:"Consul"`
+	Nacos  *NacosConfig  `yaml:"Nacos"`
+	File   *FileConfig   `yaml:"File"`
+}
+
+type ConsulConfig struct {
+	Address    string        `yaml:"Address"`
+	Token      string        `yaml:"Token"`
+	Datacenter string        `yaml:"Datacenter"`
+	Prefix     string        `yaml:"Prefix"` //KV路径前缀, 实际路径为Prefix/group/dataId
+	Timeout    time.Duration `yaml:"Timeout"`
+}
+
+type NacosConfig struct {
+	Address   string        `yaml:"Address"`
+	Namespace string        `yaml:"Namespace"`
+	Username  string        `yaml:"Username"`
+	Password  string        `yaml:"Password"`
+	Timeout   time.Duration `yaml:"Timeout"`
+}
+
+type FileConfig struct {
+	Dir string `yaml:"Dir"` //配置保存目录, 实际文件为Dir/group/dataId
+}
+
+//DNSConfig 集群实例DNS记录配置, Type为空时不维护DNS记录
+type DNSConfig struct {
+	Type       string         `yaml:"Type"` //rfc2136
+	Zone       string         `yaml:"Zone"` //集群记录为<cluster>.Zone, 实例记录为<instance_id>.<cluster>.Zone
+	TTL        int            `yaml:"TTL"`
+	UseOuterIp bool           `yaml:"UseOuterIp"`
+	RFC2136    *RFC2136Config `yaml:"RFC2136"`
+}
+
+type RFC2136Config struct {
+	Server        string        `yaml:"Server"` //host:port
+	Net           string        `yaml:"Net"`    //udp, tcp, 默认udp
+	TsigKeyName   string        `yaml:"TsigKeyName"`
+	TsigSecret    string        `yaml:"TsigSecret"`    //base64编码
+	TsigAlgorithm string        `yaml:"TsigAlgorithm"` //hmac-sha256, hmac-sha512, hmac-sha1, 默认hmac-sha256
+	Timeout       time.Duration `yaml:"Timeout"`
+}
+
 type CostConfig struct {
 	QueryOrderIntvalSec          int `yaml:"QueryOrderIntvalSec"`
 	QueryAlibabaCloudOrderPerMin int `yaml:"QueryAlibabaCloudOrderPerMin"`
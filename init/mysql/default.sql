@@ -163,6 +163,12 @@ CREATE TABLE `cluster`
     `delete_uniq_key` bigint(20) DEFAULT '0',
     `key_id`          bigint(20) DEFAULT NULL COMMENT '秘钥对ID',
     `auth_type`       varchar(32) COLLATE utf8mb4_bin NOT NULL DEFAULT 'password' COMMENT '认证类型 password/key_pair',
+    `min_count`       int(7) NOT NULL DEFAULT '0' COMMENT '最小实例数',
+    `max_count`       int(7) NOT NULL DEFAULT '0' COMMENT '最大实例数, 0表示不限制',
+    `shrink_strategy` varchar(1024) COLLATE utf8mb4_bin        DEFAULT '' COMMENT '缩容实例选择策略',
+    `health_check`    varchar(1024) COLLATE utf8mb4_bin        DEFAULT '' COMMENT '健康检查配置',
+    `warm_pool`       varchar(256) COLLATE utf8mb4_bin         DEFAULT '' COMMENT '预热池配置',
+    `auto_renew`      tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否开启自动续费',
     PRIMARY KEY (`id`),
     UNIQUE KEY `cluster_cluster_name_uindex` (`cluster_name`, `delete_uniq_key`),
     KEY `cluster_account_key_index` (`account_key`)
@@ -204,7 +210,7 @@ CREATE TABLE `instance`
     `task_id`        bigint(20) NOT NULL DEFAULT '-1',
     `shrink_task_id` bigint(20) NOT NULL DEFAULT '-1',
     `instance_id`    varchar(255)         DEFAULT NULL,
-    `status`         varchar(32) NOT NULL DEFAULT 'UNDEFINED',
+    `status`         varchar(32) NOT NULL DEFAULT 'UNDEFINED' COMMENT 'UNDEFINED/PENDING/TIMEOUT/STARTING/RUNNING/STOPPING/STOPPED/DELETING/DELETED',
     `ip_inner`       varchar(255)         DEFAULT NULL,
     `ip_outer`       varchar(255)         DEFAULT NULL,
     `attrs`          varchar(1024)        DEFAULT NULL,
@@ -214,6 +220,12 @@ CREATE TABLE `instance`
     `running_at`     timestamp NULL DEFAULT NULL,
     `charge_type`    varchar(32) collate utf8mb4_bin NOT NULL DEFAULT 'PostPaid',
     `expire_at`      timestamp NULL DEFAULT NULL comment 'PrePaid instance expire time',
+    `zone_id`        varchar(64)          DEFAULT '' COMMENT '可用区',
+    `scale_in_protected` tinyint(1) NOT NULL DEFAULT '0' COMMENT '缩容保护',
+    `health_status`      varchar(32) NOT NULL DEFAULT '' COMMENT '健康状态 HEALTHY/UNHEALTHY',
+    `health_fail_count`  int(11) NOT NULL DEFAULT '0' COMMENT '连续健康检查失败次数',
+    `health_check_at`    timestamp NULL DEFAULT NULL COMMENT '最近一次健康检查时间',
+    `release_at_expiry`  tinyint(1) NOT NULL DEFAULT '0' COMMENT '包年包月实例到期后释放, 不再续费',
     PRIMARY KEY (`id`),
     KEY              `idx_ip_inner` (`ip_inner`),
     KEY              `instance_cluster_name_status_index` (`cluster_name`,`status`),
@@ -282,6 +294,7 @@ CREATE TABLE `task`
     `err_msg`        text COLLATE utf8mb4_bin,
     `support_cancel` tinyint(1) DEFAULT NULL,
     `finish_time`    timestamp NULL,
+    `version`        bigint(20) NOT NULL DEFAULT '0',
     `create_at`      timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
     `update_at`      timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     PRIMARY KEY (`id`),
@@ -432,6 +445,316 @@ CREATE TABLE `key_pair` (
                             UNIQUE KEY `uniq_provider_region_id_key_pair_name` (`provider`,`region_id`,`key_pair_name`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群秘钥对';
 
+DROP TABLE IF EXISTS `notify_channel`;
+CREATE TABLE `notify_channel` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `org_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '组织ID',
+    `name` varchar(128) NOT NULL DEFAULT '' COMMENT '渠道名称',
+    `channel_type` varchar(32) NOT NULL DEFAULT '' COMMENT '渠道类型 lark/dingtalk/wecom/webhook/email',
+    `config` text COMMENT '渠道配置',
+    `enabled` tinyint(1) NOT NULL DEFAULT '1' COMMENT '是否启用',
+    `create_by` varchar(64) NOT NULL DEFAULT '' COMMENT '创建人',
+    `create_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_org_id` (`org_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知渠道';
+
+DROP TABLE IF EXISTS `notify_subscription`;
+CREATE TABLE `notify_subscription` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `org_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '组织ID',
+    `channel_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '通知渠道ID',
+    `cluster_name` varchar(255) NOT NULL DEFAULT '' COMMENT '集群名称, 为空表示全部集群',
+    `event_types` varchar(512) NOT NULL DEFAULT '' COMMENT '事件类型, 逗号分隔, 为空表示全部事件',
+    `min_severity` varchar(16) NOT NULL DEFAULT 'INFO' COMMENT '最低告警级别 INFO/WARNING/CRITICAL',
+    `template` text COMMENT '消息模板',
+    `create_by` varchar(64) NOT NULL DEFAULT '' COMMENT '创建人',
+    `create_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_org_id` (`org_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知订阅规则';
+
+DROP TABLE IF EXISTS `notify_record`;
+CREATE TABLE `notify_record` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `org_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '组织ID',
+    `channel_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '通知渠道ID',
+    `event_type` varchar(64) NOT NULL DEFAULT '' COMMENT '事件类型',
+    `severity` varchar(16) NOT NULL DEFAULT '' COMMENT '告警级别',
+    `cluster_name` varchar(255) NOT NULL DEFAULT '' COMMENT '集群名称',
+    `title` varchar(512) NOT NULL DEFAULT '' COMMENT '消息标题',
+    `content` text COMMENT '消息内容',
+    `status` varchar(16) NOT NULL DEFAULT 'PENDING' COMMENT '发送状态 PENDING/SUCCESS/FAILED',
+    `retry_count` int(11) NOT NULL DEFAULT '0' COMMENT '重试次数',
+    `next_retry_at` timestamp NULL DEFAULT NULL COMMENT '下次重试时间',
+    `err_msg` varchar(1024) NOT NULL DEFAULT '' COMMENT '错误信息',
+    `create_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_org_id` (`org_id`),
+    KEY `idx_status_next_retry_at` (`status`, `next_retry_at`),
+    KEY `idx_cluster_name_event_type` (`cluster_name`, `event_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='通知发送记录';
+
+DROP TABLE IF EXISTS `approval_policy`;
+CREATE TABLE `approval_policy` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `org_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '组织ID',
+    `enabled` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否启用审批',
+    `max_expand_count` int(11) NOT NULL DEFAULT '0' COMMENT '单次扩容数量超过该值需要审批, 0表示不限制',
+    `max_monthly_cost` decimal(16,2) NOT NULL DEFAULT '0.00' COMMENT '单次扩容预估月费用超过该值需要审批, 0表示不限制',
+    `expire_hours` int(11) NOT NULL DEFAULT '24' COMMENT '审批单有效期（小时）',
+    `update_by` varchar(64) NOT NULL DEFAULT '' COMMENT '更新人',
+    `create_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_org_id` (`org_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='扩容审批策略';
+
+DROP TABLE IF EXISTS `task_approval`;
+CREATE TABLE `task_approval` (
+    `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+    `task_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '任务ID',
+    `org_id` bigint(20) NOT NULL DEFAULT '0' COMMENT '组织ID',
+    `cluster_name` varchar(255) NOT NULL DEFAULT '' COMMENT '集群名称',
+    `task_action` varchar(32) NOT NULL DEFAULT '' COMMENT '任务类型',
+    `count` int(11) NOT NULL DEFAULT '0' COMMENT '扩容数量',
+    `estimated_cost` decimal(16,2) NOT NULL DEFAULT '0.00' COMMENT '预估月费用',
+    `reason` varchar(512) NOT NULL DEFAULT '' COMMENT '需要审批的原因',
+    `status` varchar(16) NOT NULL DEFAULT 'PENDING' COMMENT '审批状态 PENDING/APPROVED/REJECTED/EXPIRED',
+    `applicant` bigint(20) NOT NULL DEFAULT '0' COMMENT '申请人ID',
+    `approver` bigint(20) NOT NULL DEFAULT '0' COMMENT '审批人ID',
+    `comment` varchar(512) NOT NULL DEFAULT '' COMMENT '审批意见',
+    `expire_at` timestamp NULL DEFAULT NULL COMMENT '审批有效期',
+    `review_at` timestamp NULL DEFAULT NULL COMMENT '审批时间',
+    `create_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `update_at` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_task_id` (`task_id`),
+    KEY `idx_org_id_status` (`org_id`, `status`),
+    KEY `idx_status_expire_at` (`status`, `expire_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='任务审批单';
+
+--
+-- Table structure for table `warm_pool_instance`
+--
+
+DROP TABLE IF EXISTS `warm_pool_instance`;
+CREATE TABLE `warm_pool_instance`
+(
+    `id`            bigint(20) NOT NULL AUTO_INCREMENT,
+    `cluster_name`  varchar(64) NOT NULL COMMENT '集群名称',
+    `instance_id`   varchar(255) NOT NULL COMMENT '云厂商实例ID',
+    `status`        varchar(32) NOT NULL DEFAULT 'PENDING' COMMENT 'PENDING/STOPPING/STOPPED/STARTING/USED/RELEASED',
+    `ip_inner`      varchar(255) NOT NULL DEFAULT '',
+    `ip_outer`      varchar(255) NOT NULL DEFAULT '',
+    `zone_id`       varchar(64) NOT NULL DEFAULT '',
+    `charge_type`   varchar(32) NOT NULL DEFAULT '',
+    `instance_type` varchar(32) NOT NULL DEFAULT '' COMMENT '创建时的实例规格',
+    `image`         varchar(512) NOT NULL DEFAULT '' COMMENT '创建时的镜像',
+    `task_id`       bigint(20) NOT NULL DEFAULT '0' COMMENT '使用该实例的扩容任务ID',
+    `create_at`     timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`     timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_cluster_name_status` (`cluster_name`, `status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='预热池实例';
+
+--
+-- Table structure for table `hibernation_schedule`
+--
+
+DROP TABLE IF EXISTS `hibernation_schedule`;
+CREATE TABLE `hibernation_schedule`
+(
+    `id`           bigint(20) NOT NULL AUTO_INCREMENT,
+    `cluster_name` varchar(64) NOT NULL COMMENT '集群名称',
+    `work_days`    varchar(32) NOT NULL DEFAULT '1,2,3,4,5' COMMENT '保持开机的星期, 0表示周日',
+    `start_time`   varchar(8) NOT NULL COMMENT '每天开机时间 HH:MM',
+    `stop_time`    varchar(8) NOT NULL COMMENT '每天停机时间 HH:MM',
+    `timezone`     varchar(64) NOT NULL DEFAULT 'Local',
+    `enabled`      tinyint(1) NOT NULL DEFAULT '1',
+    `state`        varchar(16) NOT NULL DEFAULT '' COMMENT '最近一次按计划切换到的状态 AWAKE/HIBERNATED',
+    `create_by`    bigint(20) NOT NULL DEFAULT '0',
+    `create_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_cluster_name` (`cluster_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群休眠计划';
+
+DROP TABLE IF EXISTS `cluster_revision`;
+CREATE TABLE `cluster_revision`
+(
+    `id`           bigint(20) NOT NULL AUTO_INCREMENT,
+    `cluster_name` varchar(64) NOT NULL COMMENT '集群名称',
+    `revision`     int(11) NOT NULL COMMENT '集群内递增的版本号',
+    `content`      text NOT NULL COMMENT '集群配置快照(JSON)',
+    `comment`      varchar(255) NOT NULL DEFAULT '',
+    `create_by`    varchar(64) NOT NULL DEFAULT '',
+    `create_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_cluster_revision` (`cluster_name`, `revision`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='集群配置历史版本';
+
+DROP TABLE IF EXISTS `security_group_template`;
+CREATE TABLE `security_group_template`
+(
+    `id`          bigint(20) NOT NULL AUTO_INCREMENT,
+    `org_id`      bigint(20) NOT NULL,
+    `name`        varchar(64) NOT NULL COMMENT '模板名称, 如web, ssh-from-bastion',
+    `description` varchar(255) NOT NULL DEFAULT '',
+    `rules`       text NOT NULL COMMENT '安全组规则(JSON)',
+    `create_by`   varchar(64) NOT NULL DEFAULT '',
+    `create_at`   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_org_name` (`org_id`, `name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='安全组规则模板';
+
+DROP TABLE IF EXISTS `security_group_template_binding`;
+CREATE TABLE `security_group_template_binding`
+(
+    `id`                bigint(20) NOT NULL AUTO_INCREMENT,
+    `org_id`            bigint(20) NOT NULL,
+    `template_id`       bigint(20) NOT NULL,
+    `security_group_id` varchar(255) NOT NULL,
+    `account_key`       varchar(255) NOT NULL,
+    `provider`          varchar(32) NOT NULL,
+    `region_id`         varchar(64) NOT NULL,
+    `vpc_id`            varchar(255) NOT NULL DEFAULT '',
+    `auto_remediate`    tinyint(1) NOT NULL DEFAULT '0' COMMENT '检测到偏离时是否自动按模板修复',
+    `drift_status`      varchar(16) NOT NULL DEFAULT '' COMMENT 'IN_SYNC, DRIFTED, FAILED',
+    `drift_report`      text COMMENT '最近一次检测结果(JSON)',
+    `last_check_at`     timestamp NULL DEFAULT NULL,
+    `create_at`         timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`         timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_security_group_id` (`security_group_id`),
+    KEY `idx_template_id` (`template_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='安全组与规则模板的绑定及偏离检测结果';
+
+CREATE TABLE `b_network_sync_record`
+(
+    `id`           bigint(20) NOT NULL AUTO_INCREMENT,
+    `ak`           varchar(255) NOT NULL,
+    `provider`     varchar(32)  NOT NULL,
+    `region_id`    varchar(64)  NOT NULL,
+    `trigger_type` varchar(16)  NOT NULL DEFAULT '' COMMENT 'SCHEDULE, MANUAL, ACCOUNT',
+    `status`       varchar(16)  NOT NULL DEFAULT '' COMMENT 'RUNNING, SUCCESS, FAILED',
+    `report`       text COMMENT '本次同步新增/变更/删除的资源(JSON)',
+    `err_msg`      varchar(1024) NOT NULL DEFAULT '',
+    `start_at`     timestamp NULL DEFAULT NULL,
+    `end_at`       timestamp NULL DEFAULT NULL,
+    `create_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_ak_provider_region` (`ak`,`provider`,`region_id`),
+    KEY `idx_create_at` (`create_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='网络资源同步记录';
+
+CREATE TABLE `background_job`
+(
+    `id`           bigint(20) NOT NULL AUTO_INCREMENT,
+    `org_id`       bigint(20) NOT NULL DEFAULT '0',
+    `job_type`     varchar(32)  NOT NULL COMMENT 'VPC_REFRESH, SWITCH_REFRESH, ACCOUNT_REFRESH, INSTANCE_TYPE_SYNC',
+    `payload`      text COMMENT '任务参数(JSON)',
+    `status`       varchar(16)  NOT NULL DEFAULT 'PENDING' COMMENT 'PENDING, RUNNING, SUCCESS, FAILED',
+    `attempts`     int(11) NOT NULL DEFAULT '0' COMMENT '已执行次数',
+    `max_attempts` int(11) NOT NULL DEFAULT '1' COMMENT '最多执行次数',
+    `next_run_at`  timestamp NULL DEFAULT NULL COMMENT '下次执行时间',
+    `last_error`   varchar(1024) NOT NULL DEFAULT '',
+    `worker`       varchar(255) NOT NULL DEFAULT '' COMMENT '执行任务的实例',
+    `start_at`     timestamp NULL DEFAULT NULL,
+    `end_at`       timestamp NULL DEFAULT NULL,
+    `create_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`    timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    KEY `idx_status_next_run_at` (`status`, `next_run_at`),
+    KEY `idx_org_id` (`org_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='后台任务队列';
+
+CREATE TABLE `b_nat_gateway`
+(
+    `id`             bigint(20) NOT NULL AUTO_INCREMENT,
+    `ak`             varchar(255) NOT NULL,
+    `provider`       varchar(32)  NOT NULL,
+    `region_id`      varchar(64)  NOT NULL,
+    `vpc_id`         varchar(255) NOT NULL,
+    `switch_id`      varchar(255) NOT NULL DEFAULT '',
+    `nat_gateway_id` varchar(255) NOT NULL,
+    `name`           varchar(255) NOT NULL DEFAULT '',
+    `snat_table_id`  varchar(255) NOT NULL DEFAULT '',
+    `ip_addresses`   varchar(1024) NOT NULL DEFAULT '' COMMENT '绑定的公网IP, 逗号分隔',
+    `v_status`       varchar(64)  NOT NULL DEFAULT '',
+    `is_del`         tinyint(3) NOT NULL DEFAULT '0',
+    `create_at`      timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`      timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_ak_provider_region_nat` (`ak`,`provider`,`region_id`,`nat_gateway_id`),
+    KEY `idx_vpc_id` (`vpc_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='NAT网关表';
+
+CREATE TABLE `b_snat_entry`
+(
+    `id`             bigint(20) NOT NULL AUTO_INCREMENT,
+    `nat_gateway_id` varchar(255) NOT NULL,
+    `snat_table_id`  varchar(255) NOT NULL,
+    `snat_entry_id`  varchar(255) NOT NULL,
+    `name`           varchar(255) NOT NULL DEFAULT '',
+    `switch_id`      varchar(255) NOT NULL DEFAULT '',
+    `source_cidr`    varchar(64)  NOT NULL DEFAULT '',
+    `snat_ip`        varchar(255) NOT NULL DEFAULT '',
+    `v_status`       varchar(64)  NOT NULL DEFAULT '',
+    `is_del`         tinyint(3) NOT NULL DEFAULT '0',
+    `create_at`      timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`      timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_snat_table_entry` (`snat_table_id`,`snat_entry_id`),
+    KEY `idx_nat_gateway_id` (`nat_gateway_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='NAT网关SNAT条目表';
+
+CREATE TABLE `b_vpc_peering`
+(
+    `id`              bigint(20) NOT NULL AUTO_INCREMENT,
+    `ak`              varchar(255) NOT NULL,
+    `provider`        varchar(32)  NOT NULL,
+    `region_id`       varchar(64)  NOT NULL,
+    `vpc_id`          varchar(255) NOT NULL DEFAULT '',
+    `peering_id`      varchar(255) NOT NULL,
+    `name`            varchar(255) NOT NULL DEFAULT '',
+    `peer_region_id`  varchar(64)  NOT NULL DEFAULT '',
+    `peer_vpc_id`     varchar(255) NOT NULL DEFAULT '',
+    `peer_peering_id` varchar(255) NOT NULL DEFAULT '' COMMENT '对端的连接ID',
+    `v_status`        varchar(64)  NOT NULL DEFAULT '',
+    `is_del`          tinyint(3) NOT NULL DEFAULT '0',
+    `create_at`       timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`       timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_ak_provider_region_peering` (`ak`,`provider`,`region_id`,`peering_id`),
+    KEY `idx_vpc_id` (`vpc_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='VPC对等连接表';
+
+CREATE TABLE `b_route_entry`
+(
+    `id`               bigint(20) NOT NULL AUTO_INCREMENT,
+    `vpc_id`           varchar(255) NOT NULL,
+    `route_table_id`   varchar(255) NOT NULL,
+    `route_entry_id`   varchar(255) NOT NULL DEFAULT '',
+    `name`             varchar(255) NOT NULL DEFAULT '',
+    `destination_cidr` varchar(64)  NOT NULL,
+    `next_hop_type`    varchar(32)  NOT NULL DEFAULT '' COMMENT 'Instance, NatGateway, VpcPeering',
+    `next_hop_id`      varchar(255) NOT NULL DEFAULT '',
+    `v_status`         varchar(64)  NOT NULL DEFAULT '',
+    `is_del`           tinyint(3) NOT NULL DEFAULT '0',
+    `create_at`        timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `update_at`        timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uniq_route_table_destination` (`route_table_id`,`destination_cidr`),
+    KEY `idx_vpc_id` (`vpc_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='VPC自定义路由表';
+
 -- init super admin info
 INSERT INTO `user`
 VALUES (1, 'root', '87d9bb400c0634691f0e3baaf1e2fd0d', 1, 'enable', 1, '2021-11-09 12:29:44', '',
@@ -1,25 +1,65 @@
 package bcc
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/galaxy-future/BridgX/config"
 	"github.com/galaxy-future/BridgX/internal/clients"
 )
 
+const (
+	TypeEtcd   = "etcd"
+	TypeConsul = "consul"
+	TypeNacos  = "nacos"
+	TypeFile   = "file"
+)
+
 var configCenter ConfigCenter
 
+var ErrVersionConflict = errors.New("config version conflict")
+var ErrVersionUnsupported = errors.New("config center does not support compare and publish")
+
 type ConfigCenter interface {
 	GetConfig(group, dataId string) (string, error)
 	PublishConfig(group, dataId, content string) error
 }
 
+//VersionedConfigCenter 支持按版本比较并写入的配置中心, version为0表示配置不存在
+type VersionedConfigCenter interface {
+	GetConfigWithVersion(group, dataId string) (content string, version int64, err error)
+	CompareAndPublishConfig(group, dataId, content string, version int64) (bool, error)
+}
+
 func MustInit(config *config.Config) {
-	clt, err := clients.NewEtcdClient(config.EtcdConfig)
+	clt, err := NewConfigCenter(config)
 	if err != nil {
 		panic(err)
 	}
 	configCenter = clt
 }
 
+//NewConfigCenter 按ConfigCenter.Type创建配置中心, 未配置时使用etcd
+func NewConfigCenter(conf *config.Config) (ConfigCenter, error) {
+	cc := conf.ConfigCenter
+	if cc == nil || cc.Type == "" || cc.Type == TypeEtcd {
+		clt, err := clients.NewEtcdClient(conf.EtcdConfig)
+		if err != nil {
+			return nil, err
+		}
+		return clt, nil
+	}
+	switch cc.Type {
+	case TypeConsul:
+		return NewConsulClient(cc.Consul)
+	case TypeNacos:
+		return NewNacosClient(cc.Nacos)
+	case TypeFile:
+		return NewFileClient(cc.File)
+	}
+	return nil, fmt.Errorf("unsupported config center type: %v", cc.Type)
+}
+
 func GetConfig(group, dataId string) (string, error) {
 	return configCenter.GetConfig(group, dataId)
 }
@@ -27,3 +67,28 @@ func GetConfig(group, dataId string) (string, error) {
 func PublishConfig(group, dataId, content string) error {
 	return configCenter.PublishConfig(group, dataId, content)
 }
+
+//GetConfigWithVersion 获取配置及其版本, 配置中心不支持版本时version始终为0
+func GetConfigWithVersion(group, dataId string) (string, int64, error) {
+	if vcc, ok := configCenter.(VersionedConfigCenter); ok {
+		return vcc.GetConfigWithVersion(group, dataId)
+	}
+	content, err := configCenter.GetConfig(group, dataId)
+	return content, 0, err
+}
+
+//CompareAndPublishConfig 配置的当前版本等于version时才写入, 否则返回ErrVersionConflict. 配置中心不支持版本时返回ErrVersionUnsupported, 避免并发写入互相覆盖
+func CompareAndPublishConfig(group, dataId, content string, version int64) error {
+	vcc, ok := configCenter.(VersionedConfigCenter)
+	if !ok {
+		return ErrVersionUnsupported
+	}
+	succeeded, err := vcc.CompareAndPublishConfig(group, dataId, content, version)
+	if err != nil {
+		return err
+	}
+	if !succeeded {
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -1,10 +1,16 @@
 package bcc
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/galaxy-future/BridgX/config"
+	jsoniter "github.com/json-iterator/go"
 )
 
 func TestGetConfig(t *testing.T) {
@@ -31,3 +37,157 @@ func TestGetConfig(t *testing.T) {
 		t.Errorf("un equal got :%s want:%s", gotContent, testContent)
 	}
 }
+
+func testConfigCenter(t *testing.T, cc ConfigCenter) {
+	got, err := cc.GetConfig("cluster1", "WorkingIPs")
+	if err != nil || got != "" {
+		t.Errorf("GetConfig of missing key = %q, %v, want empty", got, err)
+	}
+	for _, content := range []string{"10.0.0.1,10.0.0.2", "10.0.0.3"} {
+		if err = cc.PublishConfig("cluster1", "WorkingIPs", content); err != nil {
+			t.Fatalf("PublishConfig failed, err: %v", err)
+		}
+		got, err = cc.GetConfig("cluster1", "WorkingIPs")
+		if err != nil || got != content {
+			t.Errorf("GetConfig = %q, %v, want %q", got, err, content)
+		}
+	}
+}
+
+func testVersionedConfigCenter(t *testing.T, cc ConfigCenter) {
+	configCenter = cc
+	_, version, err := GetConfigWithVersion("cluster2", "members")
+	if err != nil || version != 0 {
+		t.Fatalf("GetConfigWithVersion of missing key = %v, %v, want 0", version, err)
+	}
+	if err = CompareAndPublishConfig("cluster2", "members", "v1", version); err != nil {
+		t.Fatalf("CompareAndPublishConfig failed, err: %v", err)
+	}
+	if err = CompareAndPublishConfig("cluster2", "members", "stale", version); err != ErrVersionConflict {
+		t.Errorf("CompareAndPublishConfig with stale version, err = %v, want %v", err, ErrVersionConflict)
+	}
+	content, version, err := GetConfigWithVersion("cluster2", "members")
+	if err != nil || content != "v1" || version == 0 {
+		t.Fatalf("GetConfigWithVersion = %q, %v, %v", content, version, err)
+	}
+	if err = CompareAndPublishConfig("cluster2", "members", "v2", version); err != nil {
+		t.Errorf("CompareAndPublishConfig failed, err: %v", err)
+	}
+}
+
+func TestFileClient(t *testing.T) {
+	cc, err := NewConfigCenter(&config.Config{ConfigCenter: &config.ConfigCenterConfig{
+		Type: TypeFile,
+		File: &config.FileConfig{Dir: t.TempDir()},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testConfigCenter(t, cc)
+	if err = cc.PublishConfig("..", "WorkingIPs", ""); err == nil {
+		t.Errorf("PublishConfig with group .. should fail")
+	}
+	testVersionedConfigCenter(t, cc)
+}
+
+func TestConsulClient(t *testing.T) {
+	var lock sync.Mutex
+	kv := make(map[string]consulKVPair)
+	index := int64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		query := r.URL.Query()
+		if r.Header.Get("X-Consul-Token") != "token" || query.Get("dc") != "dc1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		pair, ok := kv[r.URL.Path]
+		switch r.Method {
+		case http.MethodGet:
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if _, raw := query["raw"]; raw {
+				_, _ = w.Write(pair.Value)
+				return
+			}
+			body, _ := jsoniter.Marshal([]consulKVPair{pair})
+			_, _ = w.Write(body)
+		case http.MethodPut:
+			if cas := query.Get("cas"); cas != "" && cas != strconv.FormatInt(pair.ModifyIndex, 10) {
+				_, _ = w.Write([]byte("false"))
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			index++
+			kv[r.URL.Path] = consulKVPair{ModifyIndex: index, Value: body}
+			_, _ = w.Write([]byte("true"))
+		}
+	}))
+	defer server.Close()
+	cc, err := NewConsulClient(&config.ConsulConfig{Address: server.URL + "/", Token: "token", Datacenter: "dc1", Prefix: "bridgx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testConfigCenter(t, cc)
+	if _, ok := kv["/v1/kv/bridgx/cluster1/WorkingIPs"]; !ok {
+		t.Errorf("unexpected consul keys: %v", kv)
+	}
+	testVersionedConfigCenter(t, cc)
+}
+
+func TestNacosClient(t *testing.T) {
+	var lock sync.Mutex
+	configs := make(map[string]string)
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		_ = r.ParseForm()
+		if r.URL.Path == "/nacos/v1/auth/login" {
+			logins++
+			_, _ = w.Write([]byte(`{"accessToken":"token","tokenTtl":18000}`))
+			return
+		}
+		if r.Form.Get("accessToken") != "token" || r.Form.Get("tenant") != "ns" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		key := r.Form.Get("group") + "/" + r.Form.Get("dataId")
+		switch r.Method {
+		case http.MethodGet:
+			v, ok := configs[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(v))
+		case http.MethodPost:
+			if v, ok := configs[key]; ok && r.Form.Get("casMd5") != "" && r.Form.Get("casMd5") != nacosMd5(v) {
+				_, _ = w.Write([]byte("false"))
+				return
+			}
+			configs[key] = r.Form.Get("content")
+			_, _ = w.Write([]byte("true"))
+		}
+	}))
+	defer server.Close()
+	cc, err := NewNacosClient(&config.NacosConfig{Address: server.URL, Namespace: "ns", Username: "nacos", Password: "nacos"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testConfigCenter(t, cc)
+	testVersionedConfigCenter(t, cc)
+	if logins != 1 {
+		t.Errorf("nacos logins = %v, want 1", logins)
+	}
+}
+
+func TestNewConfigCenterUnsupported(t *testing.T) {
+	_, err := NewConfigCenter(&config.Config{ConfigCenter: &config.ConfigCenterConfig{Type: "zookeeper"}})
+	if err == nil {
+		t.Errorf("NewConfigCenter with unsupported type should fail")
+	}
+}
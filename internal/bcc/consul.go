@@ -0,0 +1,160 @@
+package bcc
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/config"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const defaultHTTPTimeout = 5 * time.Second
+
+type consulKVPair struct {
+	ModifyIndex int64  `json:"ModifyIndex"`
+	Value       []byte `json:"Value"`
+}
+
+//ConsulClient 基于Consul KV HTTP API的配置中心
+type ConsulClient struct {
+	address    string
+	token      string
+	datacenter string
+	prefix     string
+	httpClient *http.Client
+}
+
+func NewConsulClient(conf *config.ConsulConfig) (*ConsulClient, error) {
+	if conf == nil || conf.Address == "" {
+		return nil, errors.New("empty consul config")
+	}
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	return &ConsulClient{
+		address:    strings.TrimRight(conf.Address, "/"),
+		token:      conf.Token,
+		datacenter: conf.Datacenter,
+		prefix:     strings.Trim(conf.Prefix, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (c *ConsulClient) GetConfig(group, dataId string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.kvURL(group, dataId, url.Values{"raw": []string{""}}), nil)
+	if err != nil {
+		return "", err
+	}
+	body, status, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusNotFound {
+		return "", nil
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("consul get %v/%v failed, status: %v, body: %s", group, dataId, status, body)
+	}
+	return string(body), nil
+}
+
+func (c *ConsulClient) PublishConfig(group, dataId, content string) error {
+	req, err := http.NewRequest(http.MethodPut, c.kvURL(group, dataId, url.Values{}), strings.NewReader(content))
+	if err != nil {
+		return err
+	}
+	body, status, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || strings.TrimSpace(string(body)) != "true" {
+		return fmt.Errorf("consul put %v/%v failed, status: %v, body: %s", group, dataId, status, body)
+	}
+	return nil
+}
+
+//GetConfigWithVersion 以ModifyIndex作为版本
+func (c *ConsulClient) GetConfigWithVersion(group, dataId string) (string, int64, error) {
+	req, err := http.NewRequest(http.MethodGet, c.kvURL(group, dataId, url.Values{}), nil)
+	if err != nil {
+		return "", 0, err
+	}
+	body, status, err := c.do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	if status == http.StatusNotFound {
+		return "", 0, nil
+	}
+	if status != http.StatusOK {
+		return "", 0, fmt.Errorf("consul get %v/%v failed, status: %v, body: %s", group, dataId, status, body)
+	}
+	pairs := make([]consulKVPair, 0)
+	if err = jsoniter.Unmarshal(body, &pairs); err != nil {
+		return "", 0, err
+	}
+	if len(pairs) == 0 {
+		return "", 0, nil
+	}
+	return string(pairs[0].Value), pairs[0].ModifyIndex, nil
+}
+
+//CompareAndPublishConfig 使用cas参数写入, cas为0时仅在key不存在时写入
+func (c *ConsulClient) CompareAndPublishConfig(group, dataId, content string, version int64) (bool, error) {
+	query := url.Values{"cas": []string{strconv.FormatInt(version, 10)}}
+	req, err := http.NewRequest(http.MethodPut, c.kvURL(group, dataId, query), strings.NewReader(content))
+	if err != nil {
+		return false, err
+	}
+	body, status, err := c.do(req)
+	if err != nil {
+		return false, err
+	}
+	if status != http.StatusOK {
+		return false, fmt.Errorf("consul cas %v/%v failed, status: %v, body: %s", group, dataId, status, body)
+	}
+	return strings.TrimSpace(string(body)) == "true", nil
+}
+
+func (c *ConsulClient) kvURL(group, dataId string, query url.Values) string {
+	key := fmtKey(group, dataId)
+	if c.prefix != "" {
+		key = c.prefix + "/" + key
+	}
+	if c.datacenter != "" {
+		query.Set("dc", c.datacenter)
+	}
+	u := c.address + "/v1/kv/" + key
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func (c *ConsulClient) do(req *http.Request) ([]byte, int, error) {
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+//fmtKey 与etcd保持一致的key格式
+func fmtKey(group, dataId string) string {
+	return group + "/" + dataId
+}
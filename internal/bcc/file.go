@@ -0,0 +1,124 @@
+package bcc
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/galaxy-future/BridgX/config"
+)
+
+//FileClient 基于本地文件的配置中心, 用于开发及测试环境, 每个配置保存为Dir/group/dataId
+type FileClient struct {
+	dir  string
+	lock sync.RWMutex
+}
+
+func NewFileClient(conf *config.FileConfig) (*FileClient, error) {
+	if conf == nil || conf.Dir == "" {
+		return nil, errors.New("empty file config")
+	}
+	if err := os.MkdirAll(conf.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileClient{dir: conf.Dir}, nil
+}
+
+func (f *FileClient) GetConfig(group, dataId string) (string, error) {
+	path, err := f.path(group, dataId)
+	if err != nil {
+		return "", err
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (f *FileClient) PublishConfig(group, dataId, content string) error {
+	path, err := f.path(group, dataId)
+	if err != nil {
+		return err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return writeFile(path, content)
+}
+
+//GetConfigWithVersion 文件修改时间精度不足, 以内容的哈希作为版本
+func (f *FileClient) GetConfigWithVersion(group, dataId string) (string, int64, error) {
+	content, err := f.GetConfig(group, dataId)
+	if err != nil {
+		return "", 0, err
+	}
+	return content, f.version(content), nil
+}
+
+func (f *FileClient) CompareAndPublishConfig(group, dataId, content string, version int64) (bool, error) {
+	path, err := f.path(group, dataId)
+	if err != nil {
+		return false, err
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	current, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if f.version(string(current)) != version {
+		return false, nil
+	}
+	return true, writeFile(path, content)
+}
+
+//version 空内容视为不存在, 版本为0
+func (f *FileClient) version(content string) int64 {
+	if content == "" {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(content))
+	return int64(h.Sum64()&math.MaxInt64) | 1
+}
+
+//writeFile 先写临时文件再重命名, 保证读取方不会读到写了一半的内容
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+//path 拒绝包含路径分隔符或..的group及dataId, 避免写到Dir之外
+func (f *FileClient) path(group, dataId string) (string, error) {
+	for _, name := range []string{group, dataId} {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return "", fmt.Errorf("invalid config name: %q", name)
+		}
+	}
+	return filepath.Join(f.dir, group, dataId), nil
+}
@@ -0,0 +1,203 @@
+package bcc
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/galaxy-future/BridgX/config"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//NacosClient 基于Nacos Open API的配置中心, group对应Nacos的group, dataId对应dataId
+type NacosClient struct {
+	address    string
+	namespace  string
+	username   string
+	password   string
+	httpClient *http.Client
+
+	lock        sync.Mutex
+	accessToken string
+	expireAt    time.Time
+}
+
+type nacosLoginResponse struct {
+	AccessToken string `json:"accessToken"`
+	TokenTtl    int64  `json:"tokenTtl"`
+}
+
+func NewNacosClient(conf *config.NacosConfig) (*NacosClient, error) {
+	if conf == nil || conf.Address == "" {
+		return nil, errors.New("empty nacos config")
+	}
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	return &NacosClient{
+		address:    strings.TrimRight(conf.Address, "/"),
+		namespace:  conf.Namespace,
+		username:   conf.Username,
+		password:   conf.Password,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (n *NacosClient) GetConfig(group, dataId string) (string, error) {
+	query := url.Values{"group": []string{group}, "dataId": []string{dataId}}
+	if err := n.fillCommon(query); err != nil {
+		return "", err
+	}
+	body, status, err := n.do(http.MethodGet, "/nacos/v1/cs/configs?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusNotFound {
+		return "", nil
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("nacos get %v/%v failed, status: %v, body: %s", group, dataId, status, body)
+	}
+	return string(body), nil
+}
+
+func (n *NacosClient) PublishConfig(group, dataId, content string) error {
+	form := url.Values{"group": []string{group}, "dataId": []string{dataId}, "content": []string{content}}
+	if err := n.fillCommon(form); err != nil {
+		return err
+	}
+	body, status, err := n.do(http.MethodPost, "/nacos/v1/cs/configs", form)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK || strings.TrimSpace(string(body)) != "true" {
+		return fmt.Errorf("nacos publish %v/%v failed, status: %v, body: %s", group, dataId, status, body)
+	}
+	return nil
+}
+
+//GetConfigWithVersion 以配置内容的md5作为版本, 配置不存在时版本为0
+func (n *NacosClient) GetConfigWithVersion(group, dataId string) (string, int64, error) {
+	content, err := n.GetConfig(group, dataId)
+	if err != nil {
+		return "", 0, err
+	}
+	return content, nacosVersion(content), nil
+}
+
+//CompareAndPublishConfig 当前版本等于version时携带casMd5发布, 由Nacos保证读取后配置未被修改
+func (n *NacosClient) CompareAndPublishConfig(group, dataId, content string, version int64) (bool, error) {
+	current, err := n.GetConfig(group, dataId)
+	if err != nil {
+		return false, err
+	}
+	if nacosVersion(current) != version {
+		return false, nil
+	}
+	casMd5 := nacosMd5(current)
+	form := url.Values{"group": []string{group}, "dataId": []string{dataId}, "content": []string{content}, "casMd5": []string{casMd5}}
+	if err = n.fillCommon(form); err != nil {
+		return false, err
+	}
+	body, status, err := n.do(http.MethodPost, "/nacos/v1/cs/configs", form)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusOK && strings.TrimSpace(string(body)) == "true" {
+		return true, nil
+	}
+	//cas失败时Nacos不区分冲突与其他错误, 重新读取判断配置是否已被修改
+	latest, err := n.GetConfig(group, dataId)
+	if err == nil && nacosMd5(latest) != casMd5 {
+		return false, nil
+	}
+	return false, fmt.Errorf("nacos publish %v/%v with casMd5 failed, status: %v, body: %s", group, dataId, status, body)
+}
+
+func nacosMd5(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func nacosVersion(content string) int64 {
+	if content == "" {
+		return 0
+	}
+	sum := md5.Sum([]byte(content))
+	return int64(binary.BigEndian.Uint64(sum[:8])&math.MaxInt64) | 1
+}
+
+//fillCommon 填充命名空间及鉴权token, 未配置用户名时不鉴权
+func (n *NacosClient) fillCommon(values url.Values) error {
+	if n.namespace != "" {
+		values.Set("tenant", n.namespace)
+	}
+	if n.username == "" {
+		return nil
+	}
+	token, err := n.getAccessToken()
+	if err != nil {
+		return err
+	}
+	values.Set("accessToken", token)
+	return nil
+}
+
+//getAccessToken 获取登录token, 过期前一分钟重新登录
+func (n *NacosClient) getAccessToken() (string, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.accessToken != "" && time.Now().Before(n.expireAt) {
+		return n.accessToken, nil
+	}
+	body, status, err := n.do(http.MethodPost, "/nacos/v1/auth/login", url.Values{"username": []string{n.username}, "password": []string{n.password}})
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("nacos login failed, status: %v, body: %s", status, body)
+	}
+	res := nacosLoginResponse{}
+	if err = jsoniter.Unmarshal(body, &res); err != nil {
+		return "", err
+	}
+	n.accessToken = res.AccessToken
+	n.expireAt = time.Now().Add(time.Duration(res.TokenTtl)*time.Second - time.Minute)
+	return n.accessToken, nil
+}
+
+func (n *NacosClient) do(method, path string, form url.Values) ([]byte, int, error) {
+	var req *http.Request
+	var err error
+	if form != nil {
+		req, err = http.NewRequest(method, n.address+path, strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequest(method, n.address+path, nil)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
@@ -99,6 +99,31 @@ func (e *EtcdClient) PublishConfig(group, dataId, content string) error {
 	return err
 }
 
+//GetConfigWithVersion 以key的ModRevision作为版本
+func (e *EtcdClient) GetConfigWithVersion(group, dataId string) (string, int64, error) {
+	kvs, err := e.etcdClient.KV.Get(context.Background(), fmtKey(group, dataId), clientv3.WithLimit(1))
+	if err != nil {
+		return "", 0, err
+	}
+	if len(kvs.Kvs) < 1 {
+		return "", 0, nil
+	}
+	return string(kvs.Kvs[0].Value), kvs.Kvs[0].ModRevision, nil
+}
+
+//CompareAndPublishConfig 在事务中比较ModRevision后写入, 不存在的key的ModRevision为0
+func (e *EtcdClient) CompareAndPublishConfig(group, dataId, content string, version int64) (bool, error) {
+	key := fmtKey(group, dataId)
+	resp, err := e.etcdClient.Txn(context.Background()).
+		If(clientv3.Compare(clientv3.ModRevision(key), "=", version)).
+		Then(clientv3.OpPut(key, content)).
+		Commit()
+	if err != nil {
+		return false, err
+	}
+	return resp.Succeeded, nil
+}
+
 func equalEtcdConfig(conf1, conf2 *config.EtcdConfig) bool {
 	if conf1 == conf2 {
 		return true
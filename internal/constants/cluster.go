@@ -1,19 +1,33 @@
 package constants
 
+import "time"
+
 const (
 	DeletingIPs          = "deleting_ips"
 	Instances            = "instances"
 	WorkingIPs           = "working_ips"
+	Members              = "members"
 	ExpectInstanceNumber = "expect_instance_number"
 
 	HasNoneIP       = "-"
 	HasNoneInstance = "-"
 
+	//MembershipPublishRetry 成员文档版本冲突时的最大重试次数
+	MembershipPublishRetry = 5
+	//DefaultMembershipWatchTimeout 成员长轮询未指定超时时的等待时间, 最长不超过MaxMembershipWatchTimeout
+	DefaultMembershipWatchTimeout = 30 * time.Second
+	MaxMembershipWatchTimeout     = 60 * time.Second
+	//MembershipWatchPollInterval 长轮询期间读取配置中心的间隔
+	MembershipWatchPollInterval = time.Second
+
 	Interval = 18
 	Retry    = 3
 
 	BatchMax = 100
 
+	//ExpandParallelism 扩容时同时向云厂商发起BatchCreate的最大并发数
+	ExpandParallelism = 5
+
 	DefaultUsername           = "root"
 	DefaultClusterUsageKey    = "usage"
 	DefaultClusterUsageUnused = "unused"
@@ -26,8 +40,13 @@ const (
 )
 
 const (
-	ErrClusterNotExist           = "集群: [%s] 不存在"
-	ErrPrePaidShrinkNotSupported = "不支持对包年包月的集群机器进行缩容操作"
+	ErrClusterNotExist              = "集群: [%s] 不存在"
+	ErrPrePaidShrinkNotSupported    = "不支持对包年包月的集群机器进行缩容操作"
+	ErrClusterExceedMaxCount        = "集群: [%s] 扩容后实例数%d将超过上限%d"
+	ErrClusterBelowMinCount         = "集群: [%s] 缩容后实例数%d将低于下限%d"
+	ErrClusterSizeLimitInvalid      = "集群实例数下限%d不能大于上限%d"
+	ErrClusterExpectCountOutOfRange = "集群: [%s] 期望实例数%d超出实例数范围[%d, %d]"
+	ErrInstancesScaleInProtected    = "实例%v已开启缩容保护"
 )
 
 const (
@@ -38,3 +57,83 @@ const (
 	AuthTypePassword = "password"
 	AuthTypeKeyPair  = "key_pair"
 )
+
+const (
+	ShrinkStrategyDefault        = "default"
+	ShrinkStrategyOldestFirst    = "oldest_first"
+	ShrinkStrategyNewestFirst    = "newest_first"
+	ShrinkStrategyZoneBalanced   = "zone_balanced"
+	ShrinkStrategyBillingHour    = "billing_hour"
+	ShrinkStrategyByTag          = "by_tag"
+	ShrinkStrategyUnhealthyFirst = "unhealthy_first"
+
+	//ShrinkProbeParallelism unhealthy_first策略同时探测的实例数
+	ShrinkProbeParallelism = 20
+)
+
+const (
+	InstanceHealthy   = "HEALTHY"
+	InstanceUnhealthy = "UNHEALTHY"
+
+	//DefaultUnhealthyThreshold 连续探测失败多少次后判定实例不健康
+	DefaultUnhealthyThreshold = 3
+	//HealthCheckParallelism 每个集群同时探测的实例数
+	HealthCheckParallelism = 20
+	//HealthReplaceTaskName 健康检查自动替换时创建的任务名称
+	HealthReplaceTaskName = "HEALTH_REPLACE"
+)
+
+const (
+	WarmPoolStatusPending  = "PENDING"
+	WarmPoolStatusStopping = "STOPPING"
+	WarmPoolStatusStopped  = "STOPPED"
+	WarmPoolStatusStarting = "STARTING"
+	WarmPoolStatusUsed     = "USED"
+	WarmPoolStatusReleased = "RELEASED"
+
+	//WarmPoolPrepareTimeout 预热实例超过该时间仍未停机则释放
+	WarmPoolPrepareTimeout = 15 * time.Minute
+	//WarmPoolStartInterval 从预热池开机后查询实例状态的间隔及次数
+	WarmPoolStartInterval   = 2 * time.Second
+	WarmPoolStartCheckTimes = 30
+)
+
+//WarmPoolLiveStatuses 仍占用预热池容量的实例状态
+var WarmPoolLiveStatuses = []string{WarmPoolStatusPending, WarmPoolStatusStopping, WarmPoolStatusStopped, WarmPoolStatusStarting}
+
+const (
+	HibernationStateAwake      = "AWAKE"
+	HibernationStateHibernated = "HIBERNATED"
+
+	//HibernateTaskName WakeUpTaskName 休眠计划创建的停机/开机任务名称
+	HibernateTaskName = "HIBERNATE"
+	WakeUpTaskName    = "WAKE_UP"
+	//DefaultHibernationWorkDays 未指定时周一到周五保持开机
+	DefaultHibernationWorkDays = "1,2,3,4,5"
+	//DefaultHibernationTimezone 未指定时区时使用服务所在时区
+	DefaultHibernationTimezone = "Local"
+
+	//PowerCheckInterval 开机/停机后查询实例状态的间隔及次数
+	PowerCheckInterval = 5 * time.Second
+	PowerCheckTimes    = 60
+)
+
+const (
+	//ClusterSpecVersion 集群配置文档的版本, 文档结构不兼容变化时升级
+	ClusterSpecVersion = "bridgx/v1"
+
+	ClusterSpecActionCreate = "create"
+	ClusterSpecActionEdit   = "edit"
+	ClusterSpecActionNone   = "none"
+)
+
+const (
+	//ClusterRevisionCommentInitial 首次修改集群前补记的修改前版本
+	ClusterRevisionCommentInitial  = "initial"
+	ClusterRevisionCommentCreate   = "create"
+	ClusterRevisionCommentRollback = "rollback to revision %d"
+	//ClusterRevisionCommentRoll 滚动替换任务修改集群镜像及机型
+	ClusterRevisionCommentRoll = "roll task %d"
+	//ClusterRevisionCommentRollRollback 回滚滚动替换任务时恢复集群镜像及机型
+	ClusterRevisionCommentRollRollback = "rollback roll task %d"
+)
@@ -10,4 +10,6 @@ const (
 	Running   Status = "RUNNING"
 	Deleted   Status = "DELETED"
 	Deleting  Status = "DELETING"
+	Stopping  Status = "STOPPING"
+	Stopped   Status = "STOPPED"
 )
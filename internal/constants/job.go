@@ -0,0 +1,33 @@
+package constants
+
+import "time"
+
+const (
+	JobTypeVpcRefresh       = "VPC_REFRESH"
+	JobTypeSwitchRefresh    = "SWITCH_REFRESH"
+	JobTypeAccountRefresh   = "ACCOUNT_REFRESH"
+	JobTypeInstanceTypeSync = "INSTANCE_TYPE_SYNC"
+)
+
+const (
+	JobStatusPending = "PENDING"
+	JobStatusRunning = "RUNNING"
+	JobStatusSuccess = "SUCCESS"
+	JobStatusFailed  = "FAILED"
+)
+
+//JobRetryBaseDelay 任务失败后的重试间隔基数, 第n次重试间隔为 base*2^(n-1), 最长不超过JobRetryMaxDelay
+const JobRetryBaseDelay = 30 * time.Second
+const JobRetryMaxDelay = 30 * time.Minute
+
+//JobPollInterval 任务队列扫描待执行任务的间隔
+const JobPollInterval = 2 * time.Second
+
+//JobRunningTimeout 执行超过该时长仍未结束的任务视为实例异常退出, 重新放回队列
+const JobRunningTimeout = 30 * time.Minute
+
+//JobExecuteTimeout 单次执行的超时时间, 需小于JobRunningTimeout, 保证任务被放回队列前已结束执行
+const JobExecuteTimeout = 20 * time.Minute
+
+//JobRetention 执行成功的任务记录的保留时长
+const JobRetention = 7 * 24 * time.Hour
@@ -0,0 +1,19 @@
+package constants
+
+//安全组规则与模板的偏离检测结果
+const (
+	SecurityGroupDriftInSync = "IN_SYNC"
+	SecurityGroupDrifted     = "DRIFTED"
+	SecurityGroupDriftFailed = "FAILED"
+)
+
+//网络资源同步的触发方式及状态
+const (
+	NetworkSyncTriggerSchedule = "SCHEDULE"
+	NetworkSyncTriggerManual   = "MANUAL"
+	NetworkSyncTriggerAccount  = "ACCOUNT"
+
+	NetworkSyncStatusRunning = "RUNNING"
+	NetworkSyncStatusSuccess = "SUCCESS"
+	NetworkSyncStatusFailed  = "FAILED"
+)
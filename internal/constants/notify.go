@@ -0,0 +1,49 @@
+package constants
+
+import "time"
+
+const (
+	NotifyEventTaskSuccess         = "TASK_SUCCESS"
+	NotifyEventTaskFailed          = "TASK_FAILED"
+	NotifyEventTaskPartialSuccess  = "TASK_PARTIAL_SUCCESS"
+	NotifyEventInstanceExpiring    = "INSTANCE_EXPIRING"
+	NotifyEventReconcileAnomaly    = "RECONCILE_ANOMALY"
+	NotifyEventInstanceUnhealthy   = "INSTANCE_UNHEALTHY"
+	NotifyEventInstanceRenewFailed = "INSTANCE_RENEW_FAILED"
+	NotifyEventSecurityGroupDrift  = "SECURITY_GROUP_DRIFT"
+)
+
+const (
+	NotifySeverityInfo     = "INFO"
+	NotifySeverityWarning  = "WARNING"
+	NotifySeverityCritical = "CRITICAL"
+)
+
+const (
+	NotifyRecordStatusPending = "PENDING"
+	NotifyRecordStatusSuccess = "SUCCESS"
+	NotifyRecordStatusFailed  = "FAILED"
+)
+
+//NotifyMaxRetryCount 通知发送失败后的最大重试次数
+const NotifyMaxRetryCount = 5
+
+//NotifyRetryBaseDelay 重试间隔基数, 第n次重试间隔为 base*2^(n-1)
+const NotifyRetryBaseDelay = time.Minute
+
+//DefaultInstanceExpireNoticeDays 包年包月实例到期前多少天开始通知
+const DefaultInstanceExpireNoticeDays = 7
+
+//DefaultRenewBeforeDays 自动续费策略未指定时, 在实例到期前多少天续费
+const DefaultRenewBeforeDays = 3
+
+var notifySeverityLevel = map[string]int{
+	NotifySeverityInfo:     1,
+	NotifySeverityWarning:  2,
+	NotifySeverityCritical: 3,
+}
+
+//NotifySeverityLevel 返回告警级别的数值, 未知级别返回0
+func NotifySeverityLevel(severity string) int {
+	return notifySeverityLevel[severity]
+}
@@ -12,8 +12,19 @@ const DefaultInstanceCountWatcherInterval = 10
 const DefaultKillExpireRunningTaskInterval = 10
 const DefaultInstanceCleanerRunningInterval = 600
 const DefaultQueryOrderInterval = 300
+const DefaultNotifyRetryInterval = 60
+const DefaultInstanceExpireWatcherInterval = 3600
+const DefaultApprovalExpirerInterval = 60
+const DefaultInstanceHealthCheckInterval = 30
+const DefaultWarmPoolReplenishInterval = 30
+const DefaultHibernationSchedulerInterval = 60
+const DefaultSecurityGroupDriftCheckInterval = 600
+const DefaultNetworkSyncInterval = 1800
 const DefaultTaskMaxRunningDuration = 20 * time.Minute
 
+//NetworkSyncRecordRetention 网络资源同步记录的保留时长
+const NetworkSyncRecordRetention = 7 * 24 * time.Hour
+
 //DefaultCleanMaxRunningTTL 默认清理任务最大执行时间（秒）
 const DefaultCleanMaxRunningTTL = 30
 
@@ -21,6 +32,14 @@ const TaskMonitorETCDLockKeyPrefix = "bridgx/task/locks/"
 const ClusterMonitorETCDLockKeyPrefix = "bridgx/cluster/locks/"
 const ClusterMonitorETCDReviewKeyPrefix = "bridgx/cluster/reviews/"
 const ClusterInstancesCountWatcherETCDReviewKeyPrefix = "bridgx/cluster/instance-count-watcher/"
+const NotifyRetryETCDLockKey = "bridgx/notify/retry/lock"
+const InstanceExpireWatcherETCDLockKey = "bridgx/notify/instance-expire/lock"
+const ApprovalExpirerETCDLockKey = "bridgx/approval/expirer/lock"
+const InstanceHealthCheckETCDLockKey = "bridgx/health-check/lock"
+const WarmPoolReplenishETCDLockKey = "bridgx/warm-pool/lock"
+const HibernationSchedulerETCDLockKey = "bridgx/hibernation/lock"
+const SecurityGroupDriftCheckETCDLockKey = "bridgx/security-group/drift/lock"
+const NetworkSyncETCDLockKey = "bridgx/network/sync/lock"
 
 //GetClusterScheduleLockKey 对于Cluster调度任务/执行任务时 需要获取锁的key
 func GetClusterScheduleLockKey(clusterName string) string {
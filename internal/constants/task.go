@@ -3,12 +3,39 @@ package constants
 const (
 	TaskActionExpand = "EXPAND"
 	TaskActionShrink = "SHRINK"
+	TaskActionRoll   = "ROLL"
+	TaskActionStop   = "STOP"
+	TaskActionStart  = "START"
 )
 
 const (
-	TaskStatusInit           = "INIT"
-	TaskStatusRunning        = "RUNNING"
-	TaskStatusSuccess        = "SUCCESS"
-	TaskStatusFailed         = "FAILED"
-	TaskStatusPartialSuccess = "PARTIAL_SUCCESS"
+	TaskStatusPendingApproval = "PENDING_APPROVAL"
+	TaskStatusInit            = "INIT"
+	TaskStatusRunning         = "RUNNING"
+	TaskStatusPaused          = "PAUSED"
+	TaskStatusSuccess         = "SUCCESS"
+	TaskStatusFailed          = "FAILED"
+	TaskStatusPartialSuccess  = "PARTIAL_SUCCESS"
+	TaskStatusRejected        = "REJECTED"
+)
+
+const (
+	ApprovalStatusPending  = "PENDING"
+	ApprovalStatusApproved = "APPROVED"
+	ApprovalStatusRejected = "REJECTED"
+	ApprovalStatusExpired  = "EXPIRED"
+)
+
+//DefaultApprovalExpireHours 审批单默认有效期（小时）, 超时未审批的任务将被自动拒绝
+const DefaultApprovalExpireHours = 24
+
+const (
+	DefaultRollSurge          = 1
+	DefaultRollMaxUnavailable = 0
+)
+
+const (
+	HealthCheckProtocolTCP  = "tcp"
+	HealthCheckProtocolHTTP = "http"
+	HealthCheckProtocolSSH  = "ssh"
 )
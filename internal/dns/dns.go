@@ -0,0 +1,92 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/config"
+)
+
+const (
+	TypeRFC2136 = "rfc2136"
+
+	RecordTypeA = "A"
+
+	defaultTTL = 60
+)
+
+var (
+	provider DNSProvider
+	zone     string
+	ttl      int
+)
+
+//Record 一个名称下同一类型的全部记录, Name为相对于zone的名称
+type Record struct {
+	Name   string
+	Type   string
+	TTL    int
+	Values []string
+}
+
+//DNSProvider 维护DNS记录的服务, 目前支持RFC 2136动态更新, 云厂商的DNS服务实现该接口即可接入
+type DNSProvider interface {
+	//ReplaceRecords 将每条记录对应名称及类型下的已有记录替换为Values, Values为空时删除
+	ReplaceRecords(ctx context.Context, zone string, records []Record) error
+}
+
+func MustInit(conf *config.Config) {
+	p, err := NewDNSProvider(conf.DNS)
+	if err != nil {
+		panic(err)
+	}
+	provider = p
+	if p == nil {
+		return
+	}
+	zone = Fqdn(conf.DNS.Zone)
+	ttl = conf.DNS.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+}
+
+//NewDNSProvider 按DNS.Type创建DNSProvider, 未配置时返回nil
+func NewDNSProvider(conf *config.DNSConfig) (DNSProvider, error) {
+	if conf == nil || conf.Type == "" {
+		return nil, nil
+	}
+	if conf.Zone == "" {
+		return nil, fmt.Errorf("empty dns zone")
+	}
+	switch conf.Type {
+	case TypeRFC2136:
+		return NewRFC2136Provider(conf.RFC2136)
+	}
+	return nil, fmt.Errorf("unsupported dns type: %v", conf.Type)
+}
+
+func Enabled() bool {
+	return provider != nil
+}
+
+//ReplaceRecords 在配置的zone下更新记录, TTL为0的记录使用配置的TTL
+func ReplaceRecords(ctx context.Context, records []Record) error {
+	if provider == nil || len(records) == 0 {
+		return nil
+	}
+	for i := range records {
+		if records[i].TTL <= 0 {
+			records[i].TTL = ttl
+		}
+	}
+	return provider.ReplaceRecords(ctx, zone, records)
+}
+
+func Fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
@@ -0,0 +1,353 @@
+package dns
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/config"
+)
+
+const (
+	typeA    = 1
+	typeSOA  = 6
+	typeTSIG = 250
+	classIN  = 1
+	classANY = 255
+
+	opcodeUpdate = 5
+	flagQR       = 1 << 15
+	flagTC       = 1 << 9
+
+	headerLen      = 12
+	maxUDPSize     = 512
+	maxMessageSize = 0xffff
+	maxUpdateSize  = maxMessageSize - 1024
+	tsigFudge      = 300
+	defaultTimeout = 5 * time.Second
+)
+
+var tsigAlgorithms = map[string]func() hash.Hash{
+	"hmac-sha1.":   sha1.New,
+	"hmac-sha256.": sha256.New,
+	"hmac-sha512.": sha512.New,
+}
+
+var rcodeNames = map[int]string{
+	1:  "FORMERR",
+	2:  "SERVFAIL",
+	3:  "NXDOMAIN",
+	4:  "NOTIMP",
+	5:  "REFUSED",
+	6:  "YXDOMAIN",
+	7:  "YXRRSET",
+	8:  "NXRRSET",
+	9:  "NOTAUTH",
+	10: "NOTZONE",
+}
+
+//RFC2136Provider 通过RFC 2136动态更新维护记录, 配置TSIG密钥时对更新请求签名
+type RFC2136Provider struct {
+	server   string
+	net      string
+	timeout  time.Duration
+	keyName  string
+	secret   []byte
+	algName  string
+	algoHash func() hash.Hash
+}
+
+func NewRFC2136Provider(conf *config.RFC2136Config) (*RFC2136Provider, error) {
+	if conf == nil || conf.Server == "" {
+		return nil, errors.New("empty rfc2136 config")
+	}
+	p := &RFC2136Provider{
+		server:  conf.Server,
+		net:     conf.Net,
+		timeout: conf.Timeout,
+	}
+	if p.net == "" {
+		p.net = "udp"
+	}
+	if p.net != "udp" && p.net != "tcp" {
+		return nil, fmt.Errorf("unsupported rfc2136 net: %v", p.net)
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultTimeout
+	}
+	if conf.TsigKeyName == "" {
+		return p, nil
+	}
+	secret, err := base64.StdEncoding.DecodeString(conf.TsigSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tsig secret: %v", err)
+	}
+	p.algName = "hmac-sha256."
+	if conf.TsigAlgorithm != "" {
+		p.algName = Fqdn(strings.ToLower(conf.TsigAlgorithm))
+	}
+	algoHash, ok := tsigAlgorithms[p.algName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tsig algorithm: %v", conf.TsigAlgorithm)
+	}
+	p.keyName = Fqdn(strings.ToLower(conf.TsigKeyName))
+	p.secret = secret
+	p.algoHash = algoHash
+	return p, nil
+}
+
+//ReplaceRecords 每条记录先删除已有记录集再逐个添加, 按顺序拆分为多个UPDATE消息依次提交, 单个消息不超过maxUpdateSize
+func (p *RFC2136Provider) ReplaceRecords(ctx context.Context, zone string, records []Record) error {
+	updates, err := buildUpdateRRs(Fqdn(zone), records)
+	if err != nil {
+		return err
+	}
+	for len(updates) > 0 {
+		msg, id, n, err := p.buildUpdate(Fqdn(zone), updates)
+		if err != nil {
+			return err
+		}
+		resp, err := p.exchange(ctx, msg)
+		if err != nil {
+			return err
+		}
+		if err = checkResponse(resp, id); err != nil {
+			return err
+		}
+		updates = updates[n:]
+	}
+	return nil
+}
+
+//buildUpdateRRs 将记录编码为UPDATE段的RR, 每条记录为一个删除记录集的RR及各个值的RR
+func buildUpdateRRs(zone string, records []Record) ([][]byte, error) {
+	updates := make([][]byte, 0, len(records))
+	for _, record := range records {
+		if record.Type != RecordTypeA {
+			return nil, fmt.Errorf("unsupported record type: %v", record.Type)
+		}
+		name := zone
+		if record.Name != "" {
+			name = Fqdn(record.Name) + zone
+		}
+		rr, err := appendName(nil, name)
+		if err != nil {
+			return nil, err
+		}
+		rr = appendUint16(rr, typeA, classANY)
+		rr = appendUint32(rr, 0)
+		rr = appendUint16(rr, 0)
+		updates = append(updates, rr)
+		for _, value := range record.Values {
+			ip := net.ParseIP(value).To4()
+			if ip == nil {
+				return nil, fmt.Errorf("invalid A record value: %v", value)
+			}
+			rr, _ = appendName(nil, name)
+			rr = appendUint16(rr, typeA, classIN)
+			rr = appendUint32(rr, uint32(record.TTL))
+			rr = appendUint16(rr, net.IPv4len)
+			rr = append(rr, ip...)
+			updates = append(updates, rr)
+		}
+	}
+	return updates, nil
+}
+
+//buildUpdate 从updates开头依次写入RR直到消息达到maxUpdateSize, 返回写入的RR个数, 为TSIG预留空间
+func (p *RFC2136Provider) buildUpdate(zone string, updates [][]byte) ([]byte, uint16, int, error) {
+	var idBytes [2]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, 0, 0, err
+	}
+	id := binary.BigEndian.Uint16(idBytes[:])
+	msg := make([]byte, headerLen, maxUDPSize)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], opcodeUpdate<<11)
+	binary.BigEndian.PutUint16(msg[4:], 1)
+
+	var err error
+	if msg, err = appendName(msg, zone); err != nil {
+		return nil, 0, 0, err
+	}
+	msg = appendUint16(msg, typeSOA, classIN)
+
+	n := 0
+	for ; n < len(updates); n++ {
+		if len(msg)+len(updates[n]) > maxUpdateSize {
+			break
+		}
+		msg = append(msg, updates[n]...)
+	}
+	if n == 0 && len(updates) > 0 {
+		return nil, 0, 0, errors.New("dns update record too large")
+	}
+	binary.BigEndian.PutUint16(msg[8:], uint16(n))
+	if p.keyName != "" {
+		if msg, err = p.sign(msg, id, time.Now()); err != nil {
+			return nil, 0, 0, err
+		}
+	}
+	return msg, id, n, nil
+}
+
+//sign 按RFC 8945追加TSIG记录, MAC覆盖未签名的消息及TSIG变量
+func (p *RFC2136Provider) sign(msg []byte, id uint16, now time.Time) ([]byte, error) {
+	keyName, err := appendName(nil, p.keyName)
+	if err != nil {
+		return nil, err
+	}
+	algName, err := appendName(nil, p.algName)
+	if err != nil {
+		return nil, err
+	}
+	timeSigned := appendTime48(nil, now.Unix())
+
+	mac := hmac.New(p.algoHash, p.secret)
+	mac.Write(msg)
+	mac.Write(keyName)
+	mac.Write(appendUint16(nil, classANY))
+	mac.Write(appendUint32(nil, 0))
+	mac.Write(algName)
+	mac.Write(timeSigned)
+	mac.Write(appendUint16(nil, tsigFudge, 0, 0))
+	sum := mac.Sum(nil)
+
+	rdata := append([]byte{}, algName...)
+	rdata = append(rdata, timeSigned...)
+	rdata = appendUint16(rdata, tsigFudge, uint16(len(sum)))
+	rdata = append(rdata, sum...)
+	rdata = appendUint16(rdata, id, 0, 0)
+
+	msg = append(msg, keyName...)
+	msg = appendUint16(msg, typeTSIG, classANY)
+	msg = appendUint32(msg, 0)
+	msg = appendUint16(msg, uint16(len(rdata)))
+	msg = append(msg, rdata...)
+	binary.BigEndian.PutUint16(msg[10:], 1)
+	return msg, nil
+}
+
+//exchange 超过UDP报文长度或响应被截断时使用TCP
+func (p *RFC2136Provider) exchange(ctx context.Context, msg []byte) ([]byte, error) {
+	network := p.net
+	if network == "udp" && len(msg) > maxUDPSize {
+		network = "tcp"
+	}
+	resp, err := p.send(ctx, network, msg)
+	if err != nil {
+		return nil, err
+	}
+	if network == "udp" && len(resp) >= headerLen && binary.BigEndian.Uint16(resp[2:])&flagTC != 0 {
+		return p.send(ctx, "tcp", msg)
+	}
+	return resp, nil
+}
+
+func (p *RFC2136Provider) send(ctx context.Context, network string, msg []byte) ([]byte, error) {
+	if len(msg) > maxMessageSize {
+		return nil, fmt.Errorf("dns message too large: %v bytes", len(msg))
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, network, p.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if network == "udp" {
+		if _, err = conn.Write(msg); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 0xffff)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	if _, err = conn.Write(append(appendUint16(nil, uint16(len(msg))), msg...)); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err = io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err = io.ReadFull(conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+//checkResponse 只校验响应头, 不校验响应中的TSIG
+func checkResponse(resp []byte, id uint16) error {
+	if len(resp) < headerLen {
+		return errors.New("dns response too short")
+	}
+	if binary.BigEndian.Uint16(resp[0:]) != id {
+		return errors.New("dns response id mismatch")
+	}
+	flags := binary.BigEndian.Uint16(resp[2:])
+	if flags&flagQR == 0 || (flags>>11)&0xf != opcodeUpdate {
+		return errors.New("invalid dns update response")
+	}
+	if rcode := int(flags & 0xf); rcode != 0 {
+		name, ok := rcodeNames[rcode]
+		if !ok {
+			name = fmt.Sprint(rcode)
+		}
+		return fmt.Errorf("dns update failed, rcode: %v", name)
+	}
+	return nil
+}
+
+//appendName 以非压缩格式编码域名, name需以.结尾
+func appendName(b []byte, name string) ([]byte, error) {
+	if len(name) > 255 {
+		return nil, fmt.Errorf("dns name too long: %v", name)
+	}
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if label == "" || len(label) > 63 {
+				return nil, fmt.Errorf("invalid dns name: %v", name)
+			}
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+	return append(b, 0), nil
+}
+
+func appendUint16(b []byte, values ...uint16) []byte {
+	for _, v := range values {
+		b = append(b, byte(v>>8), byte(v))
+	}
+	return b
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendTime48(b []byte, t int64) []byte {
+	return append(b, byte(t>>40), byte(t>>32), byte(t>>24), byte(t>>16), byte(t>>8), byte(t))
+}
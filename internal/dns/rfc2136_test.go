@@ -0,0 +1,240 @@
+package dns
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/config"
+)
+
+type testRR struct {
+	name  string
+	typ   uint16
+	class uint16
+	ttl   uint32
+	rdata []byte
+	start int
+}
+
+func readName(msg []byte, off int) (string, int) {
+	labels := make([]string, 0)
+	for msg[off] != 0 {
+		n := int(msg[off])
+		labels = append(labels, string(msg[off+1:off+1+n]))
+		off += n + 1
+	}
+	return strings.Join(labels, ".") + ".", off + 1
+}
+
+func parseUpdate(t *testing.T, msg []byte) (zone string, updates []testRR, tsig *testRR) {
+	if flags := binary.BigEndian.Uint16(msg[2:]); (flags>>11)&0xf != opcodeUpdate {
+		t.Fatalf("opcode is not UPDATE, flags: %x", flags)
+	}
+	zone, off := readName(msg, headerLen)
+	off += 4
+	upCount := int(binary.BigEndian.Uint16(msg[8:]))
+	adCount := int(binary.BigEndian.Uint16(msg[10:]))
+	for i := 0; i < upCount+adCount; i++ {
+		rr := testRR{start: off}
+		rr.name, off = readName(msg, off)
+		rr.typ = binary.BigEndian.Uint16(msg[off:])
+		rr.class = binary.BigEndian.Uint16(msg[off+2:])
+		rr.ttl = binary.BigEndian.Uint32(msg[off+4:])
+		n := int(binary.BigEndian.Uint16(msg[off+8:]))
+		rr.rdata = msg[off+10 : off+10+n]
+		off += 10 + n
+		if i < upCount {
+			updates = append(updates, rr)
+		} else {
+			tsig = &rr
+		}
+	}
+	return
+}
+
+//startTestServer 启动一个只回复指定rcode的UDP服务, 收到的请求写入channel
+func startTestServer(t *testing.T, rcode uint16) (string, chan []byte) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	requests := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 0xffff)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := append([]byte{}, buf[:n]...)
+			requests <- req
+			resp := make([]byte, headerLen)
+			copy(resp, req[:2])
+			binary.BigEndian.PutUint16(resp[2:], flagQR|opcodeUpdate<<11|rcode)
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String(), requests
+}
+
+func TestRFC2136ReplaceRecords(t *testing.T) {
+	secret := []byte("bridgx-test-secret")
+	server, requests := startTestServer(t, 0)
+	p, err := NewRFC2136Provider(&config.RFC2136Config{
+		Server:      server,
+		TsigKeyName: "bridgx-key",
+		TsigSecret:  base64.StdEncoding.EncodeToString(secret),
+		Timeout:     time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []Record{
+		{Name: "i-1.c1", Type: RecordTypeA, TTL: 60, Values: []string{"10.0.0.1"}},
+		{Name: "i-2.c1", Type: RecordTypeA, TTL: 60},
+		{Name: "c1", Type: RecordTypeA, TTL: 60, Values: []string{"10.0.0.1", "10.0.0.3"}},
+	}
+	if err = p.ReplaceRecords(context.Background(), "bridgx.internal", records); err != nil {
+		t.Fatal(err)
+	}
+	msg := <-requests
+	zone, updates, tsig := parseUpdate(t, msg)
+	if zone != "bridgx.internal." {
+		t.Errorf("zone = %v", zone)
+	}
+	//每条记录先删除记录集, 再添加各个值
+	want := []struct {
+		name  string
+		class uint16
+		ip    string
+	}{
+		{"i-1.c1.bridgx.internal.", classANY, ""},
+		{"i-1.c1.bridgx.internal.", classIN, "10.0.0.1"},
+		{"i-2.c1.bridgx.internal.", classANY, ""},
+		{"c1.bridgx.internal.", classANY, ""},
+		{"c1.bridgx.internal.", classIN, "10.0.0.1"},
+		{"c1.bridgx.internal.", classIN, "10.0.0.3"},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("want %v updates, got %v", len(want), len(updates))
+	}
+	for i, w := range want {
+		u := updates[i]
+		if u.name != w.name || u.typ != typeA || u.class != w.class {
+			t.Errorf("update %v = %+v, want %+v", i, u, w)
+		}
+		if w.ip != "" && net.IP(u.rdata).String() != w.ip {
+			t.Errorf("update %v ip = %v, want %v", i, net.IP(u.rdata), w.ip)
+		}
+	}
+	if tsig == nil || tsig.name != "bridgx-key." || tsig.typ != typeTSIG {
+		t.Fatalf("tsig = %+v", tsig)
+	}
+
+	//按RFC 8945重新计算MAC并比较
+	alg, off := readName(tsig.rdata, 0)
+	if alg != "hmac-sha256." {
+		t.Errorf("algorithm = %v", alg)
+	}
+	timeSigned := tsig.rdata[off : off+6]
+	macSize := int(binary.BigEndian.Uint16(tsig.rdata[off+8:]))
+	got := tsig.rdata[off+10 : off+10+macSize]
+	unsigned := append([]byte{}, msg[:tsig.start]...)
+	binary.BigEndian.PutUint16(unsigned[10:], 0)
+	keyName, _ := appendName(nil, "bridgx-key.")
+	algName, _ := appendName(nil, "hmac-sha256.")
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(unsigned)
+	mac.Write(keyName)
+	mac.Write([]byte{0, classANY, 0, 0, 0, 0})
+	mac.Write(algName)
+	mac.Write(timeSigned)
+	mac.Write([]byte{tsigFudge >> 8, tsigFudge & 0xff, 0, 0, 0, 0})
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		t.Errorf("tsig mac mismatch")
+	}
+}
+
+func TestRFC2136Refused(t *testing.T) {
+	server, _ := startTestServer(t, 5)
+	p, err := NewRFC2136Provider(&config.RFC2136Config{Server: server, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.ReplaceRecords(context.Background(), "bridgx.internal.", []Record{{Name: "c1", Type: RecordTypeA, Values: []string{"10.0.0.1"}}})
+	if err == nil || !strings.Contains(err.Error(), "REFUSED") {
+		t.Errorf("want REFUSED error, got %v", err)
+	}
+}
+
+func TestRFC2136InvalidRecord(t *testing.T) {
+	p, err := NewRFC2136Provider(&config.RFC2136Config{Server: "127.0.0.1:53"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range []Record{
+		{Name: "c1", Type: "CNAME", Values: []string{"c2"}},
+		{Name: "c1", Type: RecordTypeA, Values: []string{"fe80::1"}},
+		{Name: strings.Repeat("a", 64), Type: RecordTypeA},
+	} {
+		if err = p.ReplaceRecords(context.Background(), "bridgx.internal.", []Record{record}); err == nil {
+			t.Errorf("record %+v should be rejected", record)
+		}
+	}
+}
+
+func TestRFC2136SplitUpdate(t *testing.T) {
+	p, err := NewRFC2136Provider(&config.RFC2136Config{Server: "127.0.0.1:53", TsigKeyName: "bridgx-key", TsigSecret: "c2VjcmV0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := make([]Record, 0, 2000)
+	values := make([]string, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		ip := fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+		records = append(records, Record{Name: fmt.Sprintf("i-%d.c1", i), Type: RecordTypeA, TTL: 60, Values: []string{ip}})
+		values = append(values, ip)
+	}
+	records = append(records, Record{Name: "c1", Type: RecordTypeA, TTL: 60, Values: values})
+	updates, err := buildUpdateRRs("bridgx.internal.", records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//2000条实例记录各两个RR, 集群记录一个删除RR及2000个值
+	if len(updates) != 2000*2+1+2000 {
+		t.Fatalf("len(updates) = %v", len(updates))
+	}
+	messages, total := 0, 0
+	for len(updates) > 0 {
+		msg, _, n, err := p.buildUpdate("bridgx.internal.", updates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(msg) > maxMessageSize {
+			t.Fatalf("message size %v exceeds %v", len(msg), maxMessageSize)
+		}
+		_, rrs, tsig := parseUpdate(t, msg)
+		if len(rrs) != n || tsig == nil {
+			t.Fatalf("message has %v updates, want %v, tsig: %v", len(rrs), n, tsig)
+		}
+		messages++
+		total += n
+		updates = updates[n:]
+	}
+	if messages < 2 || total != 2000*2+1+2000 {
+		t.Errorf("messages = %v, total updates = %v", messages, total)
+	}
+
+	if _, err = p.send(context.Background(), "tcp", make([]byte, maxMessageSize+1)); err == nil {
+		t.Errorf("send should reject message larger than %v", maxMessageSize)
+	}
+}
@@ -12,6 +12,9 @@ var (
 	ErrGetRegionsFailed          = errors.New("获取地域信息失败")
 	ErrGetZonesFailed            = errors.New("获取可用区信息失败")
 	ErrVpcPending                = errors.New("pending")
+	ErrNatGatewayNotExist        = errors.New("NAT网关不存在")
+	ErrVpcPeeringNotExist        = errors.New("VPC对等连接不存在")
+	ErrRouteEntryNotExist        = errors.New("路由条目不存在")
 	ErrSaveAccountFailed         = errors.New("save account falied")
 	ErrOperatorIsNull            = errors.New("operator shouldn't be null")
 	ErrNewOrOldDataIsNotTabler   = errors.New("new or old data should be schema.Tabler") // schema.Tabler
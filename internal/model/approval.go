@@ -0,0 +1,186 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"gorm.io/gorm"
+)
+
+//ApprovalPolicy 组织级别的扩容审批策略, 阈值为0表示不按该条件审批
+type ApprovalPolicy struct {
+	Base
+	OrgId          int64   `json:"org_id" diff:"org_id"`
+	Enabled        bool    `json:"enabled" diff:"enabled"`
+	MaxExpandCount int     `json:"max_expand_count" diff:"max_expand_count"` //单次扩容数量超过该值需要审批
+	MaxMonthlyCost float64 `json:"max_monthly_cost" diff:"max_monthly_cost"` //单次扩容预估月费用超过该值需要审批
+	ExpireHours    int     `json:"expire_hours" diff:"expire_hours"`
+	UpdateBy       string  `json:"update_by" diff:"update_by"`
+}
+
+func (ApprovalPolicy) TableName() string {
+	return "approval_policy"
+}
+
+//TaskApproval 任务审批单
+type TaskApproval struct {
+	Base
+	TaskId        int64      `json:"task_id" diff:"task_id"`
+	OrgId         int64      `json:"org_id" diff:"org_id"`
+	ClusterName   string     `json:"cluster_name" diff:"cluster_name"`
+	TaskAction    string     `json:"task_action" diff:"task_action"`
+	Count         int        `json:"count" diff:"count"`
+	EstimatedCost float64    `json:"estimated_cost" diff:"estimated_cost"` //预估月费用, 未知时为0
+	Reason        string     `json:"reason" diff:"reason"`                 //需要审批的原因
+	Status        string     `json:"status" diff:"status"`
+	Applicant     int64      `json:"applicant" diff:"applicant"`
+	Approver      int64      `json:"approver" diff:"approver"`
+	Comment       string     `json:"comment" diff:"comment"`
+	ExpireAt      *time.Time `json:"expire_at" diff:"expire_at"`
+	ReviewAt      *time.Time `json:"review_at" diff:"review_at"`
+}
+
+func (TaskApproval) TableName() string {
+	return "task_approval"
+}
+
+//GetApprovalPolicyByOrgId 组织未配置审批策略时返回nil
+func GetApprovalPolicyByOrgId(ctx context.Context, orgId int64) (*ApprovalPolicy, error) {
+	policy := &ApprovalPolicy{}
+	err := clients.ReadDBCli.WithContext(ctx).Where("org_id = ?", orgId).First(policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logErr("GetApprovalPolicyByOrgId from read db", err)
+		return nil, err
+	}
+	return policy, nil
+}
+
+//CreateTaskWithApproval 在同一事务中创建待审批任务及其审批单
+func CreateTaskWithApproval(ctx context.Context, task *Task, approval *TaskApproval) error {
+	return clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			logErr("CreateTaskWithApproval to write db", err)
+			return err
+		}
+		approval.TaskId = task.Id
+		if err := tx.Create(approval).Error; err != nil {
+			logErr("CreateTaskWithApproval to write db", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func GetTaskApprovalByTaskId(ctx context.Context, taskId int64) (*TaskApproval, error) {
+	approval := &TaskApproval{}
+	if err := clients.ReadDBCli.WithContext(ctx).Where("task_id = ?", taskId).First(approval).Error; err != nil {
+		logErr("GetTaskApprovalByTaskId from read db", err)
+		return nil, err
+	}
+	return approval, nil
+}
+
+//ReviewTaskApproval 仅当审批单处于待审批状态时更新审批结果, 返回是否更新成功
+func ReviewTaskApproval(ctx context.Context, id int64, status string, approver int64, comment string) (bool, error) {
+	now := time.Now()
+	ret := clients.WriteDBCli.WithContext(ctx).Model(&TaskApproval{}).
+		Where("id = ? AND status = ?", id, constants.ApprovalStatusPending).
+		Updates(map[string]interface{}{
+			"status":    status,
+			"approver":  approver,
+			"comment":   comment,
+			"review_at": &now,
+			"update_at": &now,
+		})
+	if ret.Error != nil {
+		logErr("ReviewTaskApproval to write db", ret.Error)
+		return false, ret.Error
+	}
+	return ret.RowsAffected > 0, nil
+}
+
+var errReviewConflict = errors.New("task or approval status changed")
+
+//ReviewTaskWithApproval 在同一事务中更新审批结果及任务状态, 审批单不在待审批状态或任务不在待审批状态时均不更新, 返回是否更新成功
+func ReviewTaskWithApproval(ctx context.Context, approval *TaskApproval, status string, approver int64, comment, taskStatus, errMsg string) (bool, error) {
+	now := time.Now()
+	err := clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ret := tx.Model(&TaskApproval{}).
+			Where("id = ? AND status = ?", approval.Id, constants.ApprovalStatusPending).
+			Updates(map[string]interface{}{
+				"status":    status,
+				"approver":  approver,
+				"comment":   comment,
+				"review_at": &now,
+				"update_at": &now,
+			})
+		if ret.Error != nil {
+			return ret.Error
+		}
+		if ret.RowsAffected == 0 {
+			return errReviewConflict
+		}
+		updates := map[string]interface{}{"status": taskStatus, "update_at": &now}
+		if taskStatus != constants.TaskStatusInit {
+			updates["err_msg"] = errMsg
+			updates["finish_time"] = &now
+		}
+		ret = tx.Model(&Task{}).Where("id = ? AND status = ?", approval.TaskId, constants.TaskStatusPendingApproval).Updates(updates)
+		if ret.Error != nil {
+			return ret.Error
+		}
+		if ret.RowsAffected == 0 {
+			return errReviewConflict
+		}
+		return nil
+	})
+	if errors.Is(err, errReviewConflict) {
+		return false, nil
+	}
+	if err != nil {
+		logErr("ReviewTaskWithApproval to write db", err)
+		return false, err
+	}
+	return true, nil
+}
+
+//GetExpiredPendingApprovals 获取已超过有效期仍未审批的审批单
+func GetExpiredPendingApprovals(ctx context.Context, now time.Time) ([]TaskApproval, error) {
+	ret := make([]TaskApproval, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Where("status = ? AND expire_at < ?", constants.ApprovalStatusPending, now).Find(&ret).Error
+	if err != nil {
+		logErr("GetExpiredPendingApprovals from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+type ApprovalSearchCond struct {
+	OrgId       int64
+	ClusterName string
+	Status      string
+	PageNumber  int
+	PageSize    int
+}
+
+func ListTaskApprovals(ctx context.Context, cond ApprovalSearchCond) ([]TaskApproval, int64, error) {
+	ret := make([]TaskApproval, 0)
+	query := clients.ReadDBCli.WithContext(ctx).Model(TaskApproval{}).Where("org_id = ?", cond.OrgId)
+	if cond.ClusterName != "" {
+		query.Where("cluster_name = ?", cond.ClusterName)
+	}
+	if cond.Status != "" {
+		query.Where("status = ?", cond.Status)
+	}
+	count, err := QueryWhere(query, cond.PageNumber, cond.PageSize, &ret, "id desc", true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ret, count, nil
+}
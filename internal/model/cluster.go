@@ -21,6 +21,8 @@ type Cluster struct {
 	ClusterType  string
 	ClusterDesc  string
 	ExpectCount  int
+	MinCount     int    //最小实例数, 缩容后不能低于该值
+	MaxCount     int    //最大实例数, 0表示不限制
 	Status       string //ENABLE, DISABLE
 	RegionId     string
 	ZoneId       string
@@ -39,6 +41,11 @@ type Cluster struct {
 	ChargeConfig  string
 	ExtendConfig  string
 	AccountKey    string
+	AutoRenew     bool //与ChargeConfig中的续费策略同步, 便于查询开启了自动续费的集群
+
+	ShrinkStrategy string //按数量缩容时的默认实例选择策略
+	HealthCheck    string //集群健康检查配置, 为空表示不检查
+	WarmPool       string //预热池配置, 为空表示不启用
 
 	CreateBy      string
 	UpdateBy      string
@@ -175,6 +182,16 @@ func GetUpdatedCluster(currentTime time.Time) ([]Cluster, error) {
 	return clusters, nil
 }
 
+//GetHealthCheckClusters 获取所有配置了健康检查的集群
+func GetHealthCheckClusters(ctx context.Context) ([]Cluster, error) {
+	clusters := make([]Cluster, 0)
+	if err := clients.ReadDBCli.WithContext(ctx).Where("health_check != ''").Find(&clusters).Error; err != nil {
+		logErr("GetHealthCheckClusters from read db", err)
+		return nil, err
+	}
+	return clusters, nil
+}
+
 //GetClusterSnapshot 获取集群现状快照
 func GetClusterSnapshot(clusterName string) (*ClusterSnapshot, error) {
 	cluster, err := GetByClusterName(clusterName)
@@ -182,11 +199,18 @@ func GetClusterSnapshot(clusterName string) (*ClusterSnapshot, error) {
 		logErr("GetClusterById from read db", err)
 		return nil, err
 	}
-	instances, err := GetActiveInstancesByClusterName(cluster.ClusterName)
+	all, err := GetActiveInstancesByClusterName(cluster.ClusterName)
 	if err != nil {
 		logErr("GetActiveInstancesByClusterName from read db", err)
 		return nil, err
 	}
+	//已标记到期释放的包年包月实例视为已缩容
+	instances := make([]Instance, 0, len(all))
+	for _, instance := range all {
+		if !instance.ReleaseAtExpiry {
+			instances = append(instances, instance)
+		}
+	}
 	tasks, err := GetTaskByStatus(cluster.ClusterName, []string{constants.TaskStatusInit, constants.TaskStatusRunning})
 	if err != nil {
 		logErr("GetActiveTaskByClusterName from read db", err)
@@ -243,3 +267,26 @@ func ListClustersByCond(ctx context.Context, cond ClusterSearchCond) ([]Cluster,
 	}
 	return res, int(cnt), err
 }
+
+//UnmarshalShrinkStrategy 集群未配置缩容策略时返回nil
+func (c *Cluster) UnmarshalShrinkStrategy() (*types.ShrinkStrategyConfig, error) {
+	if c.ShrinkStrategy == "" {
+		return nil, nil
+	}
+	strategy := types.ShrinkStrategyConfig{}
+	err := jsoniter.UnmarshalFromString(c.ShrinkStrategy, &strategy)
+	if err != nil {
+		return nil, err
+	}
+	return &strategy, nil
+}
+
+//GetAutoRenewClusters 获取开启了自动续费的集群
+func GetAutoRenewClusters(ctx context.Context) ([]Cluster, error) {
+	clusters := make([]Cluster, 0)
+	if err := clients.ReadDBCli.WithContext(ctx).Where("auto_renew = ?", true).Find(&clusters).Error; err != nil {
+		logErr("GetAutoRenewClusters from read db", err)
+		return nil, err
+	}
+	return clusters, nil
+}
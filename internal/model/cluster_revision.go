@@ -0,0 +1,69 @@
+package model
+
+import (
+	"context"
+	"errors"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"gorm.io/gorm"
+)
+
+//ClusterRevision 集群配置的历史版本, 每次修改集群后记录一条, 记录后不再修改
+type ClusterRevision struct {
+	Base
+	ClusterName string
+	Revision    int    //集群内递增的版本号, 从1开始
+	Content     string //修改后的集群配置快照, 为Cluster的JSON
+	Comment     string
+	CreateBy    string
+}
+
+func (ClusterRevision) TableName() string {
+	return "cluster_revision"
+}
+
+//GetClusterRevision 获取集群的指定版本, 不存在时返回nil
+func GetClusterRevision(ctx context.Context, clusterName string, revision int) (*ClusterRevision, error) {
+	var out ClusterRevision
+	err := clients.ReadDBCli.WithContext(ctx).Where("cluster_name = ? AND revision = ?", clusterName, revision).First(&out).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logErr("GetClusterRevision from read db", err)
+		return nil, err
+	}
+	return &out, nil
+}
+
+//GetLatestClusterRevision 获取集群的最新版本, 尚未记录过版本时返回nil
+func GetLatestClusterRevision(ctx context.Context, clusterName string) (*ClusterRevision, error) {
+	var out ClusterRevision
+	err := clients.ReadDBCli.WithContext(ctx).Where("cluster_name = ?", clusterName).Order("revision DESC").First(&out).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logErr("GetLatestClusterRevision from read db", err)
+		return nil, err
+	}
+	return &out, nil
+}
+
+//ListClusterRevisions 按版本号倒序分页获取集群的历史版本, 不包含配置快照
+func ListClusterRevisions(ctx context.Context, clusterName string, pageNum, pageSize int) ([]ClusterRevision, int64, error) {
+	ret := make([]ClusterRevision, 0)
+	var total int64
+	query := clients.ReadDBCli.WithContext(ctx).Model(&ClusterRevision{}).Where("cluster_name = ?", clusterName)
+	if err := query.Count(&total).Error; err != nil {
+		logErr("ListClusterRevisions from read db", err)
+		return nil, 0, err
+	}
+	err := query.Select("id, cluster_name, revision, comment, create_by, create_at, update_at").
+		Order("revision DESC").Offset((pageNum - 1) * pageSize).Limit(pageSize).Find(&ret).Error
+	if err != nil {
+		logErr("ListClusterRevisions from read db", err)
+		return nil, 0, err
+	}
+	return ret, total, nil
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"gorm.io/gorm"
+)
+
+//HibernationSchedule 集群休眠计划, 在WorkDays的StartTime到StopTime之间保持开机, 其余时间停机
+type HibernationSchedule struct {
+	Base
+	ClusterName string
+	WorkDays    string //保持开机的星期, 逗号分隔, 0表示周日
+	StartTime   string //每天开机时间, HH:MM
+	StopTime    string //每天停机时间, HH:MM, 早于StartTime时表示次日停机
+	Timezone    string
+	Enabled     bool
+	State       string //最近一次按计划切换到的状态, AWAKE或HIBERNATED, 为空表示尚未切换过
+	CreateBy    int64
+}
+
+func (HibernationSchedule) TableName() string {
+	return "hibernation_schedule"
+}
+
+//GetHibernationSchedule 获取集群的休眠计划, 未配置时返回nil
+func GetHibernationSchedule(ctx context.Context, clusterName string) (*HibernationSchedule, error) {
+	var out HibernationSchedule
+	err := clients.ReadDBCli.WithContext(ctx).Where("cluster_name = ?", clusterName).First(&out).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logErr("GetHibernationSchedule from read db", err)
+		return nil, err
+	}
+	return &out, nil
+}
+
+//GetEnabledHibernationSchedules 获取所有已启用的休眠计划
+func GetEnabledHibernationSchedules(ctx context.Context) ([]HibernationSchedule, error) {
+	ret := make([]HibernationSchedule, 0)
+	if err := clients.ReadDBCli.WithContext(ctx).Where("enabled = ?", true).Find(&ret).Error; err != nil {
+		logErr("GetEnabledHibernationSchedules from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//UpdateHibernationState 记录休眠计划最近一次切换到的状态
+func UpdateHibernationState(ctx context.Context, id int64, state string) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&HibernationSchedule{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"state": state, "update_at": time.Now()}).Error
+	if err != nil {
+		logErr("UpdateHibernationState to write db", err)
+	}
+	return err
+}
@@ -45,6 +45,13 @@ type Instance struct {
 	DeleteAt     *time.Time
 	RunningAt    *time.Time
 	ExpireAt     *time.Time //PrePaid instance expire time
+	ZoneId       string
+
+	ScaleInProtected bool       //缩容保护, 按数量缩容时不会选中该实例
+	HealthStatus     string     //HEALTHY, UNHEALTHY, 为空表示未检查
+	HealthFailCount  int        //连续健康检查失败次数
+	HealthCheckAt    *time.Time //最近一次健康检查时间
+	ReleaseAtExpiry  bool       //包年包月实例到期后释放, 不再续费
 }
 
 func (Instance) TableName() string {
@@ -124,6 +131,57 @@ func BatchUpdateByInstanceIds(instanceIds []string, instance Instance) error {
 	return nil
 }
 
+//UpdateInstanceHealth 更新实例的健康检查结果
+func UpdateInstanceHealth(instanceId, status string, failCount int, checkAt time.Time) error {
+	err := clients.WriteDBCli.Model(&Instance{}).Where("instance_id = ?", instanceId).
+		Updates(map[string]interface{}{"health_status": status, "health_fail_count": failCount, "health_check_at": checkAt}).Error
+	if err != nil {
+		logErr("UpdateInstanceHealth from write db", err)
+	}
+	return err
+}
+
+//UpdateInstancesStatus 更新集群下指定实例的状态, 传入from时仅更新状态仍为from的实例
+func UpdateInstancesStatus(clusterName string, instanceIds []string, from, to constants.Status) error {
+	query := clients.WriteDBCli.Model(&Instance{}).Where("cluster_name = ? AND instance_id IN (?)", clusterName, instanceIds)
+	if from != "" {
+		query = query.Where("status = ?", from)
+	}
+	if err := query.Updates(map[string]interface{}{"status": to, "update_at": time.Now()}).Error; err != nil {
+		logErr("UpdateInstancesStatus from write db", err)
+		return err
+	}
+	return nil
+}
+
+//UpdateInstancesReleaseAtExpiry 更新集群下指定实例的到期释放标记, shrinkTaskId非0时同时记录缩容任务ID, 返回实际更新的实例数
+func UpdateInstancesReleaseAtExpiry(clusterName string, instanceIds []string, release bool, shrinkTaskId int64) (int64, error) {
+	updates := map[string]interface{}{"release_at_expiry": release, "update_at": time.Now()}
+	if shrinkTaskId != 0 {
+		updates["shrink_task_id"] = shrinkTaskId
+	}
+	ret := clients.WriteDBCli.Model(&Instance{}).
+		Where("cluster_name = ? AND instance_id IN (?) AND status != ?", clusterName, instanceIds, constants.Deleted).
+		Updates(updates)
+	if ret.Error != nil {
+		logErr("UpdateInstancesReleaseAtExpiry from write db", ret.Error)
+		return 0, ret.Error
+	}
+	return ret.RowsAffected, nil
+}
+
+//UpdateInstancesScaleInProtection 更新集群下指定实例的缩容保护标记, 返回实际更新的实例数
+func UpdateInstancesScaleInProtection(clusterName string, instanceIds []string, protected bool) (int64, error) {
+	ret := clients.WriteDBCli.Model(&Instance{}).
+		Where("cluster_name = ? AND instance_id IN (?) AND status != ?", clusterName, instanceIds, constants.Deleted).
+		Updates(map[string]interface{}{"scale_in_protected": protected, "update_at": time.Now()})
+	if ret.Error != nil {
+		logErr("UpdateInstancesScaleInProtection from write db", ret.Error)
+		return 0, ret.Error
+	}
+	return ret.RowsAffected, nil
+}
+
 func GetInstanceByIpInner(ipInner string) (Instance, error) {
 	//fixme 内网IP可能重复
 	instance := &Instance{}
@@ -153,11 +211,11 @@ func GetActiveInstancesByClusterName(clusterName string) ([]Instance, error) {
 	return instances, nil
 }
 
-//GetActiveInstancesWithCount 获取当前cluster下状态不为deleted状态的count个节点
-func GetActiveInstancesWithCount(clusterName string, count int) ([]Instance, error) {
+//GetShrinkCandidateInstances 获取当前cluster下状态不为deleted状态、未开启缩容保护且未标记到期释放的所有节点
+func GetShrinkCandidateInstances(clusterName string) ([]Instance, error) {
 	var instances []Instance
-	if err := clients.ReadDBCli.Where("cluster_name = ? AND status != ? ", clusterName, constants.Deleted).Limit(count).Find(&instances).Error; err != nil {
-		logErr("GetActiveInstancesWithCount from read db", err)
+	if err := clients.ReadDBCli.Where("cluster_name = ? AND status != ? AND scale_in_protected = ? AND release_at_expiry = ?", clusterName, constants.Deleted, false, false).Order("id").Find(&instances).Error; err != nil {
+		logErr("GetShrinkCandidateInstances from read db", err)
 		return instances, err
 	}
 	return instances, nil
@@ -200,13 +258,13 @@ func GetUsageInstancesBySpecifyDay(ctx context.Context, clusterName []string, cr
 	return instances, total, nil
 }
 
-//CountActiveInstancesByClusterName 获取clusters下状态不为deleted状态节点数量
+//CountActiveInstancesByClusterName 获取clusters下状态不为deleted状态节点数量, 已标记到期释放的包年包月实例视为已缩容, 不计入
 func CountActiveInstancesByClusterName(ctx context.Context, clusterNames []string) (int64, error) {
 	if len(clusterNames) == 0 {
 		return 0, nil
 	}
 	var ret int64
-	if err := clients.ReadDBCli.WithContext(ctx).Model(&Instance{}).Where("cluster_name IN (?) AND status != ? ", clusterNames, constants.Deleted).Count(&ret).Error; err != nil {
+	if err := clients.ReadDBCli.WithContext(ctx).Model(&Instance{}).Where("cluster_name IN (?) AND status != ? AND release_at_expiry = ?", clusterNames, constants.Deleted, false).Count(&ret).Error; err != nil {
 		logErr("CountActiveInstancesByClusterName from read db", err)
 		return 0, err
 	}
@@ -223,6 +281,19 @@ func GetInstanceByInstanceId(instanceId string) (*Instance, error) {
 	return &ret, nil
 }
 
+//GetInstancesByInstanceIds 批量获取Instance
+func GetInstancesByInstanceIds(instanceIds []string) ([]Instance, error) {
+	var instances []Instance
+	if len(instanceIds) == 0 {
+		return instances, nil
+	}
+	if err := clients.ReadDBCli.Where("instance_id IN (?)", instanceIds).Find(&instances).Error; err != nil {
+		logErr("GetInstancesByInstanceIds from read db", err)
+		return instances, err
+	}
+	return instances, nil
+}
+
 type InstanceTypeCondition struct {
 	Provider string
 	RegionId string
@@ -246,6 +317,21 @@ func GetInstanceTypeByName(ctx context.Context, instanceTypeName string) (*Insta
 	return &ins, err
 }
 
+//GetInstanceTypesByCond 按条件查询已激活的实例规格, Core及Memory为0时不作为条件
+func GetInstanceTypesByCond(ctx context.Context, cond InstanceTypeCondition) (ins []InstanceType, err error) {
+	query := clients.ReadDBCli.WithContext(ctx).Table(InstanceType{}.TableName()).
+		Where("i_status = ?", InstanceTypeStatusActivated).
+		Where("provider = ? AND region_id = ? AND zone_id = ?", cond.Provider, cond.RegionId, cond.ZoneId)
+	if cond.Core > 0 {
+		query = query.Where("core = ?", cond.Core)
+	}
+	if cond.Memory > 0 {
+		query = query.Where("memory = ?", cond.Memory)
+	}
+	err = query.Order("type_name").Find(&ins).Error
+	return ins, err
+}
+
 type InstanceSearchCond struct {
 	Ip           string
 	InstanceId   string
@@ -273,3 +359,39 @@ func GetInstanceByCond(ctx context.Context, cond InstanceSearchCond) (ret []Inst
 	}
 	return ret, total, nil
 }
+
+//GetExpiringInstances 获取在deadline之前到期的运行中实例
+func GetExpiringInstances(deadline time.Time) ([]Instance, error) {
+	ret := make([]Instance, 0)
+	err := clients.ReadDBCli.Where("status = ? AND expire_at IS NOT NULL AND expire_at <= ? AND release_at_expiry = ?", constants.Running, deadline, false).
+		Order("cluster_name, expire_at").Find(&ret).Error
+	if err != nil {
+		logErr("GetExpiringInstances from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//GetRenewableInstances 获取集群下deadline前到期且未标记到期释放的实例
+func GetRenewableInstances(clusterName string, deadline time.Time) ([]Instance, error) {
+	ret := make([]Instance, 0)
+	err := clients.ReadDBCli.Where("cluster_name = ? AND status != ? AND expire_at IS NOT NULL AND expire_at <= ? AND release_at_expiry = ?",
+		clusterName, constants.Deleted, deadline, false).Order("expire_at").Find(&ret).Error
+	if err != nil {
+		logErr("GetRenewableInstances from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//GetExpiredReleasingInstances 获取已到期且标记为到期释放的实例
+func GetExpiredReleasingInstances(now time.Time) ([]Instance, error) {
+	ret := make([]Instance, 0)
+	err := clients.ReadDBCli.Where("status != ? AND release_at_expiry = ? AND expire_at IS NOT NULL AND expire_at <= ?", constants.Deleted, true, now).
+		Order("cluster_name").Find(&ret).Error
+	if err != nil {
+		logErr("GetExpiredReleasingInstances from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,163 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"gorm.io/gorm"
+)
+
+//BackgroundJob 持久化的后台任务, Payload为任务参数的JSON串
+type BackgroundJob struct {
+	Base
+	OrgId       int64      `json:"org_id"`
+	JobType     string     `json:"job_type"`
+	Payload     string     `json:"payload"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"max_attempts"`
+	NextRunAt   *time.Time `json:"next_run_at"`
+	LastError   string     `json:"last_error"`
+	Worker      string     `json:"worker"`
+	StartAt     *time.Time `json:"start_at"`
+	EndAt       *time.Time `json:"end_at"`
+}
+
+func (BackgroundJob) TableName() string {
+	return "background_job"
+}
+
+type BackgroundJobSearchCond struct {
+	OrgId      int64
+	JobType    string
+	Status     string
+	PageNumber int
+	PageSize   int
+}
+
+func ListBackgroundJobs(ctx context.Context, cond BackgroundJobSearchCond) ([]BackgroundJob, int64, error) {
+	ret := make([]BackgroundJob, 0)
+	query := clients.ReadDBCli.WithContext(ctx).Model(BackgroundJob{}).Where("org_id = ?", cond.OrgId)
+	if cond.JobType != "" {
+		query.Where("job_type = ?", cond.JobType)
+	}
+	if cond.Status != "" {
+		query.Where("status = ?", cond.Status)
+	}
+	count, err := QueryWhere(query, cond.PageNumber, cond.PageSize, &ret, "id desc", true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ret, count, nil
+}
+
+//GetBackgroundJob 获取组织内的任务, 不存在时返回nil
+func GetBackgroundJob(ctx context.Context, orgId, id int64) (*BackgroundJob, error) {
+	var out BackgroundJob
+	err := clients.ReadDBCli.WithContext(ctx).Where("org_id = ? AND id = ?", orgId, id).First(&out).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logErr("GetBackgroundJob from read db", err)
+		return nil, err
+	}
+	return &out, nil
+}
+
+//GetDueBackgroundJobs 获取到达执行时间的待执行任务
+func GetDueBackgroundJobs(ctx context.Context, limit int) ([]BackgroundJob, error) {
+	ret := make([]BackgroundJob, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Where("status = ? AND next_run_at <= ?", constants.JobStatusPending, time.Now()).
+		Order("next_run_at").Limit(limit).Find(&ret).Error
+	if err != nil {
+		logErr("GetDueBackgroundJobs from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//ClaimBackgroundJob 将待执行任务置为执行中, 多个实例同时领取同一任务时只有一个成功, attempts为查询到的执行次数
+func ClaimBackgroundJob(ctx context.Context, id int64, attempts int, worker string) (bool, error) {
+	now := time.Now()
+	res := clients.WriteDBCli.WithContext(ctx).Model(&BackgroundJob{}).Where("id = ? AND status = ? AND attempts = ?", id, constants.JobStatusPending, attempts).
+		Updates(map[string]interface{}{
+			"status":    constants.JobStatusRunning,
+			"attempts":  gorm.Expr("attempts + 1"),
+			"worker":    worker,
+			"start_at":  now,
+			"update_at": now,
+		})
+	if res.Error != nil {
+		logErr("ClaimBackgroundJob to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+//RetryBackgroundJob 将执行失败的任务重新放回队列并重置执行次数, 返回任务是否处于失败状态
+func RetryBackgroundJob(ctx context.Context, orgId, id int64) (bool, error) {
+	now := time.Now()
+	res := clients.WriteDBCli.WithContext(ctx).Model(&BackgroundJob{}).
+		Where("org_id = ? AND id = ? AND status = ?", orgId, id, constants.JobStatusFailed).
+		Updates(map[string]interface{}{
+			"status":      constants.JobStatusPending,
+			"attempts":    0,
+			"next_run_at": now,
+			"update_at":   now,
+		})
+	if res.Error != nil {
+		logErr("RetryBackgroundJob to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+//FinishBackgroundJob 更新本次执行的结果, 任务已被放回队列或由其他worker重新领取时不更新
+func FinishBackgroundJob(ctx context.Context, id int64, worker string, attempts int, updates map[string]interface{}) (bool, error) {
+	res := clients.WriteDBCli.WithContext(ctx).Model(&BackgroundJob{}).
+		Where("id = ? AND status = ? AND worker = ? AND attempts = ?", id, constants.JobStatusRunning, worker, attempts).
+		Updates(updates)
+	if res.Error != nil {
+		logErr("FinishBackgroundJob to write db", res.Error)
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+//RequeueStaleBackgroundJobs 将开始时间早于before仍在执行中的任务放回队列, 已达到最多执行次数的任务置为失败
+func RequeueStaleBackgroundJobs(ctx context.Context, before time.Time) (int64, error) {
+	now := time.Now()
+	var requeued int64
+	err := clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&BackgroundJob{}).
+			Where("status = ? AND start_at < ? AND attempts >= max_attempts", constants.JobStatusRunning, before).
+			Updates(map[string]interface{}{"status": constants.JobStatusFailed, "last_error": "job running timeout", "end_at": now, "update_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		res = tx.Model(&BackgroundJob{}).
+			Where("status = ? AND start_at < ?", constants.JobStatusRunning, before).
+			Updates(map[string]interface{}{"status": constants.JobStatusPending, "next_run_at": now, "update_at": now})
+		requeued = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		logErr("RequeueStaleBackgroundJobs to write db", err)
+		return 0, err
+	}
+	return requeued, nil
+}
+
+//DeleteBackgroundJobsBefore 清理结束时间早于before的成功任务
+func DeleteBackgroundJobsBefore(ctx context.Context, before time.Time) error {
+	err := clients.WriteDBCli.WithContext(ctx).Where("status = ? AND end_at < ?", constants.JobStatusSuccess, before).
+		Delete(&BackgroundJob{}).Error
+	if err != nil {
+		logErr("DeleteBackgroundJobsBefore from write db", err)
+	}
+	return err
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/logs"
-	"github.com/galaxy-future/BridgX/pkg/utils"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
@@ -93,6 +93,79 @@ func (SecurityGroupRule) TableName() string {
 	return "b_security_group_rule"
 }
 
+type NatGateway struct {
+	Base
+	AK           string `gorm:"column:ak"`
+	Provider     string
+	RegionId     string
+	VpcId        string
+	SwitchId     string
+	NatGatewayId string
+	Name         string
+	SnatTableId  string
+	IpAddresses  string
+	VStatus      string
+	IsDel        int
+}
+
+func (NatGateway) TableName() string {
+	return "b_nat_gateway"
+}
+
+type SnatEntry struct {
+	Base
+	NatGatewayId string
+	SnatTableId  string
+	SnatEntryId  string
+	Name         string
+	SwitchId     string
+	SourceCidr   string
+	SnatIp       string
+	VStatus      string
+	IsDel        int
+}
+
+func (SnatEntry) TableName() string {
+	return "b_snat_entry"
+}
+
+type VpcPeering struct {
+	Base
+	AK            string `gorm:"column:ak"`
+	Provider      string
+	RegionId      string
+	VpcId         string
+	PeeringId     string
+	Name          string
+	PeerRegionId  string
+	PeerVpcId     string
+	PeerPeeringId string
+	VStatus       string
+	IsDel         int
+}
+
+func (VpcPeering) TableName() string {
+	return "b_vpc_peering"
+}
+
+//RouteEntry VPC的自定义路由, 同一路由表内按目标网段唯一
+type RouteEntry struct {
+	Base
+	VpcId           string
+	RouteTableId    string
+	RouteEntryId    string
+	Name            string
+	DestinationCidr string
+	NextHopType     string
+	NextHopId       string
+	VStatus         string
+	IsDel           int
+}
+
+func (RouteEntry) TableName() string {
+	return "b_route_entry"
+}
+
 type FindVpcConditions struct {
 	AccountKey string
 	VpcId      string
@@ -170,6 +243,15 @@ func UpdateVpc(ctx context.Context, vpcId, cidrBlock, vStatus string) error {
 		Error
 }
 
+//FindVpcsByAks 获取账户下所有未删除的VPC
+func FindVpcsByAks(ctx context.Context, aks []string) (result []Vpc, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("ak in (?) and is_del = 0", aks).
+		Find(&result).
+		Error
+	return result, err
+}
+
 type FindSwitchesConditions struct {
 	VpcId      string
 	ZoneId     string
@@ -246,6 +328,15 @@ func UpdateSwitch(ctx context.Context, availableIpAddressCount, isDefault int, v
 		Error
 }
 
+//FindSwitchesByVpcIds 获取VPC下所有未删除的子网
+func FindSwitchesByVpcIds(ctx context.Context, vpcIds []string) (result []Switch, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("vpc_id in (?) and is_del = 0", vpcIds).
+		Find(&result).
+		Error
+	return result, err
+}
+
 type FindSecurityGroupConditions struct {
 	AK                string
 	Provider          string
@@ -324,89 +415,92 @@ func AddSecurityGroupRule(ctx context.Context, r SecurityGroupRule) error {
 	return clients.WriteDBCli.WithContext(ctx).Create(&r).Error
 }
 
-const _effectiveTime = "DATE_ADD(now(),interval 478 minute)"
+//FindVpcsByRegion 获取账户在地域下所有未删除的VPC
+func FindVpcsByRegion(ctx context.Context, ak, provider, regionId string) (result []Vpc, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("ak = ? and provider = ? and region_id = ? and is_del = 0", ak, provider, regionId).
+		Find(&result).
+		Error
+	return result, err
+}
 
-func UpdateOrCreateVpcs(ctx context.Context, ak, provider string, regionIds []string, vpcs []Vpc) error {
-	oldVpcIds := make([]string, 0)
-	if err := clients.ReadDBCli.WithContext(ctx).Model(&Vpc{}).
-		Select("vpc_id").
-		Where("ak=? and provider=? and region_id in (?) and is_del=0 and update_at<?", ak, provider, regionIds, _effectiveTime).
-		Scan(&oldVpcIds).Error; err != nil {
-		return err
-	}
-	vpcIds := make([]string, 0, len(vpcs))
-	for _, v := range vpcs {
-		vpcIds = append(vpcIds, v.VpcId)
+//UpsertVpcs 新增或更新VPC, 已软删除的VPC在云上重新出现时恢复
+func UpsertVpcs(ctx context.Context, vpcs []Vpc) error {
+	if len(vpcs) == 0 {
+		return nil
 	}
-	vpcIdDiff := utils.StringSliceDiff(oldVpcIds, vpcIds)
-	if len(vpcIdDiff) > 0 {
-		if err := clients.WriteDBCli.WithContext(ctx).
-			Where("ak=? and provider=? and vpc_id in (?)", ak, provider, vpcIdDiff).
-			Delete(&Vpc{}).Error; err != nil {
-			return err
-		}
-	}
-
 	return clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "ak"}, {Name: "region_id"}, {Name: "vpc_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"name", "cidr_block", "v_status"}),
+		DoUpdates: clause.AssignmentColumns([]string{"name", "cidr_block", "v_status", "is_del", "update_at"}),
 	}).Create(&vpcs).Error
 }
 
-func UpdateOrCreateSwitches(ctx context.Context, vpcIds []string, switches []Switch) error {
-	oldSwitchIds := make([]string, 0)
-	if err := clients.ReadDBCli.WithContext(ctx).Model(&Switch{}).
-		Select("switch_id").
-		Where("vpc_id in (?) and is_del=0 and update_at<?", vpcIds, _effectiveTime).
-		Scan(&oldSwitchIds).Error; err != nil {
-		return err
-	}
-	switchIds := make([]string, 0, len(switches))
-	for _, v := range switches {
-		switchIds = append(switchIds, v.SwitchId)
-	}
-	switchIdDiff := utils.StringSliceDiff(oldSwitchIds, switchIds)
-	if len(switchIdDiff) > 0 {
-		if err := clients.WriteDBCli.WithContext(ctx).
-			Where("switch_id in (?)", switchIdDiff).
-			Delete(&Switch{}).Error; err != nil {
-			return err
-		}
+func SoftDeleteVpcs(ctx context.Context, ak, regionId string, vpcIds []string) error {
+	if len(vpcIds) == 0 {
+		return nil
 	}
+	return clients.WriteDBCli.WithContext(ctx).Model(&Vpc{}).
+		Where("ak = ? and region_id = ? and vpc_id in (?)", ak, regionId, vpcIds).
+		Updates(map[string]interface{}{"is_del": 1, "update_at": time.Now()}).Error
+}
 
+func UpsertSwitches(ctx context.Context, switches []Switch) error {
+	if len(switches) == 0 {
+		return nil
+	}
 	return clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "switch_id"}, {Name: "vpc_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"name", "cidr_block", `gateway_ip`, "v_status", "available_ip_address_count", "is_default"}),
+		Columns:   []clause.Column{{Name: "vpc_id"}, {Name: "switch_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "cidr_block", "gateway_ip", "v_status", "available_ip_address_count", "is_default", "is_del", "update_at"}),
 	}).Create(&switches).Error
 }
 
-func UpdateOrCreateGroups(ctx context.Context, ak, provider string, regionIds []string, groups []SecurityGroup) error {
-	oldSecGrpIds := make([]string, 0)
-	if err := clients.ReadDBCli.WithContext(ctx).Model(&SecurityGroup{}).
-		Select("security_group_id").
-		Where("ak=? and provider=? and region_id in (?) and is_del=0 and update_at<?", ak, provider, regionIds, _effectiveTime).
-		Scan(&oldSecGrpIds).Error; err != nil {
-		return err
-	}
-	secGrpIds := make([]string, 0, len(groups))
-	for _, v := range groups {
-		secGrpIds = append(secGrpIds, v.SecurityGroupId)
-	}
-	secGrpIdDiff := utils.StringSliceDiff(oldSecGrpIds, secGrpIds)
-	if len(secGrpIdDiff) > 0 {
-		if err := clients.WriteDBCli.WithContext(ctx).
-			Where("ak=? and provider=? and security_group_id in (?)", ak, provider, secGrpIdDiff).
-			Delete(&SecurityGroup{}).Error; err != nil {
-			return err
-		}
+func SoftDeleteSwitches(ctx context.Context, switchIds []string) error {
+	if len(switchIds) == 0 {
+		return nil
 	}
+	return clients.WriteDBCli.WithContext(ctx).Model(&Switch{}).
+		Where("switch_id in (?)", switchIds).
+		Updates(map[string]interface{}{"is_del": 1, "update_at": time.Now()}).Error
+}
+
+//FindSecurityGroupsByRegion 获取账户在地域下所有未删除的安全组
+func FindSecurityGroupsByRegion(ctx context.Context, ak, provider, regionId string) (result []SecurityGroup, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("ak = ? and provider = ? and region_id = ? and is_del = 0", ak, provider, regionId).
+		Find(&result).
+		Error
+	return result, err
+}
 
+func UpsertSecurityGroups(ctx context.Context, groups []SecurityGroup) error {
+	if len(groups) == 0 {
+		return nil
+	}
 	return clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "ak"}, {Name: "provider"}, {Name: "region_id"}, {Name: "security_group_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"name", "security_group_type"}),
+		DoUpdates: clause.AssignmentColumns([]string{"vpc_id", "name", "security_group_type", "is_del", "update_at"}),
 	}).Create(&groups).Error
 }
 
+//SoftDeleteSecurityGroups 同时标记安全组下的规则为删除
+func SoftDeleteSecurityGroups(ctx context.Context, ak, provider, regionId string, groupIds []string) error {
+	if len(groupIds) == 0 {
+		return nil
+	}
+	now := time.Now()
+	return clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&SecurityGroup{}).
+			Where("ak = ? and provider = ? and region_id = ? and security_group_id in (?)", ak, provider, regionId, groupIds).
+			Updates(map[string]interface{}{"is_del": 1, "update_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&SecurityGroupRule{}).
+			Where("security_group_id in (?)", groupIds).
+			Updates(map[string]interface{}{"is_del": 1, "update_at": now}).Error
+	})
+}
+
 func ReplaceRules(ctx context.Context, vpcID, groupId string, rules []SecurityGroupRule) (err error) {
 	tx := clients.WriteDBCli.WithContext(ctx).Begin()
 	defer func() {
@@ -420,9 +514,11 @@ func ReplaceRules(ctx context.Context, vpcID, groupId string, rules []SecurityGr
 	if err != nil {
 		return err
 	}
-	err = tx.CreateInBatches(&rules, len(rules)).Error
-	if err != nil {
-		return err
+	if len(rules) > 0 {
+		err = tx.CreateInBatches(&rules, len(rules)).Error
+		if err != nil {
+			return err
+		}
 	}
 	tx.Commit()
 	return nil
@@ -434,3 +530,167 @@ func FindSecurityGroupRulesById(ctx context.Context, securityGroupId string) (re
 		Find(&result).Error
 	return result, err
 }
+
+//FindNatGatewaysByRegion 获取账户在地域下所有未删除的NAT网关
+func FindNatGatewaysByRegion(ctx context.Context, ak, provider, regionId string) (result []NatGateway, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("ak = ? and provider = ? and region_id = ? and is_del = 0", ak, provider, regionId).
+		Find(&result).
+		Error
+	return result, err
+}
+
+func FindNatGatewayById(ctx context.Context, natGatewayId string) (result NatGateway, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("nat_gateway_id = ? and is_del = 0", natGatewayId).
+		First(&result).Error
+	return result, err
+}
+
+func UpsertNatGateways(ctx context.Context, gateways []NatGateway) error {
+	if len(gateways) == 0 {
+		return nil
+	}
+	return clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ak"}, {Name: "provider"}, {Name: "region_id"}, {Name: "nat_gateway_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"vpc_id", "switch_id", "name", "snat_table_id", "ip_addresses", "v_status", "is_del", "update_at"}),
+	}).Create(&gateways).Error
+}
+
+//SoftDeleteNatGateways 同时标记网关下的SNAT条目为删除
+func SoftDeleteNatGateways(ctx context.Context, ak, provider, regionId string, natGatewayIds []string) error {
+	if len(natGatewayIds) == 0 {
+		return nil
+	}
+	now := time.Now()
+	return clients.WriteDBCli.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&NatGateway{}).
+			Where("ak = ? and provider = ? and region_id = ? and nat_gateway_id in (?)", ak, provider, regionId, natGatewayIds).
+			Updates(map[string]interface{}{"is_del": 1, "update_at": now}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&SnatEntry{}).
+			Where("nat_gateway_id in (?)", natGatewayIds).
+			Updates(map[string]interface{}{"is_del": 1, "update_at": now}).Error
+	})
+}
+
+//FindSnatEntriesByNatGatewayIds 获取NAT网关下所有未删除的SNAT条目
+func FindSnatEntriesByNatGatewayIds(ctx context.Context, natGatewayIds []string) (result []SnatEntry, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("nat_gateway_id in (?) and is_del = 0", natGatewayIds).
+		Find(&result).
+		Error
+	return result, err
+}
+
+func FindSnatEntryById(ctx context.Context, snatEntryId string) (result SnatEntry, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("snat_entry_id = ? and is_del = 0", snatEntryId).
+		First(&result).Error
+	return result, err
+}
+
+func UpsertSnatEntries(ctx context.Context, entries []SnatEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "snat_table_id"}, {Name: "snat_entry_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"nat_gateway_id", "name", "switch_id", "source_cidr", "snat_ip", "v_status", "is_del", "update_at"}),
+	}).Create(&entries).Error
+}
+
+func SoftDeleteSnatEntries(ctx context.Context, snatEntryIds []string) error {
+	if len(snatEntryIds) == 0 {
+		return nil
+	}
+	return clients.WriteDBCli.WithContext(ctx).Model(&SnatEntry{}).
+		Where("snat_entry_id in (?)", snatEntryIds).
+		Updates(map[string]interface{}{"is_del": 1, "update_at": time.Now()}).Error
+}
+
+//FindVpcPeeringsByRegion 获取账户在地域下所有未删除的VPC对等连接
+func FindVpcPeeringsByRegion(ctx context.Context, ak, provider, regionId string) (result []VpcPeering, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("ak = ? and provider = ? and region_id = ? and is_del = 0", ak, provider, regionId).
+		Find(&result).
+		Error
+	return result, err
+}
+
+func FindVpcPeeringById(ctx context.Context, peeringId string) (result VpcPeering, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("peering_id = ? and is_del = 0", peeringId).
+		First(&result).Error
+	return result, err
+}
+
+func UpsertVpcPeerings(ctx context.Context, peerings []VpcPeering) error {
+	if len(peerings) == 0 {
+		return nil
+	}
+	return clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "ak"}, {Name: "provider"}, {Name: "region_id"}, {Name: "peering_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"vpc_id", "name", "peer_region_id", "peer_vpc_id", "peer_peering_id", "v_status", "is_del", "update_at"}),
+	}).Create(&peerings).Error
+}
+
+func SoftDeleteVpcPeerings(ctx context.Context, ak, provider, regionId string, peeringIds []string) error {
+	if len(peeringIds) == 0 {
+		return nil
+	}
+	return clients.WriteDBCli.WithContext(ctx).Model(&VpcPeering{}).
+		Where("ak = ? and provider = ? and region_id = ? and peering_id in (?)", ak, provider, regionId, peeringIds).
+		Updates(map[string]interface{}{"is_del": 1, "update_at": time.Now()}).Error
+}
+
+//FindRouteEntriesByVpcIds 获取VPC下所有未删除的自定义路由
+func FindRouteEntriesByVpcIds(ctx context.Context, vpcIds []string) (result []RouteEntry, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("vpc_id in (?) and is_del = 0", vpcIds).
+		Find(&result).
+		Error
+	return result, err
+}
+
+//FindRouteEntriesByNextHopIds 获取下一跳为指定资源的自定义路由
+func FindRouteEntriesByNextHopIds(ctx context.Context, nextHopIds []string) (result []RouteEntry, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("next_hop_id in (?) and is_del = 0", nextHopIds).
+		Find(&result).
+		Error
+	return result, err
+}
+
+func FindRouteEntry(ctx context.Context, routeTableId, destinationCidr string) (result RouteEntry, err error) {
+	err = clients.ReadDBCli.WithContext(ctx).
+		Where("route_table_id = ? and destination_cidr = ? and is_del = 0", routeTableId, destinationCidr).
+		First(&result).Error
+	return result, err
+}
+
+func UpsertRouteEntries(ctx context.Context, entries []RouteEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "route_table_id"}, {Name: "destination_cidr"}},
+		DoUpdates: clause.AssignmentColumns([]string{"vpc_id", "route_entry_id", "name", "next_hop_type", "next_hop_id", "v_status", "is_del", "update_at"}),
+	}).Create(&entries).Error
+}
+
+//SoftDeleteRouteEntries 路由按路由表及目标网段定位
+func SoftDeleteRouteEntries(ctx context.Context, entries []RouteEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	keys := make([][]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		keys = append(keys, []interface{}{entry.RouteTableId, entry.DestinationCidr})
+	}
+	return clients.WriteDBCli.WithContext(ctx).Model(&RouteEntry{}).
+		Where("(route_table_id, destination_cidr) in ?", keys).
+		Updates(map[string]interface{}{"is_del": 1, "update_at": time.Now()}).Error
+}
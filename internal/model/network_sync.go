@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+)
+
+//NetworkSyncRecord 账户在一个地域下的一次网络资源同步, Report为本次新增/变更/删除资源的JSON串
+type NetworkSyncRecord struct {
+	Base
+	AK          string `gorm:"column:ak"`
+	Provider    string
+	RegionId    string
+	TriggerType string
+	Status      string
+	Report      string
+	ErrMsg      string
+	StartAt     *time.Time
+	EndAt       *time.Time
+}
+
+func (NetworkSyncRecord) TableName() string {
+	return "b_network_sync_record"
+}
+
+func FinishNetworkSyncRecord(ctx context.Context, id int64, status, report, errMsg string) error {
+	now := time.Now()
+	err := clients.WriteDBCli.WithContext(ctx).Model(&NetworkSyncRecord{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "report": report, "err_msg": errMsg, "end_at": now, "update_at": now}).Error
+	if err != nil {
+		logErr("FinishNetworkSyncRecord to write db", err)
+	}
+	return err
+}
+
+//GetLatestNetworkSyncRecords 获取账户在各地域下最近一次的同步记录, regionId为空时返回所有地域
+func GetLatestNetworkSyncRecords(ctx context.Context, aks []string, regionId string) ([]NetworkSyncRecord, error) {
+	ret := make([]NetworkSyncRecord, 0)
+	latest := clients.ReadDBCli.WithContext(ctx).Model(&NetworkSyncRecord{}).Select("max(id)").
+		Where("ak in (?)", aks).Group("ak, provider, region_id")
+	if regionId != "" {
+		latest = latest.Where("region_id = ?", regionId)
+	}
+	err := clients.ReadDBCli.WithContext(ctx).Where("id in (?)", latest).Order("ak, region_id").Find(&ret).Error
+	if err != nil {
+		logErr("GetLatestNetworkSyncRecords from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//DeleteNetworkSyncRecordsBefore 清理过期的同步记录
+func DeleteNetworkSyncRecordsBefore(ctx context.Context, before time.Time) error {
+	err := clients.WriteDBCli.WithContext(ctx).Where("create_at < ?", before).Delete(&NetworkSyncRecord{}).Error
+	if err != nil {
+		logErr("DeleteNetworkSyncRecordsBefore from write db", err)
+	}
+	return err
+}
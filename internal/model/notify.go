@@ -0,0 +1,160 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+)
+
+//NotifyChannel 通知渠道, Config为渠道配置的JSON串
+type NotifyChannel struct {
+	Base
+	OrgId       int64  `json:"org_id"`
+	Name        string `json:"name"`
+	ChannelType string `json:"channel_type"`
+	Config      string `json:"config"`
+	Enabled     bool   `json:"enabled"`
+	CreateBy    string `json:"create_by"`
+}
+
+func (NotifyChannel) TableName() string {
+	return "notify_channel"
+}
+
+//NotifySubscription 订阅规则, ClusterName/EventTypes为空表示匹配全部
+type NotifySubscription struct {
+	Base
+	OrgId       int64  `json:"org_id"`
+	ChannelId   int64  `json:"channel_id"`
+	ClusterName string `json:"cluster_name"`
+	EventTypes  string `json:"event_types"` //逗号分隔
+	MinSeverity string `json:"min_severity"`
+	Template    string `json:"template"` //text/template格式的消息模板, 为空时使用默认模板
+	CreateBy    string `json:"create_by"`
+}
+
+func (NotifySubscription) TableName() string {
+	return "notify_subscription"
+}
+
+//NotifyRecord 通知发送记录, 发送失败的记录由调度器按NextRetryAt重试
+type NotifyRecord struct {
+	Base
+	OrgId       int64      `json:"org_id"`
+	ChannelId   int64      `json:"channel_id"`
+	EventType   string     `json:"event_type"`
+	Severity    string     `json:"severity"`
+	ClusterName string     `json:"cluster_name"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Status      string     `json:"status"`
+	RetryCount  int        `json:"retry_count"`
+	NextRetryAt *time.Time `json:"next_retry_at"`
+	ErrMsg      string     `json:"err_msg"`
+}
+
+func (NotifyRecord) TableName() string {
+	return "notify_record"
+}
+
+func GetNotifyChannelsByOrgId(ctx context.Context, orgId int64) ([]NotifyChannel, error) {
+	ret := make([]NotifyChannel, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Where("org_id = ?", orgId).Order("id desc").Find(&ret).Error
+	if err != nil {
+		logErr("GetNotifyChannelsByOrgId from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+func GetNotifySubscriptionsByOrgId(ctx context.Context, orgId int64) ([]NotifySubscription, error) {
+	ret := make([]NotifySubscription, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Where("org_id = ?", orgId).Order("id desc").Find(&ret).Error
+	if err != nil {
+		logErr("GetNotifySubscriptionsByOrgId from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//DeleteNotifyChannels 删除渠道的同时删除挂在该渠道上的订阅
+func DeleteNotifyChannels(ctx context.Context, orgId int64, ids []int64) error {
+	tx := clients.WriteDBCli.WithContext(ctx).Begin()
+	if err := tx.Where("org_id = ? AND id IN (?)", orgId, ids).Delete(&NotifyChannel{}).Error; err != nil {
+		tx.Rollback()
+		logErr("DeleteNotifyChannels from write db", err)
+		return err
+	}
+	if err := tx.Where("org_id = ? AND channel_id IN (?)", orgId, ids).Delete(&NotifySubscription{}).Error; err != nil {
+		tx.Rollback()
+		logErr("DeleteNotifyChannels from write db", err)
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func DeleteNotifySubscriptions(ctx context.Context, orgId int64, ids []int64) error {
+	err := clients.WriteDBCli.WithContext(ctx).Where("org_id = ? AND id IN (?)", orgId, ids).Delete(&NotifySubscription{}).Error
+	if err != nil {
+		logErr("DeleteNotifySubscriptions from write db", err)
+		return err
+	}
+	return nil
+}
+
+type NotifyRecordSearchCond struct {
+	OrgId       int64
+	ChannelId   int64
+	ClusterName string
+	EventType   string
+	Status      string
+	PageNumber  int
+	PageSize    int
+}
+
+func ListNotifyRecords(ctx context.Context, cond NotifyRecordSearchCond) ([]NotifyRecord, int64, error) {
+	ret := make([]NotifyRecord, 0)
+	query := clients.ReadDBCli.WithContext(ctx).Model(NotifyRecord{}).Where("org_id = ?", cond.OrgId)
+	if cond.ChannelId != 0 {
+		query.Where("channel_id = ?", cond.ChannelId)
+	}
+	if cond.ClusterName != "" {
+		query.Where("cluster_name = ?", cond.ClusterName)
+	}
+	if cond.EventType != "" {
+		query.Where("event_type = ?", cond.EventType)
+	}
+	if cond.Status != "" {
+		query.Where("status = ?", cond.Status)
+	}
+	count, err := QueryWhere(query, cond.PageNumber, cond.PageSize, &ret, "id desc", true)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ret, count, nil
+}
+
+//GetRetryableNotifyRecords 获取到达重试时间且未超过最大重试次数的失败记录
+func GetRetryableNotifyRecords(limit int) ([]NotifyRecord, error) {
+	ret := make([]NotifyRecord, 0)
+	err := clients.ReadDBCli.Where("status = ? AND retry_count < ? AND next_retry_at <= ?", constants.NotifyRecordStatusFailed, constants.NotifyMaxRetryCount, time.Now()).
+		Order("id").Limit(limit).Find(&ret).Error
+	if err != nil {
+		logErr("GetRetryableNotifyRecords from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//HasNotifyRecordSince 判断since之后集群是否已经产生过该类事件的通知
+func HasNotifyRecordSince(clusterName, eventType string, since time.Time) (bool, error) {
+	var cnt int64
+	err := clients.ReadDBCli.Model(NotifyRecord{}).Where("cluster_name = ? AND event_type = ? AND create_at >= ?", clusterName, eventType, since).Count(&cnt).Error
+	if err != nil {
+		logErr("HasNotifyRecordSince from read db", err)
+		return false, err
+	}
+	return cnt > 0, nil
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//SecurityGroupTemplate 组织内可复用的安全组规则模板, Rules为规则列表的JSON串
+type SecurityGroupTemplate struct {
+	Base
+	OrgId       int64  `json:"org_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Rules       string `json:"rules"`
+	CreateBy    string `json:"create_by"`
+}
+
+func (SecurityGroupTemplate) TableName() string {
+	return "security_group_template"
+}
+
+//SecurityGroupTemplateBinding 按模板创建或绑定到模板的安全组, 调度器据此检测规则是否偏离模板
+type SecurityGroupTemplateBinding struct {
+	Base
+	OrgId           int64      `json:"org_id"`
+	TemplateId      int64      `json:"template_id"`
+	SecurityGroupId string     `json:"security_group_id"`
+	AccountKey      string     `json:"account_key"`
+	Provider        string     `json:"provider"`
+	RegionId        string     `json:"region_id"`
+	VpcId           string     `json:"vpc_id"`
+	AutoRemediate   bool       `json:"auto_remediate"`
+	DriftStatus     string     `json:"drift_status"`
+	DriftReport     string     `json:"drift_report"`
+	LastCheckAt     *time.Time `json:"last_check_at"`
+}
+
+func (SecurityGroupTemplateBinding) TableName() string {
+	return "security_group_template_binding"
+}
+
+func GetSecurityGroupTemplatesByOrgId(ctx context.Context, orgId int64) ([]SecurityGroupTemplate, error) {
+	ret := make([]SecurityGroupTemplate, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Where("org_id = ?", orgId).Order("id desc").Find(&ret).Error
+	if err != nil {
+		logErr("GetSecurityGroupTemplatesByOrgId from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//GetSecurityGroupTemplate 按id或名称获取组织内的模板, 不存在时返回nil
+func GetSecurityGroupTemplate(ctx context.Context, orgId, id int64, name string) (*SecurityGroupTemplate, error) {
+	var out SecurityGroupTemplate
+	query := clients.ReadDBCli.WithContext(ctx).Where("org_id = ?", orgId)
+	if id > 0 {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("name = ?", name)
+	}
+	err := query.First(&out).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logErr("GetSecurityGroupTemplate from read db", err)
+		return nil, err
+	}
+	return &out, nil
+}
+
+func UpdateSecurityGroupTemplate(ctx context.Context, orgId int64, id int64, updates map[string]interface{}) error {
+	updates["update_at"] = time.Now()
+	err := clients.WriteDBCli.WithContext(ctx).Model(&SecurityGroupTemplate{}).Where("org_id = ? AND id = ?", orgId, id).Updates(updates).Error
+	if err != nil {
+		logErr("UpdateSecurityGroupTemplate to write db", err)
+	}
+	return err
+}
+
+//DeleteSecurityGroupTemplates 删除模板的同时解除安全组与模板的绑定, 安全组及其规则保持不变
+func DeleteSecurityGroupTemplates(ctx context.Context, orgId int64, ids []int64) error {
+	tx := clients.WriteDBCli.WithContext(ctx).Begin()
+	if err := tx.Where("org_id = ? AND id IN (?)", orgId, ids).Delete(&SecurityGroupTemplate{}).Error; err != nil {
+		tx.Rollback()
+		logErr("DeleteSecurityGroupTemplates from write db", err)
+		return err
+	}
+	if err := tx.Where("org_id = ? AND template_id IN (?)", orgId, ids).Delete(&SecurityGroupTemplateBinding{}).Error; err != nil {
+		tx.Rollback()
+		logErr("DeleteSecurityGroupTemplates from write db", err)
+		return err
+	}
+	return tx.Commit().Error
+}
+
+//SaveSecurityGroupTemplateBinding 安全组已绑定其他模板时改为绑定到新模板, 并清空上次的检测结果
+func SaveSecurityGroupTemplateBinding(ctx context.Context, binding *SecurityGroupTemplateBinding) error {
+	now := time.Now()
+	binding.CreateAt = &now
+	binding.UpdateAt = &now
+	err := clients.WriteDBCli.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "security_group_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"org_id":         binding.OrgId,
+			"template_id":    binding.TemplateId,
+			"account_key":    binding.AccountKey,
+			"provider":       binding.Provider,
+			"region_id":      binding.RegionId,
+			"vpc_id":         binding.VpcId,
+			"auto_remediate": binding.AutoRemediate,
+			"drift_status":   "",
+			"drift_report":   "",
+			"last_check_at":  nil,
+			"update_at":      now,
+		}),
+	}).Create(binding).Error
+	if err != nil {
+		logErr("SaveSecurityGroupTemplateBinding to write db", err)
+	}
+	return err
+}
+
+//GetSecurityGroupTemplateBinding 获取安全组的模板绑定, 未绑定时返回nil
+func GetSecurityGroupTemplateBinding(ctx context.Context, securityGroupId string) (*SecurityGroupTemplateBinding, error) {
+	var out SecurityGroupTemplateBinding
+	err := clients.ReadDBCli.WithContext(ctx).Where("security_group_id = ?", securityGroupId).First(&out).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		logErr("GetSecurityGroupTemplateBinding from read db", err)
+		return nil, err
+	}
+	return &out, nil
+}
+
+func GetAllSecurityGroupTemplateBindings(ctx context.Context) ([]SecurityGroupTemplateBinding, error) {
+	ret := make([]SecurityGroupTemplateBinding, 0)
+	if err := clients.ReadDBCli.WithContext(ctx).Find(&ret).Error; err != nil {
+		logErr("GetAllSecurityGroupTemplateBindings from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//UpdateSecurityGroupDrift 记录最近一次偏离检测的结果
+func UpdateSecurityGroupDrift(ctx context.Context, id int64, status, report string, checkAt time.Time) error {
+	err := clients.WriteDBCli.WithContext(ctx).Model(&SecurityGroupTemplateBinding{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"drift_status": status, "drift_report": report, "last_check_at": checkAt, "update_at": time.Now()}).Error
+	if err != nil {
+		logErr("UpdateSecurityGroupDrift to write db", err)
+	}
+	return err
+}
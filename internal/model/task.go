@@ -2,18 +2,22 @@ package model
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/galaxy-future/BridgX/internal/clients"
 	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/types"
 	"github.com/spf13/cast"
+	"gorm.io/gorm"
 )
 
 type Task struct {
 	Base
 	TaskName      string     `json:"task_name"`
-	Status        string     `json:"status"`      //INIT, RUNNING, SUCCESS, FAILED
+	Status        string     `json:"status"`      //PENDING_APPROVAL, INIT, RUNNING, SUCCESS, FAILED, REJECTED
 	TaskAction    string     `json:"task_action"` //expand, shrink
 	TaskFilter    string     `json:"task_filter"` //任务过滤，业务标识（如集群名等）
 	TaskInfo      string     `json:"task_info"`   //不同任务需要的不同的参数
@@ -21,6 +25,7 @@ type Task struct {
 	TaskResult    string     `json:"task_result"`
 	SupportCancel bool       `json:"support_cancel"`
 	FinishTime    *time.Time `json:"finish_time"`
+	Version       int64      `json:"-"` //任务每次交由执行器执行或被重新置为INIT时加1, 执行器仅在版本未变时回写结果
 }
 
 func (Task) TableName() string {
@@ -71,6 +76,33 @@ func (e *ExpandTaskInfo) GetCreateUsername() (username string) {
 
 type ExpandTaskRes struct {
 	InstanceIdList []string `json:"instance_id_list"`
+	FailedNum      int      `json:"failed_num"`
+	ErrMsgs        []string `json:"err_msgs"`
+}
+
+//Merge 合并分片扩容的结果
+func (r *ExpandTaskRes) Merge(o ExpandTaskRes) {
+	r.InstanceIdList = append(r.InstanceIdList, o.InstanceIdList...)
+	r.FailedNum += o.FailedNum
+	r.ErrMsgs = append(r.ErrMsgs, o.ErrMsgs...)
+}
+
+//Err 有分片失败时返回汇总后的错误
+func (r *ExpandTaskRes) Err() error {
+	if len(r.ErrMsgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(r.ErrMsgs, "; "))
+}
+
+//ExpandTaskError 扩容未达到预期数量时返回, 携带未创建成功的数量及每次重试的错误信息
+type ExpandTaskError struct {
+	FailedNum int
+	ErrMsgs   []string
+}
+
+func (e *ExpandTaskError) Error() string {
+	return strings.Join(e.ErrMsgs, "; ")
 }
 
 type ShrinkTaskInfo struct {
@@ -81,6 +113,8 @@ type ShrinkTaskInfo struct {
 	TaskSubmitHost string `json:"task_submit_host"`
 	UserId         int64  `json:"user_id"`
 	BeforeCount    int    `json:"before_count"`
+
+	Strategy *types.ShrinkStrategyConfig `json:"strategy"` //按数量缩容时使用的实例选择策略
 }
 
 func (e *ShrinkTaskInfo) GetCount() int {
@@ -115,7 +149,9 @@ func (s *ShrinkTaskInfo) GetCreateUsername() (username string) {
 }
 
 type TaskResult struct {
-	SuccessNum int `json:"success_num"`
+	SuccessNum int      `json:"success_num"`
+	FailedNum  int      `json:"failed_num,omitempty"`
+	ErrMsgs    []string `json:"err_msgs,omitempty"`
 }
 
 func CountByTaskStatus(taskFilter string, statuses []string) (int64, error) {
@@ -137,6 +173,19 @@ func GetTaskByStatus(clusterName string, statuses []string) ([]Task, error) {
 	return tasks, nil
 }
 
+//GetLatestTaskByName 获取集群下指定名称的最新任务, 不存在时返回nil
+func GetLatestTaskByName(clusterName, taskName string) (*Task, error) {
+	var tasks []Task
+	if err := clients.ReadDBCli.Where("task_filter = ? AND task_name = ?", clusterName, taskName).Order("id desc").Limit(1).Find(&tasks).Error; err != nil {
+		logErr("GetLatestTaskByName from read db", err)
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	return &tasks[0], nil
+}
+
 //GetExpireRunningTask 获取执行状态为Running并且最后更新时间（应该为执行时间）大于指定时间的所有的task
 func GetExpireRunningTask(duration time.Duration) ([]Task, error) {
 	var tasks []Task
@@ -157,6 +206,123 @@ func UpdateTaskStatus(taskIds []int64, status string) error {
 	return nil
 }
 
+//CompareAndSwapTaskStatus 仅当任务当前状态在from中时才更新为to, 返回是否更新成功
+func CompareAndSwapTaskStatus(taskId int64, from []string, to string) (bool, error) {
+	now := time.Now()
+	ret := clients.WriteDBCli.Model(&Task{}).Where("id = ? AND status IN (?)", taskId, from).
+		Updates(map[string]interface{}{"status": to, "update_at": &now})
+	if ret.Error != nil {
+		logErr("CompareAndSwapTaskStatus from write db", ret.Error)
+		return false, ret.Error
+	}
+	return ret.RowsAffected > 0, nil
+}
+
+//ClaimTask 将INIT状态且版本未变的任务置为RUNNING并增加版本, 成功时同步更新task
+func ClaimTask(task *Task) (bool, error) {
+	now := time.Now()
+	ret := clients.WriteDBCli.Model(&Task{}).Where("id = ? AND status = ? AND version = ?", task.Id, constants.TaskStatusInit, task.Version).
+		Updates(map[string]interface{}{
+			"status":    constants.TaskStatusRunning,
+			"version":   gorm.Expr("version + 1"),
+			"update_at": &now,
+		})
+	if ret.Error != nil {
+		logErr("ClaimTask from write db", ret.Error)
+		return false, ret.Error
+	}
+	if ret.RowsAffected == 0 {
+		return false, nil
+	}
+	task.Status = constants.TaskStatusRunning
+	task.Version++
+	task.UpdateAt = &now
+	return true, nil
+}
+
+//UpdateTaskProgress 执行器回写任务进度, 仅当任务仍处于RUNNING或PAUSED且版本未变时更新
+func UpdateTaskProgress(taskId, version int64, taskInfo string) (bool, error) {
+	now := time.Now()
+	ret := clients.WriteDBCli.Model(&Task{}).
+		Where("id = ? AND version = ? AND status IN (?)", taskId, version, []string{constants.TaskStatusRunning, constants.TaskStatusPaused}).
+		Updates(map[string]interface{}{"task_info": taskInfo, "update_at": &now})
+	if ret.Error != nil {
+		logErr("UpdateTaskProgress from write db", ret.Error)
+		return false, ret.Error
+	}
+	return ret.RowsAffected > 0, nil
+}
+
+//FinishTask 执行器回写任务结果, 仅当版本未变时更新, 避免覆盖已被恢复或回滚后重新执行的任务
+func FinishTask(task *Task) (bool, error) {
+	ret := clients.WriteDBCli.Model(&Task{}).Where("id = ? AND version = ?", task.Id, task.Version).
+		Updates(map[string]interface{}{
+			"status":      task.Status,
+			"task_info":   task.TaskInfo,
+			"err_msg":     task.ErrMsg,
+			"task_result": task.TaskResult,
+			"finish_time": task.FinishTime,
+			"update_at":   task.FinishTime,
+		})
+	if ret.Error != nil {
+		logErr("FinishTask from write db", ret.Error)
+		return false, ret.Error
+	}
+	return ret.RowsAffected > 0, nil
+}
+
+//RequeueTask 将任务重新置为INIT并增加版本, 之前的执行器无法再回写该任务
+func RequeueTask(taskId int64, from []string) (bool, error) {
+	now := time.Now()
+	ret := clients.WriteDBCli.Model(&Task{}).Where("id = ? AND status IN (?)", taskId, from).
+		Updates(map[string]interface{}{
+			"status":    constants.TaskStatusInit,
+			"version":   gorm.Expr("version + 1"),
+			"update_at": &now,
+		})
+	if ret.Error != nil {
+		logErr("RequeueTask from write db", ret.Error)
+		return false, ret.Error
+	}
+	return ret.RowsAffected > 0, nil
+}
+
+//RestartTask 将已暂停或已结束的任务以新的task_info重新置为INIT并增加版本, 等待调度器再次执行
+func RestartTask(taskId int64, from []string, taskInfo string) (bool, error) {
+	now := time.Now()
+	ret := clients.WriteDBCli.Model(&Task{}).Where("id = ? AND status IN (?)", taskId, from).
+		Updates(map[string]interface{}{
+			"status":      constants.TaskStatusInit,
+			"task_info":   taskInfo,
+			"err_msg":     "",
+			"finish_time": nil,
+			"version":     gorm.Expr("version + 1"),
+			"update_at":   &now,
+		})
+	if ret.Error != nil {
+		logErr("RestartTask from write db", ret.Error)
+		return false, ret.Error
+	}
+	return ret.RowsAffected > 0, nil
+}
+
+//FinishTaskWithStatus 仅当任务当前状态在from中时才将任务结束为status, 用于审批拒绝等未实际执行的任务
+func FinishTaskWithStatus(taskId int64, from []string, status, errMsg string) (bool, error) {
+	now := time.Now()
+	ret := clients.WriteDBCli.Model(&Task{}).Where("id = ? AND status IN (?)", taskId, from).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"err_msg":     errMsg,
+			"finish_time": &now,
+			"update_at":   &now,
+		})
+	if ret.Error != nil {
+		logErr("FinishTaskWithStatus from write db", ret.Error)
+		return false, ret.Error
+	}
+	return ret.RowsAffected > 0, nil
+}
+
 func GetTaskCount(ctx context.Context, clusterNames []string) (int64, error) {
 	var cnt int64
 	if err := clients.ReadDBCli.WithContext(ctx).Model(&Task{}).Where("task_filter IN (?) ", clusterNames).Count(&cnt).Error; err != nil {
@@ -192,3 +358,108 @@ func SearchTask(ctx context.Context, clusterNames []string, cond TaskSearchCond)
 	}
 	return ret, total, nil
 }
+
+//PowerTaskInfo 开机/停机任务参数
+type PowerTaskInfo struct {
+	ClusterName    string   `json:"cluster_name"`
+	InstanceIds    []string `json:"instance_ids"`
+	TaskExecHost   string   `json:"task_exec_host"`
+	TaskSubmitHost string   `json:"task_submit_host"`
+	UserId         int64    `json:"user_id"`
+	BeforeCount    int      `json:"before_count"`
+}
+
+func (p *PowerTaskInfo) GetCount() int {
+	return len(p.InstanceIds)
+}
+
+func (p *PowerTaskInfo) GetBeforeInstanceCount() (beforeCount int) {
+	return p.BeforeCount
+}
+
+func (p *PowerTaskInfo) GetAfterInstanceCount(success int) (afterCount int) {
+	return p.BeforeCount
+}
+
+func (p *PowerTaskInfo) GetExpectInstanceCount() (expectCount int) {
+	return p.BeforeCount
+}
+
+func (p *PowerTaskInfo) GetCreateUsername() (username string) {
+	user, _ := GetUserById(context.Background(), p.UserId)
+	if user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+//RollTaskInfo 滚动替换任务参数及进度, 进度随批次推进回写到task_info中, 用于暂停后继续执行
+type RollTaskInfo struct {
+	ClusterName     string                   `json:"cluster_name"`
+	Count           int                      `json:"count"`
+	Surge           int                      `json:"surge"`
+	MaxUnavailable  int                      `json:"max_unavailable"`
+	HealthCheck     *types.HealthCheckConfig `json:"health_check"`
+	OldImage        string                   `json:"old_image"`
+	OldInstanceType string                   `json:"old_instance_type"`
+	NewImage        string                   `json:"new_image"`
+	NewInstanceType string                   `json:"new_instance_type"`
+	PendingIds      []string                 `json:"pending_ids"` //待替换的实例
+	NewIds          []string                 `json:"new_ids"`     //本任务新建的实例
+	ReplacedCount   int                      `json:"replaced_count"`
+	BatchNum        int                      `json:"batch_num"`
+	Rollback        bool                     `json:"rollback"`
+	TaskExecHost    string                   `json:"task_exec_host"`
+	TaskSubmitHost  string                   `json:"task_submit_host"`
+	UserId          int64                    `json:"user_id"`
+	BeforeCount     int                      `json:"before_count"`
+}
+
+func (r *RollTaskInfo) GetCount() int {
+	return r.Count
+}
+
+func (r *RollTaskInfo) GetBeforeInstanceCount() (beforeCount int) {
+	return r.BeforeCount
+}
+
+func (r *RollTaskInfo) GetAfterInstanceCount(success int) (afterCount int) {
+	return r.BeforeCount
+}
+
+func (r *RollTaskInfo) GetExpectInstanceCount() (expectCount int) {
+	return r.BeforeCount
+}
+
+func (r *RollTaskInfo) GetCreateUsername() (username string) {
+	user, _ := GetUserById(context.Background(), r.UserId)
+	if user != nil {
+		return user.Username
+	}
+	return ""
+}
+
+//TargetSpec 返回当前批次新建实例应使用的镜像和规格, 回滚时为原规格
+func (r *RollTaskInfo) TargetSpec() (image, instanceType string) {
+	if r.Rollback {
+		return r.OldImage, r.OldInstanceType
+	}
+	return r.NewImage, r.NewInstanceType
+}
+
+//NextBatch 按surge和max unavailable计算下一批次: 先下线down台旧实例, 再新建up台, 就绪后再下线剩余的up-down台
+func (r *RollTaskInfo) NextBatch() (down, up int) {
+	remaining := len(r.PendingIds)
+	if remaining == 0 {
+		return 0, 0
+	}
+	down = r.MaxUnavailable
+	if down > remaining {
+		down = remaining
+	}
+	up = down + r.Surge
+	if up > remaining {
+		up = remaining
+	}
+	return down, up
+}
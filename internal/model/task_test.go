@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRollTaskInfo_NextBatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		pending        int
+		surge          int
+		maxUnavailable int
+		wantDown       int
+		wantUp         int
+	}{
+		{name: "surge only", pending: 5, surge: 2, maxUnavailable: 0, wantDown: 0, wantUp: 2},
+		{name: "unavailable only", pending: 5, surge: 0, maxUnavailable: 2, wantDown: 2, wantUp: 2},
+		{name: "surge and unavailable", pending: 5, surge: 1, maxUnavailable: 2, wantDown: 2, wantUp: 3},
+		{name: "capped by pending", pending: 2, surge: 3, maxUnavailable: 3, wantDown: 2, wantUp: 2},
+		{name: "nothing pending", pending: 0, surge: 1, maxUnavailable: 1, wantDown: 0, wantUp: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RollTaskInfo{
+				PendingIds:     make([]string, tt.pending),
+				Surge:          tt.surge,
+				MaxUnavailable: tt.maxUnavailable,
+			}
+			down, up := r.NextBatch()
+			if down != tt.wantDown || up != tt.wantUp {
+				t.Errorf("NextBatch() = (%v, %v), want (%v, %v)", down, up, tt.wantDown, tt.wantUp)
+			}
+		})
+	}
+}
+
+func TestExpandTaskError(t *testing.T) {
+	var err error = &ExpandTaskError{FailedNum: 2, ErrMsgs: []string{"attempt 1: quota exceeded", "attempt 2: no stock"}}
+	if err.Error() != "attempt 1: quota exceeded; attempt 2: no stock" {
+		t.Errorf("Error() = %v", err.Error())
+	}
+	var expandErr *ExpandTaskError
+	if !errors.As(fmt.Errorf("expand: %w", err), &expandErr) || expandErr.FailedNum != 2 {
+		t.Errorf("errors.As should find ExpandTaskError, got %v", expandErr)
+	}
+}
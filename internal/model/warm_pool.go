@@ -0,0 +1,93 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
+)
+
+//WarmPoolInstance 预热池中的实例, 与集群的活跃实例分开记录
+type WarmPoolInstance struct {
+	Base
+	ClusterName  string
+	InstanceId   string
+	Status       string //PENDING, STOPPING, STOPPED, USED, RELEASED
+	IpInner      string
+	IpOuter      string
+	ZoneId       string
+	ChargeType   string
+	InstanceType string //创建时集群的实例规格, 集群规格变化后该实例会被释放
+	Image        string
+	TaskId       int64 //从预热池中取出该实例的扩容任务ID
+}
+
+func (WarmPoolInstance) TableName() string {
+	return "warm_pool_instance"
+}
+
+//GetWarmPoolInstances 获取集群预热池中处于指定状态的实例
+func GetWarmPoolInstances(ctx context.Context, clusterName string, statuses []string) ([]WarmPoolInstance, error) {
+	ret := make([]WarmPoolInstance, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Where("cluster_name = ? AND status IN (?)", clusterName, statuses).Order("id").Find(&ret).Error
+	if err != nil {
+		logErr("GetWarmPoolInstances from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//GetLiveWarmPoolInstancesByInstanceIds 获取预热池中未释放的指定实例
+func GetLiveWarmPoolInstancesByInstanceIds(ctx context.Context, instanceIds []string) ([]WarmPoolInstance, error) {
+	ret := make([]WarmPoolInstance, 0)
+	if len(instanceIds) == 0 {
+		return ret, nil
+	}
+	err := clients.ReadDBCli.WithContext(ctx).Where("instance_id IN (?) AND status IN (?)", instanceIds, constants.WarmPoolLiveStatuses).Find(&ret).Error
+	if err != nil {
+		logErr("GetLiveWarmPoolInstancesByInstanceIds from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//GetWarmPoolClusterNames 获取预热池中仍有未释放实例的集群
+func GetWarmPoolClusterNames(ctx context.Context) ([]string, error) {
+	ret := make([]string, 0)
+	err := clients.ReadDBCli.WithContext(ctx).Model(&WarmPoolInstance{}).
+		Where("status IN (?)", constants.WarmPoolLiveStatuses).Distinct().Pluck("cluster_name", &ret).Error
+	if err != nil {
+		logErr("GetWarmPoolClusterNames from read db", err)
+		return nil, err
+	}
+	return ret, nil
+}
+
+//GetWarmPoolClusters 获取所有配置了预热池的集群
+func GetWarmPoolClusters(ctx context.Context) ([]Cluster, error) {
+	clusters := make([]Cluster, 0)
+	if err := clients.ReadDBCli.WithContext(ctx).Where("warm_pool != ''").Find(&clusters).Error; err != nil {
+		logErr("GetWarmPoolClusters from read db", err)
+		return nil, err
+	}
+	return clusters, nil
+}
+
+//UpdateWarmPoolInstances 更新指定实例, 传入from时仅更新状态仍为from的实例, 返回实际更新的数量
+func UpdateWarmPoolInstances(ctx context.Context, ids []int64, from string, updates map[string]interface{}) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	updates["update_at"] = time.Now()
+	query := clients.WriteDBCli.WithContext(ctx).Model(&WarmPoolInstance{}).Where("id IN (?)", ids)
+	if from != "" {
+		query = query.Where("status = ?", from)
+	}
+	ret := query.Updates(updates)
+	if ret.Error != nil {
+		logErr("UpdateWarmPoolInstances to write db", ret.Error)
+		return 0, ret.Error
+	}
+	return ret.RowsAffected, nil
+}
@@ -2,6 +2,7 @@ package pool
 
 import (
 	"context"
+	"errors"
 	"strings"
 	"time"
 
@@ -37,8 +38,15 @@ func doExpand(task *model.Task) {
 		taskFailed(task, err)
 		return
 	}
-	availableIds, allIds, expandErr := service.ExpandCluster(clusterInfo, taskInfo.Count, task.Id)
-	successNum := service.RepairCluster(clusterInfo, task.Id, availableIds, allIds)
+	//优先使用预热池中的实例, 不足部分再向云厂商创建
+	warmIds, _ := service.TakeFromWarmPool(clusterInfo, taskInfo.Count, task.Id)
+	successNum := len(warmIds)
+	var expandErr error
+	if rest := taskInfo.Count - successNum; rest > 0 {
+		var availableIds, allIds []string
+		availableIds, allIds, expandErr = service.ExpandCluster(clusterInfo, rest, task.Id)
+		successNum += service.RepairCluster(clusterInfo, task.Id, availableIds, allIds)
+	}
 	if successNum == taskInfo.Count {
 		taskSuccess(task, successNum)
 	} else if successNum == 0 {
@@ -58,14 +66,24 @@ func saveTaskResult(task *model.Task, taskResult *model.TaskResult, stat string,
 	if err != nil {
 		task.ErrMsg = err.Error()
 	}
+	var expandErr *model.ExpandTaskError
+	if errors.As(err, &expandErr) {
+		taskResult.FailedNum = expandErr.FailedNum
+		taskResult.ErrMsgs = expandErr.ErrMsgs
+	}
 	task.TaskResult, err = jsoniter.MarshalToString(taskResult)
 	if err != nil {
 		logs.Logger.Warnf("saveTaskResult taskResult to string failed, %v", err)
 	}
 	ft := time.Now()
 	task.FinishTime = &ft
-	_ = model.Save(task)
+	ok, _ := model.FinishTask(task)
+	if !ok {
+		logs.Logger.Warnf("Task %v:%v was requeued by others, drop result:%v", task.Id, task.TaskAction, stat)
+		return
+	}
 	logs.Logger.Warnf("Task %v:%v, %v, %v", stat, task.Id, task.TaskAction, task.TaskInfo)
+	service.NotifyTaskResult(task)
 }
 
 func taskPartialSuccess(task *model.Task, successNum int, err error) {
@@ -107,7 +125,7 @@ func doShrink(task *model.Task) {
 		if deletingIPs > 0 {
 			return service.ShrinkClusterBySpecificIps(clusterInfo, taskInfo.IPs, taskInfo.Count, task.Id)
 		} else {
-			return service.ShrinkCluster(clusterInfo, taskInfo.Count, task.Id)
+			return service.ShrinkCluster(clusterInfo, taskInfo.Count, taskInfo.Strategy, task.Id)
 		}
 	}
 	err = retry.Retry(shrink, strategy.Limit(3), strategy.Backoff(backoff.BinaryExponential(time.Second)))
@@ -124,3 +142,92 @@ func calcDeletingIPs(IPs string) int {
 	}
 	return len(strings.Split(IPs, ","))
 }
+
+func doRoll(task *model.Task) {
+	logs.Logger.Infof("Executing Task:%v, %v [%v], task info:%v", task.Id, task.TaskAction, task.TaskFilter, task.TaskInfo)
+	taskInfo := &model.RollTaskInfo{}
+	err := jsoniter.UnmarshalFromString(task.TaskInfo, taskInfo)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	taskInfo.TaskExecHost = utils.PrivateIPv4()
+	cluster, err := model.GetByClusterName(taskInfo.ClusterName)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	tags, _ := service.GetClusterTagsByClusterName(context.Background(), taskInfo.ClusterName)
+	clusterInfo, err := service.ConvertToClusterInfo(cluster, tags)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	for len(taskInfo.PendingIds) > 0 {
+		//每个批次开始前检查任务状态, 被暂停或被TaskKiller置为失败时停止执行, 进度保留在task_info中
+		current := &model.Task{}
+		if err = model.Get(task.Id, current); err != nil {
+			break
+		}
+		if current.Status != constants.TaskStatusRunning || current.Version != task.Version {
+			logs.Logger.Infof("roll task:%v stopped with status:%v", task.Id, current.Status)
+			s, _ := jsoniter.MarshalToString(taskInfo)
+			_, _ = model.UpdateTaskProgress(task.Id, task.Version, s)
+			return
+		}
+		err = service.RollClusterBatch(clusterInfo, taskInfo, task.Id)
+		task.TaskInfo, _ = jsoniter.MarshalToString(taskInfo)
+		//任务在本批次执行期间被恢复或回滚后已由其他执行器接管, 不再回写并停止执行
+		if ok, _ := model.UpdateTaskProgress(task.Id, task.Version, task.TaskInfo); !ok {
+			logs.Logger.Infof("roll task:%v was requeued by others, stop executing", task.Id)
+			return
+		}
+		if err != nil {
+			break
+		}
+	}
+	task.TaskInfo, _ = jsoniter.MarshalToString(taskInfo)
+	if err == nil {
+		taskSuccess(task, taskInfo.ReplacedCount)
+	} else if taskInfo.ReplacedCount == 0 {
+		taskFailed(task, err)
+	} else {
+		taskPartialSuccess(task, taskInfo.ReplacedCount, err)
+	}
+}
+
+func doPower(task *model.Task) {
+	logs.Logger.Infof("Executing Task:%v, %v [%v], task info:%v", task.Id, task.TaskAction, task.TaskFilter, task.TaskInfo)
+	taskInfo := &model.PowerTaskInfo{}
+	err := jsoniter.UnmarshalFromString(task.TaskInfo, taskInfo)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	taskInfo.TaskExecHost = utils.PrivateIPv4()
+	task.TaskInfo, _ = jsoniter.MarshalToString(taskInfo)
+	cluster, err := model.GetByClusterName(taskInfo.ClusterName)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	tags, _ := service.GetClusterTagsByClusterName(context.Background(), taskInfo.ClusterName)
+	clusterInfo, err := service.ConvertToClusterInfo(cluster, tags)
+	if err != nil {
+		taskFailed(task, err)
+		return
+	}
+	var successNum int
+	if task.TaskAction == constants.TaskActionStop {
+		successNum, err = service.StopClusterInstances(clusterInfo, taskInfo.InstanceIds)
+	} else {
+		successNum, err = service.StartClusterInstances(clusterInfo, taskInfo.InstanceIds)
+	}
+	if err == nil {
+		taskSuccess(task, successNum)
+	} else if successNum == 0 {
+		taskFailed(task, err)
+	} else {
+		taskPartialSuccess(task, successNum, err)
+	}
+}
@@ -7,12 +7,18 @@ import (
 
 var expandWorkerPool gopool.Pool
 var shrinkWorkerPool gopool.Pool
+var rollWorkerPool gopool.Pool
+var powerWorkerPool gopool.Pool
 var ExpandTasksChan = make(chan *model.Task, 100)
 var ShrinkTasksChan = make(chan *model.Task, 100)
+var RollTasksChan = make(chan *model.Task, 100)
+var PowerTasksChan = make(chan *model.Task, 100)
 
 func init() {
 	expandWorkerPool = gopool.NewPool("expand-worker-pool", 100, gopool.NewConfig())
 	shrinkWorkerPool = gopool.NewPool("shrink-worker-pool", 100, gopool.NewConfig())
+	rollWorkerPool = gopool.NewPool("roll-worker-pool", 100, gopool.NewConfig())
+	powerWorkerPool = gopool.NewPool("power-worker-pool", 100, gopool.NewConfig())
 	go daemon()
 }
 
@@ -31,6 +37,18 @@ func daemon() {
 					doShrink(st)
 				})
 			}
+		case rt, ok := <-RollTasksChan:
+			if ok {
+				rollWorkerPool.Go(func() {
+					doRoll(rt)
+				})
+			}
+		case pt, ok := <-PowerTasksChan:
+			if ok {
+				powerWorkerPool.Go(func() {
+					doPower(pt)
+				})
+			}
 		}
 	}
 }
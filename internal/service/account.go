@@ -12,6 +12,7 @@ import (
 	"github.com/galaxy-future/BridgX/pkg/cloud/tencent"
 
 	"github.com/galaxy-future/BridgX/internal/clients"
+	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/errs"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/model"
@@ -153,18 +154,13 @@ func CreateCloudAccount(ctx context.Context, accountName, provider, ak, sk strin
 	if err != nil {
 		return err
 	}
-	H.SubmitTask(&SimpleTask{
-		ProviderName: provider,
-		AccountKey:   ak,
-		TargetType:   TargetTypeAccount,
-		Retry:        3,
-	})
-	H.SubmitTask(&SimpleTask{
-		ProviderName: provider,
-		AccountKey:   ak,
-		TargetType:   TargetTypeInstanceType,
-		Retry:        5,
-	})
+	payload := JobPayload{Provider: provider, AccountKey: ak}
+	if _, err = SubmitJob(ctx, orgId, constants.JobTypeAccountRefresh, payload, 4); err != nil {
+		logs.Logger.Errorf("submit account refresh job failed, ak: %v, err: %v", ak, err)
+	}
+	if _, err = SubmitJob(ctx, orgId, constants.JobTypeInstanceTypeSync, payload, 6); err != nil {
+		logs.Logger.Errorf("submit instance type sync job failed, provider: %v, err: %v", provider, err)
+	}
 	return nil
 }
 
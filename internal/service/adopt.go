@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+//AdoptInstancesParam 纳管已有实例的筛选条件, InstanceIds、Tags、VpcId三者必须且只能指定一个
+type AdoptInstancesParam struct {
+	ClusterName string
+	InstanceIds []string
+	Tags        []cloud.Tag
+	VpcId       string
+}
+
+//AdoptPreview 纳管预览结果, Adoptable为可纳管的实例, Skipped为不能纳管的实例及原因
+type AdoptPreview struct {
+	ClusterName string           `json:"cluster_name"`
+	Adoptable   []cloud.Instance `json:"adoptable"`
+	Skipped     []AdoptSkipped   `json:"skipped"`
+}
+
+type AdoptSkipped struct {
+	InstanceId string `json:"instance_id"`
+	Reason     string `json:"reason"`
+}
+
+func (p AdoptInstancesParam) check() error {
+	selectors := 0
+	if len(p.InstanceIds) > 0 {
+		selectors++
+	}
+	if len(p.Tags) > 0 {
+		selectors++
+	}
+	if p.VpcId != "" {
+		selectors++
+	}
+	if selectors != 1 {
+		return errors.New("exactly one of instance_ids, tags or vpc_id is required")
+	}
+	return nil
+}
+
+//PreviewAdoptInstances 按条件查询云厂商实例, 返回可纳管到集群的实例, 不做任何修改
+func PreviewAdoptInstances(ctx context.Context, param AdoptInstancesParam) (*AdoptPreview, error) {
+	if err := param.check(); err != nil {
+		return nil, err
+	}
+	c, err := GetClusterInfo(ctx, param.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	adopter, ok := provider.(cloud.InstanceAdopter)
+	if !ok {
+		return nil, fmt.Errorf("provider %v does not support adopting instances", c.Provider)
+	}
+	var instances []cloud.Instance
+	switch {
+	case len(param.InstanceIds) > 0:
+		instances, err = provider.GetInstances(param.InstanceIds)
+	case len(param.Tags) > 0:
+		instances, err = provider.GetInstancesByTags(c.RegionId, param.Tags)
+	default:
+		instances, err = adopter.GetInstancesByVpc(c.RegionId, param.VpcId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.Id)
+	}
+	managed, err := getManagedInstances(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	preview := &AdoptPreview{ClusterName: c.Name, Adoptable: make([]cloud.Instance, 0), Skipped: make([]AdoptSkipped, 0)}
+	found := make(map[string]struct{}, len(instances))
+	for _, instance := range instances {
+		found[instance.Id] = struct{}{}
+		if reason := adoptSkipReason(c, instance, managed); reason != "" {
+			preview.Skipped = append(preview.Skipped, AdoptSkipped{InstanceId: instance.Id, Reason: reason})
+			continue
+		}
+		preview.Adoptable = append(preview.Adoptable, instance)
+	}
+	for _, id := range param.InstanceIds {
+		if _, ok := found[id]; !ok {
+			preview.Skipped = append(preview.Skipped, AdoptSkipped{InstanceId: id, Reason: "instance not found in cloud"})
+		}
+	}
+	return preview, nil
+}
+
+//AdoptInstances 为可纳管的实例打上集群标签并写入实例记录, 纳管后的实例可被缩容并计入用量统计
+func AdoptInstances(ctx context.Context, param AdoptInstancesParam) (*AdoptPreview, error) {
+	if hasUnfinishedTask(param.ClusterName) {
+		return nil, fmt.Errorf("Cluster:%v has unfinished task", param.ClusterName)
+	}
+	preview, err := PreviewAdoptInstances(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.Adoptable) == 0 {
+		return preview, nil
+	}
+	c, err := GetClusterInfo(ctx, param.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	if c.MaxCount > 0 {
+		current, err := model.CountActiveInstancesByClusterName(ctx, []string{c.Name})
+		if err != nil {
+			return nil, err
+		}
+		if int(current)+len(preview.Adoptable) > c.MaxCount {
+			return nil, fmt.Errorf(constants.ErrClusterExceedMaxCount, c.Name, int(current)+len(preview.Adoptable), c.MaxCount)
+		}
+	}
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(preview.Adoptable))
+	for _, instance := range preview.Adoptable {
+		ids = append(ids, instance.Id)
+	}
+	tags := []cloud.Tag{{Key: cloud.ClusterName, Value: c.Name}}
+	if err = provider.(cloud.InstanceAdopter).TagInstances(c.RegionId, ids, tags); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	instances := make([]model.Instance, 0, len(preview.Adoptable))
+	for _, ci := range preview.Adoptable {
+		status := constants.Running
+		if ci.Status == cloud.EcsStopped {
+			status = constants.Stopped
+		}
+		instances = append(instances, model.Instance{
+			Base:        model.Base{CreateAt: &now, UpdateAt: &now},
+			Status:      status,
+			IpInner:     ci.IpInner,
+			IpOuter:     ci.IpOuter,
+			InstanceId:  ci.Id,
+			ClusterName: c.Name,
+			ChargeType:  ci.CostWay,
+			RunningAt:   &now,
+			ExpireAt:    ci.ExpireAt,
+		})
+	}
+	if err = model.BatchCreateInstance(instances); err != nil {
+		return nil, err
+	}
+	logs.Logger.Infof("cluster:%v adopted instances:%v", c.Name, ids)
+	_ = publishShrinkConfig(c.Name)
+	_ = syncClusterDNS(c.Name, ids)
+	return preview, nil
+}
+
+//getManagedInstances 获取已被BridgX管理的实例, 包括集群中的活跃实例及预热池中的实例, value为所属集群
+func getManagedInstances(ctx context.Context, ids []string) (map[string]string, error) {
+	managed := make(map[string]string)
+	instances, err := model.GetInstancesByInstanceIds(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		if instance.Status != constants.Deleted {
+			managed[instance.InstanceId] = instance.ClusterName
+		}
+	}
+	pooled, err := model.GetLiveWarmPoolInstancesByInstanceIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range pooled {
+		managed[instance.InstanceId] = instance.ClusterName
+	}
+	return managed, nil
+}
+
+//adoptSkipReason 返回实例不能纳管的原因, 为空表示可以纳管
+func adoptSkipReason(c *types.ClusterInfo, instance cloud.Instance, managed map[string]string) string {
+	if clusterName, ok := managed[instance.Id]; ok {
+		return fmt.Sprintf("already managed by cluster %v", clusterName)
+	}
+	if instance.Status != cloud.EcsRunning && instance.Status != cloud.EcsStopped {
+		return fmt.Sprintf("instance status is %v", instance.Status)
+	}
+	if instance.IpInner == "" {
+		return "instance has no inner ip"
+	}
+	if c.ChargeConfig != nil && instance.CostWay != "" && instance.CostWay != c.ChargeConfig.ChargeType {
+		return fmt.Sprintf("charge type %v differs from cluster %v", instance.CostWay, c.ChargeConfig.ChargeType)
+	}
+	if c.NetworkConfig != nil && c.NetworkConfig.Vpc != "" && instance.Network != nil && instance.Network.VpcId != c.NetworkConfig.Vpc {
+		return fmt.Sprintf("vpc %v differs from cluster vpc %v", instance.Network.VpcId, c.NetworkConfig.Vpc)
+	}
+	return ""
+}
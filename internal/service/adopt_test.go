@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestAdoptInstancesParamCheck(t *testing.T) {
+	tests := []struct {
+		param   AdoptInstancesParam
+		wantErr bool
+	}{
+		{param: AdoptInstancesParam{InstanceIds: []string{"i-1"}}},
+		{param: AdoptInstancesParam{Tags: []cloud.Tag{{Key: "app", Value: "web"}}}},
+		{param: AdoptInstancesParam{VpcId: "vpc-1"}},
+		{param: AdoptInstancesParam{}, wantErr: true},
+		{param: AdoptInstancesParam{InstanceIds: []string{"i-1"}, VpcId: "vpc-1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := tt.param.check(); (err != nil) != tt.wantErr {
+			t.Errorf("check(%+v) error = %v, wantErr %v", tt.param, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAdoptSkipReason(t *testing.T) {
+	c := &types.ClusterInfo{
+		ChargeConfig:  &types.ChargeConfig{ChargeType: cloud.InstanceChargeTypePostPaid},
+		NetworkConfig: &types.NetworkConfig{Vpc: "vpc-1"},
+	}
+	managed := map[string]string{"i-managed": "other"}
+	instance := func(id, status, costWay, vpc string) cloud.Instance {
+		return cloud.Instance{Id: id, Status: status, CostWay: costWay, IpInner: "10.0.0.1", Network: &cloud.Network{VpcId: vpc}}
+	}
+	tests := []struct {
+		instance cloud.Instance
+		skip     bool
+	}{
+		{instance: instance("i-1", cloud.EcsRunning, cloud.InstanceChargeTypePostPaid, "vpc-1")},
+		{instance: instance("i-2", cloud.EcsStopped, cloud.InstanceChargeTypePostPaid, "vpc-1")},
+		{instance: instance("i-managed", cloud.EcsRunning, cloud.InstanceChargeTypePostPaid, "vpc-1"), skip: true},
+		{instance: instance("i-3", cloud.EcsBuilding, cloud.InstanceChargeTypePostPaid, "vpc-1"), skip: true},
+		{instance: instance("i-4", cloud.EcsRunning, cloud.InstanceChargeTypePrePaid, "vpc-1"), skip: true},
+		{instance: instance("i-5", cloud.EcsRunning, cloud.InstanceChargeTypePostPaid, "vpc-2"), skip: true},
+	}
+	for _, tt := range tests {
+		if reason := adoptSkipReason(c, tt.instance, managed); (reason != "") != tt.skip {
+			t.Errorf("adoptSkipReason(%v) = %q, skip %v", tt.instance.Id, reason, tt.skip)
+		}
+	}
+}
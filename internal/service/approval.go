@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+const (
+	OperationApprove Operation = "APPROVE"
+	OperationReject  Operation = "REJECT"
+)
+
+//GetApprovalPolicy 获取组织的审批策略, 未配置时返回未启用的默认策略
+func GetApprovalPolicy(ctx context.Context, orgId int64) (*model.ApprovalPolicy, error) {
+	policy, err := model.GetApprovalPolicyByOrgId(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &model.ApprovalPolicy{OrgId: orgId, ExpireHours: constants.DefaultApprovalExpireHours}
+	}
+	return policy, nil
+}
+
+func SaveApprovalPolicy(ctx context.Context, policy *model.ApprovalPolicy, uid int64) error {
+	if policy.MaxExpandCount < 0 || policy.MaxMonthlyCost < 0 || policy.ExpireHours < 0 {
+		return errors.New("approval policy thresholds can not be negative")
+	}
+	if policy.ExpireHours == 0 {
+		policy.ExpireHours = constants.DefaultApprovalExpireHours
+	}
+	old, err := model.GetApprovalPolicyByOrgId(ctx, policy.OrgId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	policy.UpdateAt = &now
+	operation := OperationCreate
+	if old != nil {
+		policy.Id = old.Id
+		policy.CreateAt = old.CreateAt
+		operation = OperationUpdate
+	} else {
+		policy.CreateAt = &now
+	}
+	if err = model.Save(policy); err != nil {
+		return err
+	}
+	oplog := OperationLog{
+		Operation: operation,
+		Operator:  uid,
+		New:       policy,
+	}
+	if old != nil {
+		oplog.Old = old
+	}
+	err = RecordOperationLog(ctx, oplog)
+	if err != nil {
+		logs.Logger.Errorf("RecordOperationLog failed.Err:[%s]", err.Error())
+	}
+	return nil
+}
+
+//checkExpandApproval 根据组织审批策略判断扩容是否需要审批, 需要时返回审批单
+func checkExpandApproval(ctx context.Context, cluster *model.Cluster, count int, uid int64) (*model.TaskApproval, error) {
+	orgId, err := getOrgIdByAccountKey(ctx, cluster.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := model.GetApprovalPolicyByOrgId(ctx, orgId)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil || !policy.Enabled {
+		return nil, nil
+	}
+	var reasons []string
+	if policy.MaxExpandCount > 0 && count > policy.MaxExpandCount {
+		reasons = append(reasons, fmt.Sprintf("扩容数量%d超过%d", count, policy.MaxExpandCount))
+	}
+	var monthlyCost float64
+	if policy.MaxMonthlyCost > 0 {
+		//仅部分云厂商支持费用预估, 无法预估时不按费用审批
+		cost, err := estimateClusterExpandCost(ctx, cluster, count)
+		switch {
+		case err != nil || !cost.Supported:
+			logs.Logger.Infof("cluster:%v expand cost can not be estimated, skip cost approval rule, err: %v", cluster.ClusterName, err)
+		case cost.MonthlyCost > policy.MaxMonthlyCost:
+			monthlyCost = cost.MonthlyCost
+			reasons = append(reasons, fmt.Sprintf("预估月费用%.2f超过%.2f", cost.MonthlyCost, policy.MaxMonthlyCost))
+		default:
+			monthlyCost = cost.MonthlyCost
+		}
+	}
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+	expireHours := policy.ExpireHours
+	if expireHours <= 0 {
+		expireHours = constants.DefaultApprovalExpireHours
+	}
+	now := time.Now()
+	expireAt := now.Add(time.Duration(expireHours) * time.Hour)
+	approval := &model.TaskApproval{
+		OrgId:         orgId,
+		ClusterName:   cluster.ClusterName,
+		TaskAction:    constants.TaskActionExpand,
+		Count:         count,
+		EstimatedCost: monthlyCost,
+		Reason:        strings.Join(reasons, "; "),
+		Status:        constants.ApprovalStatusPending,
+		Applicant:     uid,
+		ExpireAt:      &expireAt,
+	}
+	approval.CreateAt = &now
+	approval.UpdateAt = &now
+	return approval, nil
+}
+
+func estimateClusterExpandCost(ctx context.Context, cluster *model.Cluster, count int) (PlanCost, error) {
+	tags, _ := GetClusterTagsByClusterName(ctx, cluster.ClusterName)
+	clusterInfo, err := ConvertToClusterInfo(cluster, tags)
+	if err != nil {
+		return PlanCost{}, err
+	}
+	provider, err := getProvider(clusterInfo.Provider, clusterInfo.AccountKey, clusterInfo.RegionId)
+	if err != nil {
+		return PlanCost{}, err
+	}
+	return estimateCost(provider, clusterInfo, count)
+}
+
+//ApproveTask 审批通过, 任务进入INIT状态等待调度执行
+func ApproveTask(ctx context.Context, orgId, uid, taskId int64, comment string) error {
+	return reviewTask(ctx, orgId, uid, taskId, comment, true)
+}
+
+//RejectTask 审批拒绝, 任务直接结束
+func RejectTask(ctx context.Context, orgId, uid, taskId int64, comment string) error {
+	return reviewTask(ctx, orgId, uid, taskId, comment, false)
+}
+
+func reviewTask(ctx context.Context, orgId, uid, taskId int64, comment string, approved bool) error {
+	approval, err := model.GetTaskApprovalByTaskId(ctx, taskId)
+	if err != nil {
+		return err
+	}
+	if approval.OrgId != orgId {
+		return errors.New("task approval not found")
+	}
+	if approval.Status != constants.ApprovalStatusPending {
+		return fmt.Errorf("task approval is already %v", approval.Status)
+	}
+	if approval.ExpireAt != nil && approval.ExpireAt.Before(time.Now()) {
+		return errors.New("task approval has expired")
+	}
+	status, operation, taskStatus := constants.ApprovalStatusRejected, OperationReject, constants.TaskStatusRejected
+	if approved {
+		status, operation, taskStatus = constants.ApprovalStatusApproved, OperationApprove, constants.TaskStatusInit
+	}
+	//审批单与任务状态同时更新, 任一方状态已变化时均不生效
+	ok, err := model.ReviewTaskWithApproval(ctx, approval, status, uid, comment, taskStatus, "审批拒绝: "+comment)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("task or approval status changed, please retry")
+	}
+	reviewed := *approval
+	now := time.Now()
+	reviewed.Status, reviewed.Approver, reviewed.Comment, reviewed.ReviewAt = status, uid, comment, &now
+	err = RecordOperationLog(ctx, OperationLog{
+		Operation: operation,
+		Operator:  uid,
+		Old:       approval,
+		New:       &reviewed,
+	})
+	if err != nil {
+		logs.Logger.Errorf("RecordOperationLog failed.Err:[%s]", err.Error())
+	}
+	return nil
+}
+
+//ExpireStaleApprovals 将超过有效期仍未审批的任务置为拒绝
+func ExpireStaleApprovals(ctx context.Context) error {
+	approvals, err := model.GetExpiredPendingApprovals(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, approval := range approvals {
+		ok, err := model.ReviewTaskApproval(ctx, approval.Id, constants.ApprovalStatusExpired, 0, "")
+		if err != nil || !ok {
+			continue
+		}
+		_, err = model.FinishTaskWithStatus(approval.TaskId, []string{constants.TaskStatusPendingApproval}, constants.TaskStatusRejected, "审批已过期")
+		if err != nil {
+			logs.Logger.Errorf("expire task:%v failed: %v", approval.TaskId, err)
+			continue
+		}
+		logs.Logger.Infof("approval of task:%v expired", approval.TaskId)
+	}
+	return nil
+}
+
+func ListTaskApprovals(ctx context.Context, cond model.ApprovalSearchCond) ([]model.TaskApproval, int64, error) {
+	return model.ListTaskApprovals(ctx, cond)
+}
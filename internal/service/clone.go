@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+//CloneClusterParam 将集群克隆到其他云厂商、地域或可用区的参数, 为空的字段沿用源集群配置
+type CloneClusterParam struct {
+	SourceCluster string
+	TargetCluster string
+	Provider      string
+	AccountKey    string //跨云厂商时必填
+	RegionId      string
+	ZoneId        string
+	InstanceType  string //为空时按源集群机型的核数及内存匹配
+	Image         string //为空时按源集群镜像的操作系统匹配
+	Vpc           string //Vpc、SubnetId、SecurityGroup均为空时自动选择或按源集群网络新建
+	SubnetId      string
+	SecurityGroup string
+	KeyId         string //源集群使用密钥登录且跨地域时必填
+	Password      string
+}
+
+//ClonePlan 克隆计划, 展示目标集群的机型、镜像及网络映射结果
+type ClonePlan struct {
+	SourceCluster string       `json:"source_cluster"`
+	ClusterName   string       `json:"cluster_name"`
+	Provider      string       `json:"provider"`
+	RegionId      string       `json:"region_id"`
+	ZoneId        string       `json:"zone_id"`
+	InstanceType  string       `json:"instance_type"`
+	Image         string       `json:"image"`
+	Network       CloneNetwork `json:"network"`
+	DryRun        PlanDryRun   `json:"dry_run"`
+	Warnings      []string     `json:"warnings"`
+
+	cluster *types.ClusterInfo
+}
+
+//CloneNetwork 目标集群使用的网络, Create为true时克隆时按CidrBlock、SwitchCidrBlock及Rules新建
+type CloneNetwork struct {
+	Create          bool        `json:"create"`
+	Vpc             string      `json:"vpc"`
+	SubnetId        string      `json:"subnet_id"`
+	SecurityGroup   string      `json:"security_group"`
+	CidrBlock       string      `json:"cidr_block"`
+	SwitchCidrBlock string      `json:"switch_cidr_block"`
+	Rules           []GroupRule `json:"rules"`
+
+	securityGroupType string
+}
+
+//PlanCloneCluster 生成克隆计划, 不创建任何资源. 需要新建网络时无法预检, 跳过DryRun
+func PlanCloneCluster(ctx context.Context, param CloneClusterParam) (*ClonePlan, error) {
+	src, err := GetClusterInfo(ctx, param.SourceCluster)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := model.GetByClusterNames([]string{param.TargetCluster})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Errorf("cluster:%v already exists", param.TargetCluster)
+	}
+	target := cloneClusterInfo(src, param)
+	if target.Provider != src.Provider && param.AccountKey == "" {
+		return nil, errors.New("account_key is required when cloning to another provider")
+	}
+	crossRegion := target.Provider != src.Provider || target.RegionId != src.RegionId || target.AccountKey != src.AccountKey
+	if src.AuthType == constants.AuthTypeKeyPair && crossRegion {
+		if param.KeyId == "" {
+			return nil, errors.New("key_id is required when cloning a key pair cluster to another region")
+		}
+		target.KeyId = param.KeyId
+	}
+	plan := &ClonePlan{
+		SourceCluster: src.Name,
+		ClusterName:   target.Name,
+		Provider:      target.Provider,
+		RegionId:      target.RegionId,
+		ZoneId:        target.ZoneId,
+		Warnings:      make([]string, 0),
+		cluster:       target,
+	}
+	if err = mapCloneInstanceType(ctx, src, target, param.InstanceType, plan); err != nil {
+		return nil, err
+	}
+	if err = mapCloneImage(src, target, param.Image, plan); err != nil {
+		return nil, err
+	}
+	if err = mapCloneNetwork(ctx, src, target, param, crossRegion, plan); err != nil {
+		return nil, err
+	}
+	if target.Provider != src.Provider {
+		plan.Warnings = append(plan.Warnings, "storage config is copied from source cluster, disk categories may differ between providers")
+	}
+	plan.InstanceType, plan.Image = target.InstanceType, target.Image
+	if plan.Network.Create {
+		plan.DryRun.ErrMsg = "dry run skipped since network will be created on clone"
+		return plan, nil
+	}
+	if err = CheckClusterParam(target); err != nil {
+		plan.DryRun.ErrMsg = err.Error()
+	} else {
+		plan.DryRun.Pass = true
+	}
+	return plan, nil
+}
+
+//CloneCluster 按克隆计划新建网络(如需要), 预检通过后创建目标集群, 不创建实例
+func CloneCluster(ctx context.Context, param CloneClusterParam, username string, uid int64) (*ClonePlan, error) {
+	plan, err := PlanCloneCluster(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	target := plan.cluster
+	if plan.Network.Create {
+		res, err := CreateNetwork(ctx, &CreateNetworkRequest{
+			Provider:          target.Provider,
+			RegionId:          target.RegionId,
+			CidrBlock:         plan.Network.CidrBlock,
+			VpcName:           target.Name,
+			ZoneId:            target.ZoneId,
+			SwitchCidrBlock:   plan.Network.SwitchCidrBlock,
+			SwitchName:        target.Name,
+			SecurityGroupName: target.Name,
+			SecurityGroupType: plan.Network.securityGroupType,
+			AK:                target.AccountKey,
+			Rules:             plan.Network.Rules,
+		})
+		if err != nil {
+			return nil, err
+		}
+		logs.Logger.Infof("created network for clone of cluster:%v, vpc:%v switch:%v security group:%v", plan.SourceCluster, res.VpcId, res.SwitchId, res.SecurityGroupId)
+		plan.Network.Vpc, plan.Network.SubnetId, plan.Network.SecurityGroup = res.VpcId, res.SwitchId, res.SecurityGroupId
+		target.NetworkConfig.Vpc, target.NetworkConfig.SubnetId, target.NetworkConfig.SecurityGroup = res.VpcId, res.SwitchId, res.SecurityGroupId
+		if err = CheckClusterParam(target); err != nil {
+			plan.DryRun.ErrMsg = err.Error()
+			return plan, fmt.Errorf("dry run failed, created network is kept: %v", err)
+		}
+		plan.DryRun = PlanDryRun{Pass: true}
+	}
+	if !plan.DryRun.Pass {
+		return plan, fmt.Errorf("dry run failed: %v", plan.DryRun.ErrMsg)
+	}
+	m, err := ConvertToClusterModel(target)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]*model.ClusterTag, 0, len(target.Tags))
+	for k, v := range target.Tags {
+		tags = append(tags, &model.ClusterTag{ClusterName: target.Name, TagKey: k, TagValue: v})
+	}
+	if err = CreateClusterWithTagsAndInstances(ctx, m, tags, nil, username, uid); err != nil {
+		return nil, err
+	}
+	logs.Logger.Infof("cluster:%v cloned to cluster:%v in %v %v", plan.SourceCluster, target.Name, target.Provider, target.ZoneId)
+	return plan, nil
+}
+
+//cloneClusterInfo 复制源集群配置并替换目标云厂商、地域及可用区, 新集群的usage标签重置为未使用
+func cloneClusterInfo(src *types.ClusterInfo, param CloneClusterParam) *types.ClusterInfo {
+	target := *src
+	target.Id = 0
+	target.Name = param.TargetCluster
+	if param.Provider != "" {
+		target.Provider = param.Provider
+	}
+	if param.AccountKey != "" {
+		target.AccountKey = param.AccountKey
+	}
+	if param.RegionId != "" {
+		target.RegionId = param.RegionId
+	}
+	if param.ZoneId != "" {
+		target.ZoneId = param.ZoneId
+	}
+	if param.Password != "" {
+		target.Password = param.Password
+	}
+	network := types.NetworkConfig{}
+	if src.NetworkConfig != nil {
+		network = *src.NetworkConfig
+	}
+	target.NetworkConfig = &network
+	if src.ImageConfig != nil {
+		image := *src.ImageConfig
+		target.ImageConfig = &image
+	}
+	target.Tags = make(map[string]string, len(src.Tags))
+	for k, v := range src.Tags {
+		target.Tags[k] = v
+	}
+	target.Tags[constants.DefaultClusterUsageKey] = constants.DefaultClusterUsageUnused
+	return &target
+}
+
+//mapCloneInstanceType 在目标可用区查找核数及内存与源机型相同的机型, 优先同名, 其次同规格族
+func mapCloneInstanceType(ctx context.Context, src, target *types.ClusterInfo, instanceType string, plan *ClonePlan) error {
+	if instanceType != "" {
+		target.InstanceType = instanceType
+		return nil
+	}
+	srcType := GetInstanceTypeByName(src.InstanceType)
+	if srcType.Core == 0 && src.ExtendConfig != nil {
+		srcType.Core, srcType.Memory = src.ExtendConfig.Core, src.ExtendConfig.Memory
+	}
+	if srcType.Core == 0 || srcType.Memory == 0 {
+		return fmt.Errorf("core and memory of instance type %v are unknown, please specify instance_type", src.InstanceType)
+	}
+	candidates, err := model.GetInstanceTypesByCond(ctx, model.InstanceTypeCondition{
+		Provider: target.Provider,
+		RegionId: target.RegionId,
+		ZoneId:   target.ZoneId,
+		Core:     srcType.Core,
+		Memory:   srcType.Memory,
+	})
+	if err != nil {
+		return err
+	}
+	selected := selectCloneInstanceType(candidates, src.InstanceType, srcType.InstanceTypeFamily)
+	if selected == "" {
+		return fmt.Errorf("no instance type with %v core %vG memory in zone %v, please specify instance_type", srcType.Core, srcType.Memory, target.ZoneId)
+	}
+	if selected != src.InstanceType {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("instance type %v is mapped to %v", src.InstanceType, selected))
+	}
+	target.InstanceType = selected
+	if target.ExtendConfig != nil {
+		extend := *target.ExtendConfig
+		extend.Core, extend.Memory = srcType.Core, srcType.Memory
+		target.ExtendConfig = &extend
+	}
+	return nil
+}
+
+func selectCloneInstanceType(candidates []model.InstanceType, name, family string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	for _, candidate := range candidates {
+		if candidate.TypeName == name {
+			return candidate.TypeName
+		}
+	}
+	for _, candidate := range candidates {
+		if family != "" && candidate.Family == family {
+			return candidate.TypeName
+		}
+	}
+	return candidates[0].TypeName
+}
+
+//mapCloneImage 在目标地域的公共镜像中查找与源镜像操作系统相同的镜像, 同云厂商同地域时沿用源镜像
+func mapCloneImage(src, target *types.ClusterInfo, image string, plan *ClonePlan) error {
+	if image != "" {
+		target.Image = image
+		if target.ImageConfig != nil {
+			target.ImageConfig = &types.ImageConfig{Id: image}
+		}
+		return nil
+	}
+	if target.Provider == src.Provider && target.RegionId == src.RegionId {
+		return nil
+	}
+	srcImage := cloud.Image{ImageId: src.Image}
+	if src.ImageConfig != nil {
+		srcImage.ImageName, srcImage.Platform = src.ImageConfig.Name, src.ImageConfig.Platform
+	}
+	if srcProvider, err := getProvider(src.Provider, src.AccountKey, src.RegionId); err == nil {
+		res, err := srcProvider.DescribeImages(cloud.DescribeImagesRequest{RegionId: src.RegionId, InsType: src.InstanceType, ImageType: cloud.ImageGlobal})
+		if err == nil {
+			for _, img := range res.Images {
+				if img.ImageId == src.Image {
+					srcImage = img
+					break
+				}
+			}
+		}
+	}
+	provider, err := getProvider(target.Provider, target.AccountKey, target.RegionId)
+	if err != nil {
+		return err
+	}
+	res, err := provider.DescribeImages(cloud.DescribeImagesRequest{RegionId: target.RegionId, InsType: target.InstanceType, ImageType: cloud.ImageGlobal})
+	if err != nil {
+		return err
+	}
+	selected, exact := selectCloneImage(res.Images, srcImage)
+	if selected == nil {
+		return fmt.Errorf("no image matching %v in region %v, please specify image", srcImage.ImageId, target.RegionId)
+	}
+	if !exact {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("no image with os %v, image %v of platform %v is used", srcImage.OsName, selected.ImageId, selected.Platform))
+	}
+	target.Image = selected.ImageId
+	target.ImageConfig = &types.ImageConfig{
+		Id:       selected.ImageId,
+		Name:     selected.ImageName,
+		Type:     cloud.ImageGlobal,
+		Platform: selected.Platform,
+		Size:     selected.Size,
+	}
+	return nil
+}
+
+//selectCloneImage 优先选择操作系统名称相同的镜像, 其次选择同平台的镜像, exact表示操作系统是否完全一致
+func selectCloneImage(images []cloud.Image, src cloud.Image) (selected *cloud.Image, exact bool) {
+	for i := range images {
+		if src.OsName != "" && strings.EqualFold(images[i].OsName, src.OsName) {
+			return &images[i], true
+		}
+	}
+	for i := range images {
+		if src.Platform != "" && strings.EqualFold(images[i].Platform, src.Platform) {
+			return &images[i], false
+		}
+	}
+	return nil, false
+}
+
+//mapCloneNetwork 确定目标集群的网络: 优先使用指定的网络, 同地域时沿用源集群的VPC及安全组, 否则按源集群网络新建
+func mapCloneNetwork(ctx context.Context, src, target *types.ClusterInfo, param CloneClusterParam, crossRegion bool, plan *ClonePlan) error {
+	network := target.NetworkConfig
+	if param.Vpc != "" || param.SubnetId != "" || param.SecurityGroup != "" {
+		if param.Vpc == "" || param.SubnetId == "" || param.SecurityGroup == "" {
+			return errors.New("vpc, subnet_id and security_group must be specified together")
+		}
+		network.Vpc, network.SubnetId, network.SecurityGroup = param.Vpc, param.SubnetId, param.SecurityGroup
+		plan.Network = CloneNetwork{Vpc: network.Vpc, SubnetId: network.SubnetId, SecurityGroup: network.SecurityGroup}
+		return nil
+	}
+	if src.NetworkConfig == nil || src.NetworkConfig.Vpc == "" {
+		return errors.New("source cluster has no network config, please specify vpc, subnet_id and security_group")
+	}
+	if !crossRegion {
+		if target.ZoneId != src.ZoneId {
+			switches, _, err := model.FindSwitchesWithPage(ctx, model.FindSwitchesConditions{VpcId: network.Vpc, ZoneId: target.ZoneId})
+			if err != nil {
+				return err
+			}
+			if len(switches) == 0 {
+				return fmt.Errorf("no subnet of vpc %v in zone %v, please specify subnet_id", network.Vpc, target.ZoneId)
+			}
+			network.SubnetId = switches[0].SwitchId
+		}
+		plan.Network = CloneNetwork{Vpc: network.Vpc, SubnetId: network.SubnetId, SecurityGroup: network.SecurityGroup}
+		return nil
+	}
+	vpc, err := model.FindVpcById(ctx, model.FindVpcConditions{VpcId: src.NetworkConfig.Vpc})
+	if err != nil {
+		return fmt.Errorf("source vpc %v not found: %v", src.NetworkConfig.Vpc, err)
+	}
+	subnet, err := model.FindSwitchById(ctx, src.NetworkConfig.Vpc, src.NetworkConfig.SubnetId)
+	if err != nil {
+		return fmt.Errorf("source subnet %v not found: %v", src.NetworkConfig.SubnetId, err)
+	}
+	group, err := model.FindSecurityGroupById(ctx, src.NetworkConfig.SecurityGroup)
+	if err != nil {
+		return fmt.Errorf("source security group %v not found: %v", src.NetworkConfig.SecurityGroup, err)
+	}
+	rules, err := model.FindSecurityGroupRulesById(ctx, src.NetworkConfig.SecurityGroup)
+	if err != nil {
+		return err
+	}
+	groupRules, skipped := convertCloneGroupRules(rules)
+	for _, rule := range skipped {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("%v rule %v %v referencing security group %v is not copied", rule.Direction, rule.Protocol, rule.PortRange, rule.GroupId))
+	}
+	if len(groupRules) == 0 {
+		return errors.New("source security group has no rule to copy, please specify vpc, subnet_id and security_group")
+	}
+	network.Vpc, network.SubnetId, network.SecurityGroup = "", "", ""
+	plan.Network = CloneNetwork{
+		Create:            true,
+		CidrBlock:         vpc.CidrBlock,
+		SwitchCidrBlock:   subnet.CidrBlock,
+		Rules:             groupRules,
+		securityGroupType: group.SecurityGroupType,
+	}
+	return nil
+}
+
+//convertCloneGroupRules 将源安全组规则转换为新建规则, 引用其他安全组的规则无法在目标地域复现, 单独返回
+func convertCloneGroupRules(rules []model.SecurityGroupRule) (groupRules []GroupRule, skipped []model.SecurityGroupRule) {
+	groupRules = make([]GroupRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.GroupId != "" {
+			skipped = append(skipped, rule)
+			continue
+		}
+		from, to := parsePortRange(rule.PortRange)
+		groupRules = append(groupRules, GroupRule{
+			Protocol:     rule.Protocol,
+			PortFrom:     from,
+			PortTo:       to,
+			Direction:    rule.Direction,
+			CidrIp:       rule.CidrIp,
+			PrefixListId: rule.PrefixListId,
+		})
+	}
+	return groupRules, skipped
+}
+
+//parsePortRange 解析getPortRange生成的端口范围, 空字符串表示全部端口
+func parsePortRange(portRange string) (from, to int) {
+	if portRange == "" {
+		return -1, -1
+	}
+	parts := strings.SplitN(portRange, "-", 2)
+	from, _ = strconv.Atoi(parts[0])
+	to = from
+	if len(parts) == 2 {
+		to, _ = strconv.Atoi(parts[1])
+	}
+	return from, to
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		portRange string
+		from, to  int
+	}{
+		{portRange: "", from: -1, to: -1},
+		{portRange: "22", from: 22, to: 22},
+		{portRange: "8000-9000", from: 8000, to: 9000},
+	}
+	for _, tt := range tests {
+		from, to := parsePortRange(tt.portRange)
+		if from != tt.from || to != tt.to {
+			t.Errorf("parsePortRange(%q) = %v, %v, want %v, %v", tt.portRange, from, to, tt.from, tt.to)
+		}
+		if tt.portRange != "" && getPortRange(from, to) != tt.portRange {
+			t.Errorf("getPortRange(%v, %v) = %v, want %v", from, to, getPortRange(from, to), tt.portRange)
+		}
+	}
+}
+
+func TestSelectCloneInstanceType(t *testing.T) {
+	candidates := []model.InstanceType{
+		{TypeName: "ecs.c6.large", Family: "ecs.c6"},
+		{TypeName: "ecs.g6.large", Family: "ecs.g6"},
+		{TypeName: "ecs.g7.large", Family: "ecs.g7"},
+	}
+	tests := []struct {
+		name, family, want string
+	}{
+		{name: "ecs.g7.large", family: "ecs.g7", want: "ecs.g7.large"},
+		{name: "ecs.g6e.large", family: "ecs.g6", want: "ecs.g6.large"},
+		{name: "ecs.r6.large", family: "ecs.r6", want: "ecs.c6.large"},
+	}
+	for _, tt := range tests {
+		if got := selectCloneInstanceType(candidates, tt.name, tt.family); got != tt.want {
+			t.Errorf("selectCloneInstanceType(%v, %v) = %v, want %v", tt.name, tt.family, got, tt.want)
+		}
+	}
+	if got := selectCloneInstanceType(nil, "ecs.g7.large", "ecs.g7"); got != "" {
+		t.Errorf("selectCloneInstanceType with no candidate = %v, want empty", got)
+	}
+}
+
+func TestSelectCloneImage(t *testing.T) {
+	images := []cloud.Image{
+		{ImageId: "centos_8", Platform: "CentOS", OsName: "CentOS 8.5 64位"},
+		{ImageId: "centos_7", Platform: "CentOS", OsName: "CentOS 7.9 64位"},
+	}
+	selected, exact := selectCloneImage(images, cloud.Image{Platform: "CentOS", OsName: "CentOS 7.9 64位"})
+	if selected == nil || selected.ImageId != "centos_7" || !exact {
+		t.Errorf("selectCloneImage by os name = %v, %v", selected, exact)
+	}
+	selected, exact = selectCloneImage(images, cloud.Image{Platform: "centos", OsName: "CentOS 6.10 64位"})
+	if selected == nil || selected.ImageId != "centos_8" || exact {
+		t.Errorf("selectCloneImage by platform = %v, %v", selected, exact)
+	}
+	if selected, _ = selectCloneImage(images, cloud.Image{Platform: "Ubuntu"}); selected != nil {
+		t.Errorf("selectCloneImage with other platform = %v, want nil", selected)
+	}
+}
+
+func TestConvertCloneGroupRules(t *testing.T) {
+	rules := []model.SecurityGroupRule{
+		{Protocol: "tcp", PortRange: "22", Direction: DirectionIn, CidrIp: "10.0.0.0/8"},
+		{Protocol: "tcp", PortRange: "80-90", Direction: DirectionIn, GroupId: "sg-other"},
+		{Protocol: "all", Direction: DirectionOut, CidrIp: "0.0.0.0/0"},
+	}
+	groupRules, skipped := convertCloneGroupRules(rules)
+	if len(groupRules) != 2 || len(skipped) != 1 || skipped[0].GroupId != "sg-other" {
+		t.Fatalf("convertCloneGroupRules = %+v, skipped %+v", groupRules, skipped)
+	}
+	if groupRules[0].PortFrom != 22 || groupRules[0].PortTo != 22 || groupRules[1].PortFrom != -1 {
+		t.Errorf("convertCloneGroupRules ports = %+v", groupRules)
+	}
+}
+
+func TestCloneClusterInfo(t *testing.T) {
+	src := &types.ClusterInfo{
+		Id:            1,
+		Name:          "src",
+		Provider:      cloud.AlibabaCloud,
+		RegionId:      "cn-beijing",
+		ZoneId:        "cn-beijing-h",
+		NetworkConfig: &types.NetworkConfig{Vpc: "vpc-1", SubnetId: "vsw-1", SecurityGroup: "sg-1"},
+		Tags:          map[string]string{"app": "web", constants.DefaultClusterUsageKey: "online"},
+	}
+	target := cloneClusterInfo(src, CloneClusterParam{TargetCluster: "dst", RegionId: "cn-shanghai", ZoneId: "cn-shanghai-l"})
+	if target.Id != 0 || target.Name != "dst" || target.RegionId != "cn-shanghai" || target.Provider != src.Provider {
+		t.Errorf("cloneClusterInfo = %+v", target)
+	}
+	target.NetworkConfig.Vpc = "vpc-2"
+	target.Tags["app"] = "api"
+	if src.NetworkConfig.Vpc != "vpc-1" || src.Tags["app"] != "web" {
+		t.Errorf("cloneClusterInfo modified source cluster: %+v", src)
+	}
+	if target.Tags[constants.DefaultClusterUsageKey] != constants.DefaultClusterUsageUnused {
+		t.Errorf("usage tag = %v, want %v", target.Tags[constants.DefaultClusterUsageKey], constants.DefaultClusterUsageUnused)
+	}
+}
@@ -37,22 +37,29 @@ func ExpandInDeed(c *types.ClusterInfo, num int, taskId int64) ([]string, error)
 			Key:   cloud.ClusterName,
 			Value: c.Name,
 		}}
-	expandInstanceIds := make([]string, 0, num)
+	res := &model.ExpandTaskRes{}
 	needExpandNum := num
-	var err error
-	var ids []string
-	for k := 0; k < constants.Retry; k++ {
-		ids, err = Expand(c, tags, needExpandNum)
-		if err != nil {
-			logs.Logger.Errorf("[ExpandCLuster] Expand retry error, times: %d, error: %s", k, err.Error())
+	var last *model.ExpandTaskRes
+	for k := 0; k < constants.Retry && needExpandNum > 0; k++ {
+		last = expandInChunks(c, tags, needExpandNum)
+		if err := last.Err(); err != nil {
+			logs.Logger.Errorf("[ExpandCLuster] Expand retry error, times: %d, created: %d, error: %s", k, len(last.InstanceIdList), err.Error())
 		}
-		expandInstanceIds = append(expandInstanceIds, ids...)
-		if len(expandInstanceIds) == num {
-			break
+		for _, msg := range last.ErrMsgs {
+			res.ErrMsgs = append(res.ErrMsgs, fmt.Sprintf("attempt %d: %s", k+1, msg))
 		}
-		needExpandNum -= len(ids)
+		res.InstanceIdList = append(res.InstanceIdList, last.InstanceIdList...)
+		needExpandNum -= len(last.InstanceIdList)
+	}
+	if needExpandNum <= 0 {
+		return res.InstanceIdList, nil
+	}
+	res.FailedNum = needExpandNum
+	if len(res.ErrMsgs) == 0 {
+		res.ErrMsgs = []string{fmt.Sprintf("expect %d instances, only %d created", num, len(res.InstanceIdList))}
 	}
-	return expandInstanceIds, err
+	logs.Logger.Warnf("[ExpandCLuster] task: %d, cluster: %s, created: %d, failed: %d", taskId, c.Name, len(res.InstanceIdList), res.FailedNum)
+	return res.InstanceIdList, &model.ExpandTaskError{FailedNum: res.FailedNum, ErrMsgs: res.ErrMsgs}
 }
 
 func RepairCluster(c *types.ClusterInfo, taskId int64, availableIds []string, allIds []string) int {
@@ -132,6 +139,25 @@ func cloudDiff(cloudIds, memoryIds []string) (onlyCouldIds, onlyMemoryIds []stri
 }
 
 func CheckClusterParam(clusterInfo *types.ClusterInfo) error {
+	if clusterInfo.MaxCount > 0 && clusterInfo.MinCount > clusterInfo.MaxCount {
+		return fmt.Errorf(constants.ErrClusterSizeLimitInvalid, clusterInfo.MinCount, clusterInfo.MaxCount)
+	}
+	if err := CheckShrinkStrategy(clusterInfo.ShrinkStrategy); err != nil {
+		return err
+	}
+	if err := CheckWarmPoolConfig(clusterInfo); err != nil {
+		return err
+	}
+	if clusterInfo.HealthCheck != nil {
+		if err := CheckHealthCheckConfig(&clusterInfo.HealthCheck.HealthCheckConfig); err != nil {
+			return err
+		}
+	}
+	if clusterInfo.ChargeConfig != nil {
+		if err := CheckRenewPolicy(clusterInfo.ChargeConfig); err != nil {
+			return err
+		}
+	}
 	provider, err := getProvider(clusterInfo.Provider, clusterInfo.AccountKey, clusterInfo.RegionId)
 	if err != nil {
 		return err
@@ -146,51 +172,71 @@ func CheckClusterParam(clusterInfo *types.ClusterInfo) error {
 }
 
 func Expand(clusterInfo *types.ClusterInfo, tags []cloud.Tag, num int) (instanceIds []string, err error) {
-	batch := getBatch(num, constants.BatchMax)
-	createdBatch := make(chan []string, batch)
-	createdError := make(chan error, batch)
-	cur := num
+	res := expandInChunks(clusterInfo, tags, num)
+	return res.InstanceIdList, res.Err()
+}
+
+//expandInChunks 按云厂商单次创建上限将扩容拆分为多个分片, 以有限并发调用BatchCreate, 单个分片失败不影响其他分片
+func expandInChunks(clusterInfo *types.ClusterInfo, tags []cloud.Tag, num int) *model.ExpandTaskRes {
+	res := &model.ExpandTaskRes{}
 	provider, err := getProvider(clusterInfo.Provider, clusterInfo.AccountKey, clusterInfo.RegionId)
 	if err != nil {
-		return nil, err
+		res.FailedNum = num
+		res.ErrMsgs = []string{err.Error()}
+		return res
 	}
 	params, err := generateParams(clusterInfo, tags)
-	for ; cur > 0; cur -= constants.BatchMax {
-		go func(cur int) {
-			var bErr error
+	if err != nil {
+		res.FailedNum = num
+		res.ErrMsgs = []string{err.Error()}
+		return res
+	}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, constants.ExpandParallelism)
+	)
+	for _, chunk := range splitChunks(num, cloud.GetBatchCreateMaxNum(clusterInfo.Provider)) {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(chunk int) {
+			chunkRes := model.ExpandTaskRes{}
 			defer func() {
 				if e := recover(); e != nil {
 					logs.Logger.Errorf("[cloud.Expand] recover error. error: %v", e)
 					logs.Logger.Errorf("stacktrace from panic: \n" + string(debug.Stack()))
-					createdError <- fmt.Errorf("panic %v", e)
+					chunkRes.ErrMsgs = append(chunkRes.ErrMsgs, fmt.Sprintf("panic %v", e))
 				}
+				chunkRes.FailedNum = chunk - len(chunkRes.InstanceIdList)
+				mu.Lock()
+				res.Merge(chunkRes)
+				mu.Unlock()
+				<-sem
+				wg.Done()
 			}()
-			batchInstanceIds := make([]string, 0)
-			if cur < constants.BatchMax {
-				batchInstanceIds, bErr = provider.BatchCreate(params, cur)
-			} else {
-				batchInstanceIds, bErr = provider.BatchCreate(params, constants.BatchMax)
-			}
+			ids, bErr := provider.BatchCreate(params, chunk)
+			chunkRes.InstanceIdList = ids
 			if bErr != nil {
-				logs.Logger.Errorf("[cloud.Expand] BatchCreate error. error: %s", bErr.Error())
-				createdError <- bErr
-				return
+				logs.Logger.Errorf("[cloud.Expand] BatchCreate error. chunk: %d, created: %d, error: %s", chunk, len(ids), bErr.Error())
+				chunkRes.ErrMsgs = append(chunkRes.ErrMsgs, bErr.Error())
 			}
-			createdBatch <- batchInstanceIds
-		}(cur)
-	}
-	errs := make([]error, 0)
-	for i := 0; i < batch; i++ {
-		select {
-		case instanceIdSet := <-createdBatch:
-			instanceIds = append(instanceIds, instanceIdSet...)
-		case cErr := <-createdError:
-			// handle errs?
-			errs = append(errs, cErr)
-			err = cErr
+		}(chunk)
+	}
+	wg.Wait()
+	return res
+}
+
+//splitChunks 将num拆分为每份不超过eachMax的若干份
+func splitChunks(num, eachMax int) []int {
+	chunks := make([]int, 0, getBatch(num, eachMax))
+	for ; num > 0; num -= eachMax {
+		if num < eachMax {
+			chunks = append(chunks, num)
+		} else {
+			chunks = append(chunks, eachMax)
 		}
 	}
-	return instanceIds, err
+	return chunks
 }
 
 func GetInstanceByTag(c *types.ClusterInfo, tags []cloud.Tag) (instances []cloud.Instance, err error) {
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		num     int
+		eachMax int
+		want    []int
+	}{
+		{name: "less than max", num: 30, eachMax: 100, want: []int{30}},
+		{name: "equal to max", num: 100, eachMax: 100, want: []int{100}},
+		{name: "with remainder", num: 250, eachMax: 100, want: []int{100, 100, 50}},
+		{name: "zero", num: 0, eachMax: 100, want: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitChunks(tt.num, tt.eachMax); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitChunks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandTaskResMerge(t *testing.T) {
+	res := &model.ExpandTaskRes{}
+	res.Merge(model.ExpandTaskRes{InstanceIdList: []string{"i-1", "i-2"}})
+	if res.Err() != nil {
+		t.Errorf("want nil error, got %v", res.Err())
+	}
+	res.Merge(model.ExpandTaskRes{InstanceIdList: []string{"i-3"}, FailedNum: 1, ErrMsgs: []string{"quota exceeded"}})
+	if len(res.InstanceIdList) != 3 || res.FailedNum != 1 {
+		t.Errorf("got %v instances and %v failed, want 3 and 1", len(res.InstanceIdList), res.FailedNum)
+	}
+	if res.Err() == nil || res.Err().Error() != "quota exceeded" {
+		t.Errorf("want quota exceeded error, got %v", res.Err())
+	}
+}
+
+func TestNormalizePrice(t *testing.T) {
+	tests := []struct {
+		name        string
+		price       float64
+		unit        string
+		period      int
+		wantHourly  float64
+		wantMonthly float64
+	}{
+		{name: "hourly", price: 2, unit: cloud.PriceUnitHour, period: 0, wantHourly: 2, wantMonthly: 1460},
+		{name: "monthly", price: 1460, unit: cloud.PriceUnitMonth, period: 2, wantHourly: 1, wantMonthly: 730},
+		{name: "yearly", price: 8760, unit: cloud.PriceUnitYear, period: 1, wantHourly: 1, wantMonthly: 730},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hourly, monthly := normalizePrice(tt.price, tt.unit, tt.period)
+			if hourly != tt.wantHourly || monthly != tt.wantMonthly {
+				t.Errorf("normalizePrice() = (%v, %v), want (%v, %v)", hourly, monthly, tt.wantHourly, tt.wantMonthly)
+			}
+		})
+	}
+}
+
+func TestCalcInstanceHeadroom(t *testing.T) {
+	if got := calcInstanceHeadroom(100, 36, 8); got != 8 {
+		t.Errorf("calcInstanceHeadroom() = %v, want 8", got)
+	}
+	if got := calcInstanceHeadroom(100, 120, 8); got != 0 {
+		t.Errorf("calcInstanceHeadroom() = %v, want 0", got)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime/debug"
 	"strconv"
 	"strings"
@@ -55,16 +56,40 @@ func CreateClusterWithTagsAndInstances(ctx context.Context, cluster *model.Clust
 	if err != nil {
 		logs.Logger.Errorf("RecordOperationLog failed.Err:[%s]", err.Error())
 	}
+	if _, err = recordClusterRevision(ctx, cluster, username, constants.ClusterRevisionCommentCreate); err != nil {
+		logs.Logger.Errorf("record revision of cluster:%v failed, err: %v", cluster.ClusterName, err)
+	}
 	return nil
 }
 
 func EditCluster(cluster *model.Cluster, username string) error {
+	_, err := editCluster(context.Background(), cluster, username, "")
+	return err
+}
+
+//editCluster 保存集群配置并记录为新版本, 返回新版本号. 集群尚无版本记录时先将修改前的配置记为初始版本
+func editCluster(ctx context.Context, cluster *model.Cluster, username, comment string) (int, error) {
 	clusterInDB, err := model.GetByClusterName(cluster.ClusterName)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if clusterInDB == nil {
-		return errors.New("editing cluster not exist")
+		return 0, errors.New("editing cluster not exist")
+	}
+	latest, err := model.GetLatestClusterRevision(ctx, cluster.ClusterName)
+	if err != nil {
+		return 0, err
+	}
+	//与扩缩容一致, 期望实例数需在实例数上下限之间
+	if cluster.ExpectCount != clusterInDB.ExpectCount || cluster.MinCount != clusterInDB.MinCount || cluster.MaxCount != clusterInDB.MaxCount {
+		if err = checkExpectCount(cluster); err != nil {
+			return 0, err
+		}
+	}
+	if latest == nil {
+		if _, err = recordClusterRevision(ctx, clusterInDB, clusterInDB.UpdateBy, constants.ClusterRevisionCommentInitial); err != nil {
+			return 0, err
+		}
 	}
 	now := time.Now()
 	cluster.Id = clusterInDB.Id
@@ -73,7 +98,21 @@ func EditCluster(cluster *model.Cluster, username string) error {
 	cluster.CreateBy = clusterInDB.CreateBy
 	cluster.UpdateAt = &now
 	cluster.UpdateBy = username
-	return model.Save(cluster)
+	if err = model.Save(cluster); err != nil {
+		return 0, err
+	}
+	revision, err := recordClusterRevision(ctx, cluster, username, comment)
+	if err != nil {
+		logs.Logger.Errorf("record revision of cluster:%v failed, err: %v", cluster.ClusterName, err)
+	}
+	return revision, nil
+}
+
+func checkExpectCount(cluster *model.Cluster) error {
+	if cluster.ExpectCount < cluster.MinCount || (cluster.MaxCount > 0 && cluster.ExpectCount > cluster.MaxCount) {
+		return fmt.Errorf(constants.ErrClusterExpectCountOutOfRange, cluster.ClusterName, cluster.ExpectCount, cluster.MinCount, cluster.MaxCount)
+	}
+	return nil
 }
 
 func DeleteClusters(ctx context.Context, ids []int64, orgId int64) error {
@@ -208,6 +247,70 @@ func GetStandardClusterNamesByAccounts(ctx context.Context, accountKeys []string
 
 }
 
+//ConvertToClusterModel 将clusterInfo转换为cluster, 高级配置序列化为JSON保存
+func ConvertToClusterModel(clusterInput *types.ClusterInfo) (*model.Cluster, error) {
+	ic := ""
+	if clusterInput.ImageConfig != nil {
+		ic, _ = jsoniter.MarshalToString(clusterInput.ImageConfig)
+	}
+	ec := ""
+	if clusterInput.ExtendConfig != nil {
+		ec, _ = jsoniter.MarshalToString(clusterInput.ExtendConfig)
+	}
+	if clusterInput.NetworkConfig == nil {
+		return nil, errors.New("missing network config")
+	}
+	if clusterInput.StorageConfig == nil {
+		return nil, errors.New("missing storage config")
+	}
+	if clusterInput.ChargeConfig == nil {
+		return nil, errors.New("missing charge config")
+	}
+	ss := ""
+	if clusterInput.ShrinkStrategy != nil {
+		ss, _ = jsoniter.MarshalToString(clusterInput.ShrinkStrategy)
+	}
+	hc := ""
+	if clusterInput.HealthCheck != nil {
+		hc, _ = jsoniter.MarshalToString(clusterInput.HealthCheck)
+	}
+	wp := ""
+	if clusterInput.WarmPool != nil && clusterInput.WarmPool.Size > 0 {
+		wp, _ = jsoniter.MarshalToString(clusterInput.WarmPool)
+	}
+	nc, _ := jsoniter.MarshalToString(clusterInput.NetworkConfig)
+	sc, _ := jsoniter.MarshalToString(clusterInput.StorageConfig)
+	cc, _ := jsoniter.MarshalToString(clusterInput.ChargeConfig)
+	m := model.Cluster{
+		ClusterName:   clusterInput.Name,
+		ClusterDesc:   clusterInput.Desc,
+		ClusterType:   constants.ClusterTypeStandard,
+		RegionId:      clusterInput.RegionId,
+		ZoneId:        clusterInput.ZoneId,
+		InstanceType:  clusterInput.InstanceType,
+		Image:         clusterInput.Image,
+		Password:      clusterInput.Password,
+		Provider:      clusterInput.Provider,
+		AccountKey:    clusterInput.AccountKey,
+		KeyId:         clusterInput.KeyId,
+		AuthType:      clusterInput.AuthType,
+		MinCount:      clusterInput.MinCount,
+		MaxCount:      clusterInput.MaxCount,
+		ExpectCount:   clusterInput.ExpectCount,
+		AutoRenew:     clusterInput.ChargeConfig.RenewPolicy != nil && clusterInput.ChargeConfig.RenewPolicy.AutoRenew,
+		ImageConfig:   ic,
+		NetworkConfig: nc,
+		StorageConfig: sc,
+		ChargeConfig:  cc,
+		ExtendConfig:  ec,
+
+		ShrinkStrategy: ss,
+		HealthCheck:    hc,
+		WarmPool:       wp,
+	}
+	return &m, nil
+}
+
 //ConvertToClusterInfo 将cluster，和tags转换为一个Cloud clusterInfo
 func ConvertToClusterInfo(m *model.Cluster, tags []model.ClusterTag) (*types.ClusterInfo, error) {
 	imageConfig := &types.ImageConfig{}
@@ -245,6 +348,30 @@ func ConvertToClusterInfo(m *model.Cluster, tags []model.ClusterTag) (*types.Clu
 			return nil, err
 		}
 	}
+	var shrinkStrategy *types.ShrinkStrategyConfig
+	if m.ShrinkStrategy != "" {
+		shrinkStrategy = &types.ShrinkStrategyConfig{}
+		err := jsoniter.UnmarshalFromString(m.ShrinkStrategy, shrinkStrategy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var healthCheck *types.ClusterHealthCheckConfig
+	if m.HealthCheck != "" {
+		healthCheck = &types.ClusterHealthCheckConfig{}
+		err := jsoniter.UnmarshalFromString(m.HealthCheck, healthCheck)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var warmPool *types.WarmPoolConfig
+	if m.WarmPool != "" {
+		warmPool = &types.WarmPoolConfig{}
+		err := jsoniter.UnmarshalFromString(m.WarmPool, warmPool)
+		if err != nil {
+			return nil, err
+		}
+	}
 	var mt = make(map[string]string, 0)
 	for _, clusterTag := range tags {
 		mt[clusterTag.TagKey] = clusterTag.TagValue
@@ -271,6 +398,13 @@ func ConvertToClusterInfo(m *model.Cluster, tags []model.ClusterTag) (*types.Clu
 		Tags:          mt,
 		AuthType:      m.AuthType,
 		KeyId:         cast.ToString(m.KeyId),
+		MinCount:      m.MinCount,
+		MaxCount:      m.MaxCount,
+		ExpectCount:   m.ExpectCount,
+
+		ShrinkStrategy: shrinkStrategy,
+		HealthCheck:    healthCheck,
+		WarmPool:       warmPool,
 	}
 	return clusterInfo, nil
 }
@@ -303,6 +437,7 @@ func ExpandCluster(c *types.ClusterInfo, num int, taskId int64) ([]string, []str
 
 	//发布扩容信息到配置中心
 	_ = publishExpandConfig(c.Name, availableIds, expandIPs)
+	_ = syncClusterDNS(c.Name, availableIds)
 	return availableIds, expandInstanceIds, expandErr
 }
 
@@ -317,6 +452,10 @@ func ShrinkClusterBySpecificIps(c *types.ClusterInfo, deletingIPs string, count
 		return errors.New("need delete instance count NOT MATCH expect delete count")
 	}
 	logs.Logger.Infof("cluster:%v, DELETING ip list:%v, instances list:%v", c.Name, deletingIPs, toBeDeletedIds)
+	if isPrePaidCluster(c) {
+		return markReleaseAtExpiry(c, toBeDeletedIds, taskId)
+	}
+	_ = removeInstancesDNS(c.Name, toBeDeletedIds)
 	err = Shrink(c, toBeDeletedIds)
 	if err != nil {
 		logs.Logger.Errorf("[ShrinkCluster] Shrink instance error. cluster name: %s, error: %s", c.Name, err.Error())
@@ -339,24 +478,42 @@ func ShrinkClusterBySpecificIps(c *types.ClusterInfo, deletingIPs string, count
 	return err
 }
 
-func ShrinkCluster(c *types.ClusterInfo, num int, taskId int64) (err error) {
+func ShrinkCluster(c *types.ClusterInfo, num int, strategyConfig *types.ShrinkStrategyConfig, taskId int64) (err error) {
 	logs.Logger.Infof("Shrink %v, with count:%v", c.Name, num)
-	instances, err := model.GetActiveInstancesWithCount(c.Name, num)
+	strategy, err := NewShrinkStrategy(strategyConfig)
+	if err != nil {
+		return err
+	}
+	candidates, err := model.GetShrinkCandidateInstances(c.Name)
 	if err != nil {
 		logs.Logger.Errorf("[ShrinkCluster] Get instanceIdStr error. cluster name: %s, error: %s", c.Name, err.Error())
 		return err
 	}
+	instances, err := strategy.Select(c, candidates, num)
+	if err != nil {
+		logs.Logger.Errorf("[ShrinkCluster] Select instances error. cluster name: %s, error: %s", c.Name, err.Error())
+		return err
+	}
 	toBeDeletedInstanceIds := make([]string, 0)
 	for _, instance := range instances {
 		toBeDeletedInstanceIds = append(toBeDeletedInstanceIds, instance.InstanceId)
 	}
-	err = Shrink(c, toBeDeletedInstanceIds)
+	return ShrinkClusterByInstanceIds(c, toBeDeletedInstanceIds, taskId)
+}
+
+//ShrinkClusterByInstanceIds 释放指定的实例并发布缩容后的配置, 包年包月集群的实例标记为到期释放
+func ShrinkClusterByInstanceIds(c *types.ClusterInfo, instanceIds []string, taskId int64) (err error) {
+	if isPrePaidCluster(c) {
+		return markReleaseAtExpiry(c, instanceIds, taskId)
+	}
+	_ = removeInstancesDNS(c.Name, instanceIds)
+	err = Shrink(c, instanceIds)
 	if err != nil {
 		logs.Logger.Errorf("[ShrinkCluster] Shrink instance error. cluster name: %s, error: %s", c.Name, err.Error())
 		return
 	}
 	now := time.Now()
-	err = model.BatchUpdateByInstanceIds(toBeDeletedInstanceIds, model.Instance{
+	err = model.BatchUpdateByInstanceIds(instanceIds, model.Instance{
 		Base: model.Base{
 			UpdateAt: &now,
 		},
@@ -372,12 +529,19 @@ func ShrinkCluster(c *types.ClusterInfo, num int, taskId int64) (err error) {
 	return err
 }
 
+//CreateShrinkAllTask 缩容集群下所有未开启缩容保护的实例
 func CreateShrinkAllTask(ctx context.Context, clusterName, taskName string, uid int64) (int64, error) {
-	count, err := model.CountActiveInstancesByClusterName(ctx, []string{clusterName})
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
 	if err != nil {
 		return 0, err
 	}
-	return CreateShrinkTask(ctx, clusterName, int(count), "", taskName, uid)
+	count := 0
+	for _, instance := range instances {
+		if !instance.ScaleInProtected {
+			count++
+		}
+	}
+	return CreateShrinkTask(ctx, clusterName, count, "", nil, taskName, uid)
 }
 
 //CleanClusterUnusedInstances 清除由于系统异常导致的云厂商中残留的机器
@@ -390,12 +554,29 @@ func CleanClusterUnusedInstances(clusterInfo *types.ClusterInfo) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	//预热池中的实例同样带有集群标签, 不能当作残留实例释放
+	pooled, err := model.GetWarmPoolInstances(context.Background(), clusterInfo.Name, constants.WarmPoolLiveStatuses)
+	if err != nil {
+		return 0, err
+	}
+	for _, instance := range pooled {
+		instancesInBridgx = append(instancesInBridgx, model.Instance{InstanceId: instance.InstanceId})
+	}
 	instanceIds := calcUnusedInstancesId(instanceInCloud, instancesInBridgx)
 	if len(instanceIds) > 0 {
 		err := Shrink(clusterInfo, instanceIds)
 		if err != nil {
 			return 0, err
 		}
+		Notify(context.Background(), NotifyEvent{
+			EventType:   constants.NotifyEventReconcileAnomaly,
+			Severity:    constants.NotifySeverityWarning,
+			ClusterName: clusterInfo.Name,
+			Data: map[string]interface{}{
+				"instance_count": len(instanceIds),
+				"instance_ids":   strings.Join(instanceIds, ","),
+			},
+		})
 	}
 	return len(instanceIds), nil
 }
@@ -531,6 +712,7 @@ func saveExpandInstancesToDB(c *types.ClusterInfo, expandInstanceIds []string, t
 			Status:      constants.Pending,
 			ClusterName: c.Name,
 			ChargeType:  c.ChargeConfig.ChargeType,
+			ZoneId:      c.ZoneId,
 		})
 	}
 	return model.BatchCreateInstance(instances)
@@ -554,6 +736,10 @@ func publishExpandConfig(clusterName string, expandInstanceIds []string, expandI
 		logs.Logger.Errorf("[ExpandCluster] publishExpandIPConfig error. cluster name: %s, error: %v", clusterName, err)
 		return err
 	}
+	if err = publishMembership(clusterName); err != nil {
+		logs.Logger.Errorf("[ExpandCluster] publishMembership error. cluster name: %s, error: %v", clusterName, err)
+		return err
+	}
 	return nil
 }
 
@@ -581,26 +767,22 @@ func publishShrinkConfig(clusterName string) error {
 		logs.Logger.Infof("shrink cluster:%v no need publish config", clusterName)
 		return nil
 	}
+	if err := publishMembership(clusterName); err != nil {
+		logs.Logger.Errorf("[ShrinkCluster] publishMembership error. cluster name: %s, error: %v", clusterName, err)
+	}
 	instances, err := model.GetActiveInstancesByClusterName(clusterName)
 	if err != nil || len(instances) == 0 {
 		return err
 	}
 	restInstanceIds := make([]string, 0)
-	restInstanceIPs := make([]string, 0)
-
 	restInstancesStr := constants.HasNoneInstance
-	restIps := constants.HasNoneIP
-
 	for _, instance := range instances {
 		restInstanceIds = append(restInstanceIds, instance.InstanceId)
-		restInstanceIPs = append(restInstanceIPs, instance.IpInner)
 	}
 	if len(restInstanceIds) > 0 {
 		restInstancesStr = strings.Join(restInstanceIds, ",")
 	}
-	if len(restInstanceIPs) > 0 {
-		restIps = strings.Join(restInstanceIPs, ",")
-	}
+	restIps := joinWorkingIPs(instances)
 
 	err = bcc.PublishConfig(clusterName, constants.Instances, restInstancesStr)
 	if err != nil {
@@ -613,6 +795,44 @@ func publishShrinkConfig(clusterName string) error {
 	return err
 }
 
+//publishWorkingIPs 按实例最新的健康状态重新发布集群的WorkingIPs及成员文档
+func publishWorkingIPs(clusterName string) error {
+	_ = refreshClusterDNS(clusterName)
+	if !config.GlobalConfig.NeedPublishConfig {
+		return nil
+	}
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return err
+	}
+	if err = bcc.PublishConfig(clusterName, constants.WorkingIPs, joinWorkingIPs(instances)); err != nil {
+		return err
+	}
+	return publishMembership(clusterName)
+}
+
+//joinWorkingIPs WorkingIPs中不包含健康检查失败及停机中/已停机的实例
+func joinWorkingIPs(instances []model.Instance) string {
+	ips := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if !isWorkingInstance(instance) {
+			continue
+		}
+		ips = append(ips, instance.IpInner)
+	}
+	if len(ips) == 0 {
+		return constants.HasNoneIP
+	}
+	return strings.Join(ips, ",")
+}
+
+func isWorkingInstance(instance model.Instance) bool {
+	if instance.HealthStatus == constants.InstanceUnhealthy {
+		return false
+	}
+	return instance.Status != constants.Stopping && instance.Status != constants.Stopped && instance.Status != constants.Starting
+}
+
 func IsInstanceReady(instance cloud.Instance, needPublicIp bool) bool {
 	if instance.Status != cloud.EcsRunning || instance.IpInner == "" {
 		return false
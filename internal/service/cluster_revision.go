@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cmp"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//RollbackClusterParam 回滚集群配置的参数, Roll为true时回滚后按恢复的镜像及机型滚动替换现有实例
+type RollbackClusterParam struct {
+	ClusterName    string
+	Revision       int
+	Roll           bool
+	TaskName       string
+	Surge          int
+	MaxUnavailable int
+	HealthCheck    *types.HealthCheckConfig
+}
+
+//recordClusterRevision 将集群当前配置记录为新版本, 返回新版本号
+func recordClusterRevision(ctx context.Context, cluster *model.Cluster, username, comment string) (int, error) {
+	latest, err := model.GetLatestClusterRevision(ctx, cluster.ClusterName)
+	if err != nil {
+		return 0, err
+	}
+	revision := 1
+	if latest != nil {
+		revision = latest.Revision + 1
+	}
+	content, err := jsoniter.MarshalToString(cluster)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	err = model.Create(&model.ClusterRevision{
+		Base:        model.Base{CreateAt: &now, UpdateAt: &now},
+		ClusterName: cluster.ClusterName,
+		Revision:    revision,
+		Content:     content,
+		Comment:     comment,
+		CreateBy:    username,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return revision, nil
+}
+
+//ListClusterRevisions 分页获取集群的历史版本
+func ListClusterRevisions(ctx context.Context, clusterName string, pageNum, pageSize int) ([]model.ClusterRevision, int64, error) {
+	return model.ListClusterRevisions(ctx, clusterName, pageNum, pageSize)
+}
+
+//CompareClusterRevisions 比较集群的两个版本, to为0时与集群当前配置比较
+func CompareClusterRevisions(ctx context.Context, clusterName string, from, to int) ([]map[string]string, error) {
+	old, err := getClusterRevisionContent(ctx, clusterName, from)
+	if err != nil {
+		return nil, err
+	}
+	var cur *model.Cluster
+	if to == 0 {
+		cur, err = model.GetByClusterName(clusterName)
+	} else {
+		cur, err = getClusterRevisionContent(ctx, clusterName, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return diffClusterRevision(old, cur)
+}
+
+//RollbackCluster 将集群配置恢复为指定版本, 恢复本身也会记录为新版本. 返回新版本号及滚动替换任务ID
+func RollbackCluster(ctx context.Context, param RollbackClusterParam, username string, uid int64) (int, int64, error) {
+	cluster, err := getClusterRevisionContent(ctx, param.ClusterName, param.Revision)
+	if err != nil {
+		return 0, 0, err
+	}
+	clusterInfo, err := ConvertToClusterInfo(cluster, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err = CheckClusterParam(clusterInfo); err != nil {
+		return 0, 0, err
+	}
+	//先校验并创建滚动替换任务, 此时集群仍为修改前的配置, 任务记录的原规格即为回滚前的规格
+	var task *model.Task
+	if param.Roll {
+		task, _, _, err = createRollTask(ctx, RollTaskParam{
+			ClusterName:    param.ClusterName,
+			Image:          cluster.Image,
+			InstanceType:   cluster.InstanceType,
+			Surge:          param.Surge,
+			MaxUnavailable: param.MaxUnavailable,
+			HealthCheck:    param.HealthCheck,
+		}, param.TaskName, uid)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	revision, err := editCluster(ctx, cluster, username, fmt.Sprintf(constants.ClusterRevisionCommentRollback, param.Revision))
+	if err != nil {
+		if task != nil {
+			failRollTask(task.Id, err)
+		}
+		return 0, 0, err
+	}
+	logs.Logger.Infof("cluster:%v rolled back to revision:%v as revision:%v", param.ClusterName, param.Revision, revision)
+	if task == nil {
+		return revision, 0, nil
+	}
+	return revision, task.Id, nil
+}
+
+func getClusterRevisionContent(ctx context.Context, clusterName string, revision int) (*model.Cluster, error) {
+	rev, err := model.GetClusterRevision(ctx, clusterName, revision)
+	if err != nil {
+		return nil, err
+	}
+	if rev == nil {
+		return nil, fmt.Errorf("revision %v of cluster:%v not found", revision, clusterName)
+	}
+	cluster := &model.Cluster{}
+	if err = jsoniter.UnmarshalFromString(rev.Content, cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+//diffClusterRevision 比较两个版本的配置, 忽略状态及操作人等非配置字段, 密码只提示是否变化
+func diffClusterRevision(old, cur *model.Cluster) ([]map[string]string, error) {
+	o, n := *old, *cur
+	for _, c := range []*model.Cluster{&o, &n} {
+		c.Status = ""
+		c.CreateBy = ""
+		c.UpdateBy = ""
+		c.DeleteUniqKey = 0
+	}
+	if o.Password != n.Password {
+		o.Password, n.Password = "", maskedPassword
+	} else {
+		o.Password, n.Password = "", ""
+	}
+	res, err := cmp.Diff(&o, &n)
+	if err != nil {
+		return nil, err
+	}
+	return res.Beautiful()
+}
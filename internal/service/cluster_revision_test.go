@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestDiffClusterRevision(t *testing.T) {
+	old := &model.Cluster{ClusterName: "web", InstanceType: "ecs.g6.large", Password: "a", UpdateBy: "alice", Status: "ENABLE"}
+	cur := &model.Cluster{ClusterName: "web", InstanceType: "ecs.g6.large", Password: "a", UpdateBy: "bob", Status: "DISABLE"}
+	diff, err := diffClusterRevision(old, cur)
+	if err != nil || len(diff) != 0 {
+		t.Errorf("diffClusterRevision() unchanged = %v, %v", diff, err)
+	}
+
+	cur.InstanceType = "ecs.g6.xlarge"
+	cur.Password = "b"
+	diff, err = diffClusterRevision(old, cur)
+	if err != nil || len(diff) != 2 {
+		t.Fatalf("diffClusterRevision() = %v, %v", diff, err)
+	}
+	for _, d := range diff {
+		switch d["target"] {
+		case "InstanceType":
+			if d["old"] != "ecs.g6.large" || d["new"] != "ecs.g6.xlarge" {
+				t.Errorf("unexpected instance type diff %v", d)
+			}
+		case "Password":
+			if d["new"] != maskedPassword || d["old"] != "" {
+				t.Errorf("password should be masked, got %v", d)
+			}
+		default:
+			t.Errorf("unexpected diff %v", d)
+		}
+	}
+	if old.Password != "a" || cur.UpdateBy != "bob" {
+		t.Errorf("diffClusterRevision() should not modify its arguments")
+	}
+}
@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cmp"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//ClusterSpecDocument 集群配置文档, 以YAML格式导出后可纳入git管理, 再通过plan/apply与数据库中的集群对齐
+type ClusterSpecDocument struct {
+	Version  string        `json:"version"`
+	Clusters []ClusterSpec `json:"clusters"`
+}
+
+//ClusterSpec 单个标准集群的声明式配置. 导出时不包含密码, 新建集群时必须填写, 修改集群时为空表示保持原密码
+type ClusterSpec struct {
+	Name         string `json:"name"`
+	Desc         string `json:"desc,omitempty"`
+	Provider     string `json:"provider"`
+	AccountKey   string `json:"account_key"`
+	RegionId     string `json:"region_id"`
+	ZoneId       string `json:"zone_id"`
+	InstanceType string `json:"instance_type"`
+	Image        string `json:"image"`
+	AuthType     string `json:"auth_type,omitempty"`
+	KeyId        string `json:"key_id,omitempty"`
+	Password     string `json:"password,omitempty"`
+	MinCount     int    `json:"min_count"`
+	MaxCount     int    `json:"max_count"`
+
+	ImageConfig   *types.ImageConfig   `json:"image_config,omitempty"`
+	NetworkConfig *types.NetworkConfig `json:"network_config"`
+	StorageConfig *types.StorageConfig `json:"storage_config"`
+	ChargeConfig  *types.ChargeConfig  `json:"charge_config"`
+	ExtendConfig  *types.ExtendConfig  `json:"extend_config,omitempty"`
+
+	ShrinkStrategy *types.ShrinkStrategyConfig     `json:"shrink_strategy,omitempty"`
+	HealthCheck    *types.ClusterHealthCheckConfig `json:"health_check,omitempty"`
+	WarmPool       *types.WarmPoolConfig           `json:"warm_pool,omitempty"`
+
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+//ClusterSpecChange 文档中单个集群与数据库的差异, Diff为pkg/cmp的比较结果
+type ClusterSpecChange struct {
+	ClusterName string              `json:"cluster_name"`
+	Action      string              `json:"action"`
+	Diff        []map[string]string `json:"diff"`
+}
+
+//clusterSpecView 用于比较的扁平视图, pkg/cmp不比较嵌套结构体, 因此高级配置及标签序列化为JSON字符串整体比较
+type clusterSpecView struct {
+	Desc         string `diff:"desc"`
+	Provider     string `diff:"provider"`
+	AccountKey   string `diff:"account_key"`
+	RegionId     string `diff:"region_id"`
+	ZoneId       string `diff:"zone_id"`
+	InstanceType string `diff:"instance_type"`
+	Image        string `diff:"image"`
+	AuthType     string `diff:"auth_type"`
+	KeyId        string `diff:"key_id"`
+	Password     string `diff:"password"`
+	MinCount     int    `diff:"min_count"`
+	MaxCount     int    `diff:"max_count"`
+
+	ImageConfig   string `diff:"image_config"`
+	NetworkConfig string `diff:"network_config"`
+	StorageConfig string `diff:"storage_config"`
+	ChargeConfig  string `diff:"charge_config"`
+	ExtendConfig  string `diff:"extend_config"`
+
+	ShrinkStrategy string `diff:"shrink_strategy"`
+	HealthCheck    string `diff:"health_check"`
+	WarmPool       string `diff:"warm_pool"`
+
+	Tags string `diff:"tags"`
+}
+
+//clusterSpecPlan plan的中间结果, apply时复用
+type clusterSpecPlan struct {
+	change   ClusterSpecChange
+	spec     *ClusterSpec
+	existing *types.ClusterInfo
+	tags     []model.ClusterTag
+}
+
+const maskedPassword = "******"
+
+//ExportClusterSpecs 导出账号下的标准集群为YAML文档, names为空时导出全部
+func ExportClusterSpecs(ctx context.Context, accountKeys []string, names []string) ([]byte, error) {
+	doc := ClusterSpecDocument{Version: constants.ClusterSpecVersion, Clusters: make([]ClusterSpec, 0)}
+	if len(accountKeys) == 0 {
+		return marshalClusterSpecDocument(&doc)
+	}
+	where := map[string]interface{}{"account_key": accountKeys, "cluster_type": constants.ClusterTypeStandard}
+	if len(names) > 0 {
+		where["cluster_name"] = names
+	}
+	clusters := make([]model.Cluster, 0)
+	if err := model.QueryAll(where, &clusters, "id"); err != nil {
+		return nil, err
+	}
+	clusterTags, err := getClusterTagRows(ctx, clusters)
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusters {
+		info, err := ConvertToClusterInfo(&clusters[i], clusterTags[clusters[i].ClusterName])
+		if err != nil {
+			return nil, err
+		}
+		doc.Clusters = append(doc.Clusters, toClusterSpec(info))
+	}
+	return marshalClusterSpecDocument(&doc)
+}
+
+//ParseClusterSpecDocument 解析并校验YAML文档
+func ParseClusterSpecDocument(content []byte) (*ClusterSpecDocument, error) {
+	j, err := utils.YAMLToJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid yaml: %v", err)
+	}
+	doc := ClusterSpecDocument{}
+	if err = jsoniter.Unmarshal(j, &doc); err != nil {
+		return nil, fmt.Errorf("invalid cluster spec: %v", err)
+	}
+	if doc.Version != constants.ClusterSpecVersion {
+		return nil, fmt.Errorf("unsupported version: %v, expected %v", doc.Version, constants.ClusterSpecVersion)
+	}
+	names := make(map[string]bool, len(doc.Clusters))
+	for i := range doc.Clusters {
+		spec := &doc.Clusters[i]
+		if err = spec.check(); err != nil {
+			return nil, err
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf("duplicate cluster: %v", spec.Name)
+		}
+		names[spec.Name] = true
+	}
+	return &doc, nil
+}
+
+func (s *ClusterSpec) check() error {
+	if s.Name == "" {
+		return errors.New("cluster name can not be empty")
+	}
+	if s.Provider == "" || s.AccountKey == "" || s.RegionId == "" || s.ZoneId == "" || s.InstanceType == "" {
+		return fmt.Errorf("cluster:%v provider, account_key, region_id, zone_id and instance_type are required", s.Name)
+	}
+	if s.NetworkConfig == nil || s.StorageConfig == nil || s.ChargeConfig == nil {
+		return fmt.Errorf("cluster:%v network_config, storage_config and charge_config are required", s.Name)
+	}
+	return nil
+}
+
+//PlanClusterSpecs 比较文档与数据库中的集群, 返回每个集群需要新建、修改或无需变化
+func PlanClusterSpecs(ctx context.Context, accountKeys []string, content []byte) ([]ClusterSpecChange, error) {
+	plans, err := planClusterSpecs(ctx, accountKeys, content)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]ClusterSpecChange, 0, len(plans))
+	for _, plan := range plans {
+		changes = append(changes, plan.change)
+	}
+	return changes, nil
+}
+
+//ApplyClusterSpecs 按plan结果新建或修改集群. 所有变更先通过参数校验后才开始写入, 任一集群失败即停止
+func ApplyClusterSpecs(ctx context.Context, accountKeys []string, content []byte, username string, uid int64) ([]ClusterSpecChange, error) {
+	plans, err := planClusterSpecs(ctx, accountKeys, content)
+	if err != nil {
+		return nil, err
+	}
+	infos := make(map[string]*types.ClusterInfo, len(plans))
+	for _, plan := range plans {
+		if plan.change.Action == constants.ClusterSpecActionNone {
+			continue
+		}
+		info := plan.spec.toClusterInfo(plan.existing)
+		if plan.existing == nil && info.AuthType != constants.AuthTypeKeyPair && info.Password == "" {
+			return nil, fmt.Errorf("cluster:%v password is required when creating", info.Name)
+		}
+		if err = CheckClusterParam(info); err != nil {
+			return nil, fmt.Errorf("cluster:%v %v", info.Name, err)
+		}
+		infos[info.Name] = info
+	}
+	changes := make([]ClusterSpecChange, 0, len(plans))
+	for _, plan := range plans {
+		changes = append(changes, plan.change)
+		info, ok := infos[plan.change.ClusterName]
+		if !ok {
+			continue
+		}
+		if err = applyClusterSpec(ctx, plan, info, username, uid); err != nil {
+			return changes, fmt.Errorf("apply cluster:%v failed, err: %v", info.Name, err)
+		}
+		logs.Logger.Infof("cluster spec applied, cluster:%v action:%v", info.Name, plan.change.Action)
+	}
+	return changes, nil
+}
+
+func planClusterSpecs(ctx context.Context, accountKeys []string, content []byte) ([]clusterSpecPlan, error) {
+	doc, err := ParseClusterSpecDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(doc.Clusters))
+	for _, spec := range doc.Clusters {
+		if !utils.ContainsString(accountKeys, spec.AccountKey) {
+			return nil, fmt.Errorf("cluster:%v account %v does not belong to your organization", spec.Name, spec.AccountKey)
+		}
+		names = append(names, spec.Name)
+	}
+	clusters, err := model.GetByClusterNames(names)
+	if err != nil {
+		return nil, err
+	}
+	clusterTags, err := getClusterTagRows(ctx, clusters)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*types.ClusterInfo, len(clusters))
+	for i := range clusters {
+		c := &clusters[i]
+		if c.ClusterType == constants.ClusterTypeCustom {
+			return nil, fmt.Errorf("cluster:%v is a custom cluster and can not be managed by spec", c.ClusterName)
+		}
+		if !utils.ContainsString(accountKeys, c.AccountKey) {
+			return nil, fmt.Errorf("cluster:%v already exists in another organization", c.ClusterName)
+		}
+		if existing[c.ClusterName], err = ConvertToClusterInfo(c, clusterTags[c.ClusterName]); err != nil {
+			return nil, err
+		}
+	}
+	plans := make([]clusterSpecPlan, 0, len(doc.Clusters))
+	for i := range doc.Clusters {
+		spec := &doc.Clusters[i]
+		plan := clusterSpecPlan{spec: spec, existing: existing[spec.Name], tags: clusterTags[spec.Name]}
+		if plan.change, err = diffClusterSpec(spec, plan.existing); err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+//diffClusterSpec 使用pkg/cmp比较文档与数据库中的集群, existing为nil表示新建
+func diffClusterSpec(spec *ClusterSpec, existing *types.ClusterInfo) (ClusterSpecChange, error) {
+	change := ClusterSpecChange{ClusterName: spec.Name, Action: constants.ClusterSpecActionCreate}
+	var res cmp.DiffResult
+	var err error
+	if existing == nil {
+		res, err = cmp.Diff(nil, spec.view(nil))
+	} else {
+		old := toClusterSpec(existing)
+		res, err = cmp.Diff(old.view(existing), spec.view(existing))
+	}
+	if err != nil {
+		return change, err
+	}
+	if change.Diff, err = res.Beautiful(); err != nil {
+		return change, err
+	}
+	if existing != nil {
+		change.Action = constants.ClusterSpecActionEdit
+		if len(change.Diff) == 0 {
+			change.Action = constants.ClusterSpecActionNone
+		}
+	}
+	return change, nil
+}
+
+//view 生成比较视图, 未配置的高级配置按零值处理, 与数据库中保存的结果保持一致
+func (s *ClusterSpec) view(existing *types.ClusterInfo) *clusterSpecView {
+	v := &clusterSpecView{
+		Desc:         s.Desc,
+		Provider:     s.Provider,
+		AccountKey:   s.AccountKey,
+		RegionId:     s.RegionId,
+		ZoneId:       s.ZoneId,
+		InstanceType: s.InstanceType,
+		Image:        s.Image,
+		AuthType:     s.AuthType,
+		KeyId:        s.KeyId,
+		MinCount:     s.MinCount,
+		MaxCount:     s.MaxCount,
+	}
+	//map需按key排序后序列化, 否则相同的标签可能得到不同的字符串
+	v.Tags, _ = jsoniter.ConfigCompatibleWithStandardLibrary.MarshalToString(s.desiredTags(existing))
+	if s.Password != "" && (existing == nil || existing.Password != s.Password) {
+		v.Password = maskedPassword
+	}
+	if s.ImageConfig != nil {
+		v.ImageConfig, _ = jsoniter.MarshalToString(s.ImageConfig)
+	} else {
+		v.ImageConfig, _ = jsoniter.MarshalToString(types.ImageConfig{})
+	}
+	if s.ExtendConfig != nil {
+		v.ExtendConfig, _ = jsoniter.MarshalToString(s.ExtendConfig)
+	} else {
+		v.ExtendConfig, _ = jsoniter.MarshalToString(types.ExtendConfig{})
+	}
+	v.NetworkConfig, _ = jsoniter.MarshalToString(s.NetworkConfig)
+	v.StorageConfig, _ = jsoniter.MarshalToString(s.StorageConfig)
+	v.ChargeConfig, _ = jsoniter.MarshalToString(s.ChargeConfig)
+	if s.ShrinkStrategy != nil {
+		v.ShrinkStrategy, _ = jsoniter.MarshalToString(s.ShrinkStrategy)
+	}
+	if s.HealthCheck != nil {
+		v.HealthCheck, _ = jsoniter.MarshalToString(s.HealthCheck)
+	}
+	if s.WarmPool != nil && s.WarmPool.Size > 0 {
+		v.WarmPool, _ = jsoniter.MarshalToString(s.WarmPool)
+	}
+	return v
+}
+
+//desiredTags 文档未指定usage标签时, 新建集群使用默认值, 修改集群保持原值
+func (s *ClusterSpec) desiredTags(existing *types.ClusterInfo) map[string]string {
+	tags := make(map[string]string, len(s.Tags)+1)
+	for k, v := range s.Tags {
+		tags[k] = v
+	}
+	if _, ok := tags[constants.DefaultClusterUsageKey]; !ok {
+		tags[constants.DefaultClusterUsageKey] = constants.DefaultClusterUsageUnused
+		if existing != nil {
+			if usage, ok := existing.Tags[constants.DefaultClusterUsageKey]; ok {
+				tags[constants.DefaultClusterUsageKey] = usage
+			}
+		}
+	}
+	return tags
+}
+
+func (s *ClusterSpec) toClusterInfo(existing *types.ClusterInfo) *types.ClusterInfo {
+	password := s.Password
+	if password == "" && existing != nil {
+		password = existing.Password
+	}
+	return &types.ClusterInfo{
+		Name:           s.Name,
+		Desc:           s.Desc,
+		RegionId:       s.RegionId,
+		ZoneId:         s.ZoneId,
+		ClusterType:    constants.ClusterTypeStandard,
+		InstanceType:   s.InstanceType,
+		Image:          s.Image,
+		Provider:       s.Provider,
+		Username:       constants.DefaultUsername,
+		Password:       password,
+		AccountKey:     s.AccountKey,
+		KeyId:          s.KeyId,
+		AuthType:       s.AuthType,
+		MinCount:       s.MinCount,
+		MaxCount:       s.MaxCount,
+		ImageConfig:    s.ImageConfig,
+		NetworkConfig:  s.NetworkConfig,
+		StorageConfig:  s.StorageConfig,
+		ChargeConfig:   s.ChargeConfig,
+		ExtendConfig:   s.ExtendConfig,
+		ShrinkStrategy: s.ShrinkStrategy,
+		HealthCheck:    s.HealthCheck,
+		WarmPool:       s.WarmPool,
+		Tags:           s.desiredTags(existing),
+	}
+}
+
+func toClusterSpec(info *types.ClusterInfo) ClusterSpec {
+	return ClusterSpec{
+		Name:           info.Name,
+		Desc:           info.Desc,
+		Provider:       info.Provider,
+		AccountKey:     info.AccountKey,
+		RegionId:       info.RegionId,
+		ZoneId:         info.ZoneId,
+		InstanceType:   info.InstanceType,
+		Image:          info.Image,
+		AuthType:       info.AuthType,
+		KeyId:          info.KeyId,
+		MinCount:       info.MinCount,
+		MaxCount:       info.MaxCount,
+		ImageConfig:    info.ImageConfig,
+		NetworkConfig:  info.NetworkConfig,
+		StorageConfig:  info.StorageConfig,
+		ChargeConfig:   info.ChargeConfig,
+		ExtendConfig:   info.ExtendConfig,
+		ShrinkStrategy: info.ShrinkStrategy,
+		HealthCheck:    info.HealthCheck,
+		WarmPool:       info.WarmPool,
+		Tags:           info.Tags,
+	}
+}
+
+func applyClusterSpec(ctx context.Context, plan clusterSpecPlan, info *types.ClusterInfo, username string, uid int64) error {
+	m, err := ConvertToClusterModel(info)
+	if err != nil {
+		return err
+	}
+	if plan.existing == nil {
+		tags := make([]*model.ClusterTag, 0, len(info.Tags))
+		for k, v := range info.Tags {
+			tags = append(tags, &model.ClusterTag{ClusterName: info.Name, TagKey: k, TagValue: v})
+		}
+		return CreateClusterWithTagsAndInstances(ctx, m, tags, nil, username, uid)
+	}
+	//声明式配置不管理期望实例数, 保留集群当前值
+	m.ExpectCount = plan.existing.ExpectCount
+	if err = EditCluster(m, username); err != nil {
+		return err
+	}
+	return syncClusterTags(info.Name, plan.tags, info.Tags)
+}
+
+//syncClusterTags 将集群标签调整为desired, 多余的标签会被删除
+func syncClusterTags(clusterName string, current []model.ClusterTag, desired map[string]string) error {
+	now := time.Now()
+	toCreate := make([]model.ClusterTag, 0)
+	toEdit := make([]model.ClusterTag, 0)
+	toDelete := make([]model.ClusterTag, 0)
+	exists := make(map[string]bool, len(current))
+	for _, tag := range current {
+		exists[tag.TagKey] = true
+		value, ok := desired[tag.TagKey]
+		if !ok {
+			toDelete = append(toDelete, tag)
+		} else if value != tag.TagValue {
+			tag.TagValue = value
+			toEdit = append(toEdit, tag)
+		}
+	}
+	for k, v := range desired {
+		if !exists[k] {
+			toCreate = append(toCreate, model.ClusterTag{
+				Base:        model.Base{CreateAt: &now, UpdateAt: &now},
+				ClusterName: clusterName,
+				TagKey:      k,
+				TagValue:    v,
+			})
+		}
+	}
+	if len(toCreate) > 0 {
+		if err := CreateClusterTags(toCreate); err != nil {
+			return err
+		}
+	}
+	if len(toEdit) > 0 {
+		if err := EditClusterTags(toEdit); err != nil {
+			return err
+		}
+	}
+	if len(toDelete) > 0 {
+		return DeleteClusterTags(toDelete)
+	}
+	return nil
+}
+
+func getClusterTagRows(ctx context.Context, clusters []model.Cluster) (map[string][]model.ClusterTag, error) {
+	ret := make(map[string][]model.ClusterTag, len(clusters))
+	if len(clusters) == 0 {
+		return ret, nil
+	}
+	names := make([]string, 0, len(clusters))
+	for _, c := range clusters {
+		names = append(names, c.ClusterName)
+	}
+	tags, err := model.GetClusterTagsByClusterNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		ret[tag.ClusterName] = append(ret[tag.ClusterName], tag)
+	}
+	return ret, nil
+}
+
+func marshalClusterSpecDocument(doc *ClusterSpecDocument) ([]byte, error) {
+	j, err := jsoniter.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return utils.JSONToYAML(j)
+}
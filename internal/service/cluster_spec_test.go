@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+const testClusterSpecDocument = `
+version: bridgx/v1
+clusters:
+- name: web
+  provider: AlibabaCloud
+  account_key: ak
+  region_id: cn-beijing
+  zone_id: cn-beijing-h
+  instance_type: ecs.g6.large
+  image: img-1
+  min_count: 2
+  network_config:
+    vpc: vpc-1
+    subnet_id: vsw-1
+    security_group: sg-1
+  storage_config: {}
+  charge_config:
+    charge_type: PostPaid
+  tags:
+    app: web
+`
+
+func TestParseClusterSpecDocument(t *testing.T) {
+	doc, err := ParseClusterSpecDocument([]byte(testClusterSpecDocument))
+	if err != nil {
+		t.Fatalf("ParseClusterSpecDocument() error = %v", err)
+	}
+	if len(doc.Clusters) != 1 || doc.Clusters[0].NetworkConfig.Vpc != "vpc-1" || doc.Clusters[0].Tags["app"] != "web" {
+		t.Errorf("ParseClusterSpecDocument() = %+v", doc.Clusters)
+	}
+	invalid := []string{
+		"version: v0\nclusters: []",
+		"version: bridgx/v1\nclusters:\n- name: web",
+		testClusterSpecDocument + testClusterSpecDocument[len("\nversion: bridgx/v1\nclusters:\n"):],
+	}
+	for _, content := range invalid {
+		if _, err = ParseClusterSpecDocument([]byte(content)); err == nil {
+			t.Errorf("ParseClusterSpecDocument(%q) expected error", content)
+		}
+	}
+}
+
+func TestDiffClusterSpec(t *testing.T) {
+	doc, err := ParseClusterSpecDocument([]byte(testClusterSpecDocument))
+	if err != nil {
+		t.Fatalf("ParseClusterSpecDocument() error = %v", err)
+	}
+	spec := &doc.Clusters[0]
+	change, err := diffClusterSpec(spec, nil)
+	if err != nil || change.Action != constants.ClusterSpecActionCreate || len(change.Diff) == 0 {
+		t.Errorf("diffClusterSpec() create = %+v, %v", change, err)
+	}
+
+	existing := spec.toClusterInfo(nil)
+	existing.ImageConfig = &types.ImageConfig{}
+	existing.ExtendConfig = &types.ExtendConfig{}
+	existing.Password = "secret"
+	change, err = diffClusterSpec(spec, existing)
+	if err != nil || change.Action != constants.ClusterSpecActionNone {
+		t.Errorf("diffClusterSpec() unchanged = %+v, %v", change, err)
+	}
+
+	spec.MinCount = 3
+	spec.Password = "another"
+	change, err = diffClusterSpec(spec, existing)
+	if err != nil || change.Action != constants.ClusterSpecActionEdit || len(change.Diff) != 2 {
+		t.Fatalf("diffClusterSpec() edit = %+v, %v", change, err)
+	}
+	for _, d := range change.Diff {
+		if d["target"] == "password" && d["new"] != maskedPassword {
+			t.Errorf("password should be masked, got %v", d["new"])
+		}
+	}
+}
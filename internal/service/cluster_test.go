@@ -26,3 +26,22 @@ func TestCalcUnusedInstancesId(t *testing.T) {
 		t.Errorf("failed in calc ununsed instance want [1] , got %v", unusedInstanceIds)
 	}
 }
+
+func TestCheckExpectCount(t *testing.T) {
+	cases := []struct {
+		expect, min, max int
+		wantErr          bool
+	}{
+		{expect: 3, min: 1, max: 5},
+		{expect: 0, min: 0, max: 0},
+		{expect: 10, min: 2, max: 0},
+		{expect: 1, min: 2, max: 5, wantErr: true},
+		{expect: 6, min: 2, max: 5, wantErr: true},
+	}
+	for _, c := range cases {
+		err := checkExpectCount(&model.Cluster{ClusterName: "c", ExpectCount: c.expect, MinCount: c.min, MaxCount: c.max})
+		if (err != nil) != c.wantErr {
+			t.Errorf("checkExpectCount(%v, [%v, %v]) error = %v, wantErr %v", c.expect, c.min, c.max, err, c.wantErr)
+		}
+	}
+}
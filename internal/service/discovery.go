@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+const defaultSSHUser = "root"
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+//DiscoveryParam 服务发现导出参数, ClusterNames与Tags二选一, 只导出accountKeys下集群中运行中的实例
+type DiscoveryParam struct {
+	ClusterNames []string
+	Tags         map[string]string
+	UseOuterIp   bool
+	Port         int    //仅prometheus使用, 0表示target中不带端口
+	User         string //仅ssh_config使用, 默认root
+	IdentityFile string //仅ssh_config使用
+}
+
+//DiscoveryHost 导出的单个实例, 各导出格式都基于该结构渲染
+type DiscoveryHost struct {
+	InstanceId  string
+	Ip          string
+	IpInner     string
+	IpOuter     string
+	ZoneId      string
+	ClusterName string
+	Provider    string
+	RegionId    string
+	Tags        map[string]string
+}
+
+//PrometheusTargetGroup Prometheus file_sd及http_sd的target group格式
+type PrometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+//GetDiscoveryHosts 获取待导出的实例, 按集群名及实例ID排序保证输出稳定
+func GetDiscoveryHosts(ctx context.Context, accountKeys []string, param DiscoveryParam) ([]DiscoveryHost, error) {
+	clusters, err := getDiscoveryClusters(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	clusterMap := make(map[string]model.Cluster, len(clusters))
+	visible := make([]model.Cluster, 0, len(clusters))
+	names := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		if !utils.ContainsString(accountKeys, cluster.AccountKey) {
+			continue
+		}
+		clusterMap[cluster.ClusterName] = cluster
+		visible = append(visible, cluster)
+		names = append(names, cluster.ClusterName)
+	}
+	hosts := make([]DiscoveryHost, 0)
+	if len(names) == 0 {
+		return hosts, nil
+	}
+	tags, err := GetClusterTagsByClusters(ctx, visible)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := model.GetActiveInstancesByClusters(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		if instance.Status != constants.Running {
+			continue
+		}
+		ip := instance.IpInner
+		if param.UseOuterIp && instance.IpOuter != "" {
+			ip = instance.IpOuter
+		}
+		if ip == "" {
+			continue
+		}
+		cluster := clusterMap[instance.ClusterName]
+		hosts = append(hosts, DiscoveryHost{
+			InstanceId:  instance.InstanceId,
+			Ip:          ip,
+			IpInner:     instance.IpInner,
+			IpOuter:     instance.IpOuter,
+			ZoneId:      instance.ZoneId,
+			ClusterName: instance.ClusterName,
+			Provider:    cluster.Provider,
+			RegionId:    cluster.RegionId,
+			Tags:        tags[instance.ClusterName],
+		})
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].ClusterName != hosts[j].ClusterName {
+			return hosts[i].ClusterName < hosts[j].ClusterName
+		}
+		return hosts[i].InstanceId < hosts[j].InstanceId
+	})
+	return hosts, nil
+}
+
+func getDiscoveryClusters(ctx context.Context, param DiscoveryParam) ([]model.Cluster, error) {
+	if (len(param.ClusterNames) == 0) == (len(param.Tags) == 0) {
+		return nil, errors.New("exactly one of cluster_names or tags is required")
+	}
+	if len(param.ClusterNames) > 0 {
+		return GetClustersByNames(ctx, param.ClusterNames)
+	}
+	names := make([]string, 0)
+	for pageNum := 1; ; pageNum++ {
+		clusterTags, total, err := model.GetClusterNamesByTags(ctx, param.Tags, constants.DefaultPageSize, pageNum)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range clusterTags {
+			names = append(names, tag.ClusterName)
+		}
+		if len(clusterTags) == 0 || int64(len(names)) >= total {
+			break
+		}
+	}
+	if len(names) == 0 {
+		return []model.Cluster{}, nil
+	}
+	return GetClustersByNames(ctx, names)
+}
+
+//RenderPrometheusTargets 每个集群的每个可用区生成一个target group, 集群标签以bridgx_tag_前缀输出为label
+func RenderPrometheusTargets(hosts []DiscoveryHost, port int) []PrometheusTargetGroup {
+	groups := make([]PrometheusTargetGroup, 0)
+	index := make(map[string]int)
+	for _, host := range hosts {
+		target := host.Ip
+		if port > 0 {
+			target = fmt.Sprintf("%s:%d", host.Ip, port)
+		}
+		key := host.ClusterName + "/" + host.ZoneId
+		i, ok := index[key]
+		if !ok {
+			labels := map[string]string{
+				"bridgx_cluster":  host.ClusterName,
+				"bridgx_provider": host.Provider,
+				"bridgx_region":   host.RegionId,
+				"bridgx_zone":     host.ZoneId,
+			}
+			for k, v := range host.Tags {
+				labels["bridgx_tag_"+sanitizeName(k)] = v
+			}
+			groups = append(groups, PrometheusTargetGroup{Targets: make([]string, 0), Labels: labels})
+			i = len(groups) - 1
+			index[key] = i
+		}
+		groups[i].Targets = append(groups[i].Targets, target)
+	}
+	return groups
+}
+
+//RenderAnsibleInventory 生成Ansible动态inventory, 按集群分组为cluster_<name>, 按集群标签分组为tag_<key>_<value>
+func RenderAnsibleInventory(hosts []DiscoveryHost) map[string]interface{} {
+	hostVars := make(map[string]interface{}, len(hosts))
+	groups := make(map[string][]string)
+	for _, host := range hosts {
+		hostVars[host.Ip] = map[string]string{
+			"instance_id":  host.InstanceId,
+			"ip_inner":     host.IpInner,
+			"ip_outer":     host.IpOuter,
+			"zone_id":      host.ZoneId,
+			"region_id":    host.RegionId,
+			"provider":     host.Provider,
+			"cluster_name": host.ClusterName,
+		}
+		clusterGroup := "cluster_" + sanitizeName(host.ClusterName)
+		groups[clusterGroup] = append(groups[clusterGroup], host.Ip)
+		for k, v := range host.Tags {
+			tagGroup := "tag_" + sanitizeName(k) + "_" + sanitizeName(v)
+			groups[tagGroup] = append(groups[tagGroup], host.Ip)
+		}
+	}
+	children := make([]string, 0, len(groups))
+	inventory := map[string]interface{}{
+		"_meta": map[string]interface{}{"hostvars": hostVars},
+	}
+	for name, ips := range groups {
+		children = append(children, name)
+		inventory[name] = map[string][]string{"hosts": ips}
+	}
+	sort.Strings(children)
+	inventory["all"] = map[string][]string{"children": children}
+	return inventory
+}
+
+//RenderSSHConfig 为每个实例生成一个Host段, Host名为<cluster>-<instance_id>
+func RenderSSHConfig(hosts []DiscoveryHost, user, identityFile string) (string, error) {
+	if err := checkSSHConfigValue("user", user); err != nil {
+		return "", err
+	}
+	if err := checkSSHConfigValue("identity_file", identityFile); err != nil {
+		return "", err
+	}
+	if user == "" {
+		user = defaultSSHUser
+	}
+	var b strings.Builder
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "Host %s-%s\n", host.ClusterName, host.InstanceId)
+		fmt.Fprintf(&b, "    HostName %s\n", host.Ip)
+		fmt.Fprintf(&b, "    User %s\n", user)
+		if identityFile != "" {
+			fmt.Fprintf(&b, "    IdentityFile %s\n", identityFile)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+//checkSSHConfigValue 值原样写入ssh_config, 含空白或控制字符时可换行注入ProxyCommand等指令
+func checkSSHConfigValue(name, value string) error {
+	for _, r := range value {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("invalid %v: whitespace and control characters are not allowed", name)
+		}
+	}
+	return nil
+}
+
+//sanitizeName Prometheus label及Ansible group名只能包含字母、数字及下划线
+func sanitizeName(name string) string {
+	return invalidNameChars.ReplaceAllString(name, "_")
+}
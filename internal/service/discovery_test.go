@@ -0,0 +1,73 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDiscoveryHosts() []DiscoveryHost {
+	tags := map[string]string{"env": "prod", "app.name": "web-1"}
+	return []DiscoveryHost{
+		{InstanceId: "i-1", Ip: "10.0.0.1", IpInner: "10.0.0.1", ZoneId: "z1", ClusterName: "c1", Provider: "AlibabaCloud", RegionId: "r1", Tags: tags},
+		{InstanceId: "i-2", Ip: "10.0.0.2", IpInner: "10.0.0.2", ZoneId: "z2", ClusterName: "c1", Provider: "AlibabaCloud", RegionId: "r1", Tags: tags},
+		{InstanceId: "i-3", Ip: "10.0.0.3", IpInner: "10.0.0.3", ZoneId: "z1", ClusterName: "c1", Provider: "AlibabaCloud", RegionId: "r1", Tags: tags},
+	}
+}
+
+func TestRenderPrometheusTargets(t *testing.T) {
+	groups := RenderPrometheusTargets(testDiscoveryHosts(), 9100)
+	if len(groups) != 2 {
+		t.Fatalf("want 2 groups, got %v", len(groups))
+	}
+	if !reflect.DeepEqual(groups[0].Targets, []string{"10.0.0.1:9100", "10.0.0.3:9100"}) {
+		t.Errorf("targets = %v", groups[0].Targets)
+	}
+	if groups[0].Labels["bridgx_zone"] != "z1" || groups[0].Labels["bridgx_tag_app_name"] != "web-1" {
+		t.Errorf("labels = %v", groups[0].Labels)
+	}
+	if got := RenderPrometheusTargets(testDiscoveryHosts()[:1], 0)[0].Targets; !reflect.DeepEqual(got, []string{"10.0.0.1"}) {
+		t.Errorf("targets without port = %v", got)
+	}
+}
+
+func TestRenderAnsibleInventory(t *testing.T) {
+	inventory := RenderAnsibleInventory(testDiscoveryHosts())
+	all := inventory["all"].(map[string][]string)
+	if !reflect.DeepEqual(all["children"], []string{"cluster_c1", "tag_app_name_web_1", "tag_env_prod"}) {
+		t.Errorf("children = %v", all["children"])
+	}
+	cluster := inventory["cluster_c1"].(map[string][]string)
+	if len(cluster["hosts"]) != 3 {
+		t.Errorf("cluster hosts = %v", cluster["hosts"])
+	}
+	hostVars := inventory["_meta"].(map[string]interface{})["hostvars"].(map[string]interface{})
+	if hostVars["10.0.0.2"].(map[string]string)["instance_id"] != "i-2" {
+		t.Errorf("hostvars = %v", hostVars)
+	}
+}
+
+func TestRenderSSHConfig(t *testing.T) {
+	config, err := RenderSSHConfig(testDiscoveryHosts()[:1], "", "~/.ssh/id_rsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Host c1-i-1\n    HostName 10.0.0.1\n    User root\n    IdentityFile ~/.ssh/id_rsa\n\n"
+	if config != want {
+		t.Errorf("ssh config = %q", config)
+	}
+	if config, _ = RenderSSHConfig(testDiscoveryHosts()[:1], "ops", ""); strings.Contains(config, "IdentityFile") {
+		t.Errorf("IdentityFile should be omitted when empty")
+	}
+	for _, c := range []struct{ user, identityFile string }{
+		{"root\n    ProxyCommand sh", ""},
+		{"root", "~/.ssh/id_rsa\nProxyCommand sh"},
+		{"ro ot", ""},
+		{"root", "~/.ssh/id\trsa"},
+		{"root\x00", ""},
+	} {
+		if _, err = RenderSSHConfig(testDiscoveryHosts()[:1], c.user, c.identityFile); err == nil {
+			t.Errorf("RenderSSHConfig(%q, %q) should fail", c.user, c.identityFile)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/config"
+	"github.com/galaxy-future/BridgX/internal/dns"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+//syncClusterDNS 扩容后添加新实例的记录并刷新集群轮询记录, 已有实例的记录不变, 不重复提交
+func syncClusterDNS(clusterName string, addedIds []string) error {
+	return updateClusterDNS(clusterName, addedIds, nil)
+}
+
+//refreshClusterDNS 实例健康状态或开关机状态变化后只刷新集群轮询记录
+func refreshClusterDNS(clusterName string) error {
+	return updateClusterDNS(clusterName, nil, nil)
+}
+
+//removeInstancesDNS 释放实例前删除实例记录并将其从集群轮询记录中摘除, 避免解析到即将释放的实例
+func removeInstancesDNS(clusterName string, instanceIds []string) error {
+	return updateClusterDNS(clusterName, nil, instanceIds)
+}
+
+func updateClusterDNS(clusterName string, addedIds, removingIds []string) error {
+	if !dns.Enabled() {
+		return nil
+	}
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		logs.Logger.Errorf("[updateClusterDNS] get instances of cluster:%v failed, err: %v", clusterName, err)
+		return err
+	}
+	records, err := buildClusterDNSRecords(clusterName, instances, addedIds, removingIds, config.GlobalConfig.DNS.UseOuterIp)
+	if err != nil {
+		logs.Logger.Errorf("[updateClusterDNS] build dns records of cluster:%v failed, err: %v", clusterName, err)
+		return err
+	}
+	if err = dns.ReplaceRecords(context.Background(), records); err != nil {
+		logs.Logger.Errorf("[updateClusterDNS] update dns records of cluster:%v failed, err: %v", clusterName, err)
+		return err
+	}
+	return nil
+}
+
+//buildClusterDNSRecords 实例记录为<instance_id>.<cluster>, 只生成addedIds的实例记录及removingIds的删除记录;
+//集群记录为<cluster>, 只包含可对外服务的实例. 名称转换后为空时返回错误, 避免覆盖zone根记录
+func buildClusterDNSRecords(clusterName string, instances []model.Instance, addedIds, removingIds []string, useOuterIp bool) ([]dns.Record, error) {
+	clusterLabel := dnsLabel(clusterName)
+	if clusterLabel == "" {
+		return nil, fmt.Errorf("cluster name %v can not be used as dns label", clusterName)
+	}
+	records := make([]dns.Record, 0, len(addedIds)+len(removingIds)+1)
+	clusterIPs := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if utils.ContainsString(removingIds, instance.InstanceId) {
+			continue
+		}
+		ip := instance.IpInner
+		if useOuterIp {
+			ip = instance.IpOuter
+		}
+		if ip == "" {
+			continue
+		}
+		if utils.ContainsString(addedIds, instance.InstanceId) {
+			label := dnsLabel(instance.InstanceId)
+			if label == "" {
+				return nil, fmt.Errorf("instance id %v can not be used as dns label", instance.InstanceId)
+			}
+			records = append(records, dns.Record{Name: label + "." + clusterLabel, Type: dns.RecordTypeA, Values: []string{ip}})
+		}
+		if isWorkingInstance(instance) {
+			clusterIPs = append(clusterIPs, ip)
+		}
+	}
+	for _, id := range removingIds {
+		label := dnsLabel(id)
+		if label == "" {
+			return nil, fmt.Errorf("instance id %v can not be used as dns label", id)
+		}
+		records = append(records, dns.Record{Name: label + "." + clusterLabel, Type: dns.RecordTypeA})
+	}
+	sort.Strings(clusterIPs)
+	return append(records, dns.Record{Name: clusterLabel, Type: dns.RecordTypeA, Values: clusterIPs}), nil
+}
+
+//dnsLabel 转为小写, 非字母数字及-的字符替换为-
+func dnsLabel(name string) string {
+	label := strings.Trim(invalidLabelChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	return label
+}
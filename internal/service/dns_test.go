@@ -0,0 +1,40 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/dns"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestBuildClusterDNSRecords(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "i-1", IpInner: "10.0.0.1", IpOuter: "1.1.1.1", Status: constants.Running},
+		{InstanceId: "i-2", IpInner: "10.0.0.2", Status: constants.Running, HealthStatus: constants.InstanceUnhealthy},
+		{InstanceId: "i-3", IpInner: "10.0.0.3", Status: constants.Running},
+		{InstanceId: "i-4", Status: constants.Pending},
+	}
+	records, err := buildClusterDNSRecords("Web_Cluster", instances, []string{"i-2", "i-4"}, []string{"i-3"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []dns.Record{
+		{Name: "i-2.web-cluster", Type: dns.RecordTypeA, Values: []string{"10.0.0.2"}},
+		{Name: "i-3.web-cluster", Type: dns.RecordTypeA},
+		{Name: "web-cluster", Type: dns.RecordTypeA, Values: []string{"10.0.0.1"}},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("records = %+v", records)
+	}
+
+	records, _ = buildClusterDNSRecords("c1", instances[:1], []string{"i-1"}, nil, true)
+	if records[0].Values[0] != "1.1.1.1" || records[1].Values[0] != "1.1.1.1" {
+		t.Errorf("records with outer ip = %+v", records)
+	}
+
+	if _, err = buildClusterDNSRecords("集群_", instances, nil, nil, false); err == nil {
+		t.Errorf("cluster name without valid dns label chars should be rejected")
+	}
+}
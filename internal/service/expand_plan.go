@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+const hoursPerMonth = 730
+
+type ExpandPlan struct {
+	ClusterName string      `json:"cluster_name"`
+	Count       int         `json:"count"`
+	Feasible    bool        `json:"feasible"`
+	DryRun      PlanDryRun  `json:"dry_run"`
+	Stocks      []PlanStock `json:"stocks"`
+	Quota       PlanQuota   `json:"quota"`
+	Subnet      PlanSubnet  `json:"subnet"`
+	Cost        PlanCost    `json:"cost"`
+	Warnings    []string    `json:"warnings"`
+}
+
+type PlanDryRun struct {
+	Pass   bool   `json:"pass"`
+	ErrMsg string `json:"err_msg"`
+}
+
+type PlanStock struct {
+	ZoneId       string `json:"zone_id"`
+	InstanceType string `json:"instance_type"`
+	Status       string `json:"status"`
+}
+
+type PlanQuota struct {
+	Supported        bool `json:"supported"`
+	VCpuLimit        int  `json:"vcpu_limit"`
+	VCpuUsed         int  `json:"vcpu_used"`
+	VCpuRequired     int  `json:"vcpu_required"`
+	InstanceHeadroom int  `json:"instance_headroom"`
+}
+
+type PlanSubnet struct {
+	SubnetId         string `json:"subnet_id"`
+	AvailableIpCount int    `json:"available_ip_count"`
+	Enough           bool   `json:"enough"`
+}
+
+type PlanCost struct {
+	Supported   bool    `json:"supported"`
+	Currency    string  `json:"currency"`
+	HourlyCost  float64 `json:"hourly_cost"`
+	MonthlyCost float64 `json:"monthly_cost"`
+}
+
+//PlanExpand 在不创建任务的前提下评估集群扩容count台的可行性及费用
+func PlanExpand(ctx context.Context, clusterName string, count int) (*ExpandPlan, error) {
+	cluster, err := model.GetByClusterName(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf(constants.ErrClusterNotExist, clusterName)
+	}
+	tags, _ := GetClusterTagsByClusterName(ctx, clusterName)
+	clusterInfo, err := ConvertToClusterInfo(cluster, tags)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := getProvider(clusterInfo.Provider, clusterInfo.AccountKey, clusterInfo.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	plan := &ExpandPlan{ClusterName: clusterName, Count: count, Warnings: make([]string, 0)}
+
+	if err = CheckClusterParam(clusterInfo); err != nil {
+		plan.DryRun.ErrMsg = err.Error()
+	} else {
+		plan.DryRun.Pass = true
+	}
+	plan.Stocks = planStocks(provider, clusterInfo, plan)
+	planSubnet(provider, clusterInfo, plan)
+	planQuota(ctx, provider, clusterInfo, plan)
+	planCost(provider, clusterInfo, plan)
+
+	plan.Feasible = plan.DryRun.Pass && plan.Subnet.Enough && !isSoldOut(plan.Stocks, clusterInfo.ZoneId)
+	if plan.Quota.Supported && plan.Quota.InstanceHeadroom < count {
+		plan.Feasible = false
+	}
+	return plan, nil
+}
+
+func planStocks(provider cloud.Provider, c *types.ClusterInfo, plan *ExpandPlan) []PlanStock {
+	stocks := make([]PlanStock, 0)
+	res, err := provider.DescribeAvailableResource(cloud.DescribeAvailableResourceRequest{RegionId: c.RegionId})
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, "describe available resource failed: "+err.Error())
+		return stocks
+	}
+	seen := make(map[string]bool)
+	for zoneId, insTypes := range res.InstanceTypes {
+		for _, insType := range insTypes {
+			if insType.InsTypeName != c.InstanceType || seen[zoneId] {
+				continue
+			}
+			if !chargeTypeMatch(insType.ChargeType, c.ChargeConfig.ChargeType) {
+				continue
+			}
+			seen[zoneId] = true
+			stocks = append(stocks, PlanStock{ZoneId: zoneId, InstanceType: insType.InsTypeName, Status: insType.Status})
+		}
+	}
+	if !seen[c.ZoneId] {
+		stocks = append(stocks, PlanStock{ZoneId: c.ZoneId, InstanceType: c.InstanceType, Status: cloud.InsTypeSellOut})
+	}
+	return stocks
+}
+
+func chargeTypeMatch(insTypeChargeType, clusterChargeType string) bool {
+	if insTypeChargeType == "" || insTypeChargeType == cloud.InsTypeChargeTypeAll || clusterChargeType == "" {
+		return true
+	}
+	return strings.EqualFold(insTypeChargeType, clusterChargeType)
+}
+
+func isSoldOut(stocks []PlanStock, zoneId string) bool {
+	for _, stock := range stocks {
+		if stock.ZoneId == zoneId {
+			return stock.Status == cloud.InsTypeSellOut
+		}
+	}
+	return true
+}
+
+func planSubnet(provider cloud.Provider, c *types.ClusterInfo, plan *ExpandPlan) {
+	if c.NetworkConfig == nil || c.NetworkConfig.SubnetId == "" {
+		plan.Warnings = append(plan.Warnings, "cluster has no subnet config")
+		return
+	}
+	plan.Subnet.SubnetId = c.NetworkConfig.SubnetId
+	res, err := provider.GetSwitch(cloud.GetSwitchRequest{SwitchId: c.NetworkConfig.SubnetId})
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, "get subnet failed: "+err.Error())
+		return
+	}
+	plan.Subnet.AvailableIpCount = res.Switch.AvailableIpAddressCount
+	plan.Subnet.Enough = res.Switch.AvailableIpAddressCount >= plan.Count
+}
+
+func planQuota(ctx context.Context, provider cloud.Provider, c *types.ClusterInfo, plan *ExpandPlan) {
+	describer, ok := provider.(cloud.PlanDescriber)
+	if !ok || c.ChargeConfig.ChargeType == cloud.InstanceChargeTypePrePaid {
+		return
+	}
+	core := c.ExtendConfig.Core
+	if insType, err := model.GetInstanceTypeByName(ctx, c.InstanceType); err == nil && insType.Core > 0 {
+		core = insType.Core
+	}
+	if core <= 0 {
+		plan.Warnings = append(plan.Warnings, "unknown core count of instance type "+c.InstanceType)
+		return
+	}
+	quota, err := describer.DescribeInstanceQuota(cloud.DescribeInstanceQuotaRequest{
+		RegionId:   c.RegionId,
+		ZoneId:     c.ZoneId,
+		ChargeType: c.ChargeConfig.ChargeType,
+	})
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, "describe quota failed: "+err.Error())
+		return
+	}
+	plan.Quota = PlanQuota{
+		Supported:        true,
+		VCpuLimit:        quota.VCpuLimit,
+		VCpuUsed:         quota.VCpuUsed,
+		VCpuRequired:     core * plan.Count,
+		InstanceHeadroom: calcInstanceHeadroom(quota.VCpuLimit, quota.VCpuUsed, core),
+	}
+}
+
+func calcInstanceHeadroom(limit, used, core int) int {
+	if core <= 0 || limit <= used {
+		return 0
+	}
+	return (limit - used) / core
+}
+
+func planCost(provider cloud.Provider, c *types.ClusterInfo, plan *ExpandPlan) {
+	cost, err := estimateCost(provider, c, plan.Count)
+	if err != nil {
+		plan.Warnings = append(plan.Warnings, err.Error())
+		return
+	}
+	plan.Cost = cost
+}
+
+//estimateCost 估算扩容count台的费用, 云厂商不支持询价时返回Supported为false
+func estimateCost(provider cloud.Provider, c *types.ClusterInfo, count int) (PlanCost, error) {
+	describer, ok := provider.(cloud.PlanDescriber)
+	if !ok {
+		return PlanCost{}, nil
+	}
+	params, err := generateParams(c, nil)
+	if err != nil {
+		return PlanCost{}, fmt.Errorf("generate params failed: %w", err)
+	}
+	price, err := describer.DescribePrice(cloud.DescribePriceRequest{Params: params, Num: count})
+	if err != nil {
+		return PlanCost{}, fmt.Errorf("describe price failed: %w", err)
+	}
+	hourly, monthly := normalizePrice(price.TradePrice, price.PriceUnit, c.ChargeConfig.Period)
+	return PlanCost{
+		Supported:   true,
+		Currency:    price.Currency,
+		HourlyCost:  hourly,
+		MonthlyCost: monthly,
+	}, nil
+}
+
+//normalizePrice 将云厂商返回的价格统一换算为每小时及每月价格
+func normalizePrice(price float64, unit string, period int) (hourly, monthly float64) {
+	if period <= 0 {
+		period = 1
+	}
+	switch unit {
+	case cloud.PriceUnitMonth:
+		monthly = price / float64(period)
+	case cloud.PriceUnitYear:
+		monthly = price / float64(period*12)
+	default:
+		return price, price * hoursPerMonth
+	}
+	return monthly / hoursPerMonth, monthly
+}
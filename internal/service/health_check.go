@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	"github.com/spf13/cast"
+)
+
+const (
+	defaultHealthCheckTimeout = 3 * time.Second
+	defaultSshPort            = 22
+)
+
+//SshCredential ssh健康检查登录实例使用的凭证
+type SshCredential struct {
+	Username   string
+	Password   string
+	PrivateKey string
+}
+
+//NewSshCredential 根据集群的认证方式获取登录凭证, 非ssh检查时返回nil
+func NewSshCredential(c *types.ClusterInfo, hc *types.HealthCheckConfig) (*SshCredential, error) {
+	if hc == nil || strings.ToLower(hc.Protocol) != constants.HealthCheckProtocolSSH {
+		return nil, nil
+	}
+	cred := &SshCredential{Username: c.Username}
+	if cred.Username == "" {
+		cred.Username = constants.DefaultUsername
+	}
+	if c.AuthType == constants.AuthTypeKeyPair {
+		keyPair, err := GetKeyPair(nil, cast.ToInt64(c.KeyId))
+		if err != nil {
+			return nil, err
+		}
+		cred.PrivateKey = keyPair.PrivateKey
+	} else {
+		cred.Password = c.Password
+	}
+	return cred, nil
+}
+
+//ProbeInstance 按配置对实例内网IP做一次健康探测, 失败时按Retries重试. cred仅ssh检查使用
+func ProbeInstance(ip string, hc *types.HealthCheckConfig, cred *SshCredential) error {
+	if hc == nil {
+		return nil
+	}
+	var err error
+	for i := 0; i <= hc.Retries; i++ {
+		if err = probeOnce(ip, hc, cred); err == nil {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
+
+//CheckHealthCheckConfig 校验健康检查的协议及端口
+func CheckHealthCheckConfig(hc *types.HealthCheckConfig) error {
+	switch strings.ToLower(hc.Protocol) {
+	case constants.HealthCheckProtocolTCP, constants.HealthCheckProtocolHTTP:
+	case constants.HealthCheckProtocolSSH:
+		if hc.Command == "" {
+			return errors.New("ssh health check requires command")
+		}
+		if hc.Port == 0 {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported health check protocol: %v", hc.Protocol)
+	}
+	if hc.Port <= 0 || hc.Port > 65535 {
+		return fmt.Errorf("invalid health check port: %v", hc.Port)
+	}
+	return nil
+}
+
+func probeOnce(ip string, hc *types.HealthCheckConfig, cred *SshCredential) error {
+	timeout := defaultHealthCheckTimeout
+	if hc.TimeoutSec > 0 {
+		timeout = time.Duration(hc.TimeoutSec) * time.Second
+	}
+	addr := net.JoinHostPort(ip, strconv.Itoa(hc.Port))
+	switch strings.ToLower(hc.Protocol) {
+	case constants.HealthCheckProtocolSSH:
+		if cred == nil {
+			return errors.New("ssh health check requires login credential")
+		}
+		port := hc.Port
+		if port == 0 {
+			port = defaultSshPort
+		}
+		out, err := utils.SshRun(ip, port, cred.Username, cred.Password, cred.PrivateKey, hc.Command, timeout)
+		if err != nil {
+			return fmt.Errorf("health check command failed: %v, output: %v", err, truncateString(strings.TrimSpace(out), 200))
+		}
+		return nil
+	case constants.HealthCheckProtocolTCP:
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case constants.HealthCheckProtocolHTTP:
+		path := hc.Path
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		client := http.Client{Timeout: timeout}
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("health check %v got status code %v", addr, resp.StatusCode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported health check protocol: %v", hc.Protocol)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+//SaveHibernationSchedule 校验并保存集群的休眠计划, 已存在时覆盖原计划
+func SaveHibernationSchedule(ctx context.Context, schedule *model.HibernationSchedule) error {
+	if schedule.WorkDays == "" {
+		schedule.WorkDays = constants.DefaultHibernationWorkDays
+	}
+	if schedule.Timezone == "" {
+		schedule.Timezone = constants.DefaultHibernationTimezone
+	}
+	if err := CheckHibernationSchedule(schedule); err != nil {
+		return err
+	}
+	cluster, err := model.GetByClusterName(schedule.ClusterName)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf(constants.ErrClusterNotExist, schedule.ClusterName)
+	}
+	existing, err := model.GetHibernationSchedule(ctx, schedule.ClusterName)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	schedule.UpdateAt = &now
+	if existing == nil {
+		schedule.CreateAt = &now
+		return model.Create(schedule)
+	}
+	schedule.Id = existing.Id
+	schedule.CreateAt = existing.CreateAt
+	schedule.State = existing.State
+	return model.Save(schedule)
+}
+
+//GetHibernationSchedule 获取集群的休眠计划, 未配置时返回nil
+func GetHibernationSchedule(ctx context.Context, clusterName string) (*model.HibernationSchedule, error) {
+	return model.GetHibernationSchedule(ctx, clusterName)
+}
+
+//DeleteHibernationSchedule 删除集群的休眠计划, 不改变集群当前的开关机状态
+func DeleteHibernationSchedule(ctx context.Context, clusterName string) error {
+	schedule, err := model.GetHibernationSchedule(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		return nil
+	}
+	return model.Delete(schedule)
+}
+
+//CheckHibernationSchedule 校验休眠计划的时间、星期及时区
+func CheckHibernationSchedule(schedule *model.HibernationSchedule) error {
+	start, err := parseClock(schedule.StartTime)
+	if err != nil {
+		return err
+	}
+	stop, err := parseClock(schedule.StopTime)
+	if err != nil {
+		return err
+	}
+	if start == stop {
+		return errors.New("start_time and stop_time can not be the same")
+	}
+	if _, err = parseWorkDays(schedule.WorkDays); err != nil {
+		return err
+	}
+	if _, err = time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %v", schedule.Timezone)
+	}
+	return nil
+}
+
+//RunHibernationSchedules 按休眠计划对进入或离开开机时段的集群创建停机或开机任务
+func RunHibernationSchedules(ctx context.Context) error {
+	schedules, err := model.GetEnabledHibernationSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range schedules {
+		if err = runHibernationSchedule(ctx, &schedules[i], now); err != nil {
+			logs.Logger.Errorf("run hibernation schedule of cluster:%v failed, err: %v", schedules[i].ClusterName, err)
+		}
+	}
+	return nil
+}
+
+func runHibernationSchedule(ctx context.Context, schedule *model.HibernationSchedule, now time.Time) error {
+	want, err := desiredHibernationState(schedule, now)
+	if err != nil {
+		return err
+	}
+	if want == schedule.State {
+		return nil
+	}
+	//集群有未完成的任务时等待下一轮再切换
+	if hasUnfinishedTask(schedule.ClusterName) {
+		return nil
+	}
+	action, taskName := constants.TaskActionStart, constants.WakeUpTaskName
+	if want == constants.HibernationStateHibernated {
+		action, taskName = constants.TaskActionStop, constants.HibernateTaskName
+	}
+	taskId, err := CreatePowerTask(ctx, schedule.ClusterName, action, nil, taskName, schedule.CreateBy)
+	if err != nil && !errors.Is(err, errNoInstanceToPower) {
+		return err
+	}
+	logs.Logger.Infof("cluster:%v hibernation state %v -> %v, task:%v", schedule.ClusterName, schedule.State, want, taskId)
+	return model.UpdateHibernationState(ctx, schedule.Id, want)
+}
+
+//desiredHibernationState 计算t时刻集群按计划应处的状态. StopTime早于StartTime时开机时段跨零点, 零点后的部分属于前一天
+func desiredHibernationState(schedule *model.HibernationSchedule, t time.Time) (string, error) {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return "", err
+	}
+	start, err := parseClock(schedule.StartTime)
+	if err != nil {
+		return "", err
+	}
+	stop, err := parseClock(schedule.StopTime)
+	if err != nil {
+		return "", err
+	}
+	workDays, err := parseWorkDays(schedule.WorkDays)
+	if err != nil {
+		return "", err
+	}
+	t = t.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	weekday := t.Weekday()
+	awake := false
+	if start < stop {
+		awake = workDays[weekday] && minute >= start && minute < stop
+	} else if minute >= start {
+		awake = workDays[weekday]
+	} else if minute < stop {
+		awake = workDays[(weekday+6)%7]
+	}
+	if awake {
+		return constants.HibernationStateAwake, nil
+	}
+	return constants.HibernationStateHibernated, nil
+}
+
+//parseClock 将HH:MM解析为当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time: %v, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func parseWorkDays(s string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, d := range strings.Split(s, ",") {
+		day, err := strconv.Atoi(strings.TrimSpace(d))
+		if err != nil || day < 0 || day > 6 {
+			return nil, fmt.Errorf("invalid work day: %v, expected 0-6", d)
+		}
+		days[time.Weekday(day)] = true
+	}
+	return days, nil
+}
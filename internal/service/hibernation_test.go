@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestDesiredHibernationState(t *testing.T) {
+	daytime := &model.HibernationSchedule{WorkDays: "1,2,3,4,5", StartTime: "08:00", StopTime: "20:00", Timezone: "UTC"}
+	overnight := &model.HibernationSchedule{WorkDays: "5", StartTime: "20:00", StopTime: "02:00", Timezone: "UTC"}
+	//2021-11-12为周五
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2021, 11, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		schedule *model.HibernationSchedule
+		t        time.Time
+		want     string
+	}{
+		{daytime, at(12, 7, 59), constants.HibernationStateHibernated},
+		{daytime, at(12, 8, 0), constants.HibernationStateAwake},
+		{daytime, at(12, 20, 0), constants.HibernationStateHibernated},
+		{daytime, at(13, 12, 0), constants.HibernationStateHibernated},
+		{overnight, at(12, 21, 0), constants.HibernationStateAwake},
+		{overnight, at(13, 1, 0), constants.HibernationStateAwake},
+		{overnight, at(13, 3, 0), constants.HibernationStateHibernated},
+		{overnight, at(12, 1, 0), constants.HibernationStateHibernated},
+	}
+	for _, tt := range tests {
+		got, err := desiredHibernationState(tt.schedule, tt.t)
+		if err != nil || got != tt.want {
+			t.Errorf("desiredHibernationState(%v-%v, %v) = %v, %v, want %v", tt.schedule.StartTime, tt.schedule.StopTime, tt.t, got, err, tt.want)
+		}
+	}
+}
+
+func TestCheckHibernationSchedule(t *testing.T) {
+	tests := []struct {
+		schedule model.HibernationSchedule
+		wantErr  bool
+	}{
+		{schedule: model.HibernationSchedule{WorkDays: "1,2", StartTime: "08:00", StopTime: "20:00", Timezone: "Asia/Shanghai"}},
+		{schedule: model.HibernationSchedule{WorkDays: "1", StartTime: "8am", StopTime: "20:00"}, wantErr: true},
+		{schedule: model.HibernationSchedule{WorkDays: "1", StartTime: "08:00", StopTime: "08:00"}, wantErr: true},
+		{schedule: model.HibernationSchedule{WorkDays: "7", StartTime: "08:00", StopTime: "20:00"}, wantErr: true},
+		{schedule: model.HibernationSchedule{WorkDays: "1", StartTime: "08:00", StopTime: "20:00", Timezone: "Mars/Olympus"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckHibernationSchedule(&tt.schedule); (err != nil) != tt.wantErr {
+			t.Errorf("CheckHibernationSchedule(%+v) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+		}
+	}
+}
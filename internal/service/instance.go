@@ -13,6 +13,8 @@ import (
 	"github.com/galaxy-future/BridgX/internal/model"
 	"github.com/galaxy-future/BridgX/internal/types"
 	"github.com/galaxy-future/BridgX/pkg/cloud"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
 )
 
 const instanceTypeTmpl = "%d核%dG(%s)"
@@ -57,6 +59,20 @@ func GetInstancesByTaskId(ctx context.Context, taskId string, taskAction string)
 		m["task_id"] = taskId
 	} else if taskAction == constants.TaskActionShrink {
 		m["shrink_task_id"] = taskId
+	} else if taskAction == constants.TaskActionRoll {
+		//滚动替换任务新建的实例记录在task_id上
+		m["task_id"] = taskId
+	} else if taskAction == constants.TaskActionStop || taskAction == constants.TaskActionStart {
+		//开关机任务不修改实例的任务ID, 从任务参数中获取实例
+		task := &model.Task{}
+		if err := model.Get(cast.ToInt64(taskId), task); err != nil {
+			return nil, err
+		}
+		info := &model.PowerTaskInfo{}
+		if err := jsoniter.UnmarshalFromString(task.TaskInfo, info); err != nil {
+			return nil, err
+		}
+		m["instance_id"] = info.InstanceIds
 	} else {
 		return nil, errors.New("not support task action")
 	}
@@ -448,3 +464,16 @@ func sortZoneInsTypeCache() {
 		zoneInsTypeCache[provider] = zoneMap
 	}
 }
+
+//SetInstancesScaleInProtection 开启或关闭实例的缩容保护
+func SetInstancesScaleInProtection(ctx context.Context, clusterName string, instanceIds []string, protected bool) error {
+	if _, err := GetClusterByName(ctx, clusterName); err != nil {
+		return err
+	}
+	affected, err := model.UpdateInstancesScaleInProtection(clusterName, instanceIds, protected)
+	if err != nil {
+		return err
+	}
+	logs.Logger.Infof("cluster:%v set scale in protection:%v, instances:%v, affected:%v", clusterName, protected, instanceIds, affected)
+	return nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//CheckClustersHealth 对所有配置了健康检查的集群探测一轮
+func CheckClustersHealth(ctx context.Context) error {
+	clusters, err := model.GetHealthCheckClusters(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range clusters {
+		if err = CheckClusterHealth(ctx, &clusters[i]); err != nil {
+			logs.Logger.Errorf("check cluster:%v health failed, err: %v", clusters[i].ClusterName, err)
+		}
+	}
+	return nil
+}
+
+//CheckClusterHealth 探测集群内运行中的实例并记录健康状态, 状态变化时重新发布WorkingIPs
+func CheckClusterHealth(ctx context.Context, cluster *model.Cluster) error {
+	clusterInfo, err := ConvertToClusterInfo(cluster, nil)
+	if err != nil {
+		return err
+	}
+	hc := clusterInfo.HealthCheck
+	if hc == nil {
+		return nil
+	}
+	cred, err := NewSshCredential(clusterInfo, &hc.HealthCheckConfig)
+	if err != nil {
+		return err
+	}
+	instances, err := model.GetActiveInstancesByClusterName(cluster.ClusterName)
+	if err != nil {
+		return err
+	}
+	running := make([]model.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if instance.Status == constants.Running && instance.IpInner != "" {
+			running = append(running, instance)
+		}
+	}
+	probeErrs := probeInstances(running, &hc.HealthCheckConfig, cred)
+
+	now := time.Now()
+	changed := false
+	newlyUnhealthy := make([]string, 0)
+	unhealthy := make([]model.Instance, 0)
+	for _, instance := range running {
+		status, failCount := nextHealthState(instance, probeErrs[instance.InstanceId], hc.UnhealthyThreshold)
+		if err = model.UpdateInstanceHealth(instance.InstanceId, status, failCount, now); err != nil {
+			continue
+		}
+		if status != instance.HealthStatus {
+			changed = true
+			logs.Logger.Infof("cluster:%v instance:%v health status %v -> %v", cluster.ClusterName, instance.InstanceId, instance.HealthStatus, status)
+			if status == constants.InstanceUnhealthy {
+				newlyUnhealthy = append(newlyUnhealthy, instance.InstanceId)
+			}
+		}
+		if status == constants.InstanceUnhealthy {
+			unhealthy = append(unhealthy, instance)
+		}
+	}
+	if changed {
+		if err = publishWorkingIPs(cluster.ClusterName); err != nil {
+			logs.Logger.Errorf("publish working ips of cluster:%v failed, err: %v", cluster.ClusterName, err)
+		}
+	}
+	if len(newlyUnhealthy) > 0 {
+		Notify(ctx, NotifyEvent{
+			EventType:   constants.NotifyEventInstanceUnhealthy,
+			Severity:    constants.NotifySeverityWarning,
+			ClusterName: cluster.ClusterName,
+			Data: map[string]interface{}{
+				"instance_count": len(newlyUnhealthy),
+				"instance_ids":   strings.Join(newlyUnhealthy, ","),
+			},
+		})
+	}
+	if hc.AutoReplace {
+		return replaceUnhealthyInstances(ctx, cluster, len(instances), unhealthy)
+	}
+	return nil
+}
+
+//probeInstances 并发探测实例, 返回探测失败的实例及原因
+func probeInstances(instances []model.Instance, hc *types.HealthCheckConfig, cred *SshCredential) map[string]error {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, constants.HealthCheckParallelism)
+	)
+	failed := make(map[string]error)
+	for _, instance := range instances {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(instance model.Instance) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ProbeInstance(instance.IpInner, hc, cred); err != nil {
+				mu.Lock()
+				failed[instance.InstanceId] = err
+				mu.Unlock()
+			}
+		}(instance)
+	}
+	wg.Wait()
+	return failed
+}
+
+//nextHealthState 根据本次探测结果计算实例的健康状态, 连续失败次数达到阈值才判定为不健康
+func nextHealthState(instance model.Instance, probeErr error, threshold int) (string, int) {
+	if probeErr == nil {
+		return constants.InstanceHealthy, 0
+	}
+	if threshold <= 0 {
+		threshold = constants.DefaultUnhealthyThreshold
+	}
+	failCount := instance.HealthFailCount + 1
+	if failCount >= threshold {
+		return constants.InstanceUnhealthy, failCount
+	}
+	status := instance.HealthStatus
+	if status == "" {
+		status = constants.InstanceHealthy
+	}
+	return status, failCount
+}
+
+//replaceUnhealthyInstances 通过缩容及扩容任务替换不健康实例: 先按IP缩容未开启缩容保护的不健康实例,
+//缩容任务结束后的下一轮再按实际缩容数量扩容, 两步均走常规的任务创建流程以遵循实例数上下限及审批策略
+func replaceUnhealthyInstances(ctx context.Context, cluster *model.Cluster, total int, unhealthy []model.Instance) error {
+	if hasUnfinishedTask(cluster.ClusterName) {
+		return nil
+	}
+	last, err := model.GetLatestTaskByName(cluster.ClusterName, constants.HealthReplaceTaskName)
+	if err != nil {
+		return err
+	}
+	if last != nil && last.TaskAction == constants.TaskActionShrink {
+		if removed := removedInstanceCount(last); removed > 0 {
+			taskId, err := CreateExpandTask(ctx, cluster.ClusterName, removed, constants.HealthReplaceTaskName, 0)
+			if err != nil {
+				return fmt.Errorf("create replace expand task for shrink task:%v failed: %w", last.Id, err)
+			}
+			logs.Logger.Infof("cluster:%v replace %v instances removed by shrink task:%v, expand task:%v", cluster.ClusterName, removed, last.Id, taskId)
+			return nil
+		}
+	}
+	if len(unhealthy) == 0 {
+		return nil
+	}
+	if cluster.GetChargeType() == cloud.InstanceChargeTypePrePaid {
+		logs.Logger.Warnf("cluster:%v is PrePaid, skip replacing unhealthy instances", cluster.ClusterName)
+		return nil
+	}
+	//超过半数实例不健康时更可能是探测端网络异常, 不自动替换
+	if len(unhealthy)*2 > total {
+		logs.Logger.Warnf("cluster:%v has %v/%v unhealthy instances, skip auto replace", cluster.ClusterName, len(unhealthy), total)
+		return nil
+	}
+	ips := make([]string, 0, len(unhealthy))
+	for _, instance := range unhealthy {
+		if instance.ScaleInProtected {
+			logs.Logger.Warnf("cluster:%v instance:%v is unhealthy but scale-in protected, skip replacing", cluster.ClusterName, instance.InstanceId)
+			continue
+		}
+		ips = append(ips, instance.IpInner)
+	}
+	if len(ips) == 0 {
+		return nil
+	}
+	taskId, err := CreateShrinkTask(ctx, cluster.ClusterName, len(ips), strings.Join(ips, ","), nil, constants.HealthReplaceTaskName, 0)
+	if err != nil {
+		return fmt.Errorf("create replace shrink task failed: %w", err)
+	}
+	logs.Logger.Infof("cluster:%v replace unhealthy instances:%v, shrink task:%v", cluster.ClusterName, ips, taskId)
+	return nil
+}
+
+//removedInstanceCount 返回已结束的缩容任务实际删除的实例数
+func removedInstanceCount(shrinkTask *model.Task) int {
+	if shrinkTask.Status != constants.TaskStatusSuccess && shrinkTask.Status != constants.TaskStatusPartialSuccess {
+		return 0
+	}
+	result := &model.TaskResult{}
+	if err := jsoniter.UnmarshalFromString(shrinkTask.TaskResult, result); err != nil {
+		return 0
+	}
+	return result.SuccessNum
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestNextHealthState(t *testing.T) {
+	probeErr := errors.New("connection refused")
+	tests := []struct {
+		name       string
+		instance   model.Instance
+		probeErr   error
+		threshold  int
+		wantStatus string
+		wantCount  int
+	}{
+		{"first check ok", model.Instance{}, nil, 3, constants.InstanceHealthy, 0},
+		{"recover", model.Instance{HealthStatus: constants.InstanceUnhealthy, HealthFailCount: 5}, nil, 3, constants.InstanceHealthy, 0},
+		{"below threshold", model.Instance{HealthStatus: constants.InstanceHealthy, HealthFailCount: 1}, probeErr, 3, constants.InstanceHealthy, 2},
+		{"reach threshold", model.Instance{HealthStatus: constants.InstanceHealthy, HealthFailCount: 2}, probeErr, 3, constants.InstanceUnhealthy, 3},
+		{"default threshold", model.Instance{HealthFailCount: 1}, probeErr, 0, constants.InstanceHealthy, 2},
+	}
+	for _, tt := range tests {
+		status, count := nextHealthState(tt.instance, tt.probeErr, tt.threshold)
+		if status != tt.wantStatus || count != tt.wantCount {
+			t.Errorf("%v: got (%v, %v), want (%v, %v)", tt.name, status, count, tt.wantStatus, tt.wantCount)
+		}
+	}
+}
+
+func TestJoinWorkingIPs(t *testing.T) {
+	instances := []model.Instance{
+		{IpInner: "10.0.0.1", HealthStatus: constants.InstanceHealthy},
+		{IpInner: "10.0.0.2", HealthStatus: constants.InstanceUnhealthy},
+		{IpInner: "10.0.0.3"},
+	}
+	if got := joinWorkingIPs(instances); got != "10.0.0.1,10.0.0.3" {
+		t.Errorf("joinWorkingIPs got %v", got)
+	}
+	if got := joinWorkingIPs(instances[1:2]); got != constants.HasNoneIP {
+		t.Errorf("joinWorkingIPs of all unhealthy got %v", got)
+	}
+}
+
+func TestRemovedInstanceCount(t *testing.T) {
+	tests := []struct {
+		name string
+		task model.Task
+		want int
+	}{
+		{name: "success", task: model.Task{Status: constants.TaskStatusSuccess, TaskResult: `{"success_num":2}`}, want: 2},
+		{name: "partial success", task: model.Task{Status: constants.TaskStatusPartialSuccess, TaskResult: `{"success_num":1}`}, want: 1},
+		{name: "failed", task: model.Task{Status: constants.TaskStatusFailed, TaskResult: `{"success_num":0}`}, want: 0},
+		{name: "running", task: model.Task{Status: constants.TaskStatusRunning}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removedInstanceCount(&tt.task); got != tt.want {
+				t.Errorf("removedInstanceCount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+const (
+	//subnetReservedIPs 各云厂商在子网中保留的IP数, 取最大值
+	subnetReservedIPs = 5
+	minSubnetMaskLen  = 16
+	maxSubnetMaskLen  = 28
+	minVpcMaskLen     = 8
+	defaultVpcMaskLen = 16
+)
+
+//privateCidrPools 新VPC依次从RFC 1918私有网段中分配
+var privateCidrPools = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"}
+
+//PlanCidrParam VpcId为空时规划新VPC的网段及各可用区的子网网段, 否则在该VPC的网段内规划子网
+type PlanCidrParam struct {
+	VpcId      string
+	ZoneIds    []string
+	HostCount  int //每个可用区需要的IP数
+	VpcMaskLen int //新VPC的掩码长度, 0表示自动计算, 至少为/16
+}
+
+type CidrPlan struct {
+	VpcId     string       `json:"vpc_id"`
+	CidrBlock string       `json:"cidr_block"`
+	Subnets   []SubnetPlan `json:"subnets"`
+}
+
+type SubnetPlan struct {
+	ZoneId    string `json:"zone_id"`
+	CidrBlock string `json:"cidr_block"`
+	GatewayIp string `json:"gateway_ip"`
+	Capacity  int    `json:"capacity"` //扣除保留地址后可分配的IP数
+}
+
+//ipRange 闭区间表示的IPv4网段
+type ipRange struct {
+	start uint32
+	end   uint32
+}
+
+//PlanNetworkCidr 规划与组织下已同步的VPC及子网都不重叠的网段
+func PlanNetworkCidr(ctx context.Context, accountKeys []string, param PlanCidrParam) (*CidrPlan, error) {
+	if len(param.ZoneIds) == 0 || param.HostCount <= 0 {
+		return nil, errors.New("zone_ids and host_count are required")
+	}
+	subnetMaskLen, err := getSubnetMaskLen(param.HostCount)
+	if err != nil {
+		return nil, err
+	}
+	if param.VpcId != "" {
+		return planSubnetsInVpc(ctx, accountKeys, param, subnetMaskLen)
+	}
+	vpcMaskLen := subnetMaskLen - bits.Len(uint(len(param.ZoneIds)-1))
+	if param.VpcMaskLen > 0 {
+		if param.VpcMaskLen > vpcMaskLen {
+			return nil, fmt.Errorf("vpc mask /%v is too small for %v subnets of /%v", param.VpcMaskLen, len(param.ZoneIds), subnetMaskLen)
+		}
+		vpcMaskLen = param.VpcMaskLen
+	} else if vpcMaskLen > defaultVpcMaskLen {
+		vpcMaskLen = defaultVpcMaskLen
+	}
+	if vpcMaskLen < minVpcMaskLen {
+		return nil, fmt.Errorf("vpc mask /%v is out of range", vpcMaskLen)
+	}
+	vpcs, err := model.FindVpcsByAks(ctx, accountKeys)
+	if err != nil {
+		return nil, err
+	}
+	used := make([]ipRange, 0, len(vpcs))
+	for _, vpc := range vpcs {
+		if r, err := parseIPRange(vpc.CidrBlock); err == nil {
+			used = append(used, r)
+		}
+	}
+	for _, pool := range privateCidrPools {
+		parent, _ := parseIPRange(pool)
+		vpcRange, ok := allocateIPRange(parent, vpcMaskLen, used)
+		if !ok {
+			continue
+		}
+		subnets, err := allocateSubnets(vpcRange, param.ZoneIds, subnetMaskLen, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &CidrPlan{CidrBlock: formatIPRange(vpcRange), Subnets: subnets}, nil
+	}
+	return nil, fmt.Errorf("no free /%v block in private address space", vpcMaskLen)
+}
+
+func planSubnetsInVpc(ctx context.Context, accountKeys []string, param PlanCidrParam, subnetMaskLen int) (*CidrPlan, error) {
+	vpc, err := model.FindVpcById(ctx, model.FindVpcConditions{VpcId: param.VpcId})
+	if err != nil {
+		return nil, err
+	}
+	if !utils.ContainsString(accountKeys, vpc.AK) {
+		return nil, fmt.Errorf("vpc: [%v] not found", param.VpcId)
+	}
+	vpcRange, err := parseIPRange(vpc.CidrBlock)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cidr block of vpc: [%v], %v", param.VpcId, err)
+	}
+	switches, err := model.FindSwitchesByVpcIds(ctx, []string{vpc.VpcId})
+	if err != nil {
+		return nil, err
+	}
+	used := make([]ipRange, 0, len(switches))
+	for _, s := range switches {
+		if r, err := parseIPRange(s.CidrBlock); err == nil {
+			used = append(used, r)
+		}
+	}
+	subnets, err := allocateSubnets(vpcRange, param.ZoneIds, subnetMaskLen, used)
+	if err != nil {
+		return nil, err
+	}
+	return &CidrPlan{VpcId: vpc.VpcId, CidrBlock: vpc.CidrBlock, Subnets: subnets}, nil
+}
+
+func allocateSubnets(vpcRange ipRange, zoneIds []string, maskLen int, used []ipRange) ([]SubnetPlan, error) {
+	subnets := make([]SubnetPlan, 0, len(zoneIds))
+	for _, zoneId := range zoneIds {
+		r, ok := allocateIPRange(vpcRange, maskLen, used)
+		if !ok {
+			return nil, fmt.Errorf("no free /%v block in %v for zone: %v", maskLen, formatIPRange(vpcRange), zoneId)
+		}
+		used = append(used, r)
+		subnets = append(subnets, SubnetPlan{
+			ZoneId:    zoneId,
+			CidrBlock: formatIPRange(r),
+			GatewayIp: uint32ToIP(r.start + 1).String(),
+			Capacity:  int(r.end-r.start+1) - subnetReservedIPs,
+		})
+	}
+	return subnets, nil
+}
+
+//getSubnetMaskLen 容纳hostCount个IP及保留地址的最小子网, 限制在各云厂商都支持的/16至/28之间
+func getSubnetMaskLen(hostCount int) (int, error) {
+	maskLen := 32 - bits.Len(uint(hostCount+subnetReservedIPs-1))
+	if maskLen > maxSubnetMaskLen {
+		maskLen = maxSubnetMaskLen
+	}
+	if maskLen < minSubnetMaskLen {
+		return 0, fmt.Errorf("host_count %v exceeds the largest subnet /%v", hostCount, minSubnetMaskLen)
+	}
+	return maskLen, nil
+}
+
+//allocateIPRange 在parent中按对齐顺序查找第一个与used都不重叠的maskLen网段
+func allocateIPRange(parent ipRange, maskLen int, used []ipRange) (ipRange, bool) {
+	size := uint64(1) << uint(32-maskLen)
+	if size > uint64(parent.end-parent.start)+1 {
+		return ipRange{}, false
+	}
+	start := (uint64(parent.start) + size - 1) / size * size
+	for start+size-1 <= uint64(parent.end) {
+		candidate := ipRange{start: uint32(start), end: uint32(start + size - 1)}
+		var conflictEnd uint64
+		conflict := false
+		for _, r := range used {
+			if r.start <= candidate.end && candidate.start <= r.end {
+				conflict = true
+				if uint64(r.end) > conflictEnd {
+					conflictEnd = uint64(r.end)
+				}
+			}
+		}
+		if !conflict {
+			return candidate, true
+		}
+		start = (conflictEnd + size) / size * size
+	}
+	return ipRange{}, false
+}
+
+func parseIPRange(cidr string) (ipRange, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return ipRange{}, err
+	}
+	ip := ipNet.IP.To4()
+	if ip == nil {
+		return ipRange{}, fmt.Errorf("not an ipv4 cidr: %v", cidr)
+	}
+	ones, _ := ipNet.Mask.Size()
+	start := binary.BigEndian.Uint32(ip)
+	return ipRange{start: start, end: start | (1<<uint(32-ones) - 1)}, nil
+}
+
+func formatIPRange(r ipRange) string {
+	maskLen := 32 - bits.Len32(r.end-r.start)
+	return fmt.Sprintf("%v/%v", uint32ToIP(r.start), maskLen)
+}
+
+func uint32ToIP(v uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, v)
+	return ip
+}
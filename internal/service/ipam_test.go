@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+)
+
+func mustIPRanges(t *testing.T, cidrs ...string) []ipRange {
+	ranges := make([]ipRange, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		r, err := parseIPRange(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+func TestGetSubnetMaskLen(t *testing.T) {
+	cases := []struct {
+		hostCount int
+		want      int
+		wantErr   bool
+	}{
+		{1, 28, false},
+		{11, 28, false},
+		{12, 27, false},
+		{251, 24, false},
+		{252, 23, false},
+		{65531, 16, false},
+		{65532, 0, true},
+	}
+	for _, c := range cases {
+		got, err := getSubnetMaskLen(c.hostCount)
+		if (err != nil) != c.wantErr || got != c.want {
+			t.Errorf("getSubnetMaskLen(%v) = %v, %v, want %v", c.hostCount, got, err, c.want)
+		}
+	}
+}
+
+func TestAllocateIPRange(t *testing.T) {
+	parent := mustIPRanges(t, "10.0.0.0/8")[0]
+	cases := []struct {
+		maskLen int
+		used    []string
+		want    string
+	}{
+		{16, nil, "10.0.0.0/16"},
+		{16, []string{"10.0.0.0/16", "10.1.0.0/24"}, "10.2.0.0/16"},
+		{16, []string{"10.0.128.0/17", "10.1.0.0/16"}, "10.2.0.0/16"},
+		{16, []string{"172.16.0.0/16", "10.0.0.0/9"}, "10.128.0.0/16"},
+		{24, []string{"10.0.0.0/24", "10.0.1.128/25"}, "10.0.2.0/24"},
+	}
+	for _, c := range cases {
+		got, ok := allocateIPRange(parent, c.maskLen, mustIPRanges(t, c.used...))
+		if !ok || formatIPRange(got) != c.want {
+			t.Errorf("allocateIPRange(/%v, %v) = %v, %v, want %v", c.maskLen, c.used, formatIPRange(got), ok, c.want)
+		}
+	}
+	if _, ok := allocateIPRange(parent, 16, mustIPRanges(t, "0.0.0.0/0")); ok {
+		t.Errorf("allocateIPRange should fail when parent is fully used")
+	}
+	if _, ok := allocateIPRange(mustIPRanges(t, "192.168.0.0/16")[0], 12, nil); ok {
+		t.Errorf("allocateIPRange should fail when block is larger than parent")
+	}
+}
+
+func TestAllocateSubnets(t *testing.T) {
+	vpc := mustIPRanges(t, "10.1.0.0/16")[0]
+	subnets, err := allocateSubnets(vpc, []string{"z1", "z2"}, 24, mustIPRanges(t, "10.1.0.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnets[0].CidrBlock != "10.1.1.0/24" || subnets[0].GatewayIp != "10.1.1.1" || subnets[0].Capacity != 251 {
+		t.Errorf("subnet z1 = %+v", subnets[0])
+	}
+	if subnets[1].CidrBlock != "10.1.2.0/24" {
+		t.Errorf("subnet z2 = %+v", subnets[1])
+	}
+	if _, err = allocateSubnets(mustIPRanges(t, "10.1.0.0/23")[0], []string{"z1", "z2", "z3"}, 24, nil); err == nil {
+		t.Errorf("allocateSubnets should fail when vpc is full")
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const jobLastErrorMaxLen = 1024
+
+//Q 当前进程的任务队列, 未初始化时提交的任务只会持久化, 由其他实例执行
+var Q *JobQueue
+
+//JobPayload 后台任务的参数, 各任务类型只使用其中部分字段
+type JobPayload struct {
+	Provider   string `json:"provider"`
+	AccountKey string `json:"account_key"`
+	RegionId   string `json:"region_id,omitempty"`
+	VpcId      string `json:"vpc_id,omitempty"`
+	VpcName    string `json:"vpc_name,omitempty"`
+	SwitchId   string `json:"switch_id,omitempty"`
+}
+
+type jobHandler func(ctx context.Context, payload JobPayload) error
+
+var jobHandlers = map[string]jobHandler{
+	constants.JobTypeVpcRefresh:       refreshVpc,
+	constants.JobTypeSwitchRefresh:    refreshSwitch,
+	constants.JobTypeAccountRefresh:   RefreshAccount,
+	constants.JobTypeInstanceTypeSync: refreshInstanceType,
+}
+
+//JobQueue 定期从background_job表领取到期的任务交给worker执行, 失败后按指数退避重试
+type JobQueue struct {
+	worker      string
+	workerCount int
+	jobs        chan model.BackgroundJob
+	wake        chan struct{}
+}
+
+func Init(workerCount int) {
+	hostname, _ := os.Hostname()
+	Q = &JobQueue{
+		worker:      fmt.Sprintf("%v-%v", hostname, os.Getpid()),
+		workerCount: workerCount,
+		jobs:        make(chan model.BackgroundJob),
+		wake:        make(chan struct{}, 1),
+	}
+	for i := 0; i < workerCount; i++ {
+		go Q.work()
+	}
+	go Q.poll()
+}
+
+//SubmitJob 持久化任务并唤醒任务队列, maxAttempts为最多执行次数
+func SubmitJob(ctx context.Context, orgId int64, jobType string, payload JobPayload, maxAttempts int) (int64, error) {
+	if _, ok := jobHandlers[jobType]; !ok {
+		return 0, fmt.Errorf("unsupported job type: %v", jobType)
+	}
+	content, err := jsoniter.MarshalToString(payload)
+	if err != nil {
+		return 0, err
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	now := time.Now()
+	job := &model.BackgroundJob{
+		Base:        model.Base{CreateAt: &now, UpdateAt: &now},
+		OrgId:       orgId,
+		JobType:     jobType,
+		Payload:     content,
+		Status:      constants.JobStatusPending,
+		MaxAttempts: maxAttempts,
+		NextRunAt:   &now,
+	}
+	if err = model.Create(job); err != nil {
+		return 0, err
+	}
+	if Q != nil {
+		Q.notify()
+	}
+	return job.Id, nil
+}
+
+func ListJobs(ctx context.Context, cond model.BackgroundJobSearchCond) ([]model.BackgroundJob, int64, error) {
+	return model.ListBackgroundJobs(ctx, cond)
+}
+
+func GetJob(ctx context.Context, orgId, id int64) (*model.BackgroundJob, error) {
+	job, err := model.GetBackgroundJob(ctx, orgId, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("job: [%v] not found", id)
+	}
+	return job, nil
+}
+
+//RetryJob 重新执行失败的任务, 执行次数重新计算
+func RetryJob(ctx context.Context, orgId, id int64) error {
+	ok, err := model.RetryBackgroundJob(ctx, orgId, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("only failed jobs can be retried")
+	}
+	if Q != nil {
+		Q.notify()
+	}
+	return nil
+}
+
+func (q *JobQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *JobQueue) poll() {
+	ticker := time.NewTicker(constants.JobPollInterval)
+	defer ticker.Stop()
+	var lastCleanAt time.Time
+	for {
+		select {
+		case <-ticker.C:
+		case <-q.wake:
+		}
+		ctx := context.Background()
+		if time.Since(lastCleanAt) > time.Minute {
+			lastCleanAt = time.Now()
+			q.clean(ctx)
+		}
+		jobs, err := model.GetDueBackgroundJobs(ctx, q.workerCount)
+		if err != nil {
+			continue
+		}
+		//由空闲的worker领取任务, 领取时记录start_at, 排队等待的时间不计入执行超时
+		for _, job := range jobs {
+			q.jobs <- job
+		}
+	}
+}
+
+//clean 放回执行超时的任务, 并清理过期的成功任务
+func (q *JobQueue) clean(ctx context.Context) {
+	if n, err := model.RequeueStaleBackgroundJobs(ctx, time.Now().Add(-constants.JobRunningTimeout)); err == nil && n > 0 {
+		logs.Logger.Warnf("requeued %v background jobs running longer than %v", n, constants.JobRunningTimeout)
+	}
+	_ = model.DeleteBackgroundJobsBefore(ctx, time.Now().Add(-constants.JobRetention))
+}
+
+func (q *JobQueue) work() {
+	for job := range q.jobs {
+		ok, err := model.ClaimBackgroundJob(context.Background(), job.Id, job.Attempts, q.worker)
+		if err != nil || !ok {
+			continue
+		}
+		job.Attempts++
+		ctx, cancel := context.WithTimeout(context.Background(), constants.JobExecuteTimeout)
+		err = runJob(ctx, &job)
+		cancel()
+		now := time.Now()
+		updates := map[string]interface{}{"end_at": now, "update_at": now}
+		switch {
+		case err == nil:
+			updates["status"] = constants.JobStatusSuccess
+			updates["last_error"] = ""
+		case job.Attempts >= job.MaxAttempts:
+			updates["status"] = constants.JobStatusFailed
+			updates["last_error"] = truncateString(err.Error(), jobLastErrorMaxLen)
+		default:
+			nextRunAt := now.Add(jobRetryDelay(job.Attempts))
+			updates["status"] = constants.JobStatusPending
+			updates["next_run_at"] = &nextRunAt
+			updates["last_error"] = truncateString(err.Error(), jobLastErrorMaxLen)
+		}
+		if err != nil {
+			logs.Logger.Errorf("background job:%v %v attempt %v/%v failed, err: %v", job.Id, job.JobType, job.Attempts, job.MaxAttempts, err)
+		}
+		ok, err = model.FinishBackgroundJob(context.Background(), job.Id, q.worker, job.Attempts, updates)
+		if err == nil && !ok {
+			logs.Logger.Warnf("background job:%v %v attempt %v was requeued before finishing, result dropped", job.Id, job.JobType, job.Attempts)
+		}
+	}
+}
+
+func runJob(ctx context.Context, job *model.BackgroundJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panic: %v", r)
+		}
+	}()
+	handler, ok := jobHandlers[job.JobType]
+	if !ok {
+		return fmt.Errorf("unsupported job type: %v", job.JobType)
+	}
+	payload := JobPayload{}
+	if err = jsoniter.UnmarshalFromString(job.Payload, &payload); err != nil {
+		return err
+	}
+	return handler(ctx, payload)
+}
+
+//jobRetryDelay 第n次执行失败后的等待时间, 按指数退避
+func jobRetryDelay(n int) time.Duration {
+	if n < 1 {
+		n = 1
+	}
+	if n > 16 {
+		return constants.JobRetryMaxDelay
+	}
+	delay := constants.JobRetryBaseDelay * time.Duration(1<<uint(n-1))
+	if delay > constants.JobRetryMaxDelay {
+		delay = constants.JobRetryMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestJobRetryDelay(t *testing.T) {
+	cases := []struct {
+		n    int
+		want time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 30 * time.Minute},
+		{64, 30 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := jobRetryDelay(c.n); got != c.want {
+			t.Errorf("jobRetryDelay(%v) = %v, want %v", c.n, got, c.want)
+		}
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	if err := runJob(context.Background(), &model.BackgroundJob{JobType: "UNKNOWN"}); err == nil {
+		t.Errorf("runJob should fail with unknown job type")
+	}
+	job := &model.BackgroundJob{JobType: constants.JobTypeSwitchRefresh, Payload: `{"provider":"AlibabaCloud"}`}
+	if err := runJob(context.Background(), job); err != nil {
+		t.Errorf("runJob() without switch id error = %v", err)
+	}
+	job.Payload = "{"
+	if err := runJob(context.Background(), job); err == nil {
+		t.Errorf("runJob should fail with invalid payload")
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/bcc"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//publishMembership 按DB中的活跃实例重建集群成员文档, 基于配置中心的版本比较写入, 版本冲突时重新读取后重试.
+//成员列表总是取自DB而不是在旧文档上增减, 并发的任务最终发布的都是最新的成员
+func publishMembership(clusterName string) error {
+	for i := 0; i < constants.MembershipPublishRetry; i++ {
+		content, version, err := bcc.GetConfigWithVersion(clusterName, constants.Members)
+		if err != nil {
+			return err
+		}
+		var revision int64
+		if content != "" {
+			prev := types.ClusterMembership{}
+			if err = jsoniter.UnmarshalFromString(content, &prev); err == nil {
+				revision = prev.Revision
+			}
+		}
+		instances, err := model.GetActiveInstancesByClusterName(clusterName)
+		if err != nil {
+			return err
+		}
+		doc, err := jsoniter.MarshalToString(buildClusterMembership(clusterName, instances, revision+1, time.Now()))
+		if err != nil {
+			return err
+		}
+		err = bcc.CompareAndPublishConfig(clusterName, constants.Members, doc, version)
+		if !errors.Is(err, bcc.ErrVersionConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("publish membership of cluster:%v failed after %v retries: %w", clusterName, constants.MembershipPublishRetry, bcc.ErrVersionConflict)
+}
+
+func buildClusterMembership(clusterName string, instances []model.Instance, revision int64, now time.Time) *types.ClusterMembership {
+	members := make([]types.ClusterMember, 0, len(instances))
+	for _, instance := range instances {
+		members = append(members, types.ClusterMember{
+			InstanceId: instance.InstanceId,
+			IpInner:    instance.IpInner,
+			IpOuter:    instance.IpOuter,
+			ZoneId:     instance.ZoneId,
+			Status:     string(instance.Status),
+			Health:     instance.HealthStatus,
+		})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].InstanceId < members[j].InstanceId
+	})
+	return &types.ClusterMembership{
+		ClusterName: clusterName,
+		Revision:    revision,
+		UpdateAt:    now,
+		Members:     members,
+	}
+}
+
+//GetClusterMembership 获取配置中心中的集群成员文档, 未发布(NeedPublishConfig为false)时按DB中的实例生成, Revision为成员列表的哈希
+func GetClusterMembership(ctx context.Context, clusterName string) (*types.ClusterMembership, error) {
+	content, err := bcc.GetConfig(clusterName, constants.Members)
+	if err != nil {
+		return nil, err
+	}
+	if content != "" {
+		membership := &types.ClusterMembership{}
+		if err = jsoniter.UnmarshalFromString(content, membership); err != nil {
+			return nil, err
+		}
+		return membership, nil
+	}
+	if _, err = model.GetByClusterName(clusterName); err != nil {
+		return nil, fmt.Errorf(constants.ErrClusterNotExist, clusterName)
+	}
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	membership := buildClusterMembership(clusterName, instances, 0, time.Now())
+	membership.Revision = membersRevision(membership.Members)
+	return membership, nil
+}
+
+//membersRevision 未发布成员文档时以排序后成员列表的哈希作为Revision, 成员变化时Revision随之变化, 不会为0
+func membersRevision(members []types.ClusterMember) int64 {
+	content, _ := jsoniter.Marshal(members)
+	h := fnv.New64a()
+	_, _ = h.Write(content)
+	return int64(h.Sum64()&math.MaxInt64) | 1
+}
+
+//WatchClusterMembership 长轮询集群成员, 成员文档的Revision与revision不同时立即返回, 否则等待变化直到超时后返回当前文档
+func WatchClusterMembership(ctx context.Context, clusterName string, revision int64, timeout time.Duration) (*types.ClusterMembership, error) {
+	if timeout <= 0 {
+		timeout = constants.DefaultMembershipWatchTimeout
+	}
+	if timeout > constants.MaxMembershipWatchTimeout {
+		timeout = constants.MaxMembershipWatchTimeout
+	}
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(constants.MembershipWatchPollInterval)
+	defer ticker.Stop()
+	for {
+		membership, err := GetClusterMembership(ctx, clusterName)
+		if err != nil {
+			return nil, err
+		}
+		//Revision变小说明配置中心被重置, 同样视为变化
+		if membership.Revision != revision {
+			return membership, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-deadline.C:
+			return membership, nil
+		case <-ticker.C:
+		}
+	}
+}
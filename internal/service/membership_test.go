@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestBuildClusterMembership(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "i-2", IpInner: "10.0.0.2", ZoneId: "cn-beijing-h", Status: constants.Running, HealthStatus: constants.InstanceUnhealthy},
+		{InstanceId: "i-1", IpInner: "10.0.0.1", IpOuter: "1.1.1.1", ZoneId: "cn-beijing-g", Status: constants.Running, HealthStatus: constants.InstanceHealthy},
+	}
+	now := time.Now()
+	membership := buildClusterMembership("c1", instances, 3, now)
+	if membership.ClusterName != "c1" || membership.Revision != 3 || !membership.UpdateAt.Equal(now) {
+		t.Errorf("buildClusterMembership = %+v", membership)
+	}
+	if len(membership.Members) != 2 || membership.Members[0].InstanceId != "i-1" {
+		t.Fatalf("members should be sorted by instance id: %+v", membership.Members)
+	}
+	member := membership.Members[0]
+	if member.IpOuter != "1.1.1.1" || member.ZoneId != "cn-beijing-g" || member.Status != string(constants.Running) || member.Health != constants.InstanceHealthy {
+		t.Errorf("member = %+v", member)
+	}
+}
+
+func TestMembersRevision(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "i-1", IpInner: "10.0.0.1", Status: constants.Running},
+		{InstanceId: "i-2", IpInner: "10.0.0.2", Status: constants.Running},
+	}
+	a := membersRevision(buildClusterMembership("c1", instances, 0, time.Now()).Members)
+	b := membersRevision(buildClusterMembership("c1", []model.Instance{instances[1], instances[0]}, 0, time.Now()).Members)
+	if a == 0 || a != b {
+		t.Errorf("revision should be non-zero and independent of instance order: %v, %v", a, b)
+	}
+	instances[1].HealthStatus = constants.InstanceUnhealthy
+	if c := membersRevision(buildClusterMembership("c1", instances, 0, time.Now()).Members); c == a {
+		t.Errorf("revision should change with members")
+	}
+	if c := membersRevision(buildClusterMembership("c1", instances[:1], 0, time.Now()).Members); c == a {
+		t.Errorf("revision should change when a member is removed")
+	}
+}
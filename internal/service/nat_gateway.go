@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/errs"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type CreateNatGatewayRequest struct {
+	AK            string
+	VpcId         string
+	SwitchId      string
+	Name          string
+	Bandwidth     int
+	SnatSwitchIds []string
+}
+
+type CreateSnatEntryRequest struct {
+	AK           string
+	NatGatewayId string
+	SwitchId     string
+	SnatIp       string
+	Name         string
+}
+
+type DescribeNatGatewaysRequest struct {
+	AK       string
+	Provider string
+	RegionId string
+	VpcId    string
+}
+
+type NatGateway struct {
+	NatGatewayId string      `json:"nat_gateway_id"`
+	Name         string      `json:"name"`
+	Provider     string      `json:"provider"`
+	RegionId     string      `json:"region_id"`
+	VpcId        string      `json:"vpc_id"`
+	SwitchId     string      `json:"switch_id"`
+	SnatTableId  string      `json:"snat_table_id"`
+	IpAddresses  []string    `json:"ip_addresses"`
+	Status       string      `json:"status"`
+	SnatEntries  []SnatEntry `json:"snat_entries"`
+	CreateAt     string      `json:"create_at"`
+}
+
+type SnatEntry struct {
+	SnatEntryId string `json:"snat_entry_id"`
+	Name        string `json:"name"`
+	SwitchId    string `json:"switch_id"`
+	SourceCidr  string `json:"source_cidr"`
+	SnatIp      string `json:"snat_ip"`
+	Status      string `json:"status"`
+}
+
+//CreateNatGateway 创建NAT网关并绑定公网IP, 同时为SnatSwitchIds中的子网添加SNAT条目
+func CreateNatGateway(ctx context.Context, req CreateNatGatewayRequest) (NatGateway, error) {
+	vpc, err := findVpc(ctx, req.VpcId)
+	if err != nil {
+		return NatGateway{}, err
+	}
+	if vpc.AK != req.AK {
+		return NatGateway{}, errs.ErrVpcNotExist
+	}
+	if _, err = model.FindSwitchById(ctx, vpc.VpcId, req.SwitchId); err != nil {
+		return NatGateway{}, fmt.Errorf("switch %v not found in vpc %v", req.SwitchId, vpc.VpcId)
+	}
+	manager, err := getNatGatewayManager(vpc.Provider, req.AK, vpc.RegionId)
+	if err != nil {
+		return NatGateway{}, err
+	}
+	res, err := manager.CreateNatGateway(cloud.CreateNatGatewayRequest{
+		RegionId:  vpc.RegionId,
+		VpcId:     vpc.VpcId,
+		SwitchId:  req.SwitchId,
+		Name:      req.Name,
+		Bandwidth: req.Bandwidth,
+	})
+	if err != nil {
+		if res.NatGatewayId != "" {
+			return NatGateway{}, fmt.Errorf("nat gateway %v created but not ready: %w", res.NatGatewayId, err)
+		}
+		return NatGateway{}, err
+	}
+	now := time.Now()
+	gateway := model.NatGateway{
+		Base:         model.Base{CreateAt: &now, UpdateAt: &now},
+		AK:           req.AK,
+		Provider:     vpc.Provider,
+		RegionId:     vpc.RegionId,
+		VpcId:        vpc.VpcId,
+		SwitchId:     req.SwitchId,
+		NatGatewayId: res.NatGatewayId,
+		Name:         req.Name,
+		SnatTableId:  res.SnatTableId,
+		IpAddresses:  res.EipAddress,
+		VStatus:      cloud.NatGatewayAvailable,
+	}
+	if err = model.UpsertNatGateways(ctx, []model.NatGateway{gateway}); err != nil {
+		logs.Logger.Errorf("save nat gateway failed: %v, error: %v", res, err)
+		return NatGateway{}, err
+	}
+	entries := make([]model.SnatEntry, 0, len(req.SnatSwitchIds))
+	for _, switchId := range req.SnatSwitchIds {
+		entry, err := createSnatEntry(ctx, manager, gateway, switchId, res.EipAddress, "")
+		if err != nil {
+			return model2NatGateway(gateway, entries), err
+		}
+		entries = append(entries, entry)
+	}
+	return model2NatGateway(gateway, entries), nil
+}
+
+//CreateSnatEntry SnatIp为空时使用网关的第一个公网IP
+func CreateSnatEntry(ctx context.Context, req CreateSnatEntryRequest) (SnatEntry, error) {
+	gateway, err := model.FindNatGatewayById(ctx, req.NatGatewayId)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && gateway.AK != req.AK) {
+		return SnatEntry{}, errs.ErrNatGatewayNotExist
+	}
+	if err != nil {
+		return SnatEntry{}, errs.ErrDBQueryFailed
+	}
+	manager, err := getNatGatewayManager(gateway.Provider, gateway.AK, gateway.RegionId)
+	if err != nil {
+		return SnatEntry{}, err
+	}
+	snatIp := req.SnatIp
+	if snatIp == "" {
+		snatIp = strings.Split(gateway.IpAddresses, ",")[0]
+	}
+	entry, err := createSnatEntry(ctx, manager, gateway, req.SwitchId, snatIp, req.Name)
+	if err != nil {
+		return SnatEntry{}, err
+	}
+	return model2SnatEntry(entry), nil
+}
+
+func createSnatEntry(ctx context.Context, manager cloud.NatGatewayManager, gateway model.NatGateway, switchId, snatIp, name string) (model.SnatEntry, error) {
+	s, err := model.FindSwitchById(ctx, gateway.VpcId, switchId)
+	if err != nil {
+		return model.SnatEntry{}, fmt.Errorf("switch %v not found in vpc %v", switchId, gateway.VpcId)
+	}
+	if snatIp == "" {
+		return model.SnatEntry{}, fmt.Errorf("nat gateway %v has no public ip", gateway.NatGatewayId)
+	}
+	res, err := manager.CreateSnatEntry(cloud.CreateSnatEntryRequest{
+		RegionId:    gateway.RegionId,
+		SnatTableId: gateway.SnatTableId,
+		SwitchId:    switchId,
+		SnatIp:      snatIp,
+		Name:        name,
+	})
+	if err != nil {
+		return model.SnatEntry{}, err
+	}
+	now := time.Now()
+	entry := model.SnatEntry{
+		Base:         model.Base{CreateAt: &now, UpdateAt: &now},
+		NatGatewayId: gateway.NatGatewayId,
+		SnatTableId:  gateway.SnatTableId,
+		SnatEntryId:  res.SnatEntryId,
+		Name:         name,
+		SwitchId:     switchId,
+		SourceCidr:   s.CidrBlock,
+		SnatIp:       snatIp,
+	}
+	if err = model.UpsertSnatEntries(ctx, []model.SnatEntry{entry}); err != nil {
+		logs.Logger.Errorf("save snat entry failed: %v, error: %v", res, err)
+		return model.SnatEntry{}, err
+	}
+	return entry, nil
+}
+
+func DescribeNatGateways(ctx context.Context, req DescribeNatGatewaysRequest) ([]NatGateway, error) {
+	gateways, err := model.FindNatGatewaysByRegion(ctx, req.AK, req.Provider, req.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(gateways))
+	for _, gateway := range gateways {
+		ids = append(ids, gateway.NatGatewayId)
+	}
+	entries := make(map[string][]model.SnatEntry, len(gateways))
+	if len(ids) > 0 {
+		snatEntries, err := model.FindSnatEntriesByNatGatewayIds(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range snatEntries {
+			entries[entry.NatGatewayId] = append(entries[entry.NatGatewayId], entry)
+		}
+	}
+	ret := make([]NatGateway, 0, len(gateways))
+	for _, gateway := range gateways {
+		if req.VpcId != "" && gateway.VpcId != req.VpcId {
+			continue
+		}
+		ret = append(ret, model2NatGateway(gateway, entries[gateway.NatGatewayId]))
+	}
+	return ret, nil
+}
+
+//DeleteNatGateway 删除网关时一并删除其SNAT条目
+func DeleteNatGateway(ctx context.Context, aks []string, natGatewayId string) error {
+	gateway, err := model.FindNatGatewayById(ctx, natGatewayId)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !utils.ContainsString(aks, gateway.AK)) {
+		return errs.ErrNatGatewayNotExist
+	}
+	if err != nil {
+		return errs.ErrDBQueryFailed
+	}
+	manager, err := getNatGatewayManager(gateway.Provider, gateway.AK, gateway.RegionId)
+	if err != nil {
+		return err
+	}
+	err = manager.DeleteNatGateway(cloud.DeleteNatGatewayRequest{RegionId: gateway.RegionId, NatGatewayId: natGatewayId})
+	if err != nil {
+		return err
+	}
+	return model.SoftDeleteNatGateways(ctx, gateway.AK, gateway.Provider, gateway.RegionId, []string{natGatewayId})
+}
+
+func DeleteSnatEntry(ctx context.Context, aks []string, snatEntryId string) error {
+	entry, err := model.FindSnatEntryById(ctx, snatEntryId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("snat entry %v not found", snatEntryId)
+	}
+	if err != nil {
+		return errs.ErrDBQueryFailed
+	}
+	gateway, err := model.FindNatGatewayById(ctx, entry.NatGatewayId)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !utils.ContainsString(aks, gateway.AK)) {
+		return errs.ErrNatGatewayNotExist
+	}
+	if err != nil {
+		return errs.ErrDBQueryFailed
+	}
+	manager, err := getNatGatewayManager(gateway.Provider, gateway.AK, gateway.RegionId)
+	if err != nil {
+		return err
+	}
+	err = manager.DeleteSnatEntry(cloud.DeleteSnatEntryRequest{
+		RegionId:    gateway.RegionId,
+		SnatTableId: entry.SnatTableId,
+		SnatEntryId: snatEntryId,
+	})
+	if err != nil {
+		return err
+	}
+	return model.SoftDeleteSnatEntries(ctx, []string{snatEntryId})
+}
+
+func getNatGatewayManager(provider, ak, regionId string) (cloud.NatGatewayManager, error) {
+	p, err := getProvider(provider, ak, regionId)
+	if err != nil {
+		return nil, err
+	}
+	manager, ok := p.(cloud.NatGatewayManager)
+	if !ok {
+		return nil, fmt.Errorf("provider %v does not support nat gateway", provider)
+	}
+	return manager, nil
+}
+
+//findVpc 获取未删除的VPC, 不存在时返回ErrVpcNotExist
+func findVpc(ctx context.Context, vpcId string) (model.Vpc, error) {
+	vpc, err := model.FindVpcById(ctx, model.FindVpcConditions{VpcId: vpcId})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Vpc{}, errs.ErrVpcNotExist
+	}
+	if err != nil {
+		logs.Logger.Errorf("FindVpcById failed.err: [%v] vpc[%v]", err, vpcId)
+		return model.Vpc{}, errs.ErrDBQueryFailed
+	}
+	return vpc, nil
+}
+
+func model2NatGateway(gateway model.NatGateway, entries []model.SnatEntry) NatGateway {
+	ret := NatGateway{
+		NatGatewayId: gateway.NatGatewayId,
+		Name:         gateway.Name,
+		Provider:     gateway.Provider,
+		RegionId:     gateway.RegionId,
+		VpcId:        gateway.VpcId,
+		SwitchId:     gateway.SwitchId,
+		SnatTableId:  gateway.SnatTableId,
+		IpAddresses:  make([]string, 0),
+		Status:       gateway.VStatus,
+		SnatEntries:  make([]SnatEntry, 0, len(entries)),
+	}
+	if gateway.IpAddresses != "" {
+		ret.IpAddresses = strings.Split(gateway.IpAddresses, ",")
+	}
+	if gateway.CreateAt != nil {
+		ret.CreateAt = utils.FormatTime(*gateway.CreateAt)
+	}
+	for _, entry := range entries {
+		ret.SnatEntries = append(ret.SnatEntries, model2SnatEntry(entry))
+	}
+	return ret
+}
+
+func model2SnatEntry(entry model.SnatEntry) SnatEntry {
+	return SnatEntry{
+		SnatEntryId: entry.SnatEntryId,
+		Name:        entry.Name,
+		SwitchId:    entry.SwitchId,
+		SourceCidr:  entry.SourceCidr,
+		SnatIp:      entry.SnatIp,
+		Status:      entry.VStatus,
+	}
+}
+
+//cloud2ModelNatGateways 公网IP排序后保存, 避免顺序变化被当作变更
+func cloud2ModelNatGateways(gateways []cloud.NatGateway, ak, provider string) []model.NatGateway {
+	now := time.Now()
+	ret := make([]model.NatGateway, 0, len(gateways))
+	for _, gateway := range gateways {
+		ips := append([]string{}, gateway.IpAddresses...)
+		sort.Strings(ips)
+		ret = append(ret, model.NatGateway{
+			Base:         model.Base{CreateAt: &now, UpdateAt: &now},
+			AK:           ak,
+			Provider:     provider,
+			RegionId:     gateway.RegionId,
+			VpcId:        gateway.VpcId,
+			SwitchId:     gateway.SwitchId,
+			NatGatewayId: gateway.NatGatewayId,
+			Name:         gateway.Name,
+			SnatTableId:  gateway.SnatTableId,
+			IpAddresses:  strings.Join(ips, ","),
+			VStatus:      gateway.Status,
+		})
+	}
+	return ret
+}
+
+func cloud2ModelSnatEntries(entries []cloud.SnatEntry, natGatewayId string) []model.SnatEntry {
+	now := time.Now()
+	ret := make([]model.SnatEntry, 0, len(entries))
+	for _, entry := range entries {
+		ret = append(ret, model.SnatEntry{
+			Base:         model.Base{CreateAt: &now, UpdateAt: &now},
+			NatGatewayId: natGatewayId,
+			SnatTableId:  entry.SnatTableId,
+			SnatEntryId:  entry.SnatEntryId,
+			Name:         entry.Name,
+			SwitchId:     entry.SwitchId,
+			SourceCidr:   entry.SourceCidr,
+			SnatIp:       entry.SnatIp,
+			VStatus:      entry.Status,
+		})
+	}
+	return ret
+}
@@ -3,13 +3,12 @@ package service
 import (
 	"context"
 	"fmt"
-	"sync"
-	"sync/atomic"
 	"time"
 
 	"github.com/Rican7/retry"
 	"github.com/Rican7/retry/backoff"
 	"github.com/Rican7/retry/strategy"
+	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/errs"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/model"
@@ -18,16 +17,7 @@ import (
 	"github.com/spf13/cast"
 )
 
-type targetType int
-
 const (
-	TargetTypeVpc targetType = iota
-	TargetTypeSwitch
-	TargetTypeSecurityGroup
-	TargetTypeNetwork
-	TargetTypeAccount
-	TargetTypeInstanceType
-
 	DefaultRegion        = "cn-qingdao"
 	DefaultRegionHuaWei  = "cn-north-4"
 	DefaultRegionTencent = "ap-beijing"
@@ -35,219 +25,39 @@ const (
 	DefaultRegionAws     = "cn-north-1"
 )
 
-var H *SimpleTaskHandler
-
-type SimpleTask struct {
-	VpcId        string
-	VpcName      string
-	RegionId     string
-	Provider     cloud.Provider
-	ProviderName string
-	SwitchId     string
-	AccountKey   string
-	TargetType   targetType
-	Retry        int
-}
-
-type SimpleTaskHandler struct {
-	Tasks    chan *SimpleTask
-	capacity int
-	running  int32
-	failed   []*SimpleTask
-	lock     sync.Mutex
-}
-
-func Init(workerCount int) {
-	H = &SimpleTaskHandler{make(chan *SimpleTask, workerCount), workerCount, 0, make([]*SimpleTask, 0, 1000), sync.Mutex{}}
-	H.run()
-	//RefreshCache()
-}
-
-func (s *SimpleTaskHandler) SubmitTask(t *SimpleTask) {
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logs.Logger.Errorf("SubmitTask recover : %v", r)
-			}
-		}()
-		select {
-		case s.Tasks <- t:
-			fmt.Printf("有任务啦 %v", t)
-			s.run()
-		case <-time.After(5 * time.Minute):
-			s.lock.Lock()
-			s.failed = append(s.failed, t)
-			s.lock.Unlock()
-		}
-	}()
-}
-
-func (s *SimpleTaskHandler) run() {
-	if atomic.LoadInt32(&s.running) >= int32(s.capacity) {
-		return
-	}
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logs.Logger.Errorf("SimpleTaskHandler recover : %v", r)
-			}
-		}()
-		atomic.AddInt32(&s.running, 1)
-		for {
-			var t *SimpleTask
-			select {
-			case <-time.After(1 * time.Hour):
-				s.lock.Lock()
-				if len(s.failed) > 0 {
-					t = s.failed[0]
-					s.failed = s.failed[1:]
-				}
-				s.lock.Unlock()
-			case t = <-s.Tasks:
-			}
-			if t != nil {
-				s.taskHandle(t)
-			}
-		}
-	}()
-}
-
-func (s *SimpleTaskHandler) taskHandle(t *SimpleTask) {
-	var err error
-	switch t.TargetType {
-	case TargetTypeVpc:
-		err = refreshVpc(t)
-	case TargetTypeSwitch:
-		err = refreshSwitch(t)
-	//case TargetTypeSecurityGroup:
-	//	err = refreshVpc(t)
-	case TargetTypeNetwork:
-		err = refreshVpc(t)
-		if err != nil {
-			break
-		}
-		err = refreshSwitch(t)
-	case TargetTypeAccount:
-		err = RefreshAccount(t)
-		//case TargetTypeInstanceType:
-		//	err = refreshInstanceType(t)
-	}
-	if err == nil {
-		return
-	}
-	logs.Logger.Errorf("taskHandle failed,task: [%v] err: [%v]", t, err)
-	if t.Retry > 0 {
-		t.Retry--
-		s.SubmitTask(t)
-	}
-}
-
-func refreshInstanceType(t *SimpleTask) error {
-	err := SyncInstanceTypes(context.Background(), t.ProviderName)
+func refreshInstanceType(ctx context.Context, payload JobPayload) error {
+	err := SyncInstanceTypes(ctx, payload.Provider)
 	if err != nil {
-		logs.Logger.Error("SyncInstanceTypes failed :%v", err)
+		logs.Logger.Errorf("SyncInstanceTypes failed :%v", err)
 		return err
 	}
 	return RefreshCache()
 }
 
-func RefreshAccount(t *SimpleTask) error {
-	if t.AccountKey == "" {
+func RefreshAccount(ctx context.Context, payload JobPayload) error {
+	if payload.AccountKey == "" {
 		return nil
 	}
-	ctx := context.Background()
-	accounts, err := GetOrgKeysByAk(ctx, t.AccountKey)
-	regions, err := GetRegions(ctx, GetRegionsRequest{
-		Provider: t.ProviderName,
-		Account:  accounts,
-	})
-	if err != nil {
-		return err
-	}
-
-	regionIds := make([]string, 0, len(regions))
-	for _, region := range regions {
-		regionIds = append(regionIds, region.RegionId)
-	}
-	err = syncNetworkConfig(ctx, regionIds, t.ProviderName, t.AccountKey)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func syncNetworkConfig(ctx context.Context, regionIds []string, provider, ak string) error {
-	vpcs, err := updateOrCreateVpcs(ctx, regionIds, provider, ak)
-	if err != nil {
-		return err
-	}
-
-	var groups []cloud.SecurityGroup
-	var wg sync.WaitGroup
-	wg.Add(2)
-	go func() {
-		updateOrCreateSwitch(ctx, vpcs, provider, ak)
-		wg.Done()
-	}()
-	go func() {
-		groups, err = updateOrCreateSecurityGroups(ctx, regionIds, vpcs, provider, ak)
-		wg.Done()
-	}()
-	wg.Wait()
-
+	regionIds, err := getAccountRegionIds(ctx, payload.Provider, payload.AccountKey)
 	if err != nil {
 		return err
 	}
-	go updateOrCreateSecurityGroupRules(ctx, groups, provider, ak)
-	return nil
+	return syncNetworkConfig(ctx, regionIds, payload.Provider, payload.AccountKey, constants.NetworkSyncTriggerAccount)
 }
 
-func updateOrCreateVpcs(ctx context.Context, regionIds []string, provider, ak string) ([]cloud.VPC, error) {
-	vpcs := make([]cloud.VPC, 0, 64)
-	describeVpcReq := cloud.DescribeVpcsRequest{}
+//syncNetworkConfig 逐个地域同步并记录结果, 返回第一个失败地域的错误
+func syncNetworkConfig(ctx context.Context, regionIds []string, provider, ak, triggerType string) error {
+	var firstErr error
 	for _, regionId := range regionIds {
-		describeVpcReq.RegionId = regionId
-		cloudCli, err := getProvider(provider, ak, regionId)
-		if err != nil {
-			logs.Logger.Errorf("getProvider failed: %s", err.Error())
-			continue
-		}
-		vpcsRes, err := cloudCli.DescribeVpcs(describeVpcReq)
-		if err != nil {
-			logs.Logger.Errorf("DescribeVpcs failed: %s", err.Error())
-			continue
-		}
-		vpcs = append(vpcs, vpcsRes.Vpcs...)
-	}
-	vpcModels := cloud2ModelVpc(vpcs, ak, provider)
-	err := model.UpdateOrCreateVpcs(ctx, ak, provider, regionIds, vpcModels)
-	return vpcs, err
-}
-
-func updateOrCreateSwitch(ctx context.Context, vpcs []cloud.VPC, provider, ak string) {
-	vpcIds := make([]string, 0, len(vpcs))
-	switches := make([]cloud.Switch, 0, 64)
-	describeSwitchesReq := cloud.DescribeSwitchesRequest{}
-	for _, vpc := range vpcs {
-		vpcIds = append(vpcIds, vpc.VpcId)
-		describeSwitchesReq.VpcId = vpc.VpcId
-		cloudCli, err := getProvider(provider, ak, vpc.RegionId)
-		if err != nil {
-			logs.Logger.Errorf("getProvider failed.err: %s", err.Error())
-			continue
+		record, err := startNetworkSync(ctx, ak, provider, regionId, triggerType)
+		if err == nil {
+			err = finishNetworkSync(ctx, record)
 		}
-		switchesRes, err := cloudCli.DescribeSwitches(describeSwitchesReq)
-		if err != nil {
-			logs.Logger.Errorf("DescribeSwitches failed: %s", err.Error())
-			continue
+		if err != nil && firstErr == nil {
+			firstErr = err
 		}
-		switches = append(switches, switchesRes.Switches...)
-	}
-	switchesModels := cloud2ModelSwitches(switches)
-	err := model.UpdateOrCreateSwitches(ctx, vpcIds, switchesModels)
-	if err != nil {
-		logs.Logger.Errorf("updateOrCreateSwitch failed.err : [%s]", err.Error())
 	}
+	return firstErr
 }
 
 func DescribeSecurityGroups(provider, ak, regionId, vpcId string) ([]cloud.SecurityGroup, error) {
@@ -272,57 +82,6 @@ func DoesSecurityGroupBelongsVpc(provider string) bool {
 	return true
 }
 
-func updateOrCreateSecurityGroups(ctx context.Context, regionIds []string, vpcs []cloud.VPC, provider,
-	ak string) ([]cloud.SecurityGroup, error) {
-	groups := make([]cloud.SecurityGroup, 0, 64)
-	if DoesSecurityGroupBelongsVpc(provider) {
-		for _, vpc := range vpcs {
-			secGroups, err := DescribeSecurityGroups(provider, ak, vpc.RegionId, vpc.VpcId)
-			if err != nil {
-				logs.Logger.Errorf("DescribeSecurityGroups failed: %s", err.Error())
-				continue
-			}
-			groups = append(groups, secGroups...)
-		}
-	} else {
-		for _, regionId := range regionIds {
-			secGroups, err := DescribeSecurityGroups(provider, ak, regionId, "")
-			if err != nil {
-				logs.Logger.Errorf("DescribeSecurityGroups failed: %s", err.Error())
-				continue
-			}
-			groups = append(groups, secGroups...)
-		}
-	}
-
-	groupsModels := cloud2ModelGroups(groups, ak, provider)
-	err := model.UpdateOrCreateGroups(ctx, ak, provider, regionIds, groupsModels)
-	return groups, err
-}
-
-func updateOrCreateSecurityGroupRules(ctx context.Context, groups []cloud.SecurityGroup, provider, ak string) {
-	rulesReq := cloud.DescribeGroupRulesRequest{}
-	for _, group := range groups {
-		rulesReq.RegionId = group.RegionId
-		rulesReq.SecurityGroupId = group.SecurityGroupId
-		cloudCli, err := getProvider(provider, ak, group.RegionId)
-		if err != nil {
-			logs.Logger.Errorf("getProvider failed.err: %s", err.Error())
-			continue
-		}
-		rulesRes, err := cloudCli.DescribeGroupRules(rulesReq)
-		if err != nil {
-			logs.Logger.Errorf("DescribeGroupRules failed.err: %s", err.Error())
-			continue
-		}
-		rulesModels := cloud2ModelRules(rulesRes.Rules)
-		err = model.ReplaceRules(ctx, group.VpcId, group.SecurityGroupId, rulesModels)
-		if err != nil {
-			logs.Logger.Errorf("updateOrCreateSecurityGroupRules failed.err : [%s]", err.Error())
-		}
-	}
-}
-
 func cloud2ModelVpc(vpcs []cloud.VPC, ak, provider string) []model.Vpc {
 	res := make([]model.Vpc, 0, len(vpcs))
 	for _, vpc := range vpcs {
@@ -438,37 +197,42 @@ func cloud2ModelRules(rules []cloud.SecurityGroupRule) []model.SecurityGroupRule
 	return res
 }
 
-func refreshVpc(t *SimpleTask) error {
-	fmt.Println("refreshing")
-	if t.VpcId == "" {
+func refreshVpc(ctx context.Context, payload JobPayload) error {
+	if payload.VpcId == "" {
 		return nil
 	}
-
-	res, err := t.Provider.GetVPC(cloud.GetVpcRequest{
-		VpcId:    t.VpcId,
-		RegionId: t.RegionId,
-		VpcName:  t.VpcName,
+	p, err := getProvider(payload.Provider, payload.AccountKey, payload.RegionId)
+	if err != nil {
+		return err
+	}
+	res, err := p.GetVPC(cloud.GetVpcRequest{
+		VpcId:    payload.VpcId,
+		RegionId: payload.RegionId,
+		VpcName:  payload.VpcName,
 	})
 	if err != nil {
 		return err
 	}
 	vpc := res.Vpc
-	return model.UpdateVpc(context.Background(), vpc.VpcId, vpc.CidrBlock, vpc.Status)
+	return model.UpdateVpc(ctx, vpc.VpcId, vpc.CidrBlock, vpc.Status)
 }
 
-func refreshSwitch(t *SimpleTask) error {
-	if t.SwitchId == "" {
+func refreshSwitch(ctx context.Context, payload JobPayload) error {
+	if payload.SwitchId == "" {
 		return nil
 	}
-
-	res, err := t.Provider.GetSwitch(cloud.GetSwitchRequest{
-		SwitchId: t.SwitchId,
+	p, err := getProvider(payload.Provider, payload.AccountKey, payload.RegionId)
+	if err != nil {
+		return err
+	}
+	res, err := p.GetSwitch(cloud.GetSwitchRequest{
+		SwitchId: payload.SwitchId,
 	})
 	if err != nil {
 		return err
 	}
 	vswitch := res.Switch
-	return model.UpdateSwitch(context.Background(),
+	return model.UpdateSwitch(ctx,
 		vswitch.AvailableIpAddressCount, vswitch.IsDefault,
 		vswitch.VpcId, vswitch.SwitchId, vswitch.Name,
 		vswitch.VStatus, vswitch.CidrBlock)
@@ -492,6 +256,7 @@ type CreateNetworkRequest struct {
 	SecurityGroupType string
 	AK                string
 	Rules             []GroupRule
+	HostCount         int
 }
 
 type CreateNetworkResponse struct {
@@ -515,6 +280,14 @@ type CreateVPCRequest struct {
 }
 
 func CreateNetwork(ctx context.Context, req *CreateNetworkRequest) (vpcRes CreateNetworkResponse, err error) {
+	if req.CidrBlock == "" && req.SwitchCidrBlock == "" {
+		if err = planCreateNetworkCidr(ctx, req); err != nil {
+			return CreateNetworkResponse{}, err
+		}
+	}
+	if req.CidrBlock == "" || req.SwitchCidrBlock == "" {
+		return CreateNetworkResponse{}, fmt.Errorf("cidr_block and switch_cidr_block are required")
+	}
 	// createVpc
 	vpcId, err := CreateVPC(ctx, CreateVPCRequest{
 		Provider:  req.Provider,
@@ -572,8 +345,46 @@ func CreateNetwork(ctx context.Context, req *CreateNetworkRequest) (vpcRes Creat
 	}, nil
 }
 
-func SyncNetwork(ctx context.Context, req SyncNetworkRequest) error {
-	return syncNetworkConfig(ctx, []string{req.RegionId}, req.Provider, req.AccountKey)
+//planCreateNetworkCidr 未指定网段时按HostCount规划与AK所在组织的网络都不重叠的网段
+func planCreateNetworkCidr(ctx context.Context, req *CreateNetworkRequest) error {
+	if req.HostCount <= 0 {
+		return fmt.Errorf("host_count is required when cidr_block is empty")
+	}
+	account, err := model.GetAccountByAk(ctx, req.AK)
+	if err != nil {
+		return err
+	}
+	aks, err := GetAksByOrgId(account.OrgId)
+	if err != nil {
+		return err
+	}
+	plan, err := PlanNetworkCidr(ctx, aks, PlanCidrParam{ZoneIds: []string{req.ZoneId}, HostCount: req.HostCount})
+	if err != nil {
+		return err
+	}
+	req.CidrBlock = plan.CidrBlock
+	req.SwitchCidrBlock = plan.Subnets[0].CidrBlock
+	if req.GatewayIp == "" {
+		req.GatewayIp = plan.Subnets[0].GatewayIp
+	}
+	return nil
+}
+
+//SyncNetwork 手动触发一个地域的同步, 返回的同步记录ID可用于查询同步状态
+func SyncNetwork(ctx context.Context, req SyncNetworkRequest) (int64, error) {
+	record, err := startNetworkSync(ctx, req.AccountKey, req.Provider, req.RegionId, constants.NetworkSyncTriggerManual)
+	if err != nil {
+		return 0, err
+	}
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logs.Logger.Errorf("SyncNetwork recover : %v", r)
+			}
+		}()
+		_ = finishNetworkSync(context.Background(), record)
+	}()
+	return record.Id, nil
 }
 
 func waitForVpcStatus(ctx context.Context, req *CreateNetworkRequest, vpcId string) error {
@@ -646,13 +457,16 @@ func CreateVPC(ctx context.Context, req CreateVPCRequest) (vpcId string, err err
 		logs.Logger.Errorf("save Vpc failed: %v, error: %v", res, err.Error())
 		return "", nil
 	}
-	H.SubmitTask(&SimpleTask{
-		VpcId:      res.VpcId,
+	orgId, _ := getOrgIdByAccountKey(ctx, req.AK)
+	_, err = SubmitJob(ctx, orgId, constants.JobTypeVpcRefresh, JobPayload{
+		Provider:   req.Provider,
+		AccountKey: req.AK,
 		RegionId:   req.RegionId,
-		Provider:   p,
-		TargetType: TargetTypeVpc,
-		Retry:      3,
-	})
+		VpcId:      res.VpcId,
+	}, 4)
+	if err != nil {
+		logs.Logger.Errorf("submit vpc refresh job failed, vpc: %v, err: %v", res.VpcId, err)
+	}
 	return res.VpcId, nil
 }
 
@@ -827,14 +641,17 @@ func CreateSwitch(ctx context.Context, req CreateSwitchRequest) (switchId string
 		logs.Logger.Errorf("save Switch failed: %v, error: %v", res, err.Error())
 		return "", nil
 	}
-	H.SubmitTask(&SimpleTask{
+	orgId, _ := getOrgIdByAccountKey(ctx, req.AK)
+	_, err = SubmitJob(ctx, orgId, constants.JobTypeSwitchRefresh, JobPayload{
+		Provider:   vpc.Provider,
+		AccountKey: req.AK,
+		RegionId:   vpc.RegionId,
 		VpcId:      req.VpcId,
 		SwitchId:   res.SwitchId,
-		RegionId:   vpc.RegionId,
-		Provider:   p,
-		TargetType: TargetTypeSwitch,
-		Retry:      3,
-	})
+	}, 4)
+	if err != nil {
+		logs.Logger.Errorf("submit switch refresh job failed, switch: %v, err: %v", res.SwitchId, err)
+	}
 	return res.SwitchId, nil
 }
 
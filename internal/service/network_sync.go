@@ -0,0 +1,534 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//ResourceChanges 一次同步中新增, 变更及删除的资源ID
+type ResourceChanges struct {
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+}
+
+//NetworkSyncReport 一次同步的变更报告, SecurityGroupRules.Updated为规则发生变化的安全组,
+//RouteEntries中的资源ID为"路由表ID/目标网段"
+type NetworkSyncReport struct {
+	Vpcs               ResourceChanges `json:"vpcs"`
+	Switches           ResourceChanges `json:"switches"`
+	SecurityGroups     ResourceChanges `json:"security_groups"`
+	SecurityGroupRules ResourceChanges `json:"security_group_rules"`
+	NatGateways        ResourceChanges `json:"nat_gateways"`
+	SnatEntries        ResourceChanges `json:"snat_entries"`
+	VpcPeerings        ResourceChanges `json:"vpc_peerings"`
+	RouteEntries       ResourceChanges `json:"route_entries"`
+}
+
+type NetworkSyncStatus struct {
+	Id          int64              `json:"id"`
+	AccountKey  string             `json:"account_key"`
+	Provider    string             `json:"provider"`
+	RegionId    string             `json:"region_id"`
+	TriggerType string             `json:"trigger_type"`
+	Status      string             `json:"status"`
+	ErrMsg      string             `json:"err_msg"`
+	Report      *NetworkSyncReport `json:"report"`
+	StartAt     string             `json:"start_at"`
+	EndAt       string             `json:"end_at"`
+}
+
+//RunNetworkSync 依次同步所有账户在各地域下的VPC, 子网, 安全组及规则, NAT网关, 对等连接及路由, 并清理过期的同步记录
+func RunNetworkSync(ctx context.Context) error {
+	accounts := make([]model.Account, 0)
+	if err := model.QueryAll(map[string]interface{}{}, &accounts, "id"); err != nil {
+		return err
+	}
+	synced := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		if synced[account.AccountKey] {
+			continue
+		}
+		synced[account.AccountKey] = true
+		regionIds, err := getAccountRegionIds(ctx, account.Provider, account.AccountKey)
+		if err != nil {
+			logs.Logger.Errorf("get regions of account:%v failed, err: %v", account.AccountKey, err)
+			continue
+		}
+		_ = syncNetworkConfig(ctx, regionIds, account.Provider, account.AccountKey, constants.NetworkSyncTriggerSchedule)
+	}
+	return model.DeleteNetworkSyncRecordsBefore(ctx, time.Now().Add(-constants.NetworkSyncRecordRetention))
+}
+
+//GetNetworkSyncStatus 获取账户在各地域下最近一次的同步结果
+func GetNetworkSyncStatus(ctx context.Context, accountKeys []string, regionId string) ([]NetworkSyncStatus, error) {
+	records, err := model.GetLatestNetworkSyncRecords(ctx, accountKeys, regionId)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]NetworkSyncStatus, 0, len(records))
+	for _, record := range records {
+		status := NetworkSyncStatus{
+			Id:          record.Id,
+			AccountKey:  record.AK,
+			Provider:    record.Provider,
+			RegionId:    record.RegionId,
+			TriggerType: record.TriggerType,
+			Status:      record.Status,
+			ErrMsg:      record.ErrMsg,
+		}
+		if record.Report != "" {
+			status.Report = &NetworkSyncReport{}
+			_ = jsoniter.UnmarshalFromString(record.Report, status.Report)
+		}
+		if record.StartAt != nil {
+			status.StartAt = utils.FormatTime(*record.StartAt)
+		}
+		if record.EndAt != nil {
+			status.EndAt = utils.FormatTime(*record.EndAt)
+		}
+		ret = append(ret, status)
+	}
+	return ret, nil
+}
+
+func getAccountRegionIds(ctx context.Context, provider, ak string) ([]string, error) {
+	accounts, err := GetOrgKeysByAk(ctx, ak)
+	if err != nil {
+		return nil, err
+	}
+	regions, err := GetRegions(ctx, GetRegionsRequest{
+		Provider: provider,
+		Account:  accounts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	regionIds := make([]string, 0, len(regions))
+	for _, region := range regions {
+		regionIds = append(regionIds, region.RegionId)
+	}
+	return regionIds, nil
+}
+
+func startNetworkSync(ctx context.Context, ak, provider, regionId, triggerType string) (*model.NetworkSyncRecord, error) {
+	now := time.Now()
+	record := &model.NetworkSyncRecord{
+		Base:        model.Base{CreateAt: &now, UpdateAt: &now},
+		AK:          ak,
+		Provider:    provider,
+		RegionId:    regionId,
+		TriggerType: triggerType,
+		Status:      constants.NetworkSyncStatusRunning,
+		StartAt:     &now,
+	}
+	if err := model.Create(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func finishNetworkSync(ctx context.Context, record *model.NetworkSyncRecord) error {
+	report, err := syncRegionNetwork(ctx, record.AK, record.Provider, record.RegionId)
+	status, errMsg := constants.NetworkSyncStatusSuccess, ""
+	if err != nil {
+		logs.Logger.Errorf("sync network of ak:%v region:%v failed, err: %v", record.AK, record.RegionId, err)
+		status, errMsg = constants.NetworkSyncStatusFailed, err.Error()
+	}
+	content, _ := jsoniter.MarshalToString(report)
+	if ferr := model.FinishNetworkSyncRecord(ctx, record.Id, status, content, errMsg); ferr != nil && err == nil {
+		err = ferr
+	}
+	return err
+}
+
+//syncRegionNetwork 比较库中与云上的资源, 只写入新增及变更的资源, 云上已不存在的资源标记为删除.
+//任一资源查询失败时中止同步, 避免把未查到的资源误删
+func syncRegionNetwork(ctx context.Context, ak, provider, regionId string) (*NetworkSyncReport, error) {
+	report := &NetworkSyncReport{}
+	p, err := getProvider(provider, ak, regionId)
+	if err != nil {
+		return report, err
+	}
+	vpcRes, err := p.DescribeVpcs(cloud.DescribeVpcsRequest{RegionId: regionId})
+	if err != nil {
+		return report, fmt.Errorf("describe vpcs failed: %w", err)
+	}
+	oldVpcs, err := model.FindVpcsByRegion(ctx, ak, provider, regionId)
+	if err != nil {
+		return report, err
+	}
+	vpcs := cloud2ModelVpc(vpcRes.Vpcs, ak, provider)
+	vpcIds := make([]string, 0, len(oldVpcs)+len(vpcs))
+	oldPrints, prints := make(map[string]string, len(oldVpcs)), make(map[string]string, len(vpcs))
+	for _, vpc := range oldVpcs {
+		vpcIds = append(vpcIds, vpc.VpcId)
+		oldPrints[vpc.VpcId] = vpcFingerprint(vpc)
+	}
+	for _, vpc := range vpcs {
+		vpcIds = append(vpcIds, vpc.VpcId)
+		prints[vpc.VpcId] = vpcFingerprint(vpc)
+	}
+	report.Vpcs = diffResources(oldPrints, prints)
+	changedVpcs := make([]model.Vpc, 0)
+	for _, vpc := range vpcs {
+		if report.Vpcs.changed(vpc.VpcId) {
+			changedVpcs = append(changedVpcs, vpc)
+		}
+	}
+	if err = model.UpsertVpcs(ctx, changedVpcs); err != nil {
+		return report, err
+	}
+	if err = model.SoftDeleteVpcs(ctx, ak, regionId, report.Vpcs.Removed); err != nil {
+		return report, err
+	}
+
+	if err = syncSwitches(ctx, p, vpcIds, vpcRes.Vpcs, report); err != nil {
+		return report, err
+	}
+	groups, err := syncSecurityGroups(ctx, p, ak, provider, regionId, vpcRes.Vpcs, report)
+	if err != nil {
+		return report, err
+	}
+	if err = syncSecurityGroupRules(ctx, p, groups, report); err != nil {
+		return report, err
+	}
+	if manager, ok := p.(cloud.NatGatewayManager); ok {
+		if err = syncNatGateways(ctx, manager, ak, provider, regionId, report); err != nil {
+			return report, err
+		}
+	}
+	if manager, ok := p.(cloud.VpcPeeringManager); ok {
+		if err = syncVpcPeerings(ctx, manager, ak, provider, regionId, report); err != nil {
+			return report, err
+		}
+		if err = syncRouteEntries(ctx, manager, regionId, vpcIds, vpcRes.Vpcs, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func syncSwitches(ctx context.Context, p cloud.Provider, vpcIds []string, vpcs []cloud.VPC, report *NetworkSyncReport) error {
+	oldSwitches, err := model.FindSwitchesByVpcIds(ctx, vpcIds)
+	if err != nil {
+		return err
+	}
+	cloudSwitches := make([]cloud.Switch, 0)
+	for _, vpc := range vpcs {
+		res, err := p.DescribeSwitches(cloud.DescribeSwitchesRequest{VpcId: vpc.VpcId})
+		if err != nil {
+			return fmt.Errorf("describe switches of vpc:%v failed: %w", vpc.VpcId, err)
+		}
+		cloudSwitches = append(cloudSwitches, res.Switches...)
+	}
+	switches := cloud2ModelSwitches(cloudSwitches)
+	oldPrints, prints := make(map[string]string, len(oldSwitches)), make(map[string]string, len(switches))
+	for _, s := range oldSwitches {
+		oldPrints[s.SwitchId] = switchFingerprint(s)
+	}
+	for _, s := range switches {
+		prints[s.SwitchId] = switchFingerprint(s)
+	}
+	report.Switches = diffResources(oldPrints, prints)
+	changed := make([]model.Switch, 0)
+	for _, s := range switches {
+		if report.Switches.changed(s.SwitchId) {
+			changed = append(changed, s)
+		}
+	}
+	if err = model.UpsertSwitches(ctx, changed); err != nil {
+		return err
+	}
+	return model.SoftDeleteSwitches(ctx, report.Switches.Removed)
+}
+
+func syncSecurityGroups(ctx context.Context, p cloud.Provider, ak, provider, regionId string, vpcs []cloud.VPC, report *NetworkSyncReport) ([]cloud.SecurityGroup, error) {
+	cloudGroups := make([]cloud.SecurityGroup, 0)
+	if DoesSecurityGroupBelongsVpc(provider) {
+		for _, vpc := range vpcs {
+			res, err := p.DescribeSecurityGroups(cloud.DescribeSecurityGroupsRequest{VpcId: vpc.VpcId, RegionId: regionId})
+			if err != nil {
+				return nil, fmt.Errorf("describe security groups of vpc:%v failed: %w", vpc.VpcId, err)
+			}
+			cloudGroups = append(cloudGroups, res.Groups...)
+		}
+	} else {
+		res, err := p.DescribeSecurityGroups(cloud.DescribeSecurityGroupsRequest{RegionId: regionId})
+		if err != nil {
+			return nil, fmt.Errorf("describe security groups failed: %w", err)
+		}
+		cloudGroups = res.Groups
+	}
+	oldGroups, err := model.FindSecurityGroupsByRegion(ctx, ak, provider, regionId)
+	if err != nil {
+		return nil, err
+	}
+	groups := cloud2ModelGroups(cloudGroups, ak, provider)
+	oldPrints, prints := make(map[string]string, len(oldGroups)), make(map[string]string, len(groups))
+	for _, g := range oldGroups {
+		oldPrints[g.SecurityGroupId] = securityGroupFingerprint(g)
+	}
+	for _, g := range groups {
+		prints[g.SecurityGroupId] = securityGroupFingerprint(g)
+	}
+	report.SecurityGroups = diffResources(oldPrints, prints)
+	changed := make([]model.SecurityGroup, 0)
+	for _, g := range groups {
+		if report.SecurityGroups.changed(g.SecurityGroupId) {
+			changed = append(changed, g)
+		}
+	}
+	if err = model.UpsertSecurityGroups(ctx, changed); err != nil {
+		return nil, err
+	}
+	if err = model.SoftDeleteSecurityGroups(ctx, ak, provider, regionId, report.SecurityGroups.Removed); err != nil {
+		return nil, err
+	}
+	return cloudGroups, nil
+}
+
+//syncSecurityGroupRules 规则没有稳定的ID, 按安全组比较规则集合, 有变化时整体替换
+func syncSecurityGroupRules(ctx context.Context, p cloud.Provider, groups []cloud.SecurityGroup, report *NetworkSyncReport) error {
+	report.SecurityGroupRules = ResourceChanges{Created: make([]string, 0), Updated: make([]string, 0), Removed: make([]string, 0)}
+	var errMsgs []string
+	for _, group := range groups {
+		res, err := p.DescribeGroupRules(cloud.DescribeGroupRulesRequest{RegionId: group.RegionId, SecurityGroupId: group.SecurityGroupId})
+		if err != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("describe rules of security group:%v failed: %v", group.SecurityGroupId, err))
+			continue
+		}
+		oldRules, err := model.FindSecurityGroupRulesById(ctx, group.SecurityGroupId)
+		if err != nil {
+			return err
+		}
+		added, removed := diffGroupRules(model2GroupRules(oldRules), cloud2GroupRules(res.Rules))
+		if len(added)+len(removed) == 0 {
+			continue
+		}
+		if err = model.ReplaceRules(ctx, group.VpcId, group.SecurityGroupId, cloud2ModelRules(res.Rules)); err != nil {
+			return err
+		}
+		report.SecurityGroupRules.Updated = append(report.SecurityGroupRules.Updated, group.SecurityGroupId)
+	}
+	sort.Strings(report.SecurityGroupRules.Updated)
+	if len(errMsgs) > 0 {
+		return errors.New(strings.Join(errMsgs, "; "))
+	}
+	return nil
+}
+
+//syncNatGateways 同步NAT网关及其SNAT条目
+func syncNatGateways(ctx context.Context, manager cloud.NatGatewayManager, ak, provider, regionId string, report *NetworkSyncReport) error {
+	res, err := manager.DescribeNatGateways(cloud.DescribeNatGatewaysRequest{RegionId: regionId})
+	if err != nil {
+		return fmt.Errorf("describe nat gateways failed: %w", err)
+	}
+	oldGateways, err := model.FindNatGatewaysByRegion(ctx, ak, provider, regionId)
+	if err != nil {
+		return err
+	}
+	gateways := cloud2ModelNatGateways(res.NatGateways, ak, provider)
+	gatewayIds := make([]string, 0, len(oldGateways)+len(gateways))
+	oldPrints, prints := make(map[string]string, len(oldGateways)), make(map[string]string, len(gateways))
+	for _, g := range oldGateways {
+		gatewayIds = append(gatewayIds, g.NatGatewayId)
+		oldPrints[g.NatGatewayId] = natGatewayFingerprint(g)
+	}
+	for _, g := range gateways {
+		gatewayIds = append(gatewayIds, g.NatGatewayId)
+		prints[g.NatGatewayId] = natGatewayFingerprint(g)
+	}
+	report.NatGateways = diffResources(oldPrints, prints)
+	changed := make([]model.NatGateway, 0)
+	for _, g := range gateways {
+		if report.NatGateways.changed(g.NatGatewayId) {
+			changed = append(changed, g)
+		}
+	}
+	if err = model.UpsertNatGateways(ctx, changed); err != nil {
+		return err
+	}
+	if err = model.SoftDeleteNatGateways(ctx, ak, provider, regionId, report.NatGateways.Removed); err != nil {
+		return err
+	}
+
+	entries := make([]model.SnatEntry, 0)
+	for _, g := range gateways {
+		if g.SnatTableId == "" {
+			continue
+		}
+		res, err := manager.DescribeSnatEntries(cloud.DescribeSnatEntriesRequest{RegionId: regionId, SnatTableId: g.SnatTableId})
+		if err != nil {
+			return fmt.Errorf("describe snat entries of nat gateway:%v failed: %w", g.NatGatewayId, err)
+		}
+		entries = append(entries, cloud2ModelSnatEntries(res.SnatEntries, g.NatGatewayId)...)
+	}
+	oldEntries := make([]model.SnatEntry, 0)
+	if len(gatewayIds) > 0 {
+		if oldEntries, err = model.FindSnatEntriesByNatGatewayIds(ctx, gatewayIds); err != nil {
+			return err
+		}
+	}
+	oldPrints, prints = make(map[string]string, len(oldEntries)), make(map[string]string, len(entries))
+	for _, e := range oldEntries {
+		oldPrints[e.SnatEntryId] = snatEntryFingerprint(e)
+	}
+	for _, e := range entries {
+		prints[e.SnatEntryId] = snatEntryFingerprint(e)
+	}
+	report.SnatEntries = diffResources(oldPrints, prints)
+	changedEntries := make([]model.SnatEntry, 0)
+	for _, e := range entries {
+		if report.SnatEntries.changed(e.SnatEntryId) {
+			changedEntries = append(changedEntries, e)
+		}
+	}
+	if err = model.UpsertSnatEntries(ctx, changedEntries); err != nil {
+		return err
+	}
+	return model.SoftDeleteSnatEntries(ctx, report.SnatEntries.Removed)
+}
+
+func syncVpcPeerings(ctx context.Context, manager cloud.VpcPeeringManager, ak, provider, regionId string, report *NetworkSyncReport) error {
+	res, err := manager.DescribeVpcPeerings(cloud.DescribeVpcPeeringsRequest{RegionId: regionId})
+	if err != nil {
+		return fmt.Errorf("describe vpc peerings failed: %w", err)
+	}
+	oldPeerings, err := model.FindVpcPeeringsByRegion(ctx, ak, provider, regionId)
+	if err != nil {
+		return err
+	}
+	peerings := cloud2ModelVpcPeerings(res.Peerings, ak, provider)
+	oldPrints, prints := make(map[string]string, len(oldPeerings)), make(map[string]string, len(peerings))
+	for _, p := range oldPeerings {
+		oldPrints[p.PeeringId] = vpcPeeringFingerprint(p)
+	}
+	for _, p := range peerings {
+		prints[p.PeeringId] = vpcPeeringFingerprint(p)
+	}
+	report.VpcPeerings = diffResources(oldPrints, prints)
+	changed := make([]model.VpcPeering, 0)
+	for _, p := range peerings {
+		if report.VpcPeerings.changed(p.PeeringId) {
+			changed = append(changed, p)
+		}
+	}
+	if err = model.UpsertVpcPeerings(ctx, changed); err != nil {
+		return err
+	}
+	return model.SoftDeleteVpcPeerings(ctx, ak, provider, regionId, report.VpcPeerings.Removed)
+}
+
+//syncRouteEntries 只同步自定义路由, 路由按路由表及目标网段识别
+func syncRouteEntries(ctx context.Context, manager cloud.VpcPeeringManager, regionId string, vpcIds []string, vpcs []cloud.VPC, report *NetworkSyncReport) error {
+	cloudEntries := make([]cloud.RouteEntry, 0)
+	for _, vpc := range vpcs {
+		res, err := manager.DescribeRouteEntries(cloud.DescribeRouteEntriesRequest{RegionId: regionId, VpcId: vpc.VpcId})
+		if err != nil {
+			return fmt.Errorf("describe route entries of vpc:%v failed: %w", vpc.VpcId, err)
+		}
+		cloudEntries = append(cloudEntries, res.RouteEntries...)
+	}
+	oldEntries := make([]model.RouteEntry, 0)
+	if len(vpcIds) > 0 {
+		var err error
+		if oldEntries, err = model.FindRouteEntriesByVpcIds(ctx, vpcIds); err != nil {
+			return err
+		}
+	}
+	entries := cloud2ModelRouteEntries(cloudEntries)
+	oldByKey := make(map[string]model.RouteEntry, len(oldEntries))
+	oldPrints, prints := make(map[string]string, len(oldEntries)), make(map[string]string, len(entries))
+	for _, e := range oldEntries {
+		oldByKey[routeEntryKey(e)] = e
+		oldPrints[routeEntryKey(e)] = routeEntryFingerprint(e)
+	}
+	for _, e := range entries {
+		prints[routeEntryKey(e)] = routeEntryFingerprint(e)
+	}
+	report.RouteEntries = diffResources(oldPrints, prints)
+	changed := make([]model.RouteEntry, 0)
+	for _, e := range entries {
+		if report.RouteEntries.changed(routeEntryKey(e)) {
+			changed = append(changed, e)
+		}
+	}
+	if err := model.UpsertRouteEntries(ctx, changed); err != nil {
+		return err
+	}
+	removed := make([]model.RouteEntry, 0, len(report.RouteEntries.Removed))
+	for _, key := range report.RouteEntries.Removed {
+		removed = append(removed, oldByKey[key])
+	}
+	return model.SoftDeleteRouteEntries(ctx, removed)
+}
+
+//diffResources 按资源ID比较库中与云上的资源, 指纹不同视为变更
+func diffResources(old, latest map[string]string) ResourceChanges {
+	changes := ResourceChanges{Created: make([]string, 0), Updated: make([]string, 0), Removed: make([]string, 0)}
+	for id, fp := range latest {
+		oldFp, ok := old[id]
+		if !ok {
+			changes.Created = append(changes.Created, id)
+		} else if oldFp != fp {
+			changes.Updated = append(changes.Updated, id)
+		}
+	}
+	for id := range old {
+		if _, ok := latest[id]; !ok {
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+	sort.Strings(changes.Created)
+	sort.Strings(changes.Updated)
+	sort.Strings(changes.Removed)
+	return changes
+}
+
+func (c ResourceChanges) changed(id string) bool {
+	return utils.ContainsString(c.Created, id) || utils.ContainsString(c.Updated, id)
+}
+
+func vpcFingerprint(vpc model.Vpc) string {
+	return strings.Join([]string{vpc.Name, vpc.CidrBlock, vpc.VStatus}, "|")
+}
+
+func switchFingerprint(s model.Switch) string {
+	return fmt.Sprintf("%v|%v|%v|%v|%v|%v|%v|%v", s.VpcId, s.ZoneId, s.Name, s.CidrBlock, s.GatewayIp, s.VStatus, s.IsDefault, s.AvailableIpAddressCount)
+}
+
+func securityGroupFingerprint(g model.SecurityGroup) string {
+	return strings.Join([]string{g.VpcId, g.Name, g.SecurityGroupType}, "|")
+}
+
+func natGatewayFingerprint(g model.NatGateway) string {
+	return strings.Join([]string{g.VpcId, g.SwitchId, g.Name, g.SnatTableId, g.IpAddresses, g.VStatus}, "|")
+}
+
+func snatEntryFingerprint(e model.SnatEntry) string {
+	return strings.Join([]string{e.NatGatewayId, e.Name, e.SwitchId, e.SourceCidr, e.SnatIp, e.VStatus}, "|")
+}
+
+func vpcPeeringFingerprint(p model.VpcPeering) string {
+	return strings.Join([]string{p.VpcId, p.Name, p.PeerRegionId, p.PeerVpcId, p.PeerPeeringId, p.VStatus}, "|")
+}
+
+func routeEntryKey(e model.RouteEntry) string {
+	return e.RouteTableId + "/" + e.DestinationCidr
+}
+
+func routeEntryFingerprint(e model.RouteEntry) string {
+	return strings.Join([]string{e.VpcId, e.RouteEntryId, e.Name, e.NextHopType, e.NextHopId, e.VStatus}, "|")
+}
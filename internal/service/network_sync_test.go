@@ -0,0 +1,28 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffResources(t *testing.T) {
+	old := map[string]string{"vpc-a": "a|10.0.0.0/16|Available", "vpc-b": "b|10.1.0.0/16|Available", "vpc-c": "c|10.2.0.0/16|Available"}
+	latest := map[string]string{"vpc-a": "a|10.0.0.0/16|Available", "vpc-b": "b2|10.1.0.0/16|Available", "vpc-e": "e|10.4.0.0/16|Pending", "vpc-d": "d|10.3.0.0/16|Pending"}
+	got := diffResources(old, latest)
+	want := ResourceChanges{
+		Created: []string{"vpc-d", "vpc-e"},
+		Updated: []string{"vpc-b"},
+		Removed: []string{"vpc-c"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffResources() = %+v, want %+v", got, want)
+	}
+	if !got.changed("vpc-b") || !got.changed("vpc-d") || got.changed("vpc-a") || got.changed("vpc-c") {
+		t.Errorf("changed() mismatch for %+v", got)
+	}
+
+	got = diffResources(map[string]string{}, map[string]string{})
+	if len(got.Created)+len(got.Updated)+len(got.Removed) != 0 || got.Created == nil {
+		t.Errorf("diffResources() of empty inputs = %+v", got)
+	}
+}
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/notify"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	notifyRetryBatchSize = 100
+	notifyErrMsgMaxLen   = 1024
+)
+
+var notifyPool = gopool.NewPool("notify-pool", 20, gopool.NewConfig())
+
+//NotifyEvent 需要通知的事件, OrgId为0时根据集群所属账号推断
+type NotifyEvent struct {
+	EventType   string
+	Severity    string
+	OrgId       int64
+	ClusterName string
+	Data        map[string]interface{}
+	OccurAt     time.Time
+}
+
+var notifyTitles = map[string]string{
+	constants.NotifyEventTaskSuccess:         "任务执行成功",
+	constants.NotifyEventTaskFailed:          "任务执行失败",
+	constants.NotifyEventTaskPartialSuccess:  "任务部分成功",
+	constants.NotifyEventInstanceExpiring:    "实例即将到期",
+	constants.NotifyEventReconcileAnomaly:    "集群实例对账异常",
+	constants.NotifyEventInstanceUnhealthy:   "实例健康检查失败",
+	constants.NotifyEventInstanceRenewFailed: "实例自动续费失败",
+	constants.NotifyEventSecurityGroupDrift:  "安全组规则偏离模板",
+}
+
+var defaultNotifyTemplates = map[string]string{
+	constants.NotifyEventTaskSuccess: `集群: {{.ClusterName}}
+任务: {{.Data.task_id}} {{.Data.task_name}} ({{.Data.task_action}})
+成功数量: {{.Data.success_num}}`,
+	constants.NotifyEventTaskFailed: `集群: {{.ClusterName}}
+任务: {{.Data.task_id}} {{.Data.task_name}} ({{.Data.task_action}})
+错误信息: {{.Data.err_msg}}`,
+	constants.NotifyEventTaskPartialSuccess: `集群: {{.ClusterName}}
+任务: {{.Data.task_id}} {{.Data.task_name}} ({{.Data.task_action}})
+成功数量: {{.Data.success_num}}
+错误信息: {{.Data.err_msg}}`,
+	constants.NotifyEventInstanceExpiring: `集群: {{.ClusterName}}
+{{.Data.instance_count}}台包年包月实例将在{{.Data.notice_days}}天内到期, 最早到期时间: {{.Data.earliest_expire_at}}
+实例: {{.Data.instance_ids}}`,
+	constants.NotifyEventReconcileAnomaly: `集群: {{.ClusterName}}
+发现{{.Data.instance_count}}台云厂商残留实例并已释放
+实例: {{.Data.instance_ids}}`,
+	constants.NotifyEventInstanceUnhealthy: `集群: {{.ClusterName}}
+{{.Data.instance_count}}台实例连续健康检查失败, 已从WorkingIPs中摘除
+实例: {{.Data.instance_ids}}`,
+	constants.NotifyEventInstanceRenewFailed: `集群: {{.ClusterName}}
+{{.Data.instance_count}}台包年包月实例自动续费失败, 请尽快手动续费
+实例: {{.Data.instance_ids}}
+错误信息: {{.Data.reason}}`,
+	constants.NotifyEventSecurityGroupDrift: `安全组: {{.Data.security_group_id}}
+模板: {{.Data.template_name}}
+多出的规则: {{.Data.added}}
+缺少的规则: {{.Data.removed}}
+已自动修复: {{.Data.remediated}}`,
+}
+
+func CreateNotifyChannel(ctx context.Context, channel *model.NotifyChannel) error {
+	conf, err := notify.ParseConfig(channel.Config)
+	if err != nil {
+		return fmt.Errorf("invalid channel config: %w", err)
+	}
+	if err = conf.Validate(channel.ChannelType); err != nil {
+		return err
+	}
+	now := time.Now()
+	channel.CreateAt = &now
+	channel.UpdateAt = &now
+	return model.Create(channel)
+}
+
+func ListNotifyChannels(ctx context.Context, orgId int64) ([]model.NotifyChannel, error) {
+	return model.GetNotifyChannelsByOrgId(ctx, orgId)
+}
+
+func DeleteNotifyChannels(ctx context.Context, orgId int64, ids []int64) error {
+	return model.DeleteNotifyChannels(ctx, orgId, ids)
+}
+
+//TestNotifyChannel 向渠道发送一条测试消息, 不生成通知记录
+func TestNotifyChannel(ctx context.Context, orgId, channelId int64) error {
+	channel, err := getNotifyChannel(orgId, channelId)
+	if err != nil {
+		return err
+	}
+	return sendToChannel(ctx, channel, notify.Message{
+		Title:   "BridgX通知测试",
+		Content: fmt.Sprintf("渠道[%v]配置正常\n时间: %v", channel.Name, utils.FormatTime(time.Now())),
+	})
+}
+
+func CreateNotifySubscription(ctx context.Context, sub *model.NotifySubscription) error {
+	if _, err := getNotifyChannel(sub.OrgId, sub.ChannelId); err != nil {
+		return err
+	}
+	for _, eventType := range splitEventTypes(sub.EventTypes) {
+		if _, ok := notifyTitles[eventType]; !ok {
+			return fmt.Errorf("unsupported event type: %v", eventType)
+		}
+	}
+	if sub.MinSeverity == "" {
+		sub.MinSeverity = constants.NotifySeverityInfo
+	}
+	if constants.NotifySeverityLevel(sub.MinSeverity) == 0 {
+		return fmt.Errorf("unsupported severity: %v", sub.MinSeverity)
+	}
+	if sub.Template != "" {
+		if _, err := template.New("notify").Parse(sub.Template); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	now := time.Now()
+	sub.CreateAt = &now
+	sub.UpdateAt = &now
+	return model.Create(sub)
+}
+
+func ListNotifySubscriptions(ctx context.Context, orgId int64) ([]model.NotifySubscription, error) {
+	return model.GetNotifySubscriptionsByOrgId(ctx, orgId)
+}
+
+func DeleteNotifySubscriptions(ctx context.Context, orgId int64, ids []int64) error {
+	return model.DeleteNotifySubscriptions(ctx, orgId, ids)
+}
+
+func ListNotifyRecords(ctx context.Context, cond model.NotifyRecordSearchCond) ([]model.NotifyRecord, int64, error) {
+	return model.ListNotifyRecords(ctx, cond)
+}
+
+//Notify 按订阅规则将事件分发到各通知渠道, 发送过程异步执行, 失败后由调度器重试
+func Notify(ctx context.Context, event NotifyEvent) {
+	if event.OccurAt.IsZero() {
+		event.OccurAt = time.Now()
+	}
+	if event.OrgId == 0 {
+		orgId, err := getClusterOrgId(ctx, event.ClusterName)
+		if err != nil {
+			logs.Logger.Warnf("notify event:%v of cluster:%v skipped, get org failed: %v", event.EventType, event.ClusterName, err)
+			return
+		}
+		event.OrgId = orgId
+	}
+	subs, err := model.GetNotifySubscriptionsByOrgId(ctx, event.OrgId)
+	if err != nil || len(subs) == 0 {
+		return
+	}
+	channels, err := model.GetNotifyChannelsByOrgId(ctx, event.OrgId)
+	if err != nil {
+		return
+	}
+	channelMap := make(map[int64]model.NotifyChannel, len(channels))
+	for _, channel := range channels {
+		if channel.Enabled {
+			channelMap[channel.Id] = channel
+		}
+	}
+	sent := make(map[int64]bool)
+	for _, sub := range subs {
+		channel, ok := channelMap[sub.ChannelId]
+		if !ok || sent[channel.Id] || !matchSubscription(sub, event) {
+			continue
+		}
+		msg, err := renderNotifyMessage(sub.Template, event)
+		if err != nil {
+			logs.Logger.Errorf("render notify message of subscription:%v failed: %v", sub.Id, err)
+			continue
+		}
+		sent[channel.Id] = true
+		now := time.Now()
+		record := &model.NotifyRecord{
+			OrgId:       event.OrgId,
+			ChannelId:   channel.Id,
+			EventType:   event.EventType,
+			Severity:    event.Severity,
+			ClusterName: event.ClusterName,
+			Title:       msg.Title,
+			Content:     msg.Content,
+			Status:      constants.NotifyRecordStatusPending,
+		}
+		record.CreateAt = &now
+		record.UpdateAt = &now
+		if err = model.Create(record); err != nil {
+			continue
+		}
+		ch := channel
+		notifyPool.Go(func() {
+			deliverNotifyRecord(context.Background(), &ch, record)
+		})
+	}
+}
+
+//NotifyTaskResult 任务结束后根据任务状态发送通知
+func NotifyTaskResult(task *model.Task) {
+	var eventType, severity string
+	switch task.Status {
+	case constants.TaskStatusSuccess:
+		eventType, severity = constants.NotifyEventTaskSuccess, constants.NotifySeverityInfo
+	case constants.TaskStatusPartialSuccess:
+		eventType, severity = constants.NotifyEventTaskPartialSuccess, constants.NotifySeverityWarning
+	case constants.TaskStatusFailed:
+		eventType, severity = constants.NotifyEventTaskFailed, constants.NotifySeverityCritical
+	default:
+		return
+	}
+	result := &model.TaskResult{}
+	_ = jsoniter.UnmarshalFromString(task.TaskResult, result)
+	Notify(context.Background(), NotifyEvent{
+		EventType:   eventType,
+		Severity:    severity,
+		ClusterName: task.TaskFilter,
+		Data: map[string]interface{}{
+			"task_id":     task.Id,
+			"task_name":   task.TaskName,
+			"task_action": task.TaskAction,
+			"status":      task.Status,
+			"success_num": result.SuccessNum,
+			"err_msg":     task.ErrMsg,
+		},
+	})
+}
+
+//RetryFailedNotifications 重新发送到达重试时间的失败通知
+func RetryFailedNotifications(ctx context.Context) error {
+	records, err := model.GetRetryableNotifyRecords(notifyRetryBatchSize)
+	if err != nil {
+		return err
+	}
+	channels := make(map[int64]*model.NotifyChannel)
+	for i := range records {
+		record := &records[i]
+		channel, ok := channels[record.ChannelId]
+		if !ok {
+			channel, _ = getNotifyChannel(record.OrgId, record.ChannelId)
+			channels[record.ChannelId] = channel
+		}
+		if channel == nil || !channel.Enabled {
+			_ = model.Updates(model.NotifyRecord{}, []int64{record.Id}, map[string]interface{}{
+				"retry_count": constants.NotifyMaxRetryCount,
+				"err_msg":     "channel is deleted or disabled",
+				"update_at":   time.Now(),
+			})
+			continue
+		}
+		deliverNotifyRecord(ctx, channel, record)
+	}
+	return nil
+}
+
+//NotifyExpiringInstances 按集群汇总noticeDays天内到期的包年包月实例并通知, 同一集群每天最多通知一次
+func NotifyExpiringInstances(ctx context.Context, noticeDays int) error {
+	now := time.Now()
+	instances, err := model.GetExpiringInstances(now.AddDate(0, 0, noticeDays))
+	if err != nil {
+		return err
+	}
+	clusterInstances := make(map[string][]model.Instance)
+	for _, instance := range instances {
+		clusterInstances[instance.ClusterName] = append(clusterInstances[instance.ClusterName], instance)
+	}
+	for clusterName, list := range clusterInstances {
+		notified, err := model.HasNotifyRecordSince(clusterName, constants.NotifyEventInstanceExpiring, now.AddDate(0, 0, -1))
+		if err != nil || notified {
+			continue
+		}
+		ids := make([]string, 0, len(list))
+		earliest := *list[0].ExpireAt
+		for _, instance := range list {
+			ids = append(ids, instance.InstanceId)
+			if instance.ExpireAt.Before(earliest) {
+				earliest = *instance.ExpireAt
+			}
+		}
+		severity := constants.NotifySeverityWarning
+		if earliest.Before(now.AddDate(0, 0, 1)) {
+			severity = constants.NotifySeverityCritical
+		}
+		Notify(ctx, NotifyEvent{
+			EventType:   constants.NotifyEventInstanceExpiring,
+			Severity:    severity,
+			ClusterName: clusterName,
+			Data: map[string]interface{}{
+				"instance_count":     len(ids),
+				"instance_ids":       strings.Join(ids, ","),
+				"notice_days":        noticeDays,
+				"earliest_expire_at": utils.FormatTime(earliest),
+			},
+		})
+	}
+	return nil
+}
+
+func deliverNotifyRecord(ctx context.Context, channel *model.NotifyChannel, record *model.NotifyRecord) {
+	err := sendToChannel(ctx, channel, notify.Message{Title: record.Title, Content: record.Content})
+	now := time.Now()
+	updates := map[string]interface{}{"update_at": now}
+	if err == nil {
+		updates["status"] = constants.NotifyRecordStatusSuccess
+		updates["err_msg"] = ""
+	} else {
+		logs.Logger.Warnf("send notify record:%v to channel:%v failed: %v", record.Id, channel.Id, err)
+		retryCount := record.RetryCount
+		if record.Status == constants.NotifyRecordStatusFailed {
+			retryCount++
+		}
+		nextRetryAt := now.Add(notifyRetryDelay(retryCount + 1))
+		updates["status"] = constants.NotifyRecordStatusFailed
+		updates["retry_count"] = retryCount
+		updates["next_retry_at"] = &nextRetryAt
+		updates["err_msg"] = truncateString(err.Error(), notifyErrMsgMaxLen)
+	}
+	_ = model.Updates(model.NotifyRecord{}, []int64{record.Id}, updates)
+}
+
+//notifyRetryDelay 第n次重试前的等待时间, 按指数退避
+func notifyRetryDelay(n int) time.Duration {
+	if n < 1 {
+		n = 1
+	}
+	return constants.NotifyRetryBaseDelay * time.Duration(1<<uint(n-1))
+}
+
+func sendToChannel(ctx context.Context, channel *model.NotifyChannel, msg notify.Message) error {
+	conf, err := notify.ParseConfig(channel.Config)
+	if err != nil {
+		return err
+	}
+	sender, err := notify.NewSender(channel.ChannelType, conf)
+	if err != nil {
+		return err
+	}
+	return sender.Send(ctx, msg)
+}
+
+func matchSubscription(sub model.NotifySubscription, event NotifyEvent) bool {
+	if sub.ClusterName != "" && sub.ClusterName != event.ClusterName {
+		return false
+	}
+	if eventTypes := splitEventTypes(sub.EventTypes); len(eventTypes) > 0 && !utils.ContainsString(eventTypes, event.EventType) {
+		return false
+	}
+	return constants.NotifySeverityLevel(event.Severity) >= constants.NotifySeverityLevel(sub.MinSeverity)
+}
+
+func renderNotifyMessage(tpl string, event NotifyEvent) (notify.Message, error) {
+	if tpl == "" {
+		tpl = defaultNotifyTemplates[event.EventType]
+	}
+	t, err := template.New("notify").Option("missingkey=zero").Parse(tpl)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, event); err != nil {
+		return notify.Message{}, err
+	}
+	title := fmt.Sprintf("[BridgX][%v] %v", event.Severity, notifyTitles[event.EventType])
+	content := fmt.Sprintf("%v\n发生时间: %v", strings.TrimSpace(buf.String()), utils.FormatTime(event.OccurAt))
+	return notify.Message{Title: title, Content: content}, nil
+}
+
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func splitEventTypes(s string) []string {
+	ret := make([]string, 0)
+	for _, t := range strings.Split(s, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			ret = append(ret, t)
+		}
+	}
+	return ret
+}
+
+func getNotifyChannel(orgId, channelId int64) (*model.NotifyChannel, error) {
+	channel := &model.NotifyChannel{}
+	if err := model.Get(channelId, channel); err != nil {
+		return nil, err
+	}
+	if channel.OrgId != orgId {
+		return nil, errors.New("notify channel not found")
+	}
+	return channel, nil
+}
+
+func getClusterOrgId(ctx context.Context, clusterName string) (int64, error) {
+	cluster, err := model.GetByClusterName(clusterName)
+	if err != nil {
+		return 0, err
+	}
+	if cluster == nil {
+		return 0, fmt.Errorf(constants.ErrClusterNotExist, clusterName)
+	}
+	return getOrgIdByAccountKey(ctx, cluster.AccountKey)
+}
+
+func getOrgIdByAccountKey(ctx context.Context, ak string) (int64, error) {
+	account, err := model.GetAccountByAk(ctx, ak)
+	if err != nil {
+		return 0, err
+	}
+	return account.OrgId, nil
+}
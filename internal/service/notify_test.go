@@ -0,0 +1,75 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestMatchSubscription(t *testing.T) {
+	event := NotifyEvent{
+		EventType:   constants.NotifyEventTaskFailed,
+		Severity:    constants.NotifySeverityCritical,
+		ClusterName: "c1",
+	}
+	tests := []struct {
+		name string
+		sub  model.NotifySubscription
+		want bool
+	}{
+		{name: "match all", sub: model.NotifySubscription{MinSeverity: constants.NotifySeverityInfo}, want: true},
+		{name: "other cluster", sub: model.NotifySubscription{ClusterName: "c2", MinSeverity: constants.NotifySeverityInfo}, want: false},
+		{name: "event type listed", sub: model.NotifySubscription{EventTypes: "TASK_SUCCESS, TASK_FAILED", MinSeverity: constants.NotifySeverityInfo}, want: true},
+		{name: "event type not listed", sub: model.NotifySubscription{EventTypes: constants.NotifyEventTaskSuccess, MinSeverity: constants.NotifySeverityInfo}, want: false},
+		{name: "severity equal", sub: model.NotifySubscription{MinSeverity: constants.NotifySeverityCritical}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchSubscription(tt.sub, event); got != tt.want {
+				t.Errorf("matchSubscription() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	event.Severity = constants.NotifySeverityWarning
+	if matchSubscription(model.NotifySubscription{MinSeverity: constants.NotifySeverityCritical}, event) {
+		t.Errorf("WARNING event should not match CRITICAL subscription")
+	}
+}
+
+func TestRenderNotifyMessage(t *testing.T) {
+	event := NotifyEvent{
+		EventType:   constants.NotifyEventTaskFailed,
+		Severity:    constants.NotifySeverityCritical,
+		ClusterName: "c1",
+		Data:        map[string]interface{}{"task_id": 123, "err_msg": "quota exceeded"},
+		OccurAt:     time.Now(),
+	}
+	msg, err := renderNotifyMessage("", event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg.Title, constants.NotifySeverityCritical) || !strings.Contains(msg.Content, "quota exceeded") {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+
+	msg, err = renderNotifyMessage("{{.ClusterName}} task {{.Data.task_id}} {{.Data.missing}}", event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(msg.Content, "c1 task 123") {
+		t.Errorf("unexpected content: %v", msg.Content)
+	}
+}
+
+func TestNotifyRetryDelay(t *testing.T) {
+	if got := notifyRetryDelay(1); got != constants.NotifyRetryBaseDelay {
+		t.Errorf("notifyRetryDelay(1) = %v", got)
+	}
+	if got := notifyRetryDelay(3); got != 4*constants.NotifyRetryBaseDelay {
+		t.Errorf("notifyRetryDelay(3) = %v", got)
+	}
+}
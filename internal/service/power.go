@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/id_generator"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+var errNoInstanceToPower = errors.New("no instance to start or stop")
+
+//CreatePowerTask 创建开机或停机任务, instanceIds为空时作用于集群内所有可操作的实例
+func CreatePowerTask(ctx context.Context, clusterName, action string, instanceIds []string, taskName string, uid int64) (int64, error) {
+	if action != constants.TaskActionStop && action != constants.TaskActionStart {
+		return 0, fmt.Errorf("unsupported power action: %v", action)
+	}
+	if hasUnfinishedTask(clusterName) {
+		return 0, fmt.Errorf("Cluster:%v has unfinished task", clusterName)
+	}
+	cluster, err := model.GetByClusterName(clusterName)
+	if err != nil {
+		return 0, err
+	}
+	if cluster == nil {
+		return 0, fmt.Errorf(constants.ErrClusterNotExist, clusterName)
+	}
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := selectPowerInstances(instances, action, instanceIds)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, fmt.Errorf("cluster:%v %w", clusterName, errNoInstanceToPower)
+	}
+	info := &model.PowerTaskInfo{
+		ClusterName:    clusterName,
+		InstanceIds:    ids,
+		TaskSubmitHost: utils.PrivateIPv4(),
+		UserId:         uid,
+		BeforeCount:    len(instances),
+	}
+	s, _ := jsoniter.MarshalToString(info)
+	task := &model.Task{
+		TaskName:   taskName,
+		TaskAction: action,
+		Status:     constants.TaskStatusInit,
+		TaskFilter: clusterName,
+		TaskInfo:   s,
+	}
+	now := time.Now()
+	task.Id = int64(id_generator.GetNextId())
+	task.CreateAt = &now
+	task.UpdateAt = &now
+	if err = model.Create(task); err != nil {
+		return 0, err
+	}
+	return task.Id, nil
+}
+
+//selectPowerInstances 停机只作用于运行中的实例, 开机只作用于已停机的实例
+func selectPowerInstances(instances []model.Instance, action string, instanceIds []string) ([]string, error) {
+	from := constants.Running
+	if action == constants.TaskActionStart {
+		from = constants.Stopped
+	}
+	ids := make([]string, 0, len(instances))
+	if len(instanceIds) == 0 {
+		for _, instance := range instances {
+			if instance.Status == from {
+				ids = append(ids, instance.InstanceId)
+			}
+		}
+		return ids, nil
+	}
+	byId := make(map[string]model.Instance, len(instances))
+	for _, instance := range instances {
+		byId[instance.InstanceId] = instance
+	}
+	for _, instanceId := range instanceIds {
+		instance, ok := byId[instanceId]
+		if !ok {
+			return nil, fmt.Errorf("instance:%v not found in cluster", instanceId)
+		}
+		if instance.Status != from {
+			return nil, fmt.Errorf("instance:%v status is %v, expected %v", instanceId, instance.Status, from)
+		}
+		ids = append(ids, instanceId)
+	}
+	return ids, nil
+}
+
+//StopClusterInstances 先将实例从WorkingIPs中摘除再停机, 返回已停机的实例数
+func StopClusterInstances(c *types.ClusterInfo, instanceIds []string) (int, error) {
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return 0, err
+	}
+	if err = model.UpdateInstancesStatus(c.Name, instanceIds, constants.Running, constants.Stopping); err != nil {
+		return 0, err
+	}
+	if err = publishWorkingIPs(c.Name); err != nil {
+		logs.Logger.Errorf("publish working ips of cluster:%v failed, err: %v", c.Name, err)
+	}
+	if err = provider.StopInstances(instanceIds); err != nil {
+		_ = model.UpdateInstancesStatus(c.Name, instanceIds, constants.Stopping, constants.Running)
+		_ = publishWorkingIPs(c.Name)
+		return 0, err
+	}
+	latest := waitInstances(provider, instanceIds, func(instance cloud.Instance) bool {
+		return instance.Status == cloud.EcsStopped
+	})
+	stopped := make([]string, 0, len(instanceIds))
+	for _, instanceId := range instanceIds {
+		if instance, ok := latest[instanceId]; ok && instance.Status == cloud.EcsStopped {
+			stopped = append(stopped, instanceId)
+		}
+	}
+	if len(stopped) > 0 {
+		if err = model.UpdateInstancesStatus(c.Name, stopped, constants.Stopping, constants.Stopped); err != nil {
+			return 0, err
+		}
+	}
+	logs.Logger.Infof("cluster:%v stopped instances:%v", c.Name, stopped)
+	if len(stopped) < len(instanceIds) {
+		//未确认停机的实例恢复为运行中, 避免一直处于STOPPING而无法再次操作
+		unconfirmed := utils.StringSliceDiff(instanceIds, stopped)
+		if err = model.UpdateInstancesStatus(c.Name, unconfirmed, constants.Stopping, constants.Running); err != nil {
+			logs.Logger.Errorf("revert instances:%v of cluster:%v to running failed, err: %v", unconfirmed, c.Name, err)
+		}
+		_ = publishWorkingIPs(c.Name)
+		return len(stopped), fmt.Errorf("%v instances not stopped in time", len(unconfirmed))
+	}
+	return len(stopped), nil
+}
+
+//StartClusterInstances 开机并等待实例就绪后重新加入WorkingIPs, 返回已就绪的实例数
+func StartClusterInstances(c *types.ClusterInfo, instanceIds []string) (int, error) {
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return 0, err
+	}
+	if err = model.UpdateInstancesStatus(c.Name, instanceIds, constants.Stopped, constants.Starting); err != nil {
+		return 0, err
+	}
+	if err = provider.StartInstances(instanceIds); err != nil {
+		_ = model.UpdateInstancesStatus(c.Name, instanceIds, constants.Starting, constants.Stopped)
+		return 0, err
+	}
+	needPublicIp := c.NetworkConfig != nil && c.NetworkConfig.InternetMaxBandwidthOut > 0
+	latest := waitInstances(provider, instanceIds, func(instance cloud.Instance) bool {
+		return IsInstanceReady(instance, needPublicIp)
+	})
+	started := make([]string, 0, len(instanceIds))
+	for _, instanceId := range instanceIds {
+		instance, ok := latest[instanceId]
+		if !ok || !IsInstanceReady(instance, needPublicIp) {
+			continue
+		}
+		//公网IP在停机后可能被回收, 以开机后查询到的为准
+		err = model.BatchUpdateByInstanceIds([]string{instanceId}, model.Instance{
+			Status:  constants.Running,
+			IpInner: instance.IpInner,
+			IpOuter: instance.IpOuter,
+		})
+		if err == nil {
+			started = append(started, instanceId)
+		}
+	}
+	//未确认就绪的实例恢复为已停机, 可再次开机
+	unconfirmed := utils.StringSliceDiff(instanceIds, started)
+	if len(unconfirmed) > 0 {
+		if err = model.UpdateInstancesStatus(c.Name, unconfirmed, constants.Starting, constants.Stopped); err != nil {
+			logs.Logger.Errorf("revert instances:%v of cluster:%v to stopped failed, err: %v", unconfirmed, c.Name, err)
+		}
+	}
+	if err = publishWorkingIPs(c.Name); err != nil {
+		logs.Logger.Errorf("publish working ips of cluster:%v failed, err: %v", c.Name, err)
+	}
+	logs.Logger.Infof("cluster:%v started instances:%v", c.Name, started)
+	if len(unconfirmed) > 0 {
+		return len(started), fmt.Errorf("%v instances not started in time", len(unconfirmed))
+	}
+	return len(started), nil
+}
+
+//waitInstances 轮询实例直到全部满足ready或超过检查次数, 返回每个实例最后一次查询到的信息
+func waitInstances(provider cloud.Provider, instanceIds []string, ready func(cloud.Instance) bool) map[string]cloud.Instance {
+	latest := make(map[string]cloud.Instance, len(instanceIds))
+	pending := instanceIds
+	for k := 0; k < constants.PowerCheckTimes && len(pending) > 0; k++ {
+		time.Sleep(constants.PowerCheckInterval)
+		instances, err := provider.GetInstances(pending)
+		if err != nil {
+			continue
+		}
+		done := make([]string, 0, len(instances))
+		for _, instance := range instances {
+			latest[instance.Id] = instance
+			if ready(instance) {
+				done = append(done, instance.Id)
+			}
+		}
+		pending = utils.StringSliceDiff(pending, done)
+	}
+	return latest
+}
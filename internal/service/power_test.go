@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+)
+
+func TestSelectPowerInstances(t *testing.T) {
+	instances := []model.Instance{
+		{InstanceId: "1", Status: constants.Running},
+		{InstanceId: "2", Status: constants.Stopped},
+		{InstanceId: "3", Status: constants.Running},
+	}
+	ids, err := selectPowerInstances(instances, constants.TaskActionStop, nil)
+	if err != nil || len(ids) != 2 || ids[0] != "1" || ids[1] != "3" {
+		t.Errorf("stop all want [1 3], got %v, %v", ids, err)
+	}
+	ids, err = selectPowerInstances(instances, constants.TaskActionStart, nil)
+	if err != nil || len(ids) != 1 || ids[0] != "2" {
+		t.Errorf("start all want [2], got %v, %v", ids, err)
+	}
+	if _, err = selectPowerInstances(instances, constants.TaskActionStart, []string{"1"}); err == nil {
+		t.Errorf("start running instance should fail")
+	}
+	if _, err = selectPowerInstances(instances, constants.TaskActionStop, []string{"4"}); err == nil {
+		t.Errorf("stop unknown instance should fail")
+	}
+}
+
+func TestIsWorkingInstance(t *testing.T) {
+	for status, want := range map[constants.Status]bool{
+		constants.Running:  true,
+		constants.Stopping: false,
+		constants.Stopped:  false,
+		constants.Starting: false,
+	} {
+		if got := isWorkingInstance(model.Instance{Status: status}); got != want {
+			t.Errorf("isWorkingInstance(%v) = %v, want %v", status, got, want)
+		}
+	}
+}
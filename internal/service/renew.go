@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//CheckRenewPolicy 校验包年包月集群的续费策略
+func CheckRenewPolicy(chargeConfig *types.ChargeConfig) error {
+	policy := chargeConfig.RenewPolicy
+	if policy == nil || !policy.AutoRenew {
+		return nil
+	}
+	if chargeConfig.ChargeType != cloud.InstanceChargeTypePrePaid {
+		return errors.New("renew policy is only supported for PrePaid cluster")
+	}
+	return checkRenewPeriod(policy.Period, policy.PeriodUnit)
+}
+
+func checkRenewPeriod(period int, periodUnit string) error {
+	if period <= 0 {
+		return errors.New("renew period must be positive")
+	}
+	if periodUnit != cloud.Month && periodUnit != cloud.Year {
+		return fmt.Errorf("invalid renew period unit: %v", periodUnit)
+	}
+	return nil
+}
+
+//SaveRenewPolicy 更新集群的自动续费策略, 与集群编辑一样记录为新版本
+func SaveRenewPolicy(ctx context.Context, clusterName string, policy *types.RenewPolicy, username string) error {
+	cluster, err := model.GetByClusterName(clusterName)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf(constants.ErrClusterNotExist, clusterName)
+	}
+	chargeConfig, err := cluster.UnmarshalChargeConfig()
+	if err != nil {
+		return err
+	}
+	chargeConfig.RenewPolicy = policy
+	if err = CheckRenewPolicy(chargeConfig); err != nil {
+		return err
+	}
+	c := *cluster
+	c.ChargeConfig, _ = jsoniter.MarshalToString(chargeConfig)
+	c.AutoRenew = policy != nil && policy.AutoRenew
+	_, err = editCluster(ctx, &c, username, "")
+	return err
+}
+
+//RenewInstances 手动续费集群下的包年包月实例, 部分实例续费失败时返回失败的实例及原因
+func RenewInstances(ctx context.Context, clusterName string, instanceIds []string, period int, periodUnit string) error {
+	failed, err := renewInstances(clusterName, instanceIds, period, periodUnit)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("renew instances failed: %v", failed)
+	}
+	return nil
+}
+
+//renewInstances 逐台续费实例, 每台续费成功后立即按续费时长更新到期时间, 避免同步失败时被再次续费.
+//之后再从云厂商同步实际到期时间, 同步失败只记录告警. 返回续费失败的实例及原因
+func renewInstances(clusterName string, instanceIds []string, period int, periodUnit string) (map[string]string, error) {
+	if err := checkRenewPeriod(period, periodUnit); err != nil {
+		return nil, err
+	}
+	c, renewer, err := getClusterRenewer(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkClusterInstances(clusterName, instanceIds); err != nil {
+		return nil, err
+	}
+	instances, err := model.GetInstancesByInstanceIds(instanceIds)
+	if err != nil {
+		return nil, err
+	}
+	expireAt := make(map[string]*time.Time, len(instances))
+	for _, instance := range instances {
+		expireAt[instance.InstanceId] = instance.ExpireAt
+	}
+	failed := make(map[string]string)
+	renewed := make([]string, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		res, err := renewer.RenewInstances(cloud.RenewInstancesRequest{InstanceIds: []string{id}, Period: period, PeriodUnit: periodUnit})
+		if err != nil {
+			failed[id] = err.Error()
+			continue
+		}
+		if reason, ok := res.Failed[id]; ok {
+			failed[id] = reason
+			continue
+		}
+		renewed = append(renewed, id)
+		next := nextExpireAt(expireAt[id], period, periodUnit, time.Now())
+		if err = model.UpdateByInstanceId(model.Instance{InstanceId: id, ExpireAt: &next}); err != nil {
+			logs.Logger.Errorf("cluster:%v instance:%v renewed but update expire_at failed, err: %v", clusterName, id, err)
+		}
+	}
+	if len(renewed) > 0 {
+		logs.Logger.Infof("cluster:%v renewed instances:%v for %v %v", clusterName, renewed, period, periodUnit)
+		if err = syncInstancesExpireAt(c, renewed); err != nil {
+			logs.Logger.Warnf("cluster:%v sync expire_at of renewed instances:%v failed, err: %v", clusterName, renewed, err)
+		}
+	}
+	return failed, nil
+}
+
+//nextExpireAt 按续费时长推算续费后的到期时间, 已过期或未知到期时间时从当前时间起算
+func nextExpireAt(expireAt *time.Time, period int, periodUnit string, now time.Time) time.Time {
+	base := now
+	if expireAt != nil && expireAt.After(now) {
+		base = *expireAt
+	}
+	if periodUnit == cloud.Year {
+		return base.AddDate(period, 0, 0)
+	}
+	return base.AddDate(0, period, 0)
+}
+
+//SetInstancesReleaseAtExpiry 设置实例到期后是否释放, 标记后云厂商侧不再续费, 到期后由调度任务释放
+func SetInstancesReleaseAtExpiry(ctx context.Context, clusterName string, instanceIds []string, release bool) (int64, error) {
+	_, renewer, err := getClusterRenewer(clusterName)
+	if err != nil {
+		return 0, err
+	}
+	if err = checkClusterInstances(clusterName, instanceIds); err != nil {
+		return 0, err
+	}
+	if err = modifyInstancesRenewal(renewer, instanceIds, release); err != nil {
+		return 0, err
+	}
+	return model.UpdateInstancesReleaseAtExpiry(clusterName, instanceIds, release, 0)
+}
+
+//markReleaseAtExpiry 包年包月集群缩容时不直接释放实例, 而是标记为到期释放
+func markReleaseAtExpiry(c *types.ClusterInfo, instanceIds []string, taskId int64) error {
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return err
+	}
+	//云厂商侧无法关闭自动续费时不标记, 否则实例不再计入集群却仍被续费
+	renewer, ok := provider.(cloud.InstanceRenewer)
+	if !ok {
+		return fmt.Errorf("provider %v does not support renewal", c.Provider)
+	}
+	if err = modifyInstancesRenewal(renewer, instanceIds, true); err != nil {
+		return err
+	}
+	_, err = model.UpdateInstancesReleaseAtExpiry(c.Name, instanceIds, true, taskId)
+	if err != nil {
+		return err
+	}
+	logs.Logger.Infof("cluster:%v task:%v mark instances release at expiry:%v", c.Name, taskId, instanceIds)
+	return nil
+}
+
+func modifyInstancesRenewal(renewer cloud.InstanceRenewer, instanceIds []string, release bool) error {
+	status := cloud.RenewalStatusNormal
+	if release {
+		status = cloud.RenewalStatusNotRenewal
+	}
+	return renewer.ModifyInstancesRenewal(cloud.ModifyInstancesRenewalRequest{InstanceIds: instanceIds, RenewalStatus: status})
+}
+
+func isPrePaidCluster(c *types.ClusterInfo) bool {
+	return c.ChargeConfig != nil && c.ChargeConfig.ChargeType == cloud.InstanceChargeTypePrePaid
+}
+
+//RenewExpiringInstances 按集群的自动续费策略续费即将到期的实例, 续费失败时发送通知
+func RenewExpiringInstances(ctx context.Context) error {
+	clusters, err := model.GetAutoRenewClusters(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, cluster := range clusters {
+		chargeConfig, err := cluster.UnmarshalChargeConfig()
+		if err != nil || chargeConfig.RenewPolicy == nil || !chargeConfig.RenewPolicy.AutoRenew {
+			continue
+		}
+		policy := chargeConfig.RenewPolicy
+		renewBeforeDays := policy.RenewBeforeDays
+		if renewBeforeDays <= 0 {
+			renewBeforeDays = constants.DefaultRenewBeforeDays
+		}
+		instances, err := model.GetRenewableInstances(cluster.ClusterName, now.AddDate(0, 0, renewBeforeDays))
+		if err != nil || len(instances) == 0 {
+			continue
+		}
+		ids := make([]string, 0, len(instances))
+		for _, instance := range instances {
+			ids = append(ids, instance.InstanceId)
+		}
+		failed, err := renewInstances(cluster.ClusterName, ids, policy.Period, policy.PeriodUnit)
+		if err != nil {
+			failed = make(map[string]string, len(ids))
+			for _, id := range ids {
+				failed[id] = err.Error()
+			}
+		}
+		if len(failed) == 0 {
+			continue
+		}
+		logs.Logger.Errorf("auto renew instances of cluster:%v failed: %v", cluster.ClusterName, failed)
+		failedIds := make([]string, 0, len(failed))
+		reasons := make([]string, 0, len(failed))
+		for _, id := range ids {
+			if reason, ok := failed[id]; ok {
+				failedIds = append(failedIds, id)
+				reasons = append(reasons, id+": "+reason)
+			}
+		}
+		Notify(ctx, NotifyEvent{
+			EventType:   constants.NotifyEventInstanceRenewFailed,
+			Severity:    constants.NotifySeverityCritical,
+			ClusterName: cluster.ClusterName,
+			Data: map[string]interface{}{
+				"instance_count": len(failedIds),
+				"instance_ids":   strings.Join(failedIds, ","),
+				"reason":         strings.Join(reasons, "; "),
+			},
+		})
+	}
+	return nil
+}
+
+//ReleaseExpiredInstances 释放已到期且标记为到期释放的实例, 已被云厂商回收的实例直接标记为已删除
+func ReleaseExpiredInstances(ctx context.Context) error {
+	instances, err := model.GetExpiredReleasingInstances(time.Now())
+	if err != nil {
+		return err
+	}
+	clusterInstanceIds := make(map[string][]string)
+	for _, instance := range instances {
+		clusterInstanceIds[instance.ClusterName] = append(clusterInstanceIds[instance.ClusterName], instance.InstanceId)
+	}
+	for clusterName, ids := range clusterInstanceIds {
+		if err = releaseExpiredInstances(ctx, clusterName, ids); err != nil {
+			logs.Logger.Errorf("release expired instances of cluster:%v failed, err: %v", clusterName, err)
+		}
+	}
+	return nil
+}
+
+func releaseExpiredInstances(ctx context.Context, clusterName string, instanceIds []string) error {
+	c, err := GetClusterInfo(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	released := instanceIds
+	_ = removeInstancesDNS(clusterName, instanceIds)
+	if err = Shrink(c, instanceIds); err != nil {
+		provider, perr := getProvider(c.Provider, c.AccountKey, c.RegionId)
+		if perr != nil {
+			return err
+		}
+		existing, perr := provider.GetInstances(instanceIds)
+		if perr != nil {
+			return err
+		}
+		existIds := make([]string, 0, len(existing))
+		for _, instance := range existing {
+			if instance.Status != cloud.EcsDeleted {
+				existIds = append(existIds, instance.Id)
+			}
+		}
+		released = utils.StringSliceDiff(instanceIds, existIds)
+		if len(released) == 0 {
+			return err
+		}
+	}
+	now := time.Now()
+	err = model.BatchUpdateByInstanceIds(released, model.Instance{
+		Base:     model.Base{UpdateAt: &now},
+		Status:   constants.Deleted,
+		DeleteAt: &now,
+	})
+	if err != nil {
+		return err
+	}
+	logs.Logger.Infof("cluster:%v released expired instances:%v", clusterName, released)
+	return publishShrinkConfig(clusterName)
+}
+
+func getClusterRenewer(clusterName string) (*types.ClusterInfo, cloud.InstanceRenewer, error) {
+	c, err := GetClusterInfo(context.Background(), clusterName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isPrePaidCluster(c) {
+		return nil, nil, fmt.Errorf("cluster:%v is not PrePaid", clusterName)
+	}
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return nil, nil, err
+	}
+	renewer, ok := provider.(cloud.InstanceRenewer)
+	if !ok {
+		return nil, nil, fmt.Errorf("provider %v does not support renewal", c.Provider)
+	}
+	return c, renewer, nil
+}
+
+//checkClusterInstances 校验实例均属于集群且未被删除
+func checkClusterInstances(clusterName string, instanceIds []string) error {
+	if len(instanceIds) == 0 {
+		return errors.New("instance_ids can not be empty")
+	}
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return err
+	}
+	active := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		active = append(active, instance.InstanceId)
+	}
+	if missing := utils.StringSliceDiff(instanceIds, active); len(missing) > 0 {
+		return fmt.Errorf("instances not found in cluster:%v", missing)
+	}
+	return nil
+}
+
+//syncInstancesExpireAt 从云厂商查询实例的到期时间并更新
+func syncInstancesExpireAt(c *types.ClusterInfo, instanceIds []string) error {
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return err
+	}
+	instances, err := provider.GetInstances(instanceIds)
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		if instance.ExpireAt == nil {
+			continue
+		}
+		if err = model.UpdateByInstanceId(model.Instance{InstanceId: instance.Id, ExpireAt: instance.ExpireAt}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
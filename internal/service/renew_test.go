@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestCheckRenewPolicy(t *testing.T) {
+	prePaid := func(policy *types.RenewPolicy) *types.ChargeConfig {
+		return &types.ChargeConfig{ChargeType: cloud.InstanceChargeTypePrePaid, RenewPolicy: policy}
+	}
+	tests := []struct {
+		cfg     *types.ChargeConfig
+		wantErr bool
+	}{
+		{cfg: prePaid(nil)},
+		{cfg: prePaid(&types.RenewPolicy{AutoRenew: false})},
+		{cfg: prePaid(&types.RenewPolicy{AutoRenew: true, Period: 1, PeriodUnit: cloud.Month})},
+		{cfg: prePaid(&types.RenewPolicy{AutoRenew: true, Period: 0, PeriodUnit: cloud.Month}), wantErr: true},
+		{cfg: prePaid(&types.RenewPolicy{AutoRenew: true, Period: 1, PeriodUnit: "Week"}), wantErr: true},
+		{cfg: &types.ChargeConfig{ChargeType: cloud.InstanceChargeTypePostPaid, RenewPolicy: &types.RenewPolicy{AutoRenew: true, Period: 1, PeriodUnit: cloud.Month}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckRenewPolicy(tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("CheckRenewPolicy(%+v) error = %v, wantErr %v", tt.cfg.RenewPolicy, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNextExpireAt(t *testing.T) {
+	now := time.Date(2022, 3, 1, 0, 0, 0, 0, time.Local)
+	future := now.AddDate(0, 0, 5)
+	past := now.AddDate(0, 0, -5)
+	tests := []struct {
+		expireAt   *time.Time
+		period     int
+		periodUnit string
+		want       time.Time
+	}{
+		{expireAt: &future, period: 1, periodUnit: cloud.Month, want: future.AddDate(0, 1, 0)},
+		{expireAt: &future, period: 2, periodUnit: cloud.Year, want: future.AddDate(2, 0, 0)},
+		{expireAt: &past, period: 1, periodUnit: cloud.Month, want: now.AddDate(0, 1, 0)},
+		{expireAt: nil, period: 3, periodUnit: cloud.Month, want: now.AddDate(0, 3, 0)},
+	}
+	for _, tt := range tests {
+		if got := nextExpireAt(tt.expireAt, tt.period, tt.periodUnit, now); !got.Equal(tt.want) {
+			t.Errorf("nextExpireAt(%v, %v %v) = %v, want %v", tt.expireAt, tt.period, tt.periodUnit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/id_generator"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+type RollTaskParam struct {
+	ClusterName    string
+	Image          string
+	InstanceType   string
+	Surge          int
+	MaxUnavailable int
+	HealthCheck    *types.HealthCheckConfig
+}
+
+//CreateRollTask 创建滚动替换任务, 将集群规格切换为目标镜像/机型, 并将现有实例分批替换
+func CreateRollTask(ctx context.Context, param RollTaskParam, taskName string, uid int64) (int64, error) {
+	task, info, cluster, err := createRollTask(ctx, param, taskName, uid)
+	if err != nil {
+		return 0, err
+	}
+	//先创建任务再修改集群规格, 修改失败时将未执行的任务置为失败
+	comment := fmt.Sprintf(constants.ClusterRevisionCommentRoll, task.Id)
+	if err = updateClusterSpec(ctx, cluster, info.NewImage, info.NewInstanceType, info.GetCreateUsername(), comment); err != nil {
+		failRollTask(task.Id, err)
+		return 0, err
+	}
+	return task.Id, nil
+}
+
+//createRollTask 校验并创建滚动替换任务, 不修改集群配置. 返回任务、任务信息及创建前的集群配置
+func createRollTask(ctx context.Context, param RollTaskParam, taskName string, uid int64) (*model.Task, *model.RollTaskInfo, *model.Cluster, error) {
+	if hasUnfinishedTask(param.ClusterName) {
+		return nil, nil, nil, fmt.Errorf("Cluster:%v has unfinished task", param.ClusterName)
+	}
+	cluster, err := model.GetByClusterName(param.ClusterName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if cluster == nil {
+		return nil, nil, nil, fmt.Errorf(constants.ErrClusterNotExist, param.ClusterName)
+	}
+	if chargeType := cluster.GetChargeType(); chargeType == cloud.InstanceChargeTypePrePaid {
+		return nil, nil, nil, errors.New(constants.ErrPrePaidShrinkNotSupported)
+	}
+	if param.Surge < 0 || param.MaxUnavailable < 0 {
+		return nil, nil, nil, errors.New("surge and max_unavailable can not be negative")
+	}
+	if param.Surge+param.MaxUnavailable == 0 {
+		param.Surge, param.MaxUnavailable = constants.DefaultRollSurge, constants.DefaultRollMaxUnavailable
+	}
+	if param.Image == "" {
+		param.Image = cluster.Image
+	}
+	if param.InstanceType == "" {
+		param.InstanceType = cluster.InstanceType
+	}
+	instances, err := model.GetActiveInstancesByClusterName(param.ClusterName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(instances) == 0 {
+		return nil, nil, nil, fmt.Errorf("cluster:%v has no instance to roll", param.ClusterName)
+	}
+	//与缩容一致, 开启缩容保护的实例不参与替换
+	pendingIds := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if !instance.ScaleInProtected {
+			pendingIds = append(pendingIds, instance.InstanceId)
+		}
+	}
+	if len(pendingIds) == 0 {
+		return nil, nil, nil, fmt.Errorf("cluster:%v only has instances with scale-in protection, nothing to roll", param.ClusterName)
+	}
+
+	clusterInfo, err := ConvertToClusterInfo(cluster, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	setClusterInfoSpec(clusterInfo, param.Image, param.InstanceType)
+	if err = CheckClusterParam(clusterInfo); err != nil {
+		return nil, nil, nil, err
+	}
+
+	info := &model.RollTaskInfo{
+		ClusterName:     param.ClusterName,
+		Count:           len(pendingIds),
+		Surge:           param.Surge,
+		MaxUnavailable:  param.MaxUnavailable,
+		HealthCheck:     param.HealthCheck,
+		OldImage:        cluster.Image,
+		OldInstanceType: cluster.InstanceType,
+		NewImage:        param.Image,
+		NewInstanceType: param.InstanceType,
+		PendingIds:      pendingIds,
+		TaskSubmitHost:  utils.PrivateIPv4(),
+		UserId:          uid,
+		BeforeCount:     len(pendingIds),
+	}
+	s, _ := jsoniter.MarshalToString(info)
+	logs.Logger.Infof("cluster:%v roll task info:%v", param.ClusterName, s)
+	taskId := id_generator.GetNextId()
+	task := &model.Task{
+		TaskName:      taskName,
+		TaskAction:    constants.TaskActionRoll,
+		Status:        constants.TaskStatusInit,
+		TaskFilter:    param.ClusterName,
+		TaskInfo:      s,
+		SupportCancel: true,
+	}
+	now := time.Now()
+	task.Id = int64(taskId)
+	task.CreateAt = &now
+	task.UpdateAt = &now
+	err = model.Create(task)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return task, info, cluster, nil
+}
+
+//failRollTask 将尚未执行的滚动替换任务置为失败
+func failRollTask(taskId int64, err error) {
+	if _, e := model.FinishTaskWithStatus(taskId, []string{constants.TaskStatusInit}, constants.TaskStatusFailed, err.Error()); e != nil {
+		logs.Logger.Errorf("mark roll task:%v failed error: %v", taskId, e)
+	}
+}
+
+//PauseRollTask 暂停滚动替换任务, 执行中的任务会在当前批次结束后停止
+func PauseRollTask(ctx context.Context, taskId int64) error {
+	if _, err := getRollTask(taskId); err != nil {
+		return err
+	}
+	ok, err := model.CompareAndSwapTaskStatus(taskId, []string{constants.TaskStatusInit, constants.TaskStatusRunning}, constants.TaskStatusPaused)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("only INIT or RUNNING roll task can be paused")
+	}
+	return nil
+}
+
+//ResumeRollTask 继续已暂停的滚动替换任务, 暂停前的执行器若仍在执行当前批次, 结束后不会再回写任务
+func ResumeRollTask(ctx context.Context, taskId int64) error {
+	if _, err := getRollTask(taskId); err != nil {
+		return err
+	}
+	ok, err := model.RequeueTask(taskId, []string{constants.TaskStatusPaused})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("only PAUSED roll task can be resumed")
+	}
+	return nil
+}
+
+//RollbackRollTask 回滚滚动替换任务: 集群恢复为原规格, 并将本任务已创建的实例替换回原规格
+func RollbackRollTask(ctx context.Context, taskId int64) error {
+	task, err := getRollTask(taskId)
+	if err != nil {
+		return err
+	}
+	rollbackable := []string{constants.TaskStatusPaused, constants.TaskStatusFailed, constants.TaskStatusPartialSuccess, constants.TaskStatusSuccess}
+	if !utils.ContainsString(rollbackable, task.Status) {
+		return fmt.Errorf("roll task in %v status can not be rolled back", task.Status)
+	}
+	if task.Status != constants.TaskStatusPaused && hasUnfinishedTask(task.TaskFilter) {
+		return fmt.Errorf("Cluster:%v has unfinished task", task.TaskFilter)
+	}
+	info := &model.RollTaskInfo{}
+	if err = jsoniter.UnmarshalFromString(task.TaskInfo, info); err != nil {
+		return err
+	}
+	if info.Rollback {
+		return errors.New("roll task has already been rolled back")
+	}
+	cluster, err := model.GetByClusterName(info.ClusterName)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf(constants.ErrClusterNotExist, info.ClusterName)
+	}
+	pendingIds, err := filterRollableInstanceIds(info.ClusterName, info.NewIds)
+	if err != nil {
+		return err
+	}
+	info.Rollback = true
+	info.PendingIds = pendingIds
+	info.NewIds = nil
+	info.Count = len(pendingIds)
+	info.ReplacedCount = 0
+	info.BatchNum = 0
+	s, _ := jsoniter.MarshalToString(info)
+	ok, err := model.RestartTask(taskId, []string{task.Status}, s)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("roll task status changed, please retry")
+	}
+	comment := fmt.Sprintf(constants.ClusterRevisionCommentRollRollback, taskId)
+	if err = updateClusterSpec(ctx, cluster, info.OldImage, info.OldInstanceType, info.GetCreateUsername(), comment); err != nil {
+		return fmt.Errorf("roll task restarted to roll back instances, but restore cluster spec failed: %w", err)
+	}
+	return nil
+}
+
+//RollClusterBatch 执行一个批次的滚动替换, 并将进度回写到info中
+func RollClusterBatch(c *types.ClusterInfo, info *model.RollTaskInfo, taskId int64) error {
+	pendingIds, err := filterRollableInstanceIds(c.Name, info.PendingIds)
+	if err != nil {
+		return err
+	}
+	info.PendingIds = pendingIds
+	down, up := info.NextBatch()
+	if up == 0 {
+		return nil
+	}
+	info.BatchNum++
+	victims := append([]string{}, info.PendingIds[:up]...)
+	logs.Logger.Infof("roll cluster:%v batch:%v, down:%v, up:%v, victims:%v", c.Name, info.BatchNum, down, up, victims)
+	if down > 0 {
+		if err = ShrinkClusterByInstanceIds(c, victims[:down], taskId); err != nil {
+			return err
+		}
+		info.PendingIds = info.PendingIds[down:]
+		info.ReplacedCount += down
+	}
+
+	//每个批次单独打标签, 避免按任务标签查询实例时与之前批次的实例混在一起
+	batchId := int64(id_generator.GetNextId())
+	image, instanceType := info.TargetSpec()
+	setClusterInfoSpec(c, image, instanceType)
+	availableIds, allIds, expandErr := ExpandCluster(c, up, batchId)
+	successNum := RepairCluster(c, batchId, availableIds, allIds)
+	if len(availableIds) > 0 {
+		_ = model.BatchUpdateByInstanceIds(availableIds, model.Instance{TaskId: taskId})
+		info.NewIds = append(info.NewIds, availableIds...)
+	}
+	if successNum < up {
+		if expandErr == nil {
+			expandErr = fmt.Errorf("roll batch %v expect %v instances, only %v ready", info.BatchNum, up, successNum)
+		}
+		return expandErr
+	}
+	if err = checkInstancesHealth(c, availableIds, info.HealthCheck); err != nil {
+		return err
+	}
+
+	rest := victims[down:up]
+	if len(rest) == 0 {
+		return nil
+	}
+	if err = ShrinkClusterByInstanceIds(c, rest, taskId); err != nil {
+		return err
+	}
+	info.PendingIds = info.PendingIds[len(rest):]
+	info.ReplacedCount += len(rest)
+	return nil
+}
+
+func checkInstancesHealth(c *types.ClusterInfo, instanceIds []string, hc *types.HealthCheckConfig) error {
+	if hc == nil || len(instanceIds) == 0 {
+		return nil
+	}
+	cred, err := NewSshCredential(c, hc)
+	if err != nil {
+		return err
+	}
+	instances, err := model.GetInstancesByInstanceIds(instanceIds)
+	if err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		if err = ProbeInstance(instance.IpInner, hc, cred); err != nil {
+			return fmt.Errorf("instance:%v health check failed: %w", instance.InstanceId, err)
+		}
+	}
+	return nil
+}
+
+//filterRollableInstanceIds 过滤出仍在集群中且未开启缩容保护的实例
+func filterRollableInstanceIds(clusterName string, instanceIds []string) ([]string, error) {
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]struct{}, len(instances))
+	for _, instance := range instances {
+		if !instance.ScaleInProtected {
+			active[instance.InstanceId] = struct{}{}
+		}
+	}
+	ret := make([]string, 0, len(instanceIds))
+	for _, id := range instanceIds {
+		if _, ok := active[id]; ok {
+			ret = append(ret, id)
+		}
+	}
+	return ret, nil
+}
+
+func getRollTask(taskId int64) (*model.Task, error) {
+	task := &model.Task{}
+	if err := model.Get(taskId, task); err != nil {
+		return nil, err
+	}
+	if task.TaskAction != constants.TaskActionRoll {
+		return nil, fmt.Errorf("task:%v is not a roll task", taskId)
+	}
+	return task, nil
+}
+
+func setClusterInfoSpec(c *types.ClusterInfo, image, instanceType string) {
+	c.Image = image
+	c.InstanceType = instanceType
+	if c.ImageConfig != nil && c.ImageConfig.Id != "" {
+		c.ImageConfig.Id = image
+	}
+}
+
+//updateClusterSpec 通过集群编辑修改镜像及机型, 与手动编辑一样记录为新版本
+func updateClusterSpec(ctx context.Context, cluster *model.Cluster, image, instanceType, username, comment string) error {
+	if cluster.Image == image && cluster.InstanceType == instanceType {
+		return nil
+	}
+	c := *cluster
+	c.Image = image
+	c.InstanceType = instanceType
+	if c.ImageConfig != "" {
+		imageConfig := &types.ImageConfig{}
+		if err := jsoniter.UnmarshalFromString(c.ImageConfig, imageConfig); err != nil {
+			return err
+		}
+		if imageConfig.Id != "" {
+			imageConfig.Id = image
+			c.ImageConfig, _ = jsoniter.MarshalToString(imageConfig)
+		}
+	}
+	_, err := editCluster(ctx, &c, username, comment)
+	return err
+}
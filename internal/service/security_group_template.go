@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+//SecurityGroupDrift 安全组规则相对模板的偏离. Added为云上多出的规则, Removed为云上缺少的模板规则;
+//DBAdded/DBRemoved为security_group_rule表相对云上规则多出及缺少的规则
+type SecurityGroupDrift struct {
+	SecurityGroupId string      `json:"security_group_id"`
+	TemplateId      int64       `json:"template_id"`
+	TemplateName    string      `json:"template_name"`
+	Added           []GroupRule `json:"added"`
+	Removed         []GroupRule `json:"removed"`
+	DBAdded         []GroupRule `json:"db_added"`
+	DBRemoved       []GroupRule `json:"db_removed"`
+	Remediated      bool        `json:"remediated"`
+	RemediateErr    string      `json:"remediate_err,omitempty"`
+	CheckAt         time.Time   `json:"check_at"`
+}
+
+func (d *SecurityGroupDrift) Drifted() bool {
+	return len(d.Added)+len(d.Removed)+len(d.DBAdded)+len(d.DBRemoved) > 0
+}
+
+func CreateSecurityGroupTemplate(ctx context.Context, tpl *model.SecurityGroupTemplate, rules []GroupRule) error {
+	content, err := marshalTemplateRules(rules)
+	if err != nil {
+		return err
+	}
+	existing, err := model.GetSecurityGroupTemplate(ctx, tpl.OrgId, 0, tpl.Name)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("security group template: [%v] already exists", tpl.Name)
+	}
+	now := time.Now()
+	tpl.Rules = content
+	tpl.CreateAt = &now
+	tpl.UpdateAt = &now
+	return model.Create(tpl)
+}
+
+//UpdateSecurityGroupTemplate 修改模板的规则, 已绑定的安全组在下次偏离检测时按新规则比较
+func UpdateSecurityGroupTemplate(ctx context.Context, orgId, id int64, description string, rules []GroupRule) error {
+	content, err := marshalTemplateRules(rules)
+	if err != nil {
+		return err
+	}
+	tpl, err := model.GetSecurityGroupTemplate(ctx, orgId, id, "")
+	if err != nil {
+		return err
+	}
+	if tpl == nil {
+		return fmt.Errorf("security group template: [%v] not found", id)
+	}
+	return model.UpdateSecurityGroupTemplate(ctx, orgId, id, map[string]interface{}{"description": description, "rules": content})
+}
+
+func ListSecurityGroupTemplates(ctx context.Context, orgId int64) ([]model.SecurityGroupTemplate, error) {
+	return model.GetSecurityGroupTemplatesByOrgId(ctx, orgId)
+}
+
+func DeleteSecurityGroupTemplates(ctx context.Context, orgId int64, ids []int64) error {
+	return model.DeleteSecurityGroupTemplates(ctx, orgId, ids)
+}
+
+//GetSecurityGroupTemplateRules 按id或名称获取模板及其规则
+func GetSecurityGroupTemplateRules(ctx context.Context, orgId, id int64, name string) (*model.SecurityGroupTemplate, []GroupRule, error) {
+	tpl, err := model.GetSecurityGroupTemplate(ctx, orgId, id, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tpl == nil {
+		if id > 0 {
+			return nil, nil, fmt.Errorf("security group template: [%v] not found", id)
+		}
+		return nil, nil, fmt.Errorf("security group template: [%v] not found", name)
+	}
+	rules := make([]GroupRule, 0)
+	if err = jsoniter.UnmarshalFromString(tpl.Rules, &rules); err != nil {
+		return nil, nil, err
+	}
+	return tpl, rules, nil
+}
+
+//BindSecurityGroupTemplate 将安全组绑定到模板, 之后由调度器定期检测规则是否偏离模板
+func BindSecurityGroupTemplate(ctx context.Context, orgId int64, accountKeys []string, securityGroupId string, templateId int64, autoRemediate bool) error {
+	group, err := model.FindSecurityGroupById(ctx, securityGroupId)
+	if err != nil || !utils.ContainsString(accountKeys, group.AK) {
+		return errors.New("security group not found")
+	}
+	tpl, err := model.GetSecurityGroupTemplate(ctx, orgId, templateId, "")
+	if err != nil {
+		return err
+	}
+	if tpl == nil {
+		return fmt.Errorf("security group template: [%v] not found", templateId)
+	}
+	return model.SaveSecurityGroupTemplateBinding(ctx, &model.SecurityGroupTemplateBinding{
+		OrgId:           orgId,
+		TemplateId:      tpl.Id,
+		SecurityGroupId: group.SecurityGroupId,
+		AccountKey:      group.AK,
+		Provider:        group.Provider,
+		RegionId:        group.RegionId,
+		VpcId:           group.VpcId,
+		AutoRemediate:   autoRemediate,
+	})
+}
+
+//CheckSecurityGroupDrift 立即检测已绑定模板的安全组, remediate为true时按模板修复云上规则并刷新security_group_rule表
+func CheckSecurityGroupDrift(ctx context.Context, orgId int64, securityGroupId string, remediate bool) (*SecurityGroupDrift, error) {
+	binding, err := model.GetSecurityGroupTemplateBinding(ctx, securityGroupId)
+	if err != nil {
+		return nil, err
+	}
+	if binding == nil || binding.OrgId != orgId {
+		return nil, fmt.Errorf("security group: [%v] is not bound to any template", securityGroupId)
+	}
+	return checkBindingDrift(ctx, binding, remediate)
+}
+
+//RunSecurityGroupDriftChecks 检测所有绑定了模板的安全组, 开启自动修复的直接修复, 新出现偏离或修复后发送通知
+func RunSecurityGroupDriftChecks(ctx context.Context) error {
+	bindings, err := model.GetAllSecurityGroupTemplateBindings(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range bindings {
+		binding := bindings[i]
+		drift, err := checkBindingDrift(ctx, &binding, binding.AutoRemediate)
+		if err != nil {
+			logs.Logger.Errorf("check drift of security group:%v failed, err: %v", binding.SecurityGroupId, err)
+			continue
+		}
+		if drift.Drifted() && (binding.DriftStatus != constants.SecurityGroupDrifted || drift.Remediated) {
+			notifySecurityGroupDrift(ctx, binding.OrgId, drift)
+		}
+	}
+	return nil
+}
+
+func checkBindingDrift(ctx context.Context, binding *model.SecurityGroupTemplateBinding, remediate bool) (*SecurityGroupDrift, error) {
+	drift, err := diffSecurityGroup(ctx, binding, remediate)
+	now := time.Now()
+	if err != nil {
+		_ = model.UpdateSecurityGroupDrift(ctx, binding.Id, constants.SecurityGroupDriftFailed, err.Error(), now)
+		return nil, err
+	}
+	drift.CheckAt = now
+	status := constants.SecurityGroupDriftInSync
+	if drift.Drifted() {
+		status = constants.SecurityGroupDrifted
+	}
+	report, _ := jsoniter.MarshalToString(drift)
+	if err = model.UpdateSecurityGroupDrift(ctx, binding.Id, status, report, now); err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+func diffSecurityGroup(ctx context.Context, binding *model.SecurityGroupTemplateBinding, remediate bool) (*SecurityGroupDrift, error) {
+	tpl, expected, err := GetSecurityGroupTemplateRules(ctx, binding.OrgId, binding.TemplateId, "")
+	if err != nil {
+		return nil, err
+	}
+	p, err := getProvider(binding.Provider, binding.AccountKey, binding.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	cloudRules, err := describeGroupRules(p, binding)
+	if err != nil {
+		return nil, err
+	}
+	dbRules, err := model.FindSecurityGroupRulesById(ctx, binding.SecurityGroupId)
+	if err != nil {
+		return nil, err
+	}
+	actual := cloud2GroupRules(cloudRules)
+	drift := &SecurityGroupDrift{SecurityGroupId: binding.SecurityGroupId, TemplateId: tpl.Id, TemplateName: tpl.Name}
+	drift.Added, drift.Removed = diffGroupRules(expected, actual)
+	drift.DBAdded, drift.DBRemoved = diffGroupRules(actual, model2GroupRules(dbRules))
+	if !remediate || !drift.Drifted() {
+		return drift, nil
+	}
+	if err = remediateSecurityGroup(p, binding, drift); err != nil {
+		drift.RemediateErr = err.Error()
+	}
+	//无论修复是否全部成功, 都以修复后云上的规则刷新规则表
+	if cloudRules, err = describeGroupRules(p, binding); err == nil && len(cloudRules) > 0 {
+		err = model.ReplaceRules(ctx, binding.VpcId, binding.SecurityGroupId, cloud2ModelRules(cloudRules))
+	}
+	if err != nil && drift.RemediateErr == "" {
+		drift.RemediateErr = err.Error()
+	}
+	drift.Remediated = drift.RemediateErr == ""
+	return drift, nil
+}
+
+//remediateSecurityGroup 补齐缺少的模板规则并删除多出的规则, 云厂商不支持删除规则时只补齐
+func remediateSecurityGroup(p cloud.Provider, binding *model.SecurityGroupTemplateBinding, drift *SecurityGroupDrift) error {
+	var errMsgs []string
+	for _, rule := range drift.Removed {
+		req := groupRule2CloudRequest(binding, rule)
+		var err error
+		if rule.Direction == DirectionIn {
+			err = p.AddIngressSecurityGroupRule(req)
+		} else {
+			err = p.AddEgressSecurityGroupRule(req)
+		}
+		if err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+	if len(drift.Added) > 0 {
+		revoker, ok := p.(cloud.SecurityGroupRuleRevoker)
+		if !ok {
+			errMsgs = append(errMsgs, fmt.Sprintf("provider %v does not support revoking security group rules", binding.Provider))
+		}
+		for _, rule := range drift.Added {
+			if !ok {
+				break
+			}
+			req := groupRule2CloudRequest(binding, rule)
+			var err error
+			if rule.Direction == DirectionIn {
+				err = revoker.RevokeIngressSecurityGroupRule(req)
+			} else {
+				err = revoker.RevokeEgressSecurityGroupRule(req)
+			}
+			if err != nil {
+				errMsgs = append(errMsgs, err.Error())
+			}
+		}
+	}
+	if len(errMsgs) > 0 {
+		return errors.New(strings.Join(errMsgs, "; "))
+	}
+	return nil
+}
+
+func describeGroupRules(p cloud.Provider, binding *model.SecurityGroupTemplateBinding) ([]cloud.SecurityGroupRule, error) {
+	res, err := p.DescribeGroupRules(cloud.DescribeGroupRulesRequest{RegionId: binding.RegionId, SecurityGroupId: binding.SecurityGroupId})
+	if err != nil {
+		return nil, err
+	}
+	return res.Rules, nil
+}
+
+func notifySecurityGroupDrift(ctx context.Context, orgId int64, drift *SecurityGroupDrift) {
+	Notify(ctx, NotifyEvent{
+		EventType: constants.NotifyEventSecurityGroupDrift,
+		Severity:  constants.NotifySeverityWarning,
+		OrgId:     orgId,
+		Data: map[string]interface{}{
+			"security_group_id": drift.SecurityGroupId,
+			"template_name":     drift.TemplateName,
+			"added":             formatGroupRules(drift.Added),
+			"removed":           formatGroupRules(drift.Removed),
+			"remediated":        drift.Remediated,
+		},
+	})
+}
+
+//diffGroupRules 返回actual相对expected多出及缺少的规则, 重复的规则只计一次
+func diffGroupRules(expected, actual []GroupRule) (added, removed []GroupRule) {
+	expectedKeys := make(map[string]bool, len(expected))
+	for _, rule := range expected {
+		expectedKeys[groupRuleKey(rule)] = true
+	}
+	actualKeys := make(map[string]bool, len(actual))
+	added = make([]GroupRule, 0)
+	for _, rule := range actual {
+		key := groupRuleKey(rule)
+		if !expectedKeys[key] && !actualKeys[key] {
+			added = append(added, rule)
+		}
+		actualKeys[key] = true
+	}
+	removed = make([]GroupRule, 0)
+	for _, rule := range expected {
+		key := groupRuleKey(rule)
+		if !actualKeys[key] {
+			removed = append(removed, rule)
+			actualKeys[key] = true
+		}
+	}
+	return added, removed
+}
+
+//groupRuleKey 规则的比较键. 非tcp/udp协议忽略端口, 未指定来源时与添加规则时一样视为0.0.0.0/0
+func groupRuleKey(rule GroupRule) string {
+	protocol := strings.ToLower(rule.Protocol)
+	portRange := ""
+	if protocol == cloud.ProtocolTcp || protocol == cloud.ProtocolUdp {
+		portRange = getPortRange(rule.PortFrom, rule.PortTo)
+	}
+	cidrIp := rule.CidrIp
+	if cidrIp == "" && rule.GroupId == "" && rule.PrefixListId == "" {
+		cidrIp = "0.0.0.0/0"
+	}
+	return strings.Join([]string{rule.Direction, protocol, portRange, rule.GroupId, cidrIp, rule.PrefixListId}, "|")
+}
+
+func formatGroupRules(rules []GroupRule) string {
+	keys := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		keys = append(keys, groupRuleKey(rule))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func marshalTemplateRules(rules []GroupRule) (string, error) {
+	if len(rules) == 0 {
+		return "", errors.New("template rules are required")
+	}
+	for _, rule := range rules {
+		if rule.Direction != DirectionIn && rule.Direction != DirectionOut {
+			return "", fmt.Errorf("invalid direction %s", rule.Direction)
+		}
+		if rule.Protocol == "" {
+			return "", errors.New("protocol of rule is required")
+		}
+		if rule.PortFrom > rule.PortTo || rule.PortTo > 65535 {
+			return "", fmt.Errorf("invalid port range %v-%v", rule.PortFrom, rule.PortTo)
+		}
+	}
+	return jsoniter.MarshalToString(rules)
+}
+
+func groupRule2CloudRequest(binding *model.SecurityGroupTemplateBinding, rule GroupRule) cloud.AddSecurityGroupRuleRequest {
+	vpcId := ""
+	if DoesSecurityGroupBelongsVpc(binding.Provider) {
+		vpcId = binding.VpcId
+	}
+	return cloud.AddSecurityGroupRuleRequest{
+		RegionId:        binding.RegionId,
+		VpcId:           vpcId,
+		SecurityGroupId: binding.SecurityGroupId,
+		IpProtocol:      rule.Protocol,
+		PortFrom:        rule.PortFrom,
+		PortTo:          rule.PortTo,
+		GroupId:         rule.GroupId,
+		CidrIp:          rule.CidrIp,
+		PrefixListId:    rule.PrefixListId,
+	}
+}
+
+func cloud2GroupRules(rules []cloud.SecurityGroupRule) []GroupRule {
+	ret := make([]GroupRule, 0, len(rules))
+	for _, rule := range rules {
+		ret = append(ret, GroupRule{
+			Protocol:     rule.Protocol,
+			PortFrom:     rule.PortFrom,
+			PortTo:       rule.PortTo,
+			Direction:    rule.Direction,
+			GroupId:      rule.GroupId,
+			CidrIp:       rule.CidrIp,
+			PrefixListId: rule.PrefixListId,
+		})
+	}
+	return ret
+}
+
+func model2GroupRules(rules []model.SecurityGroupRule) []GroupRule {
+	ret := make([]GroupRule, 0, len(rules))
+	for _, rule := range rules {
+		from, to := parsePortRange(rule.PortRange)
+		ret = append(ret, GroupRule{
+			Protocol:     rule.Protocol,
+			PortFrom:     from,
+			PortTo:       to,
+			Direction:    rule.Direction,
+			GroupId:      rule.GroupId,
+			CidrIp:       rule.CidrIp,
+			PrefixListId: rule.PrefixListId,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestGroupRuleKey(t *testing.T) {
+	cases := []struct {
+		a, b GroupRule
+		same bool
+	}{
+		{
+			GroupRule{Protocol: "TCP", PortFrom: 22, PortTo: 22, Direction: DirectionIn, CidrIp: "10.0.0.0/8"},
+			GroupRule{Protocol: "tcp", PortFrom: 22, PortTo: 22, Direction: DirectionIn, CidrIp: "10.0.0.0/8"},
+			true,
+		},
+		{
+			GroupRule{Protocol: "all", PortFrom: -1, PortTo: -1, Direction: DirectionIn},
+			GroupRule{Protocol: "all", Direction: DirectionIn, CidrIp: "0.0.0.0/0"},
+			true,
+		},
+		{
+			GroupRule{Protocol: "icmp", PortFrom: 1, PortTo: 1, Direction: DirectionOut, CidrIp: "0.0.0.0/0"},
+			GroupRule{Protocol: "icmp", Direction: DirectionOut, CidrIp: "0.0.0.0/0"},
+			true,
+		},
+		{
+			GroupRule{Protocol: "tcp", PortFrom: 80, PortTo: 80, Direction: DirectionIn},
+			GroupRule{Protocol: "tcp", PortFrom: 80, PortTo: 80, Direction: DirectionOut},
+			false,
+		},
+		{
+			GroupRule{Protocol: "tcp", PortFrom: 22, PortTo: 22, Direction: DirectionIn, GroupId: "sg-bastion"},
+			GroupRule{Protocol: "tcp", PortFrom: 22, PortTo: 22, Direction: DirectionIn},
+			false,
+		},
+	}
+	for _, c := range cases {
+		if got := groupRuleKey(c.a) == groupRuleKey(c.b); got != c.same {
+			t.Errorf("groupRuleKey(%+v) == groupRuleKey(%+v) is %v, want %v", c.a, c.b, got, c.same)
+		}
+	}
+}
+
+func TestDiffGroupRules(t *testing.T) {
+	web := GroupRule{Protocol: "tcp", PortFrom: 80, PortTo: 80, Direction: DirectionIn}
+	https := GroupRule{Protocol: "tcp", PortFrom: 443, PortTo: 443, Direction: DirectionIn}
+	ssh := GroupRule{Protocol: "tcp", PortFrom: 22, PortTo: 22, Direction: DirectionIn, CidrIp: "0.0.0.0/0"}
+	expected := []GroupRule{web, https}
+	actual := []GroupRule{web, ssh, ssh}
+
+	added, removed := diffGroupRules(expected, actual)
+	if len(added) != 1 || groupRuleKey(added[0]) != groupRuleKey(ssh) {
+		t.Errorf("added = %+v, want [%+v]", added, ssh)
+	}
+	if len(removed) != 1 || groupRuleKey(removed[0]) != groupRuleKey(https) {
+		t.Errorf("removed = %+v, want [%+v]", removed, https)
+	}
+
+	added, removed = diffGroupRules(expected, []GroupRule{https, web})
+	if len(added) != 0 || len(removed) != 0 {
+		t.Errorf("diffGroupRules of same rules = %+v, %+v, want no drift", added, removed)
+	}
+}
+
+func TestMarshalTemplateRules(t *testing.T) {
+	if _, err := marshalTemplateRules(nil); err == nil {
+		t.Errorf("marshalTemplateRules should fail without rules")
+	}
+	if _, err := marshalTemplateRules([]GroupRule{{Protocol: "tcp", PortFrom: 22, PortTo: 22, Direction: "inbound"}}); err == nil {
+		t.Errorf("marshalTemplateRules should fail with invalid direction")
+	}
+	if _, err := marshalTemplateRules([]GroupRule{{Protocol: "tcp", PortFrom: 443, PortTo: 80, Direction: DirectionIn}}); err == nil {
+		t.Errorf("marshalTemplateRules should fail with invalid port range")
+	}
+	if _, err := marshalTemplateRules([]GroupRule{{Protocol: "tcp", PortFrom: 22, PortTo: 22, Direction: DirectionIn}}); err != nil {
+		t.Errorf("marshalTemplateRules() error = %v", err)
+	}
+}
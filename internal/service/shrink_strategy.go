@@ -0,0 +1,252 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+//ShrinkStrategy 按数量缩容时从候选实例中选出待释放的num个实例
+type ShrinkStrategy interface {
+	Select(c *types.ClusterInfo, candidates []model.Instance, num int) ([]model.Instance, error)
+}
+
+var shrinkStrategies = map[string]func(cfg *types.ShrinkStrategyConfig) ShrinkStrategy{
+	constants.ShrinkStrategyDefault:     func(*types.ShrinkStrategyConfig) ShrinkStrategy { return defaultShrinkStrategy{} },
+	constants.ShrinkStrategyOldestFirst: func(*types.ShrinkStrategyConfig) ShrinkStrategy { return createTimeShrinkStrategy{} },
+	constants.ShrinkStrategyNewestFirst: func(*types.ShrinkStrategyConfig) ShrinkStrategy {
+		return createTimeShrinkStrategy{newestFirst: true}
+	},
+	constants.ShrinkStrategyZoneBalanced: func(*types.ShrinkStrategyConfig) ShrinkStrategy { return zoneBalancedShrinkStrategy{} },
+	constants.ShrinkStrategyBillingHour:  func(*types.ShrinkStrategyConfig) ShrinkStrategy { return billingHourShrinkStrategy{} },
+	constants.ShrinkStrategyByTag: func(cfg *types.ShrinkStrategyConfig) ShrinkStrategy {
+		return tagShrinkStrategy{tag: cloud.Tag{Key: cfg.TagKey, Value: cfg.TagValue}}
+	},
+	constants.ShrinkStrategyUnhealthyFirst: func(cfg *types.ShrinkStrategyConfig) ShrinkStrategy {
+		return unhealthyFirstShrinkStrategy{hc: cfg.HealthCheck}
+	},
+}
+
+//CheckShrinkStrategy 校验缩容策略名称及其所需参数, nil表示使用默认策略
+func CheckShrinkStrategy(cfg *types.ShrinkStrategyConfig) error {
+	if cfg == nil || cfg.Name == "" {
+		return nil
+	}
+	if _, ok := shrinkStrategies[cfg.Name]; !ok {
+		return fmt.Errorf("unsupported shrink strategy: %v", cfg.Name)
+	}
+	switch cfg.Name {
+	case constants.ShrinkStrategyByTag:
+		if cfg.TagKey == "" || cfg.TagValue == "" {
+			return errors.New("shrink strategy by_tag requires tag_key and tag_value")
+		}
+	case constants.ShrinkStrategyUnhealthyFirst:
+		if cfg.HealthCheck == nil {
+			return nil
+		}
+		if err := CheckHealthCheckConfig(cfg.HealthCheck); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//NewShrinkStrategy 根据配置创建缩容策略, 未配置时返回默认策略
+func NewShrinkStrategy(cfg *types.ShrinkStrategyConfig) (ShrinkStrategy, error) {
+	if err := CheckShrinkStrategy(cfg); err != nil {
+		return nil, err
+	}
+	if cfg == nil || cfg.Name == "" {
+		return defaultShrinkStrategy{}, nil
+	}
+	return shrinkStrategies[cfg.Name](cfg), nil
+}
+
+//resolveShrinkStrategy 请求中指定的策略优先于集群配置的策略
+func resolveShrinkStrategy(req, cluster *types.ShrinkStrategyConfig) *types.ShrinkStrategyConfig {
+	if req != nil && req.Name != "" {
+		return req
+	}
+	if cluster != nil && cluster.Name != "" {
+		return cluster
+	}
+	return &types.ShrinkStrategyConfig{Name: constants.ShrinkStrategyDefault}
+}
+
+func firstN(instances []model.Instance, num int) []model.Instance {
+	if num > len(instances) {
+		num = len(instances)
+	}
+	return instances[:num]
+}
+
+//instanceStartTime 实例开始计费的时间, 优先使用RunningAt
+func instanceStartTime(instance model.Instance) time.Time {
+	if instance.RunningAt != nil {
+		return *instance.RunningAt
+	}
+	if instance.CreateAt != nil {
+		return *instance.CreateAt
+	}
+	return time.Time{}
+}
+
+type defaultShrinkStrategy struct{}
+
+func (defaultShrinkStrategy) Select(_ *types.ClusterInfo, candidates []model.Instance, num int) ([]model.Instance, error) {
+	return firstN(candidates, num), nil
+}
+
+type createTimeShrinkStrategy struct {
+	newestFirst bool
+}
+
+func (s createTimeShrinkStrategy) Select(_ *types.ClusterInfo, candidates []model.Instance, num int) ([]model.Instance, error) {
+	sorted := append([]model.Instance(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if s.newestFirst {
+			return instanceStartTime(sorted[i]).After(instanceStartTime(sorted[j]))
+		}
+		return instanceStartTime(sorted[i]).Before(instanceStartTime(sorted[j]))
+	})
+	return firstN(sorted, num), nil
+}
+
+//zoneBalancedShrinkStrategy 每次从剩余实例最多的可用区中释放一台, 使缩容后各可用区实例数尽量均衡
+type zoneBalancedShrinkStrategy struct{}
+
+func (zoneBalancedShrinkStrategy) Select(c *types.ClusterInfo, candidates []model.Instance, num int) ([]model.Instance, error) {
+	zones := make([]string, 0)
+	byZone := make(map[string][]model.Instance)
+	for _, instance := range candidates {
+		zone := instance.ZoneId
+		if zone == "" {
+			zone = c.ZoneId
+		}
+		if _, ok := byZone[zone]; !ok {
+			zones = append(zones, zone)
+		}
+		byZone[zone] = append(byZone[zone], instance)
+	}
+	sort.Strings(zones)
+	selected := make([]model.Instance, 0, num)
+	for len(selected) < num {
+		picked := ""
+		for _, zone := range zones {
+			if len(byZone[zone]) > 0 && (picked == "" || len(byZone[zone]) > len(byZone[picked])) {
+				picked = zone
+			}
+		}
+		if picked == "" {
+			break
+		}
+		selected = append(selected, byZone[picked][0])
+		byZone[picked] = byZone[picked][1:]
+	}
+	return selected, nil
+}
+
+//billingHourShrinkStrategy 优先释放距离下一个整点计费周期最近的实例, 减少已付费时长的浪费
+type billingHourShrinkStrategy struct{}
+
+func (billingHourShrinkStrategy) Select(_ *types.ClusterInfo, candidates []model.Instance, num int) ([]model.Instance, error) {
+	now := time.Now()
+	untilNextHour := func(instance model.Instance) time.Duration {
+		return time.Hour - now.Sub(instanceStartTime(instance))%time.Hour
+	}
+	sorted := append([]model.Instance(nil), candidates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return untilNextHour(sorted[i]) < untilNextHour(sorted[j])
+	})
+	return firstN(sorted, num), nil
+}
+
+//tagShrinkStrategy 优先释放云厂商侧带有指定标签的实例, 不足时按默认顺序补齐
+type tagShrinkStrategy struct {
+	tag cloud.Tag
+}
+
+func (s tagShrinkStrategy) Select(c *types.ClusterInfo, candidates []model.Instance, num int) ([]model.Instance, error) {
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return nil, err
+	}
+	tagged, err := provider.GetInstancesByTags(c.RegionId, []cloud.Tag{s.tag})
+	if err != nil {
+		return nil, err
+	}
+	taggedIds := make(map[string]struct{}, len(tagged))
+	for _, instance := range tagged {
+		taggedIds[instance.Id] = struct{}{}
+	}
+	return preferInstances(candidates, num, func(instance model.Instance) bool {
+		_, ok := taggedIds[instance.InstanceId]
+		return ok
+	}), nil
+}
+
+//unhealthyFirstShrinkStrategy 优先释放不健康的实例. 未指定探测配置时使用集群健康检查记录的状态, 否则实时探测所有候选实例
+type unhealthyFirstShrinkStrategy struct {
+	hc *types.HealthCheckConfig
+}
+
+func (s unhealthyFirstShrinkStrategy) Select(c *types.ClusterInfo, candidates []model.Instance, num int) ([]model.Instance, error) {
+	if s.hc == nil {
+		return preferInstances(candidates, num, func(instance model.Instance) bool {
+			return instance.HealthStatus == constants.InstanceUnhealthy
+		}), nil
+	}
+	cred, err := NewSshCredential(c, s.hc)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, constants.ShrinkProbeParallelism)
+	)
+	unhealthy := make(map[string]struct{})
+	for _, instance := range candidates {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(instance model.Instance) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := ProbeInstance(instance.IpInner, s.hc, cred); err != nil {
+				logs.Logger.Infof("cluster:%v instance:%v is unhealthy: %v", c.Name, instance.InstanceId, err)
+				mu.Lock()
+				unhealthy[instance.InstanceId] = struct{}{}
+				mu.Unlock()
+			}
+		}(instance)
+	}
+	wg.Wait()
+	return preferInstances(candidates, num, func(instance model.Instance) bool {
+		_, ok := unhealthy[instance.InstanceId]
+		return ok
+	}), nil
+}
+
+//preferInstances 先选满足prefer的实例, 不足num个时按原顺序补齐
+func preferInstances(candidates []model.Instance, num int, prefer func(model.Instance) bool) []model.Instance {
+	preferred := make([]model.Instance, 0, len(candidates))
+	others := make([]model.Instance, 0, len(candidates))
+	for _, instance := range candidates {
+		if prefer(instance) {
+			preferred = append(preferred, instance)
+		} else {
+			others = append(others, instance)
+		}
+	}
+	return firstN(append(preferred, others...), num)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+)
+
+func instanceIds(instances []model.Instance) []string {
+	ids := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	return ids
+}
+
+func TestCreateTimeShrinkStrategy(t *testing.T) {
+	now := time.Now()
+	t1, t2, t3 := now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour)
+	candidates := []model.Instance{
+		{InstanceId: "b", RunningAt: &t2},
+		{InstanceId: "c", RunningAt: &t3},
+		{InstanceId: "a", RunningAt: &t1},
+	}
+	got, _ := createTimeShrinkStrategy{}.Select(nil, candidates, 2)
+	if ids := instanceIds(got); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("oldest first want [a b], got %v", ids)
+	}
+	got, _ = createTimeShrinkStrategy{newestFirst: true}.Select(nil, candidates, 2)
+	if ids := instanceIds(got); len(ids) != 2 || ids[0] != "c" || ids[1] != "b" {
+		t.Errorf("newest first want [c b], got %v", ids)
+	}
+}
+
+func TestZoneBalancedShrinkStrategy(t *testing.T) {
+	c := &types.ClusterInfo{ZoneId: "z1"}
+	candidates := []model.Instance{
+		{InstanceId: "1", ZoneId: "z1"},
+		{InstanceId: "2"},
+		{InstanceId: "3", ZoneId: "z1"},
+		{InstanceId: "4", ZoneId: "z2"},
+	}
+	got, _ := zoneBalancedShrinkStrategy{}.Select(c, candidates, 2)
+	if ids := instanceIds(got); len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Errorf("zone balanced want [1 2], got %v", ids)
+	}
+	got, _ = zoneBalancedShrinkStrategy{}.Select(c, candidates, 10)
+	if len(got) != 4 {
+		t.Errorf("zone balanced want all 4 candidates, got %v", instanceIds(got))
+	}
+}
+
+func TestBillingHourShrinkStrategy(t *testing.T) {
+	now := time.Now()
+	almostHour, justStarted := now.Add(-55*time.Minute), now.Add(-5*time.Minute)
+	candidates := []model.Instance{
+		{InstanceId: "new", RunningAt: &justStarted},
+		{InstanceId: "old", RunningAt: &almostHour},
+	}
+	got, _ := billingHourShrinkStrategy{}.Select(nil, candidates, 1)
+	if ids := instanceIds(got); len(ids) != 1 || ids[0] != "old" {
+		t.Errorf("billing hour want [old], got %v", ids)
+	}
+}
+
+func TestPreferInstances(t *testing.T) {
+	candidates := []model.Instance{{InstanceId: "1"}, {InstanceId: "2"}, {InstanceId: "3"}}
+	got := preferInstances(candidates, 2, func(instance model.Instance) bool {
+		return instance.InstanceId == "3"
+	})
+	if ids := instanceIds(got); len(ids) != 2 || ids[0] != "3" || ids[1] != "1" {
+		t.Errorf("prefer want [3 1], got %v", ids)
+	}
+}
+
+func TestCheckShrinkStrategy(t *testing.T) {
+	tests := []struct {
+		cfg     *types.ShrinkStrategyConfig
+		wantErr bool
+	}{
+		{cfg: nil},
+		{cfg: &types.ShrinkStrategyConfig{Name: constants.ShrinkStrategyOldestFirst}},
+		{cfg: &types.ShrinkStrategyConfig{Name: "random"}, wantErr: true},
+		{cfg: &types.ShrinkStrategyConfig{Name: constants.ShrinkStrategyByTag, TagKey: "role"}, wantErr: true},
+		{cfg: &types.ShrinkStrategyConfig{Name: constants.ShrinkStrategyUnhealthyFirst}},
+		{cfg: &types.ShrinkStrategyConfig{Name: constants.ShrinkStrategyUnhealthyFirst, HealthCheck: &types.HealthCheckConfig{Protocol: "ssh"}}, wantErr: true},
+		{cfg: &types.ShrinkStrategyConfig{Name: constants.ShrinkStrategyUnhealthyFirst, HealthCheck: &types.HealthCheckConfig{Protocol: "tcp", Port: 22}}},
+	}
+	for _, tt := range tests {
+		if err := CheckShrinkStrategy(tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("CheckShrinkStrategy(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+		}
+	}
+}
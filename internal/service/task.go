@@ -10,7 +10,7 @@ import (
 	"github.com/galaxy-future/BridgX/internal/constants"
 	"github.com/galaxy-future/BridgX/internal/logs"
 	"github.com/galaxy-future/BridgX/internal/model"
-	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/internal/types"
 	"github.com/galaxy-future/BridgX/pkg/id_generator"
 	"github.com/galaxy-future/BridgX/pkg/utils"
 	jsoniter "github.com/json-iterator/go"
@@ -32,6 +32,13 @@ func CreateExpandTask(ctx context.Context, clusterName string, count int, taskNa
 	if err != nil {
 		return 0, err
 	}
+	if cluster.MaxCount > 0 && int(currentCount)+count > cluster.MaxCount {
+		return 0, fmt.Errorf(constants.ErrClusterExceedMaxCount, clusterName, int(currentCount)+count, cluster.MaxCount)
+	}
+	approval, err := checkExpandApproval(ctx, cluster, count, uid)
+	if err != nil {
+		return 0, err
+	}
 	info := &model.ExpandTaskInfo{
 		ClusterName:    clusterName,
 		Count:          count,
@@ -54,13 +61,19 @@ func CreateExpandTask(ctx context.Context, clusterName string, count int, taskNa
 	task.Id = int64(taskId)
 	task.CreateAt = &now
 	task.UpdateAt = &now
-	err = model.Create(task)
+	if approval != nil {
+		task.Status = constants.TaskStatusPendingApproval
+		logs.Logger.Infof("cluster:%v expand task:%v need approval, reason:%v", clusterName, task.Id, approval.Reason)
+		err = model.CreateTaskWithApproval(ctx, task, approval)
+	} else {
+		err = model.Create(task)
+	}
 	if err != nil {
 		return 0, err
 	}
 	return task.Id, nil
 }
-func CreateShrinkTask(ctx context.Context, clusterName string, count int, ips string, taskName string, uid int64) (int64, error) {
+func CreateShrinkTask(ctx context.Context, clusterName string, count int, ips string, strategy *types.ShrinkStrategyConfig, taskName string, uid int64) (int64, error) {
 	if hasUnfinishedTask(clusterName) {
 		return 0, errors.New(fmt.Sprintf("Cluster:%v has unfinished task", clusterName))
 	}
@@ -71,13 +84,24 @@ func CreateShrinkTask(ctx context.Context, clusterName string, count int, ips st
 	if cluster == nil {
 		return 0, fmt.Errorf(constants.ErrClusterNotExist, clusterName)
 	}
-	if chargeType := cluster.GetChargeType(); chargeType == cloud.InstanceChargeTypePrePaid {
-		return 0, errors.New(constants.ErrPrePaidShrinkNotSupported)
-	}
 	currentCount, err := model.CountActiveInstancesByClusterName(ctx, []string{clusterName})
 	if err != nil {
 		return 0, err
 	}
+	if int(currentCount)-count < cluster.MinCount {
+		return 0, fmt.Errorf(constants.ErrClusterBelowMinCount, clusterName, int(currentCount)-count, cluster.MinCount)
+	}
+	if err = checkScaleInProtection(clusterName, count, ips); err != nil {
+		return 0, err
+	}
+	clusterStrategy, err := cluster.UnmarshalShrinkStrategy()
+	if err != nil {
+		return 0, err
+	}
+	strategy = resolveShrinkStrategy(strategy, clusterStrategy)
+	if err = CheckShrinkStrategy(strategy); err != nil {
+		return 0, err
+	}
 	info := &model.ShrinkTaskInfo{
 		ClusterName:    clusterName,
 		Count:          count,
@@ -85,6 +109,7 @@ func CreateShrinkTask(ctx context.Context, clusterName string, count int, ips st
 		TaskSubmitHost: utils.PrivateIPv4(),
 		UserId:         uid,
 		BeforeCount:    int(currentCount),
+		Strategy:       strategy,
 	}
 	s, _ := jsoniter.MarshalToString(info)
 	logs.Logger.Infof("cluster:%v shrink task info:%v", clusterName, s)
@@ -108,8 +133,40 @@ func CreateShrinkTask(ctx context.Context, clusterName string, count int, ips st
 	return task.Id, nil
 }
 
+//checkScaleInProtection 指定IP缩容时不允许包含受保护实例, 按数量缩容时未受保护的实例数需满足缩容数量
+func checkScaleInProtection(clusterName string, count int, ips string) error {
+	instances, err := model.GetActiveInstancesByClusterName(clusterName)
+	if err != nil {
+		return err
+	}
+	deleting := make(map[string]struct{})
+	for _, ip := range strings.Split(ips, ",") {
+		if ip != "" {
+			deleting[ip] = struct{}{}
+		}
+	}
+	protected := make([]string, 0)
+	unprotectedNum := 0
+	for _, instance := range instances {
+		if !instance.ScaleInProtected {
+			unprotectedNum++
+			continue
+		}
+		if _, ok := deleting[instance.IpInner]; ok {
+			protected = append(protected, instance.IpInner)
+		}
+	}
+	if len(protected) > 0 {
+		return fmt.Errorf(constants.ErrInstancesScaleInProtected, protected)
+	}
+	if len(deleting) == 0 && count > unprotectedNum {
+		return fmt.Errorf("cluster:%v only has %v instances without scale-in protection, can not shrink %v", clusterName, unprotectedNum, count)
+	}
+	return nil
+}
+
 func hasUnfinishedTask(clusterName string) bool {
-	cnt, err := model.CountByTaskStatus(clusterName, []string{constants.TaskStatusInit, constants.TaskStatusRunning})
+	cnt, err := model.CountByTaskStatus(clusterName, []string{constants.TaskStatusPendingApproval, constants.TaskStatusInit, constants.TaskStatusRunning, constants.TaskStatusPaused})
 	if err != nil {
 		return false
 	}
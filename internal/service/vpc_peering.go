@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/errs"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	"gorm.io/gorm"
+)
+
+//CreateVpcPeeringRequest AddRoutes为true时在两端VPC各添加一条指向对端网段的路由
+type CreateVpcPeeringRequest struct {
+	AK        string
+	VpcId     string
+	PeerVpcId string
+	Name      string
+	AddRoutes bool
+}
+
+type CreateRouteEntryRequest struct {
+	AK              string
+	VpcId           string
+	RouteTableId    string
+	DestinationCidr string
+	NextHopType     string
+	NextHopId       string
+	Name            string
+}
+
+type VpcPeering struct {
+	PeeringId     string `json:"peering_id"`
+	Name          string `json:"name"`
+	Provider      string `json:"provider"`
+	RegionId      string `json:"region_id"`
+	VpcId         string `json:"vpc_id"`
+	PeerRegionId  string `json:"peer_region_id"`
+	PeerVpcId     string `json:"peer_vpc_id"`
+	PeerPeeringId string `json:"peer_peering_id"`
+	Status        string `json:"status"`
+	CreateAt      string `json:"create_at"`
+}
+
+type RouteEntry struct {
+	RouteEntryId    string `json:"route_entry_id"`
+	RouteTableId    string `json:"route_table_id"`
+	VpcId           string `json:"vpc_id"`
+	Name            string `json:"name"`
+	DestinationCidr string `json:"destination_cidr"`
+	NextHopType     string `json:"next_hop_type"`
+	NextHopId       string `json:"next_hop_id"`
+	Status          string `json:"status"`
+}
+
+//CreateVpcPeering 两端VPC需属于同一云账户, 连接状态在下次网络同步时更新
+func CreateVpcPeering(ctx context.Context, req CreateVpcPeeringRequest) (VpcPeering, error) {
+	vpc, err := findVpc(ctx, req.VpcId)
+	if err != nil {
+		return VpcPeering{}, err
+	}
+	peerVpc, err := findVpc(ctx, req.PeerVpcId)
+	if err != nil {
+		return VpcPeering{}, err
+	}
+	if vpc.AK != req.AK || peerVpc.AK != req.AK {
+		return VpcPeering{}, errors.New("both vpcs must belong to the account")
+	}
+	if req.AddRoutes && cidrOverlaps(vpc.CidrBlock, peerVpc.CidrBlock) {
+		return VpcPeering{}, fmt.Errorf("cidr of vpc %v overlaps with vpc %v", vpc.VpcId, peerVpc.VpcId)
+	}
+	manager, err := getVpcPeeringManager(vpc.Provider, req.AK, vpc.RegionId)
+	if err != nil {
+		return VpcPeering{}, err
+	}
+	res, err := manager.CreateVpcPeering(cloud.CreateVpcPeeringRequest{
+		RegionId:     vpc.RegionId,
+		VpcId:        vpc.VpcId,
+		PeerRegionId: peerVpc.RegionId,
+		PeerVpcId:    peerVpc.VpcId,
+		Name:         req.Name,
+	})
+	if err != nil {
+		return VpcPeering{}, err
+	}
+	now := time.Now()
+	peering := model.VpcPeering{
+		Base:          model.Base{CreateAt: &now, UpdateAt: &now},
+		AK:            req.AK,
+		Provider:      vpc.Provider,
+		RegionId:      vpc.RegionId,
+		VpcId:         vpc.VpcId,
+		PeeringId:     res.PeeringId,
+		Name:          req.Name,
+		PeerRegionId:  peerVpc.RegionId,
+		PeerVpcId:     peerVpc.VpcId,
+		PeerPeeringId: res.PeerPeeringId,
+	}
+	peerings := []model.VpcPeering{peering}
+	if res.PeerPeeringId != "" {
+		peerings = append(peerings, model.VpcPeering{
+			Base:          model.Base{CreateAt: &now, UpdateAt: &now},
+			AK:            req.AK,
+			Provider:      peerVpc.Provider,
+			RegionId:      peerVpc.RegionId,
+			VpcId:         peerVpc.VpcId,
+			PeeringId:     res.PeerPeeringId,
+			Name:          req.Name,
+			PeerRegionId:  vpc.RegionId,
+			PeerVpcId:     vpc.VpcId,
+			PeerPeeringId: res.PeeringId,
+		})
+	}
+	if err = model.UpsertVpcPeerings(ctx, peerings); err != nil {
+		logs.Logger.Errorf("save vpc peering failed: %v, error: %v", res, err)
+		return VpcPeering{}, err
+	}
+	if req.AddRoutes {
+		if err = addPeeringRoutes(ctx, req.AK, peeringRouteRequests(vpc, peerVpc, res)); err != nil {
+			return model2VpcPeering(peering), err
+		}
+	}
+	return model2VpcPeering(peering), nil
+}
+
+//addPeeringRoutes 依次添加两端的路由, 任一端失败时删除已添加的路由, 连接本身保留
+func addPeeringRoutes(ctx context.Context, ak string, routes []CreateRouteEntryRequest) error {
+	added := make([]model.RouteEntry, 0, len(routes))
+	for _, route := range routes {
+		route.AK = ak
+		entry, err := createRouteEntry(ctx, route)
+		if err == nil {
+			added = append(added, entry)
+			continue
+		}
+		err = fmt.Errorf("add route to vpc %v failed: %w", route.VpcId, err)
+		for _, e := range added {
+			if rollbackErr := deleteRouteEntry(ctx, ak, e); rollbackErr != nil {
+				logs.Logger.Errorf("rollback route %v/%v failed, error: %v", e.RouteTableId, e.DestinationCidr, rollbackErr)
+				return fmt.Errorf("%w, and rollback left route %v/%v in vpc %v", err, e.RouteTableId, e.DestinationCidr, e.VpcId)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+//peeringRouteRequests 两端各添加一条目标为对端VPC网段, 下一跳为本端连接的路由
+func peeringRouteRequests(vpc, peerVpc model.Vpc, res cloud.CreateVpcPeeringResponse) []CreateRouteEntryRequest {
+	routes := []CreateRouteEntryRequest{{
+		VpcId:           vpc.VpcId,
+		DestinationCidr: peerVpc.CidrBlock,
+		NextHopType:     cloud.RouteNextHopPeering,
+		NextHopId:       res.PeeringId,
+	}}
+	peerHopId := res.PeerPeeringId
+	if peerHopId == "" {
+		peerHopId = res.PeeringId
+	}
+	routes = append(routes, CreateRouteEntryRequest{
+		VpcId:           peerVpc.VpcId,
+		DestinationCidr: vpc.CidrBlock,
+		NextHopType:     cloud.RouteNextHopPeering,
+		NextHopId:       peerHopId,
+	})
+	return routes
+}
+
+func cidrOverlaps(a, b string) bool {
+	_, netA, errA := net.ParseCIDR(a)
+	_, netB, errB := net.ParseCIDR(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return netA.Contains(netB.IP) || netB.Contains(netA.IP)
+}
+
+func DescribeVpcPeerings(ctx context.Context, ak, provider, regionId, vpcId string) ([]VpcPeering, error) {
+	peerings, err := model.FindVpcPeeringsByRegion(ctx, ak, provider, regionId)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]VpcPeering, 0, len(peerings))
+	for _, peering := range peerings {
+		if vpcId != "" && peering.VpcId != vpcId {
+			continue
+		}
+		ret = append(ret, model2VpcPeering(peering))
+	}
+	return ret, nil
+}
+
+//DeleteVpcPeering 先删除两端以该连接为下一跳的路由, 再删除连接
+func DeleteVpcPeering(ctx context.Context, aks []string, peeringId string) error {
+	peering, err := model.FindVpcPeeringById(ctx, peeringId)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !utils.ContainsString(aks, peering.AK)) {
+		return errs.ErrVpcPeeringNotExist
+	}
+	if err != nil {
+		return errs.ErrDBQueryFailed
+	}
+	hopIds := []string{peering.PeeringId}
+	if peering.PeerPeeringId != "" {
+		hopIds = append(hopIds, peering.PeerPeeringId)
+	}
+	routes, err := model.FindRouteEntriesByNextHopIds(ctx, hopIds)
+	if err != nil {
+		return errs.ErrDBQueryFailed
+	}
+	for _, route := range routes {
+		if err = deleteRouteEntry(ctx, peering.AK, route); err != nil {
+			return err
+		}
+	}
+	manager, err := getVpcPeeringManager(peering.Provider, peering.AK, peering.RegionId)
+	if err != nil {
+		return err
+	}
+	if err = manager.DeleteVpcPeering(cloud.DeleteVpcPeeringRequest{RegionId: peering.RegionId, PeeringId: peeringId}); err != nil {
+		return err
+	}
+	if err = model.SoftDeleteVpcPeerings(ctx, peering.AK, peering.Provider, peering.RegionId, []string{peeringId}); err != nil {
+		return err
+	}
+	if peering.PeerPeeringId == "" {
+		return nil
+	}
+	return model.SoftDeleteVpcPeerings(ctx, peering.AK, peering.Provider, peering.PeerRegionId, []string{peering.PeerPeeringId})
+}
+
+//CreateRouteEntry RouteTableId为空时添加到VPC的系统路由表
+func CreateRouteEntry(ctx context.Context, req CreateRouteEntryRequest) (RouteEntry, error) {
+	entry, err := createRouteEntry(ctx, req)
+	if err != nil {
+		return RouteEntry{}, err
+	}
+	return model2RouteEntry(entry), nil
+}
+
+func createRouteEntry(ctx context.Context, req CreateRouteEntryRequest) (model.RouteEntry, error) {
+	if _, _, err := net.ParseCIDR(req.DestinationCidr); err != nil {
+		return model.RouteEntry{}, fmt.Errorf("invalid destination cidr: %v", req.DestinationCidr)
+	}
+	vpc, err := findVpc(ctx, req.VpcId)
+	if err != nil {
+		return model.RouteEntry{}, err
+	}
+	if vpc.AK != req.AK {
+		return model.RouteEntry{}, errs.ErrVpcNotExist
+	}
+	manager, err := getVpcPeeringManager(vpc.Provider, vpc.AK, vpc.RegionId)
+	if err != nil {
+		return model.RouteEntry{}, err
+	}
+	res, err := manager.CreateRouteEntry(cloud.CreateRouteEntryRequest{
+		RegionId:        vpc.RegionId,
+		VpcId:           vpc.VpcId,
+		RouteTableId:    req.RouteTableId,
+		DestinationCidr: req.DestinationCidr,
+		NextHopType:     req.NextHopType,
+		NextHopId:       req.NextHopId,
+		Name:            req.Name,
+	})
+	if err != nil {
+		return model.RouteEntry{}, err
+	}
+	now := time.Now()
+	entry := model.RouteEntry{
+		Base:            model.Base{CreateAt: &now, UpdateAt: &now},
+		VpcId:           vpc.VpcId,
+		RouteTableId:    res.RouteTableId,
+		RouteEntryId:    res.RouteEntryId,
+		Name:            req.Name,
+		DestinationCidr: req.DestinationCidr,
+		NextHopType:     req.NextHopType,
+		NextHopId:       req.NextHopId,
+		VStatus:         res.Status,
+	}
+	if err = model.UpsertRouteEntries(ctx, []model.RouteEntry{entry}); err != nil {
+		logs.Logger.Errorf("save route entry failed: %v, error: %v", req, err)
+		return model.RouteEntry{}, err
+	}
+	return entry, nil
+}
+
+func DescribeRouteEntries(ctx context.Context, aks []string, vpcId string) ([]RouteEntry, error) {
+	vpc, err := findVpc(ctx, vpcId)
+	if err != nil {
+		return nil, err
+	}
+	if !utils.ContainsString(aks, vpc.AK) {
+		return nil, errs.ErrVpcNotExist
+	}
+	entries, err := model.FindRouteEntriesByVpcIds(ctx, []string{vpcId})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]RouteEntry, 0, len(entries))
+	for _, entry := range entries {
+		ret = append(ret, model2RouteEntry(entry))
+	}
+	return ret, nil
+}
+
+func DeleteRouteEntry(ctx context.Context, aks []string, routeTableId, destinationCidr string) error {
+	entry, err := model.FindRouteEntry(ctx, routeTableId, destinationCidr)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errs.ErrRouteEntryNotExist
+	}
+	if err != nil {
+		return errs.ErrDBQueryFailed
+	}
+	vpc, err := findVpc(ctx, entry.VpcId)
+	if err != nil {
+		return err
+	}
+	if !utils.ContainsString(aks, vpc.AK) {
+		return errs.ErrRouteEntryNotExist
+	}
+	return deleteRouteEntry(ctx, vpc.AK, entry)
+}
+
+func deleteRouteEntry(ctx context.Context, ak string, entry model.RouteEntry) error {
+	vpc, err := findVpc(ctx, entry.VpcId)
+	if err != nil {
+		return err
+	}
+	manager, err := getVpcPeeringManager(vpc.Provider, ak, vpc.RegionId)
+	if err != nil {
+		return err
+	}
+	err = manager.DeleteRouteEntry(cloud.DeleteRouteEntryRequest{
+		RegionId:        vpc.RegionId,
+		RouteTableId:    entry.RouteTableId,
+		RouteEntryId:    entry.RouteEntryId,
+		DestinationCidr: entry.DestinationCidr,
+		NextHopId:       entry.NextHopId,
+	})
+	if err != nil {
+		return err
+	}
+	return model.SoftDeleteRouteEntries(ctx, []model.RouteEntry{entry})
+}
+
+func getVpcPeeringManager(provider, ak, regionId string) (cloud.VpcPeeringManager, error) {
+	p, err := getProvider(provider, ak, regionId)
+	if err != nil {
+		return nil, err
+	}
+	manager, ok := p.(cloud.VpcPeeringManager)
+	if !ok {
+		return nil, fmt.Errorf("provider %v does not support vpc peering", provider)
+	}
+	return manager, nil
+}
+
+func model2VpcPeering(peering model.VpcPeering) VpcPeering {
+	ret := VpcPeering{
+		PeeringId:     peering.PeeringId,
+		Name:          peering.Name,
+		Provider:      peering.Provider,
+		RegionId:      peering.RegionId,
+		VpcId:         peering.VpcId,
+		PeerRegionId:  peering.PeerRegionId,
+		PeerVpcId:     peering.PeerVpcId,
+		PeerPeeringId: peering.PeerPeeringId,
+		Status:        peering.VStatus,
+	}
+	if peering.CreateAt != nil {
+		ret.CreateAt = utils.FormatTime(*peering.CreateAt)
+	}
+	return ret
+}
+
+func model2RouteEntry(entry model.RouteEntry) RouteEntry {
+	return RouteEntry{
+		RouteEntryId:    entry.RouteEntryId,
+		RouteTableId:    entry.RouteTableId,
+		VpcId:           entry.VpcId,
+		Name:            entry.Name,
+		DestinationCidr: entry.DestinationCidr,
+		NextHopType:     entry.NextHopType,
+		NextHopId:       entry.NextHopId,
+		Status:          entry.VStatus,
+	}
+}
+
+func cloud2ModelVpcPeerings(peerings []cloud.VpcPeering, ak, provider string) []model.VpcPeering {
+	now := time.Now()
+	ret := make([]model.VpcPeering, 0, len(peerings))
+	for _, peering := range peerings {
+		ret = append(ret, model.VpcPeering{
+			Base:          model.Base{CreateAt: &now, UpdateAt: &now},
+			AK:            ak,
+			Provider:      provider,
+			RegionId:      peering.RegionId,
+			VpcId:         peering.VpcId,
+			PeeringId:     peering.PeeringId,
+			Name:          peering.Name,
+			PeerRegionId:  peering.PeerRegionId,
+			PeerVpcId:     peering.PeerVpcId,
+			PeerPeeringId: peering.PeerPeeringId,
+			VStatus:       peering.Status,
+		})
+	}
+	return ret
+}
+
+func cloud2ModelRouteEntries(entries []cloud.RouteEntry) []model.RouteEntry {
+	now := time.Now()
+	ret := make([]model.RouteEntry, 0, len(entries))
+	for _, entry := range entries {
+		ret = append(ret, model.RouteEntry{
+			Base:            model.Base{CreateAt: &now, UpdateAt: &now},
+			VpcId:           entry.VpcId,
+			RouteTableId:    entry.RouteTableId,
+			RouteEntryId:    entry.RouteEntryId,
+			Name:            entry.Name,
+			DestinationCidr: entry.DestinationCidr,
+			NextHopType:     entry.NextHopType,
+			NextHopId:       entry.NextHopId,
+			VStatus:         entry.Status,
+		})
+	}
+	return ret
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestPeeringRouteRequests(t *testing.T) {
+	vpc := model.Vpc{VpcId: "vpc-a", CidrBlock: "10.0.0.0/16"}
+	peerVpc := model.Vpc{VpcId: "vpc-b", CidrBlock: "172.16.0.0/16"}
+
+	routes := peeringRouteRequests(vpc, peerVpc, cloud.CreateVpcPeeringResponse{PeeringId: "ri-a", PeerPeeringId: "ri-b"})
+	if len(routes) != 2 {
+		t.Fatalf("len(routes) = %v, want 2", len(routes))
+	}
+	if r := routes[0]; r.VpcId != "vpc-a" || r.DestinationCidr != "172.16.0.0/16" || r.NextHopId != "ri-a" || r.NextHopType != cloud.RouteNextHopPeering {
+		t.Errorf("route of vpc-a = %+v", r)
+	}
+	if r := routes[1]; r.VpcId != "vpc-b" || r.DestinationCidr != "10.0.0.0/16" || r.NextHopId != "ri-b" {
+		t.Errorf("route of vpc-b = %+v", r)
+	}
+
+	routes = peeringRouteRequests(vpc, peerVpc, cloud.CreateVpcPeeringResponse{PeeringId: "pcx-1"})
+	if routes[1].NextHopId != "pcx-1" {
+		t.Errorf("peer route next hop = %v, want pcx-1", routes[1].NextHopId)
+	}
+}
+
+func TestCidrOverlaps(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"10.0.0.0/16", "10.0.1.0/24", true},
+		{"10.0.1.0/24", "10.0.0.0/16", true},
+		{"10.0.0.0/16", "10.1.0.0/16", false},
+		{"10.0.0.0/16", "172.16.0.0/12", false},
+		{"10.0.0.0/16", "", false},
+	}
+	for _, c := range cases {
+		if got := cidrOverlaps(c.a, c.b); got != c.want {
+			t.Errorf("cidrOverlaps(%v, %v) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestNatGatewayFingerprint(t *testing.T) {
+	a := cloud2ModelNatGateways([]cloud.NatGateway{{NatGatewayId: "ngw-1", IpAddresses: []string{"1.1.1.2", "1.1.1.1"}}}, "ak", cloud.AlibabaCloud)
+	b := cloud2ModelNatGateways([]cloud.NatGateway{{NatGatewayId: "ngw-1", IpAddresses: []string{"1.1.1.1", "1.1.1.2"}}}, "ak", cloud.AlibabaCloud)
+	if natGatewayFingerprint(a[0]) != natGatewayFingerprint(b[0]) {
+		t.Errorf("fingerprint should not depend on ip order: %v, %v", a[0].IpAddresses, b[0].IpAddresses)
+	}
+	if a[0].IpAddresses != "1.1.1.1,1.1.1.2" {
+		t.Errorf("IpAddresses = %v, want 1.1.1.1,1.1.1.2", a[0].IpAddresses)
+	}
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/galaxy-future/BridgX/internal/constants"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/internal/model"
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/id_generator"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+//WarmPoolStatus 集群预热池的容量统计
+type WarmPoolStatus struct {
+	ClusterName string                   `json:"cluster_name"`
+	Size        int                      `json:"size"`
+	MinSize     int                      `json:"min_size"`
+	Ready       int                      `json:"ready"`     //已停机可直接使用的实例数
+	Preparing   int                      `json:"preparing"` //创建中或停机中的实例数
+	Instances   []model.WarmPoolInstance `json:"instances"`
+}
+
+func GetWarmPoolStatus(ctx context.Context, clusterName string) (*WarmPoolStatus, error) {
+	cluster, err := GetClusterByName(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	clusterInfo, err := ConvertToClusterInfo(cluster, nil)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := model.GetWarmPoolInstances(ctx, clusterName, constants.WarmPoolLiveStatuses)
+	if err != nil {
+		return nil, err
+	}
+	status := &WarmPoolStatus{ClusterName: clusterName, Instances: instances}
+	status.Size, status.MinSize = warmPoolTarget(clusterInfo.WarmPool)
+	for _, instance := range instances {
+		switch instance.Status {
+		case constants.WarmPoolStatusStopped:
+			status.Ready++
+		case constants.WarmPoolStatusPending, constants.WarmPoolStatusStopping:
+			status.Preparing++
+		}
+	}
+	return status, nil
+}
+
+//CheckWarmPoolConfig 包年包月实例停机后仍然计费, 不支持预热池
+func CheckWarmPoolConfig(clusterInfo *types.ClusterInfo) error {
+	wp := clusterInfo.WarmPool
+	if wp == nil || wp.Size == 0 {
+		return nil
+	}
+	if wp.MinSize > wp.Size {
+		return errors.New("warm pool min_size can not be greater than size")
+	}
+	if clusterInfo.ChargeConfig != nil && clusterInfo.ChargeConfig.ChargeType == cloud.InstanceChargeTypePrePaid {
+		return errors.New("warm pool is not supported for PrePaid cluster")
+	}
+	return nil
+}
+
+func warmPoolTarget(wp *types.WarmPoolConfig) (size, minSize int) {
+	if wp == nil {
+		return 0, 0
+	}
+	minSize = wp.MinSize
+	if minSize == 0 {
+		minSize = wp.Size
+	}
+	return wp.Size, minSize
+}
+
+//ReplenishWarmPools 推进所有集群预热池中实例的状态, 并按目标容量补充或释放实例
+func ReplenishWarmPools(ctx context.Context) error {
+	clusters, err := model.GetWarmPoolClusters(ctx)
+	if err != nil {
+		return err
+	}
+	names, err := model.GetWarmPoolClusterNames(ctx)
+	if err != nil {
+		return err
+	}
+	//已关闭预热池但仍有实例的集群也需要处理, 以便释放剩余实例
+	seen := make(map[string]bool, len(clusters))
+	for _, cluster := range clusters {
+		seen[cluster.ClusterName] = true
+	}
+	missing := make([]string, 0)
+	for _, name := range names {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		disabled, err := model.GetByClusterNames(missing)
+		if err != nil {
+			return err
+		}
+		clusters = append(clusters, disabled...)
+	}
+	for i := range clusters {
+		clusterInfo, err := ConvertToClusterInfo(&clusters[i], nil)
+		if err != nil {
+			logs.Logger.Errorf("convert cluster:%v failed, err: %v", clusters[i].ClusterName, err)
+			continue
+		}
+		if err = replenishWarmPool(ctx, clusterInfo); err != nil {
+			logs.Logger.Errorf("replenish warm pool of cluster:%v failed, err: %v", clusterInfo.Name, err)
+		}
+	}
+	return nil
+}
+
+func replenishWarmPool(ctx context.Context, c *types.ClusterInfo) error {
+	instances, err := model.GetWarmPoolInstances(ctx, c.Name, constants.WarmPoolLiveStatuses)
+	if err != nil {
+		return err
+	}
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return err
+	}
+	preparing := make([]string, 0)
+	for _, instance := range instances {
+		if instance.Status == constants.WarmPoolStatusPending || instance.Status == constants.WarmPoolStatusStopping {
+			preparing = append(preparing, instance.InstanceId)
+		}
+	}
+	cloudInstances := make(map[string]cloud.Instance, len(preparing))
+	if len(preparing) > 0 {
+		ret, err := provider.GetInstances(preparing)
+		if err != nil {
+			return err
+		}
+		for _, instance := range ret {
+			cloudInstances[instance.Id] = instance
+		}
+	}
+
+	size, minSize := warmPoolTarget(c.WarmPool)
+	live := make([]model.WarmPoolInstance, 0, len(instances))
+	release := make([]model.WarmPoolInstance, 0)
+	for _, instance := range instances {
+		switch instance.Status {
+		case constants.WarmPoolStatusPending:
+			ci, ok := cloudInstances[instance.InstanceId]
+			if ok && IsInstanceReady(ci, false) {
+				if err = provider.StopInstances([]string{instance.InstanceId}); err != nil {
+					logs.Logger.Errorf("stop warm pool instance:%v failed, err: %v", instance.InstanceId, err)
+					break
+				}
+				_, _ = model.UpdateWarmPoolInstances(ctx, []int64{instance.Id}, constants.WarmPoolStatusPending, map[string]interface{}{
+					"status": constants.WarmPoolStatusStopping, "ip_inner": ci.IpInner, "ip_outer": ci.IpOuter,
+				})
+			} else if isWarmPoolPrepareTimeout(instance) {
+				release = append(release, instance)
+				continue
+			}
+		case constants.WarmPoolStatusStopping:
+			if ci, ok := cloudInstances[instance.InstanceId]; ok && ci.Status == cloud.EcsStopped {
+				_, _ = model.UpdateWarmPoolInstances(ctx, []int64{instance.Id}, constants.WarmPoolStatusStopping, map[string]interface{}{
+					"status": constants.WarmPoolStatusStopped,
+				})
+			} else if isWarmPoolPrepareTimeout(instance) {
+				release = append(release, instance)
+				continue
+			}
+		case constants.WarmPoolStatusStarting:
+			//扩容任务异常中断时实例会停留在STARTING状态
+			if instance.UpdateAt != nil && time.Since(*instance.UpdateAt) > constants.WarmPoolPrepareTimeout {
+				release = append(release, instance)
+				continue
+			}
+		case constants.WarmPoolStatusStopped:
+			//集群规格变化后旧规格的实例不再可用
+			if instance.InstanceType != c.InstanceType || instance.Image != c.Image {
+				release = append(release, instance)
+				continue
+			}
+		}
+		live = append(live, instance)
+	}
+
+	//超过目标容量时优先释放已停机的实例
+	for i := len(live) - 1; i >= 0 && len(live) > size; i-- {
+		if live[i].Status == constants.WarmPoolStatusStopped {
+			release = append(release, live[i])
+			live = append(live[:i], live[i+1:]...)
+		}
+	}
+	if err = releaseWarmPoolInstances(ctx, c, release); err != nil {
+		return err
+	}
+	if len(live) >= minSize || len(live) >= size {
+		return nil
+	}
+	return prepareWarmPoolInstances(ctx, c, size-len(live))
+}
+
+func isWarmPoolPrepareTimeout(instance model.WarmPoolInstance) bool {
+	return instance.CreateAt != nil && time.Since(*instance.CreateAt) > constants.WarmPoolPrepareTimeout
+}
+
+//prepareWarmPoolInstances 按集群当前配置创建实例, 就绪后由下一轮补充任务停机
+func prepareWarmPoolInstances(ctx context.Context, c *types.ClusterInfo, num int) error {
+	batchId := int64(id_generator.GetNextId())
+	tags := []cloud.Tag{
+		{Key: cloud.TaskId, Value: strconv.FormatInt(batchId, 10)},
+		{Key: cloud.ClusterName, Value: c.Name},
+	}
+	res := expandInChunks(c, tags, num)
+	now := time.Now()
+	instances := make([]model.WarmPoolInstance, 0, len(res.InstanceIdList))
+	for _, instanceId := range res.InstanceIdList {
+		instance := model.WarmPoolInstance{
+			ClusterName:  c.Name,
+			InstanceId:   instanceId,
+			Status:       constants.WarmPoolStatusPending,
+			ZoneId:       c.ZoneId,
+			InstanceType: c.InstanceType,
+			Image:        c.Image,
+		}
+		if c.ChargeConfig != nil {
+			instance.ChargeType = c.ChargeConfig.ChargeType
+		}
+		instance.CreateAt = &now
+		instance.UpdateAt = &now
+		instances = append(instances, instance)
+	}
+	if len(instances) > 0 {
+		if err := model.BatchCreate(&instances); err != nil {
+			return err
+		}
+	}
+	logs.Logger.Infof("cluster:%v warm pool prepare %v instances, created:%v", c.Name, num, len(instances))
+	return res.Err()
+}
+
+func releaseWarmPoolInstances(ctx context.Context, c *types.ClusterInfo, instances []model.WarmPoolInstance) error {
+	if len(instances) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(instances))
+	instanceIds := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		ids = append(ids, instance.Id)
+		instanceIds = append(instanceIds, instance.InstanceId)
+	}
+	if err := Shrink(c, instanceIds); err != nil {
+		return err
+	}
+	_, err := model.UpdateWarmPoolInstances(ctx, ids, "", map[string]interface{}{"status": constants.WarmPoolStatusReleased})
+	logs.Logger.Infof("cluster:%v warm pool released instances:%v", c.Name, instanceIds)
+	return err
+}
+
+//TakeFromWarmPool 从预热池中取出最多num个已停机实例开机并加入集群, 返回成功加入的实例ID及IP
+func TakeFromWarmPool(c *types.ClusterInfo, num int, taskId int64) ([]string, []string) {
+	if c.WarmPool == nil || num <= 0 {
+		return nil, nil
+	}
+	ctx := context.Background()
+	stopped, err := model.GetWarmPoolInstances(ctx, c.Name, []string{constants.WarmPoolStatusStopped})
+	if err != nil {
+		return nil, nil
+	}
+	claimed := make(map[string]model.WarmPoolInstance)
+	for _, instance := range stopped {
+		if len(claimed) >= num {
+			break
+		}
+		if instance.InstanceType != c.InstanceType || instance.Image != c.Image {
+			continue
+		}
+		n, err := model.UpdateWarmPoolInstances(ctx, []int64{instance.Id}, constants.WarmPoolStatusStopped, map[string]interface{}{
+			"status": constants.WarmPoolStatusStarting, "task_id": taskId,
+		})
+		if err == nil && n > 0 {
+			claimed[instance.InstanceId] = instance
+		}
+	}
+	if len(claimed) == 0 {
+		return nil, nil
+	}
+	instanceIds := make([]string, 0, len(claimed))
+	for instanceId := range claimed {
+		instanceIds = append(instanceIds, instanceId)
+	}
+	started := startWarmPoolInstances(c, instanceIds)
+
+	now := time.Now()
+	availableIds := make([]string, 0, len(started))
+	ips := make([]string, 0, len(started))
+	instances := make([]model.Instance, 0, len(started))
+	used := make([]int64, 0, len(started))
+	failed := make([]model.WarmPoolInstance, 0)
+	for _, instanceId := range instanceIds {
+		ci, ok := started[instanceId]
+		if !ok {
+			failed = append(failed, claimed[instanceId])
+			continue
+		}
+		pooled := claimed[instanceId]
+		instances = append(instances, model.Instance{
+			Base:        model.Base{CreateAt: &now, UpdateAt: &now},
+			Status:      constants.Running,
+			IpInner:     ci.IpInner,
+			IpOuter:     ci.IpOuter,
+			InstanceId:  instanceId,
+			ClusterName: c.Name,
+			TaskId:      taskId,
+			ChargeType:  pooled.ChargeType,
+			ZoneId:      pooled.ZoneId,
+			RunningAt:   &now,
+		})
+		used = append(used, pooled.Id)
+		availableIds = append(availableIds, instanceId)
+		ips = append(ips, ci.IpInner)
+	}
+	if len(instances) > 0 {
+		if err = model.BatchCreateInstance(instances); err != nil {
+			logs.Logger.Errorf("save warm pool instances of cluster:%v failed, err: %v", c.Name, err)
+			return nil, nil
+		}
+		_, _ = model.UpdateWarmPoolInstances(ctx, used, constants.WarmPoolStatusStarting, map[string]interface{}{"status": constants.WarmPoolStatusUsed})
+		_ = publishExpandConfig(c.Name, availableIds, ips)
+		_ = syncClusterDNS(c.Name, availableIds)
+	}
+	//开机失败的实例直接释放, 由补充任务重新创建
+	if err = releaseWarmPoolInstances(ctx, c, failed); err != nil {
+		logs.Logger.Errorf("release warm pool instances of cluster:%v failed, err: %v", c.Name, err)
+	}
+	logs.Logger.Infof("cluster:%v task:%v took %v instances from warm pool", c.Name, taskId, len(availableIds))
+	return availableIds, ips
+}
+
+//startWarmPoolInstances 开机并等待实例运行, 返回已运行的实例
+func startWarmPoolInstances(c *types.ClusterInfo, instanceIds []string) map[string]cloud.Instance {
+	running := make(map[string]cloud.Instance, len(instanceIds))
+	provider, err := getProvider(c.Provider, c.AccountKey, c.RegionId)
+	if err != nil {
+		return running
+	}
+	if err = provider.StartInstances(instanceIds); err != nil {
+		logs.Logger.Errorf("start warm pool instances:%v failed, err: %v", instanceIds, err)
+		return running
+	}
+	needPublicIp := c.NetworkConfig != nil && c.NetworkConfig.InternetMaxBandwidthOut > 0
+	for k := 0; k < constants.WarmPoolStartCheckTimes && len(running) < len(instanceIds); k++ {
+		time.Sleep(constants.WarmPoolStartInterval)
+		instances, err := provider.GetInstances(utils.StringSliceDiff(instanceIds, mapKeys(running)))
+		if err != nil {
+			continue
+		}
+		for _, instance := range instances {
+			if IsInstanceReady(instance, needPublicIp) {
+				running[instance.Id] = instance
+			}
+		}
+	}
+	return running
+}
+
+func mapKeys(m map[string]cloud.Instance) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/galaxy-future/BridgX/internal/types"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+func TestCheckWarmPoolConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    *types.ClusterInfo
+		wantErr bool
+	}{
+		{"disabled", &types.ClusterInfo{}, false},
+		{"valid", &types.ClusterInfo{WarmPool: &types.WarmPoolConfig{Size: 5, MinSize: 2}}, false},
+		{"min greater than size", &types.ClusterInfo{WarmPool: &types.WarmPoolConfig{Size: 2, MinSize: 5}}, true},
+		{"prepaid", &types.ClusterInfo{
+			WarmPool:     &types.WarmPoolConfig{Size: 2},
+			ChargeConfig: &types.ChargeConfig{ChargeType: cloud.InstanceChargeTypePrePaid},
+		}, true},
+	}
+	for _, tt := range tests {
+		if err := CheckWarmPoolConfig(tt.info); (err != nil) != tt.wantErr {
+			t.Errorf("%v: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWarmPoolTarget(t *testing.T) {
+	if size, minSize := warmPoolTarget(&types.WarmPoolConfig{Size: 4}); size != 4 || minSize != 4 {
+		t.Errorf("default min size want 4, got %v %v", size, minSize)
+	}
+	if size, minSize := warmPoolTarget(nil); size != 0 || minSize != 0 {
+		t.Errorf("nil config want 0 0, got %v %v", size, minSize)
+	}
+}
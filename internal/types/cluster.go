@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/galaxy-future/BridgX/pkg/cloud"
 )
 
@@ -23,6 +25,9 @@ type ClusterInfo struct {
 	KeyPairName  string `json:"key_pair_name"`
 	PrivateKey   string `json:"private_key"`
 	AuthType     string `json:"auth_type"`
+	MinCount     int    `json:"min_count" binding:"min=0"`
+	MaxCount     int    `json:"max_count" binding:"min=0"` //0表示不限制
+	ExpectCount  int    `json:"expect_count" binding:"min=0"`
 	//Advanced Config
 	ImageConfig   *ImageConfig   `json:"image_config"`
 	NetworkConfig *NetworkConfig `json:"network_config"`
@@ -30,6 +35,10 @@ type ClusterInfo struct {
 	ChargeConfig  *ChargeConfig  `json:"charge_config"`
 	ExtendConfig  *ExtendConfig  `json:"extend_config"`
 
+	ShrinkStrategy *ShrinkStrategyConfig     `json:"shrink_strategy"`
+	HealthCheck    *ClusterHealthCheckConfig `json:"health_check"`
+	WarmPool       *WarmPoolConfig           `json:"warm_pool"`
+
 	//Custom Config
 	Tags map[string]string `json:"tags"`
 }
@@ -58,9 +67,18 @@ type StorageConfig struct {
 }
 
 type ChargeConfig struct {
-	ChargeType string `json:"charge_type"`
-	Period     int    `json:"period"`
-	PeriodUnit string `json:"period_unit"`
+	ChargeType  string       `json:"charge_type"`
+	Period      int          `json:"period"`
+	PeriodUnit  string       `json:"period_unit"`
+	RenewPolicy *RenewPolicy `json:"renew_policy,omitempty"`
+}
+
+//RenewPolicy 包年包月实例的自动续费策略, 到期前RenewBeforeDays天内按Period和PeriodUnit续费
+type RenewPolicy struct {
+	AutoRenew       bool   `json:"auto_renew"`
+	Period          int    `json:"period"`
+	PeriodUnit      string `json:"period_unit"`
+	RenewBeforeDays int    `json:"renew_before_days"`
 }
 
 type ExtendConfig struct {
@@ -85,3 +103,51 @@ type Pager struct {
 	PageSize   int
 	Total      int
 }
+
+//ShrinkStrategyConfig 按数量缩容时选择待释放实例的策略
+type ShrinkStrategyConfig struct {
+	Name        string             `json:"name"`         //default, oldest_first, newest_first, zone_balanced, billing_hour, by_tag, unhealthy_first
+	TagKey      string             `json:"tag_key"`      //仅by_tag使用
+	TagValue    string             `json:"tag_value"`    //仅by_tag使用
+	HealthCheck *HealthCheckConfig `json:"health_check"` //仅unhealthy_first使用, 为空时使用集群健康检查记录的状态
+}
+
+//WarmPoolConfig 预热池配置, 预先创建并停机的实例在扩容时直接开机使用
+type WarmPoolConfig struct {
+	Size    int `json:"size" binding:"min=0"`     //预热池目标实例数, 0表示不启用
+	MinSize int `json:"min_size" binding:"min=0"` //低于该值时补充到Size, 默认等于Size
+}
+
+//HealthCheckConfig 实例健康检查配置, 探测目标为实例内网IP
+type HealthCheckConfig struct {
+	Protocol   string `json:"protocol"` //tcp, http, ssh
+	Port       int    `json:"port"`
+	Path       string `json:"path"`    //仅http使用
+	Command    string `json:"command"` //仅ssh使用, 退出码为0视为健康
+	TimeoutSec int    `json:"timeout_sec"`
+	Retries    int    `json:"retries"`
+}
+
+//ClusterHealthCheckConfig 集群级别的健康检查, 由调度器周期性探测集群内所有运行中的实例
+type ClusterHealthCheckConfig struct {
+	HealthCheckConfig
+	UnhealthyThreshold int  `json:"unhealthy_threshold"` //连续失败次数达到该值判定为不健康, 默认3
+	AutoReplace        bool `json:"auto_replace"`        //是否自动替换不健康实例
+}
+
+//ClusterMembership 集群成员文档, 以JSON发布到配置中心, 每次发布Revision加1; 未发布时Revision为成员列表的哈希
+type ClusterMembership struct {
+	ClusterName string          `json:"cluster_name"`
+	Revision    int64           `json:"revision"`
+	UpdateAt    time.Time       `json:"update_at"`
+	Members     []ClusterMember `json:"members"`
+}
+
+type ClusterMember struct {
+	InstanceId string `json:"instance_id"`
+	IpInner    string `json:"ip_inner"`
+	IpOuter    string `json:"ip_outer"`
+	ZoneId     string `json:"zone_id"`
+	Status     string `json:"status"`
+	Health     string `json:"health"` //HEALTHY, UNHEALTHY, 为空表示未检查
+}
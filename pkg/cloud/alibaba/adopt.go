@@ -0,0 +1,56 @@
+package alibaba
+
+import (
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+const _maxNumTagResources = 50
+
+func (p *AlibabaCloud) GetInstancesByVpc(regionId, vpcId string) (instances []cloud.Instance, err error) {
+	cloudInstance := make([]ecs.Instance, 0)
+	nextToken := ""
+	for {
+		request := ecs.CreateDescribeInstancesRequest()
+		request.Scheme = "https"
+		request.RegionId = regionId
+		request.VpcId = vpcId
+		request.MaxResults = requests.NewInteger(100)
+		request.NextToken = nextToken
+		response, err := p.client.DescribeInstances(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeInstances AlibabaCloud failed.err: [%v] vpc[%v]", err, vpcId)
+			return nil, err
+		}
+		cloudInstance = append(cloudInstance, response.Instances.Instance...)
+		if response.NextToken == "" {
+			break
+		}
+		nextToken = response.NextToken
+	}
+	return generateInstances(cloudInstance), nil
+}
+
+// TagInstances 阿里云单次最多为50个资源打标签
+func (p *AlibabaCloud) TagInstances(regionId string, ids []string, tags []cloud.Tag) error {
+	eTags := make([]ecs.TagResourcesTag, 0, len(tags))
+	for _, tag := range tags {
+		eTags = append(eTags, ecs.TagResourcesTag{Key: tag.Key, Value: tag.Value})
+	}
+	for _, onceIds := range utils.StringSliceSplit(ids, _maxNumTagResources) {
+		request := ecs.CreateTagResourcesRequest()
+		request.Scheme = "https"
+		request.RegionId = regionId
+		request.ResourceType = "instance"
+		request.ResourceId = &onceIds
+		request.Tag = &eTags
+		if _, err := p.client.TagResources(request); err != nil {
+			logs.Logger.Errorf("TagResources AlibabaCloud failed.err: [%v] ids[%v]", err, onceIds)
+			return err
+		}
+	}
+	return nil
+}
@@ -94,3 +94,22 @@ var _subnetStatus = map[string]string{
 	"Pending":   cloud.SubnetPending,
 	"Available": cloud.SubnetAvailable,
 }
+
+var _natGatewayStatus = map[string]string{
+	"Creating":  cloud.NatGatewayPending,
+	"Available": cloud.NatGatewayAvailable,
+	"Deleting":  cloud.NatGatewayDeleting,
+}
+
+//阿里云VPC对等连接由一对路由器接口实现, 路由下一跳为路由器接口
+var _inRouteNextHopType = map[string]string{
+	cloud.RouteNextHopInstance:   "Instance",
+	cloud.RouteNextHopNatGateway: "NatGateway",
+	cloud.RouteNextHopPeering:    "RouterInterface",
+}
+
+var _outRouteNextHopType = map[string]string{
+	"Instance":        cloud.RouteNextHopInstance,
+	"NatGateway":      cloud.RouteNextHopNatGateway,
+	"RouterInterface": cloud.RouteNextHopPeering,
+}
@@ -0,0 +1,257 @@
+package alibaba
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alibabacloud-go/tea/tea"
+	vpcClient "github.com/alibabacloud-go/vpc-20160428/v2/client"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+const (
+	_natWaitInterval = 3 * time.Second
+	_natWaitTimes    = 40
+	_defaultEipBw    = 5
+)
+
+//CreateNatGateway 创建增强型NAT网关, 网关可用后申请EIP并绑定, 绑定失败时返回已创建的网关ID
+func (p *AlibabaCloud) CreateNatGateway(req cloud.CreateNatGatewayRequest) (cloud.CreateNatGatewayResponse, error) {
+	request := &vpcClient.CreateNatGatewayRequest{
+		RegionId:           tea.String(req.RegionId),
+		VpcId:              tea.String(req.VpcId),
+		VSwitchId:          tea.String(req.SwitchId),
+		Name:               tea.String(req.Name),
+		NatType:            tea.String("Enhanced"),
+		InternetChargeType: tea.String("PayByLcu"),
+	}
+	response, err := p.vpcClient.CreateNatGateway(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateNatGateway AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateNatGatewayResponse{}, err
+	}
+	res := cloud.CreateNatGatewayResponse{NatGatewayId: tea.StringValue(response.Body.NatGatewayId)}
+	if response.Body.SnatTableIds != nil && len(response.Body.SnatTableIds.SnatTableId) > 0 {
+		res.SnatTableId = tea.StringValue(response.Body.SnatTableIds.SnatTableId[0])
+	}
+	if err = p.waitNatGatewayAvailable(req.RegionId, res.NatGatewayId); err != nil {
+		return res, err
+	}
+
+	bandwidth := req.Bandwidth
+	if bandwidth <= 0 {
+		bandwidth = _defaultEipBw
+	}
+	eip, err := p.vpcClient.AllocateEipAddress(&vpcClient.AllocateEipAddressRequest{
+		RegionId:           tea.String(req.RegionId),
+		Bandwidth:          tea.String(fmt.Sprint(bandwidth)),
+		InternetChargeType: tea.String("PayByTraffic"),
+		Name:               tea.String(req.Name),
+	})
+	if err != nil {
+		logs.Logger.Errorf("AllocateEipAddress AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return res, err
+	}
+	res.EipId = tea.StringValue(eip.Body.AllocationId)
+	res.EipAddress = tea.StringValue(eip.Body.EipAddress)
+	_, err = p.vpcClient.AssociateEipAddress(&vpcClient.AssociateEipAddressRequest{
+		RegionId:     tea.String(req.RegionId),
+		AllocationId: tea.String(res.EipId),
+		InstanceId:   tea.String(res.NatGatewayId),
+		InstanceType: tea.String("Nat"),
+	})
+	if err != nil {
+		logs.Logger.Errorf("AssociateEipAddress AlibabaCloud failed.err: [%v], nat[%v] eip[%v]", err, res.NatGatewayId, res.EipId)
+		return res, err
+	}
+	return res, nil
+}
+
+func (p *AlibabaCloud) waitNatGatewayAvailable(regionId, natGatewayId string) error {
+	for i := 0; i < _natWaitTimes; i++ {
+		res, err := p.describeNatGateways(&vpcClient.DescribeNatGatewaysRequest{
+			RegionId:     tea.String(regionId),
+			NatGatewayId: tea.String(natGatewayId),
+		})
+		if err != nil {
+			return err
+		}
+		if len(res) > 0 && res[0].Status == cloud.NatGatewayAvailable {
+			return nil
+		}
+		time.Sleep(_natWaitInterval)
+	}
+	return fmt.Errorf("nat gateway %v is not available after %v", natGatewayId, _natWaitInterval*_natWaitTimes)
+}
+
+func (p *AlibabaCloud) DescribeNatGateways(req cloud.DescribeNatGatewaysRequest) (cloud.DescribeNatGatewaysResponse, error) {
+	request := &vpcClient.DescribeNatGatewaysRequest{RegionId: tea.String(req.RegionId)}
+	if req.VpcId != "" {
+		request.VpcId = tea.String(req.VpcId)
+	}
+	gateways, err := p.describeNatGateways(request)
+	if err != nil {
+		return cloud.DescribeNatGatewaysResponse{}, err
+	}
+	return cloud.DescribeNatGatewaysResponse{NatGateways: gateways}, nil
+}
+
+func (p *AlibabaCloud) describeNatGateways(request *vpcClient.DescribeNatGatewaysRequest) ([]cloud.NatGateway, error) {
+	var page int32 = 1
+	gateways := make([]cloud.NatGateway, 0)
+	for {
+		request.PageNumber = tea.Int32(page)
+		request.PageSize = tea.Int32(50)
+		response, err := p.vpcClient.DescribeNatGateways(request)
+		if err != nil {
+			logs.Logger.Errorf("DescribeNatGateways AlibabaCloud failed.err: [%v], req[%v]", err, request)
+			return nil, err
+		}
+		if response.Body.NatGateways == nil {
+			break
+		}
+		for _, nat := range response.Body.NatGateways.NatGateway {
+			gateway := cloud.NatGateway{
+				NatGatewayId: tea.StringValue(nat.NatGatewayId),
+				Name:         tea.StringValue(nat.Name),
+				RegionId:     tea.StringValue(nat.RegionId),
+				VpcId:        tea.StringValue(nat.VpcId),
+				Status:       _natGatewayStatus[tea.StringValue(nat.Status)],
+				CreateAt:     tea.StringValue(nat.CreationTime),
+				IpAddresses:  make([]string, 0),
+			}
+			if nat.NatGatewayPrivateInfo != nil {
+				gateway.SwitchId = tea.StringValue(nat.NatGatewayPrivateInfo.VswitchId)
+			}
+			if nat.SnatTableIds != nil && len(nat.SnatTableIds.SnatTableId) > 0 {
+				gateway.SnatTableId = tea.StringValue(nat.SnatTableIds.SnatTableId[0])
+			}
+			if nat.IpLists != nil {
+				for _, ip := range nat.IpLists.IpList {
+					gateway.IpAddresses = append(gateway.IpAddresses, tea.StringValue(ip.IpAddress))
+				}
+			}
+			gateways = append(gateways, gateway)
+		}
+		if tea.Int32Value(response.Body.TotalCount) > page*50 {
+			page++
+		} else {
+			break
+		}
+	}
+	return gateways, nil
+}
+
+//DeleteNatGateway 强制删除NAT网关及其SNAT条目, 并释放网关绑定的EIP
+func (p *AlibabaCloud) DeleteNatGateway(req cloud.DeleteNatGatewayRequest) error {
+	eips, err := p.vpcClient.DescribeEipAddresses(&vpcClient.DescribeEipAddressesRequest{
+		RegionId:               tea.String(req.RegionId),
+		AssociatedInstanceType: tea.String("Nat"),
+		AssociatedInstanceId:   tea.String(req.NatGatewayId),
+		PageSize:               tea.Int32(50),
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeEipAddresses AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	_, err = p.vpcClient.DeleteNatGateway(&vpcClient.DeleteNatGatewayRequest{
+		RegionId:     tea.String(req.RegionId),
+		NatGatewayId: tea.String(req.NatGatewayId),
+		Force:        tea.Bool(true),
+	})
+	if err != nil {
+		logs.Logger.Errorf("DeleteNatGateway AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	if eips.Body.EipAddresses == nil {
+		return nil
+	}
+	for _, eip := range eips.Body.EipAddresses.EipAddress {
+		p.releaseEip(req.RegionId, tea.StringValue(eip.AllocationId))
+	}
+	return nil
+}
+
+//releaseEip EIP解绑是异步的, 解绑完成前释放会失败, 因此需要重试
+func (p *AlibabaCloud) releaseEip(regionId, allocationId string) {
+	var err error
+	for i := 0; i < _natWaitTimes; i++ {
+		_, err = p.vpcClient.ReleaseEipAddress(&vpcClient.ReleaseEipAddressRequest{
+			RegionId:     tea.String(regionId),
+			AllocationId: tea.String(allocationId),
+		})
+		if err == nil {
+			return
+		}
+		time.Sleep(_natWaitInterval)
+	}
+	logs.Logger.Errorf("ReleaseEipAddress AlibabaCloud failed.err: [%v], eip[%v]", err, allocationId)
+}
+
+func (p *AlibabaCloud) CreateSnatEntry(req cloud.CreateSnatEntryRequest) (cloud.CreateSnatEntryResponse, error) {
+	request := &vpcClient.CreateSnatEntryRequest{
+		RegionId:        tea.String(req.RegionId),
+		SnatTableId:     tea.String(req.SnatTableId),
+		SourceVSwitchId: tea.String(req.SwitchId),
+		SnatIp:          tea.String(req.SnatIp),
+	}
+	if req.Name != "" {
+		request.SnatEntryName = tea.String(req.Name)
+	}
+	response, err := p.vpcClient.CreateSnatEntry(request)
+	if err != nil {
+		logs.Logger.Errorf("CreateSnatEntry AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateSnatEntryResponse{}, err
+	}
+	return cloud.CreateSnatEntryResponse{SnatEntryId: tea.StringValue(response.Body.SnatEntryId)}, nil
+}
+
+func (p *AlibabaCloud) DescribeSnatEntries(req cloud.DescribeSnatEntriesRequest) (cloud.DescribeSnatEntriesResponse, error) {
+	var page int32 = 1
+	entries := make([]cloud.SnatEntry, 0)
+	for {
+		response, err := p.vpcClient.DescribeSnatTableEntries(&vpcClient.DescribeSnatTableEntriesRequest{
+			RegionId:    tea.String(req.RegionId),
+			SnatTableId: tea.String(req.SnatTableId),
+			PageNumber:  tea.Int32(page),
+			PageSize:    tea.Int32(50),
+		})
+		if err != nil {
+			logs.Logger.Errorf("DescribeSnatTableEntries AlibabaCloud failed.err: [%v], req[%v]", err, req)
+			return cloud.DescribeSnatEntriesResponse{}, err
+		}
+		if response.Body.SnatTableEntries == nil {
+			break
+		}
+		for _, entry := range response.Body.SnatTableEntries.SnatTableEntry {
+			entries = append(entries, cloud.SnatEntry{
+				SnatEntryId: tea.StringValue(entry.SnatEntryId),
+				SnatTableId: tea.StringValue(entry.SnatTableId),
+				Name:        tea.StringValue(entry.SnatEntryName),
+				SwitchId:    tea.StringValue(entry.SourceVSwitchId),
+				SourceCidr:  tea.StringValue(entry.SourceCIDR),
+				SnatIp:      tea.StringValue(entry.SnatIp),
+				Status:      tea.StringValue(entry.Status),
+			})
+		}
+		if tea.Int32Value(response.Body.TotalCount) > page*50 {
+			page++
+		} else {
+			break
+		}
+	}
+	return cloud.DescribeSnatEntriesResponse{SnatEntries: entries}, nil
+}
+
+func (p *AlibabaCloud) DeleteSnatEntry(req cloud.DeleteSnatEntryRequest) error {
+	_, err := p.vpcClient.DeleteSnatEntry(&vpcClient.DeleteSnatEntryRequest{
+		RegionId:    tea.String(req.RegionId),
+		SnatTableId: tea.String(req.SnatTableId),
+		SnatEntryId: tea.String(req.SnatEntryId),
+	})
+	if err != nil {
+		logs.Logger.Errorf("DeleteSnatEntry AlibabaCloud failed.err: [%v], req[%v]", err, req)
+	}
+	return err
+}
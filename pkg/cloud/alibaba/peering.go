@@ -0,0 +1,362 @@
+package alibaba
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alibabacloud-go/tea/tea"
+	vpcClient "github.com/alibabacloud-go/vpc-20160428/v2/client"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+const (
+	_routerTypeVRouter     = "VRouter"
+	_routerInterfaceSpec   = "Large.2"
+	_routerInterfaceActive = "Active"
+	_routeTableTypeSystem  = "System"
+	_routeEntryTypeCustom  = "Custom"
+)
+
+//CreateVpcPeering 阿里云通过一对路由器接口连通两个VPC, 先创建接收端再由发起端发起连接, PeeringId为发起端接口ID
+func (p *AlibabaCloud) CreateVpcPeering(req cloud.CreateVpcPeeringRequest) (cloud.CreateVpcPeeringResponse, error) {
+	routerId, err := p.vpcRouterId(req.RegionId, req.VpcId)
+	if err != nil {
+		return cloud.CreateVpcPeeringResponse{}, err
+	}
+	peerRouterId, err := p.vpcRouterId(req.PeerRegionId, req.PeerVpcId)
+	if err != nil {
+		return cloud.CreateVpcPeeringResponse{}, err
+	}
+
+	acceptor := ecs.CreateCreateRouterInterfaceRequest()
+	acceptor.Scheme = "https"
+	acceptor.RegionId = req.PeerRegionId
+	acceptor.Role = "AcceptingSide"
+	acceptor.Spec = "Negative"
+	acceptor.RouterType = _routerTypeVRouter
+	acceptor.RouterId = peerRouterId
+	acceptor.OppositeRegionId = req.RegionId
+	acceptor.OppositeRouterType = _routerTypeVRouter
+	acceptor.OppositeRouterId = routerId
+	acceptor.Name = req.Name
+	acceptorRes, err := p.client.CreateRouterInterface(acceptor)
+	if err != nil {
+		logs.Logger.Errorf("CreateRouterInterface AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateVpcPeeringResponse{}, err
+	}
+	res := cloud.CreateVpcPeeringResponse{PeerPeeringId: acceptorRes.RouterInterfaceId}
+
+	initiator := ecs.CreateCreateRouterInterfaceRequest()
+	initiator.Scheme = "https"
+	initiator.RegionId = req.RegionId
+	initiator.Role = "InitiatingSide"
+	initiator.Spec = _routerInterfaceSpec
+	initiator.InstanceChargeType = "PostPaid"
+	initiator.RouterType = _routerTypeVRouter
+	initiator.RouterId = routerId
+	initiator.OppositeRegionId = req.PeerRegionId
+	initiator.OppositeRouterType = _routerTypeVRouter
+	initiator.OppositeRouterId = peerRouterId
+	initiator.OppositeInterfaceId = res.PeerPeeringId
+	initiator.Name = req.Name
+	initiatorRes, err := p.client.CreateRouterInterface(initiator)
+	if err != nil {
+		logs.Logger.Errorf("CreateRouterInterface AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateVpcPeeringResponse{}, p.rollbackVpcPeering(req, res, err)
+	}
+	res.PeeringId = initiatorRes.RouterInterfaceId
+
+	_, err = p.vpcClient.ModifyRouterInterfaceAttribute(&vpcClient.ModifyRouterInterfaceAttributeRequest{
+		RegionId:            tea.String(req.PeerRegionId),
+		RouterInterfaceId:   tea.String(res.PeerPeeringId),
+		OppositeInterfaceId: tea.String(res.PeeringId),
+		OppositeRouterId:    tea.String(routerId),
+		OppositeRouterType:  tea.String(_routerTypeVRouter),
+	})
+	if err != nil {
+		logs.Logger.Errorf("ModifyRouterInterfaceAttribute AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateVpcPeeringResponse{}, p.rollbackVpcPeering(req, res, err)
+	}
+	_, err = p.vpcClient.ConnectRouterInterface(&vpcClient.ConnectRouterInterfaceRequest{
+		RegionId:          tea.String(req.RegionId),
+		RouterInterfaceId: tea.String(res.PeeringId),
+	})
+	if err != nil {
+		logs.Logger.Errorf("ConnectRouterInterface AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateVpcPeeringResponse{}, p.rollbackVpcPeering(req, res, err)
+	}
+	return res, nil
+}
+
+//rollbackVpcPeering 连接建立失败时删除已创建的路由器接口, 删除失败时在错误中返回残留的接口ID
+func (p *AlibabaCloud) rollbackVpcPeering(req cloud.CreateVpcPeeringRequest, res cloud.CreateVpcPeeringResponse, cause error) error {
+	left := make([]string, 0, 2)
+	if res.PeeringId != "" {
+		if err := p.deleteRouterInterface(req.RegionId, res.PeeringId); err != nil {
+			left = append(left, res.PeeringId)
+		}
+	}
+	if err := p.deleteRouterInterface(req.PeerRegionId, res.PeerPeeringId); err != nil {
+		left = append(left, res.PeerPeeringId)
+	}
+	if len(left) > 0 {
+		return fmt.Errorf("%w, and rollback left router interfaces %v", cause, left)
+	}
+	return cause
+}
+
+func (p *AlibabaCloud) vpcRouterId(regionId, vpcId string) (string, error) {
+	response, err := p.vpcClient.DescribeVpcAttribute(&vpcClient.DescribeVpcAttributeRequest{
+		RegionId: tea.String(regionId),
+		VpcId:    tea.String(vpcId),
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeVpcAttribute AlibabaCloud failed.err: [%v], vpc[%v]", err, vpcId)
+		return "", err
+	}
+	return tea.StringValue(response.Body.VRouterId), nil
+}
+
+//DescribeVpcPeerings 返回地域下两端均为VPC路由器的接口, 每个接口对应一端的连接
+func (p *AlibabaCloud) DescribeVpcPeerings(req cloud.DescribeVpcPeeringsRequest) (cloud.DescribeVpcPeeringsResponse, error) {
+	interfaces, err := p.describeRouterInterfaces(req.RegionId, &vpcClient.DescribeRouterInterfacesRequestFilter{
+		Key:   tea.String("RouterType"),
+		Value: []*string{tea.String(_routerTypeVRouter)},
+	})
+	if err != nil {
+		return cloud.DescribeVpcPeeringsResponse{}, err
+	}
+	peerings := make([]cloud.VpcPeering, 0, len(interfaces))
+	for _, ri := range interfaces {
+		if tea.StringValue(ri.OppositeRouterType) != _routerTypeVRouter {
+			continue
+		}
+		peerings = append(peerings, cloud.VpcPeering{
+			PeeringId:     tea.StringValue(ri.RouterInterfaceId),
+			Name:          tea.StringValue(ri.Name),
+			RegionId:      req.RegionId,
+			VpcId:         tea.StringValue(ri.VpcInstanceId),
+			PeerRegionId:  tea.StringValue(ri.OppositeRegionId),
+			PeerVpcId:     tea.StringValue(ri.OppositeVpcInstanceId),
+			PeerPeeringId: tea.StringValue(ri.OppositeInterfaceId),
+			Status:        tea.StringValue(ri.Status),
+			CreateAt:      tea.StringValue(ri.CreationTime),
+		})
+	}
+	return cloud.DescribeVpcPeeringsResponse{Peerings: peerings}, nil
+}
+
+func (p *AlibabaCloud) describeRouterInterfaces(regionId string, filter *vpcClient.DescribeRouterInterfacesRequestFilter) ([]*vpcClient.DescribeRouterInterfacesResponseBodyRouterInterfaceSetRouterInterfaceType, error) {
+	var page int32 = 1
+	ret := make([]*vpcClient.DescribeRouterInterfacesResponseBodyRouterInterfaceSetRouterInterfaceType, 0)
+	for {
+		response, err := p.vpcClient.DescribeRouterInterfaces(&vpcClient.DescribeRouterInterfacesRequest{
+			RegionId:   tea.String(regionId),
+			Filter:     []*vpcClient.DescribeRouterInterfacesRequestFilter{filter},
+			PageNumber: tea.Int32(page),
+			PageSize:   tea.Int32(50),
+		})
+		if err != nil {
+			logs.Logger.Errorf("DescribeRouterInterfaces AlibabaCloud failed.err: [%v], region[%v] filter[%v]", err, regionId, filter)
+			return nil, err
+		}
+		if response.Body.RouterInterfaceSet == nil {
+			break
+		}
+		ret = append(ret, response.Body.RouterInterfaceSet.RouterInterfaceType...)
+		if tea.Int32Value(response.Body.TotalCount) > page*50 {
+			page++
+		} else {
+			break
+		}
+	}
+	return ret, nil
+}
+
+//DeleteVpcPeering 同时删除两端的路由器接口, 接口需先停用才能删除
+func (p *AlibabaCloud) DeleteVpcPeering(req cloud.DeleteVpcPeeringRequest) error {
+	interfaces, err := p.describeRouterInterfaces(req.RegionId, &vpcClient.DescribeRouterInterfacesRequestFilter{
+		Key:   tea.String("RouterInterfaceId"),
+		Value: []*string{tea.String(req.PeeringId)},
+	})
+	if err != nil {
+		return err
+	}
+	if len(interfaces) == 0 {
+		return fmt.Errorf("vpc peering %v not found", req.PeeringId)
+	}
+	ri := interfaces[0]
+	ends := [][2]string{{req.RegionId, req.PeeringId}}
+	if opposite := tea.StringValue(ri.OppositeInterfaceId); opposite != "" {
+		ends = append(ends, [2]string{tea.StringValue(ri.OppositeRegionId), opposite})
+	}
+	if tea.StringValue(ri.Status) == _routerInterfaceActive {
+		for _, end := range ends {
+			_, err = p.vpcClient.DeactivateRouterInterface(&vpcClient.DeactivateRouterInterfaceRequest{
+				RegionId:          tea.String(end[0]),
+				RouterInterfaceId: tea.String(end[1]),
+			})
+			if err != nil {
+				logs.Logger.Errorf("DeactivateRouterInterface AlibabaCloud failed.err: [%v], interface[%v]", err, end[1])
+				return err
+			}
+		}
+	}
+	for _, end := range ends {
+		if err = p.deleteRouterInterface(end[0], end[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//deleteRouterInterface 接口停用是异步的, 停用完成前删除会失败, 因此需要重试
+func (p *AlibabaCloud) deleteRouterInterface(regionId, id string) error {
+	var err error
+	for i := 0; i < _natWaitTimes; i++ {
+		_, err = p.vpcClient.DeleteRouterInterface(&vpcClient.DeleteRouterInterfaceRequest{
+			RegionId:          tea.String(regionId),
+			RouterInterfaceId: tea.String(id),
+		})
+		if err == nil {
+			return nil
+		}
+		time.Sleep(_natWaitInterval)
+	}
+	logs.Logger.Errorf("DeleteRouterInterface AlibabaCloud failed.err: [%v], interface[%v]", err, id)
+	return err
+}
+
+func (p *AlibabaCloud) CreateRouteEntry(req cloud.CreateRouteEntryRequest) (cloud.CreateRouteEntryResponse, error) {
+	routeTableId := req.RouteTableId
+	if routeTableId == "" {
+		tables, err := p.describeRouteTables(req.RegionId, req.VpcId)
+		if err != nil {
+			return cloud.CreateRouteEntryResponse{}, err
+		}
+		for _, table := range tables {
+			if tea.StringValue(table.RouteTableType) == _routeTableTypeSystem {
+				routeTableId = tea.StringValue(table.RouteTableId)
+			}
+		}
+		if routeTableId == "" {
+			return cloud.CreateRouteEntryResponse{}, fmt.Errorf("system route table of vpc %v not found", req.VpcId)
+		}
+	}
+	request := &vpcClient.CreateRouteEntryRequest{
+		RegionId:             tea.String(req.RegionId),
+		RouteTableId:         tea.String(routeTableId),
+		DestinationCidrBlock: tea.String(req.DestinationCidr),
+		NextHopType:          tea.String(_inRouteNextHopType[req.NextHopType]),
+		NextHopId:            tea.String(req.NextHopId),
+	}
+	if req.Name != "" {
+		request.RouteEntryName = tea.String(req.Name)
+	}
+	if _, err := p.vpcClient.CreateRouteEntry(request); err != nil {
+		logs.Logger.Errorf("CreateRouteEntry AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return cloud.CreateRouteEntryResponse{}, err
+	}
+	res := cloud.CreateRouteEntryResponse{RouteTableId: routeTableId}
+	//创建接口不返回路由ID, 按路由表及目标网段查询
+	response, err := p.vpcClient.DescribeRouteEntryList(&vpcClient.DescribeRouteEntryListRequest{
+		RegionId:             tea.String(req.RegionId),
+		RouteTableId:         tea.String(routeTableId),
+		DestinationCidrBlock: tea.String(req.DestinationCidr),
+		RouteEntryType:       tea.String(_routeEntryTypeCustom),
+	})
+	if err != nil {
+		logs.Logger.Warnf("DescribeRouteEntryList AlibabaCloud failed.err: [%v], table[%v] cidr[%v]", err, routeTableId, req.DestinationCidr)
+		return res, nil
+	}
+	if response.Body.RouteEntrys != nil && len(response.Body.RouteEntrys.RouteEntry) > 0 {
+		entry := response.Body.RouteEntrys.RouteEntry[0]
+		res.RouteEntryId = tea.StringValue(entry.RouteEntryId)
+		res.Status = tea.StringValue(entry.Status)
+	}
+	return res, nil
+}
+
+func (p *AlibabaCloud) describeRouteTables(regionId, vpcId string) ([]*vpcClient.DescribeRouteTableListResponseBodyRouterTableListRouterTableListType, error) {
+	response, err := p.vpcClient.DescribeRouteTableList(&vpcClient.DescribeRouteTableListRequest{
+		RegionId: tea.String(regionId),
+		VpcId:    tea.String(vpcId),
+		PageSize: tea.Int32(50),
+	})
+	if err != nil {
+		logs.Logger.Errorf("DescribeRouteTableList AlibabaCloud failed.err: [%v], vpc[%v]", err, vpcId)
+		return nil, err
+	}
+	if response.Body.RouterTableList == nil {
+		return nil, nil
+	}
+	return response.Body.RouterTableList.RouterTableListType, nil
+}
+
+//DescribeRouteEntries 只返回自定义路由, 系统路由由云厂商维护
+func (p *AlibabaCloud) DescribeRouteEntries(req cloud.DescribeRouteEntriesRequest) (cloud.DescribeRouteEntriesResponse, error) {
+	tables, err := p.describeRouteTables(req.RegionId, req.VpcId)
+	if err != nil {
+		return cloud.DescribeRouteEntriesResponse{}, err
+	}
+	entries := make([]cloud.RouteEntry, 0)
+	for _, table := range tables {
+		var nextToken *string
+		for {
+			response, err := p.vpcClient.DescribeRouteEntryList(&vpcClient.DescribeRouteEntryListRequest{
+				RegionId:       tea.String(req.RegionId),
+				RouteTableId:   table.RouteTableId,
+				RouteEntryType: tea.String(_routeEntryTypeCustom),
+				MaxResult:      tea.Int32(100),
+				NextToken:      nextToken,
+			})
+			if err != nil {
+				logs.Logger.Errorf("DescribeRouteEntryList AlibabaCloud failed.err: [%v], table[%v]", err, tea.StringValue(table.RouteTableId))
+				return cloud.DescribeRouteEntriesResponse{}, err
+			}
+			if response.Body.RouteEntrys != nil {
+				for _, entry := range response.Body.RouteEntrys.RouteEntry {
+					e := cloud.RouteEntry{
+						RouteEntryId:    tea.StringValue(entry.RouteEntryId),
+						RouteTableId:    tea.StringValue(entry.RouteTableId),
+						VpcId:           req.VpcId,
+						Name:            tea.StringValue(entry.RouteEntryName),
+						DestinationCidr: tea.StringValue(entry.DestinationCidrBlock),
+						Status:          tea.StringValue(entry.Status),
+					}
+					if entry.NextHops != nil && len(entry.NextHops.NextHop) > 0 {
+						hop := entry.NextHops.NextHop[0]
+						e.NextHopType = _outRouteNextHopType[tea.StringValue(hop.NextHopType)]
+						e.NextHopId = tea.StringValue(hop.NextHopId)
+					}
+					entries = append(entries, e)
+				}
+			}
+			if tea.StringValue(response.Body.NextToken) == "" {
+				break
+			}
+			nextToken = response.Body.NextToken
+		}
+	}
+	return cloud.DescribeRouteEntriesResponse{RouteEntries: entries}, nil
+}
+
+func (p *AlibabaCloud) DeleteRouteEntry(req cloud.DeleteRouteEntryRequest) error {
+	request := &vpcClient.DeleteRouteEntryRequest{
+		RegionId:     tea.String(req.RegionId),
+		RouteTableId: tea.String(req.RouteTableId),
+	}
+	if req.RouteEntryId != "" {
+		request.RouteEntryId = tea.String(req.RouteEntryId)
+	} else {
+		request.DestinationCidrBlock = tea.String(req.DestinationCidr)
+		request.NextHopId = tea.String(req.NextHopId)
+	}
+	if _, err := p.vpcClient.DeleteRouteEntry(request); err != nil {
+		logs.Logger.Errorf("DeleteRouteEntry AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
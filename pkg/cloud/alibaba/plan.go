@@ -0,0 +1,97 @@
+package alibaba
+
+import (
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/spf13/cast"
+)
+
+const (
+	_attrMaxPostPaidVCpu  = "max-postpaid-instance-vcpu-count"
+	_attrUsedPostPaidVCpu = "used-postpaid-instance-vcpu-count"
+)
+
+// DescribeInstanceQuota 阿里云仅对按量付费实例有vCPU配额限制
+func (p *AlibabaCloud) DescribeInstanceQuota(req cloud.DescribeInstanceQuotaRequest) (cloud.DescribeInstanceQuotaResponse, error) {
+	request := ecs.CreateDescribeAccountAttributesRequest()
+	request.Scheme = "https"
+	request.RegionId = req.RegionId
+	request.ZoneId = req.ZoneId
+	request.AttributeName = &[]string{_attrMaxPostPaidVCpu, _attrUsedPostPaidVCpu}
+	response, err := p.client.DescribeAccountAttributes(request)
+	if err != nil {
+		logs.Logger.Errorf("DescribeAccountAttributes AlibabaCloud failed.err: [%v] req[%v]", err, req)
+		return cloud.DescribeInstanceQuotaResponse{}, err
+	}
+	ret := cloud.DescribeInstanceQuotaResponse{}
+	for _, item := range response.AccountAttributeItems.AccountAttributeItem {
+		if len(item.AttributeValues.ValueItem) == 0 {
+			continue
+		}
+		value := cast.ToInt(item.AttributeValues.ValueItem[0].Value)
+		switch item.AttributeName {
+		case _attrMaxPostPaidVCpu:
+			ret.VCpuLimit = value
+		case _attrUsedPostPaidVCpu:
+			ret.VCpuUsed = value
+		}
+	}
+	return ret, nil
+}
+
+func (p *AlibabaCloud) DescribePrice(req cloud.DescribePriceRequest) (cloud.DescribePriceResponse, error) {
+	m := req.Params
+	request := ecs.CreateDescribePriceRequest()
+	request.Scheme = "https"
+	request.RegionId = m.Region
+	request.ZoneId = m.Zone
+	request.ResourceType = "instance"
+	request.InstanceType = m.InstanceType
+	request.ImageId = m.ImageId
+	request.Amount = requests.NewInteger(req.Num)
+	if m.Network != nil && m.Network.InternetMaxBandwidthOut != 0 {
+		request.InternetChargeType = m.Network.InternetChargeType
+		request.InternetMaxBandwidthOut = requests.NewInteger(m.Network.InternetMaxBandwidthOut)
+	}
+	if m.Disks != nil {
+		request.SystemDiskCategory = m.Disks.SystemDisk.Category
+		request.SystemDiskSize = requests.NewInteger(m.Disks.SystemDisk.Size)
+		for i, disk := range m.Disks.DataDisk {
+			setPriceDataDisk(request, i+1, disk)
+		}
+	}
+	request.PriceUnit = cloud.PriceUnitHour
+	if m.Charge != nil && m.Charge.ChargeType == cloud.InstanceChargeTypePrePaid {
+		request.PriceUnit = m.Charge.PeriodUnit
+		request.Period = requests.NewInteger(m.Charge.Period)
+	}
+	response, err := p.client.DescribePrice(request)
+	if err != nil {
+		logs.Logger.Errorf("DescribePrice AlibabaCloud failed.err: [%v] req[%v]", err, req)
+		return cloud.DescribePriceResponse{}, err
+	}
+	return cloud.DescribePriceResponse{
+		Currency:   response.PriceInfo.Price.Currency,
+		TradePrice: response.PriceInfo.Price.TradePrice,
+		PriceUnit:  request.PriceUnit,
+	}, nil
+}
+
+// setPriceDataDisk DescribePrice最多支持4块数据盘
+func setPriceDataDisk(request *ecs.DescribePriceRequest, index int, disk cloud.DiskConf) {
+	size := requests.NewInteger(disk.Size)
+	switch index {
+	case 1:
+		request.DataDisk1Category, request.DataDisk1Size = disk.Category, size
+	case 2:
+		request.DataDisk2Category, request.DataDisk2Size = disk.Category, size
+	case 3:
+		request.DataDisk3Category, request.DataDisk3Size = disk.Category, size
+	case 4:
+		request.DataDisk4Category, request.DataDisk4Size = disk.Category, size
+	default:
+		logs.Logger.Warnf("DescribePrice ignore data disk %d", index)
+	}
+}
@@ -0,0 +1,47 @@
+package alibaba
+
+import (
+	"strings"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/sdk/requests"
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/ecs"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+	"github.com/galaxy-future/BridgX/pkg/utils"
+)
+
+// RenewInstances 阿里云每次只能续费一台实例, 单台失败时继续续费其余实例并返回逐台结果
+func (p *AlibabaCloud) RenewInstances(req cloud.RenewInstancesRequest) (cloud.RenewInstancesResponse, error) {
+	res := cloud.RenewInstancesResponse{Failed: make(map[string]string)}
+	for _, id := range req.InstanceIds {
+		request := ecs.CreateRenewInstanceRequest()
+		request.Scheme = "https"
+		request.InstanceId = id
+		request.Period = requests.NewInteger(req.Period)
+		request.PeriodUnit = req.PeriodUnit
+		_, err := p.client.RenewInstance(request)
+		if err != nil {
+			logs.Logger.Errorf("RenewInstance AlibabaCloud failed.err: [%v] instance[%v]", err, id)
+			res.Failed[id] = err.Error()
+			continue
+		}
+		res.RenewedIds = append(res.RenewedIds, id)
+	}
+	return res, nil
+}
+
+func (p *AlibabaCloud) ModifyInstancesRenewal(req cloud.ModifyInstancesRenewalRequest) error {
+	batchIds := utils.StringSliceSplit(req.InstanceIds, _maxNumEcsPerOperation)
+	for _, onceIds := range batchIds {
+		request := ecs.CreateModifyInstanceAutoRenewAttributeRequest()
+		request.Scheme = "https"
+		request.InstanceId = strings.Join(onceIds, ",")
+		request.RenewalStatus = req.RenewalStatus
+		_, err := p.client.ModifyInstanceAutoRenewAttribute(request)
+		if err != nil {
+			logs.Logger.Errorf("ModifyInstanceAutoRenewAttribute AlibabaCloud failed.err: [%v] req[%v]", err, req)
+			return err
+		}
+	}
+	return nil
+}
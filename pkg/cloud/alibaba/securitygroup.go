@@ -0,0 +1,49 @@
+package alibaba
+
+import (
+	ecsClient "github.com/alibabacloud-go/ecs-20140526/v2/client"
+	"github.com/alibabacloud-go/tea/tea"
+	"github.com/galaxy-future/BridgX/internal/logs"
+	"github.com/galaxy-future/BridgX/pkg/cloud"
+)
+
+//RevokeIngressSecurityGroupRule 规则的匹配条件与AddIngressSecurityGroupRule一致
+func (p *AlibabaCloud) RevokeIngressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	if req.GroupId == "" && req.CidrIp == "" && req.PrefixListId == "" {
+		req.CidrIp = "0.0.0.0/0"
+	}
+	request := &ecsClient.RevokeSecurityGroupRequest{
+		RegionId:           tea.String(req.RegionId),
+		SecurityGroupId:    tea.String(req.SecurityGroupId),
+		IpProtocol:         tea.String(_protocol[req.IpProtocol]),
+		PortRange:          tea.String(getPortRange(req.PortFrom, req.PortTo, req.IpProtocol)),
+		SourceGroupId:      tea.String(req.GroupId),
+		SourceCidrIp:       tea.String(req.CidrIp),
+		SourcePrefixListId: tea.String(req.PrefixListId),
+	}
+	if _, err := p.ecsClient.RevokeSecurityGroup(request); err != nil {
+		logs.Logger.Errorf("RevokeIngressSecurityGroupRule AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
+
+func (p *AlibabaCloud) RevokeEgressSecurityGroupRule(req cloud.AddSecurityGroupRuleRequest) error {
+	if req.GroupId == "" && req.CidrIp == "" && req.PrefixListId == "" {
+		req.CidrIp = "0.0.0.0/0"
+	}
+	request := &ecsClient.RevokeSecurityGroupEgressRequest{
+		RegionId:         tea.String(req.RegionId),
+		SecurityGroupId:  tea.String(req.SecurityGroupId),
+		IpProtocol:       tea.String(_protocol[req.IpProtocol]),
+		PortRange:        tea.String(getPortRange(req.PortFrom, req.PortTo, req.IpProtocol)),
+		DestGroupId:      tea.String(req.GroupId),
+		DestCidrIp:       tea.String(req.CidrIp),
+		DestPrefixListId: tea.String(req.PrefixListId),
+	}
+	if _, err := p.ecsClient.RevokeSecurityGroupEgress(request); err != nil {
+		logs.Logger.Errorf("RevokeEgressSecurityGroupRule AlibabaCloud failed.err: [%v], req[%v]", err, req)
+		return err
+	}
+	return nil
+}
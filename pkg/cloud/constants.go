@@ -10,6 +10,24 @@ const (
 	AWSCloud     = "AWSCloud"
 )
 
+//单次BatchCreate最多创建的实例数量, 超出部分由调用方拆分为多次调用
+var batchCreateMaxNum = map[string]int{
+	AlibabaCloud: 100, //RunInstances Amount上限
+	HuaweiCloud:  100,
+	TencentCloud: 100, //RunInstances InstanceCount上限
+	BaiduCloud:   100,
+	AWSCloud:     50, //单次RunInstances数量过大时容易整体触发InsufficientInstanceCapacity
+}
+
+const defaultBatchCreateMaxNum = 100
+
+func GetBatchCreateMaxNum(provider string) int {
+	if n, ok := batchCreateMaxNum[provider]; ok {
+		return n
+	}
+	return defaultBatchCreateMaxNum
+}
+
 const (
 	TaskId      = "TaskId"
 	ClusterName = "ClusterName"
@@ -110,3 +128,26 @@ const (
 	Year  = "Year"
 	Month = "Month"
 )
+
+const (
+	RenewalStatusNormal     = "Normal"
+	RenewalStatusNotRenewal = "NotRenewal"
+)
+
+const (
+	PriceUnitHour  = "Hour"
+	PriceUnitMonth = "Month"
+	PriceUnitYear  = "Year"
+)
+
+const (
+	NatGatewayPending   = "Pending"
+	NatGatewayAvailable = "Available"
+	NatGatewayDeleting  = "Deleting"
+)
+
+const (
+	RouteNextHopInstance   = "Instance"
+	RouteNextHopNatGateway = "NatGateway"
+	RouteNextHopPeering    = "VpcPeering"
+)
@@ -348,3 +348,209 @@ type KeyPair struct {
 	KeyPairId   string
 	KeyPairName string
 }
+
+type DescribeInstanceQuotaRequest struct {
+	RegionId   string
+	ZoneId     string
+	ChargeType string
+}
+
+type DescribeInstanceQuotaResponse struct {
+	VCpuLimit int
+	VCpuUsed  int
+}
+
+type DescribePriceRequest struct {
+	Params Params
+	Num    int
+}
+
+//DescribePriceResponse 按量付费返回每小时价格, 包年包月返回整个购买周期的价格
+type DescribePriceResponse struct {
+	Currency   string
+	TradePrice float64
+	PriceUnit  string
+}
+
+type RenewInstancesRequest struct {
+	InstanceIds []string
+	Period      int
+	PeriodUnit  string
+}
+
+//RenewInstancesResponse 逐台续费的结果, 单台续费失败不影响其他实例, Failed为失败的实例及原因
+type RenewInstancesResponse struct {
+	RenewedIds []string
+	Failed     map[string]string
+}
+
+//ModifyInstancesRenewalRequest RenewalStatus为RenewalStatusNotRenewal时实例到期后不再续费
+type ModifyInstancesRenewalRequest struct {
+	InstanceIds   []string
+	RenewalStatus string
+}
+
+//CreateNatGatewayRequest Bandwidth为NAT网关绑定的EIP带宽, 单位Mbps
+type CreateNatGatewayRequest struct {
+	RegionId  string
+	VpcId     string
+	SwitchId  string
+	Name      string
+	Bandwidth int
+}
+
+type CreateNatGatewayResponse struct {
+	NatGatewayId string
+	SnatTableId  string
+	EipId        string
+	EipAddress   string
+}
+
+type DescribeNatGatewaysRequest struct {
+	RegionId string
+	VpcId    string
+}
+
+type DescribeNatGatewaysResponse struct {
+	NatGateways []NatGateway
+}
+
+type NatGateway struct {
+	NatGatewayId string
+	Name         string
+	RegionId     string
+	VpcId        string
+	SwitchId     string
+	SnatTableId  string
+	IpAddresses  []string
+	Status       string
+	CreateAt     string
+}
+
+type DeleteNatGatewayRequest struct {
+	RegionId     string
+	NatGatewayId string
+}
+
+//CreateSnatEntryRequest 子网内的实例通过SnatIp访问公网
+type CreateSnatEntryRequest struct {
+	RegionId    string
+	SnatTableId string
+	SwitchId    string
+	SnatIp      string
+	Name        string
+}
+
+type CreateSnatEntryResponse struct {
+	SnatEntryId string
+}
+
+type DescribeSnatEntriesRequest struct {
+	RegionId    string
+	SnatTableId string
+}
+
+type DescribeSnatEntriesResponse struct {
+	SnatEntries []SnatEntry
+}
+
+type SnatEntry struct {
+	SnatEntryId string
+	SnatTableId string
+	Name        string
+	SwitchId    string
+	SourceCidr  string
+	SnatIp      string
+	Status      string
+}
+
+type DeleteSnatEntryRequest struct {
+	RegionId    string
+	SnatTableId string
+	SnatEntryId string
+}
+
+type CreateVpcPeeringRequest struct {
+	RegionId     string
+	VpcId        string
+	PeerRegionId string
+	PeerVpcId    string
+	Name         string
+}
+
+//CreateVpcPeeringResponse PeerPeeringId为对端的连接ID, 对端添加路由时作为下一跳
+type CreateVpcPeeringResponse struct {
+	PeeringId     string
+	PeerPeeringId string
+}
+
+type DescribeVpcPeeringsRequest struct {
+	RegionId string
+}
+
+type DescribeVpcPeeringsResponse struct {
+	Peerings []VpcPeering
+}
+
+type VpcPeering struct {
+	PeeringId     string
+	Name          string
+	RegionId      string
+	VpcId         string
+	PeerRegionId  string
+	PeerVpcId     string
+	PeerPeeringId string
+	Status        string
+	CreateAt      string
+}
+
+type DeleteVpcPeeringRequest struct {
+	RegionId  string
+	PeeringId string
+}
+
+//CreateRouteEntryRequest RouteTableId为空时添加到VPC的系统路由表
+type CreateRouteEntryRequest struct {
+	RegionId        string
+	VpcId           string
+	RouteTableId    string
+	DestinationCidr string
+	NextHopType     string
+	NextHopId       string
+	Name            string
+}
+
+//CreateRouteEntryResponse RouteEntryId及Status在云厂商未返回时为空
+type CreateRouteEntryResponse struct {
+	RouteTableId string
+	RouteEntryId string
+	Status       string
+}
+
+type DescribeRouteEntriesRequest struct {
+	RegionId string
+	VpcId    string
+}
+
+type DescribeRouteEntriesResponse struct {
+	RouteEntries []RouteEntry
+}
+
+type RouteEntry struct {
+	RouteEntryId    string
+	RouteTableId    string
+	VpcId           string
+	Name            string
+	DestinationCidr string
+	NextHopType     string
+	NextHopId       string
+	Status          string
+}
+
+type DeleteRouteEntryRequest struct {
+	RegionId        string
+	RouteTableId    string
+	RouteEntryId    string
+	DestinationCidr string
+	NextHopId       string
+}
@@ -38,3 +38,49 @@ var registeredPlugins = map[string]ProviderDriverFunc{}
 func RegisterProviderDriver(name string, f ProviderDriverFunc) {
 	registeredPlugins[name] = f
 }
+
+//以下为可选能力, 并非所有云厂商都实现, 使用时需做类型断言
+
+//PlanDescriber 扩容规划时查询配额及价格
+type PlanDescriber interface {
+	DescribeInstanceQuota(req DescribeInstanceQuotaRequest) (DescribeInstanceQuotaResponse, error)
+	DescribePrice(req DescribePriceRequest) (DescribePriceResponse, error)
+}
+
+//InstanceRenewer 包年包月实例续费及到期续费设置
+type InstanceRenewer interface {
+	RenewInstances(req RenewInstancesRequest) (RenewInstancesResponse, error)
+	ModifyInstancesRenewal(req ModifyInstancesRenewalRequest) error
+}
+
+//InstanceAdopter 纳管已有实例时按VPC查询实例并为实例打标签
+type InstanceAdopter interface {
+	GetInstancesByVpc(regionId, vpcId string) (instances []Instance, err error)
+	TagInstances(regionId string, ids []string, tags []Tag) error
+}
+
+//SecurityGroupRuleRevoker 删除安全组规则
+type SecurityGroupRuleRevoker interface {
+	RevokeIngressSecurityGroupRule(req AddSecurityGroupRuleRequest) error
+	RevokeEgressSecurityGroupRule(req AddSecurityGroupRuleRequest) error
+}
+
+//NatGatewayManager 管理NAT网关及SNAT条目
+type NatGatewayManager interface {
+	CreateNatGateway(req CreateNatGatewayRequest) (CreateNatGatewayResponse, error)
+	DescribeNatGateways(req DescribeNatGatewaysRequest) (DescribeNatGatewaysResponse, error)
+	DeleteNatGateway(req DeleteNatGatewayRequest) error
+	CreateSnatEntry(req CreateSnatEntryRequest) (CreateSnatEntryResponse, error)
+	DescribeSnatEntries(req DescribeSnatEntriesRequest) (DescribeSnatEntriesResponse, error)
+	DeleteSnatEntry(req DeleteSnatEntryRequest) error
+}
+
+//VpcPeeringManager 管理VPC对等连接及路由条目
+type VpcPeeringManager interface {
+	CreateVpcPeering(req CreateVpcPeeringRequest) (CreateVpcPeeringResponse, error)
+	DescribeVpcPeerings(req DescribeVpcPeeringsRequest) (DescribeVpcPeeringsResponse, error)
+	DeleteVpcPeering(req DeleteVpcPeeringRequest) error
+	CreateRouteEntry(req CreateRouteEntryRequest) (CreateRouteEntryResponse, error)
+	DescribeRouteEntries(req DescribeRouteEntriesRequest) (DescribeRouteEntriesResponse, error)
+	DeleteRouteEntry(req DeleteRouteEntryRequest) error
+}
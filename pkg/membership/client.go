@@ -0,0 +1,211 @@
+package membership
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	StatusRunning = "RUNNING"
+	HealthBad     = "UNHEALTHY"
+
+	defaultWaitTimeout   = 30 * time.Second
+	defaultRetryInterval = 3 * time.Second
+)
+
+//Membership 与BridgX发布的集群成员文档格式一致
+type Membership struct {
+	ClusterName string    `json:"cluster_name"`
+	Revision    int64     `json:"revision"`
+	UpdateAt    time.Time `json:"update_at"`
+	Members     []Member  `json:"members"`
+}
+
+type Member struct {
+	InstanceId string `json:"instance_id"`
+	IpInner    string `json:"ip_inner"`
+	IpOuter    string `json:"ip_outer"`
+	ZoneId     string `json:"zone_id"`
+	Status     string `json:"status"`
+	Health     string `json:"health"`
+}
+
+//WorkingIPs 返回运行中且未被判定为不健康的成员内网IP
+func (m *Membership) WorkingIPs() []string {
+	ips := make([]string, 0, len(m.Members))
+	for _, member := range m.Members {
+		if member.Status != StatusRunning || member.Health == HealthBad || member.IpInner == "" {
+			continue
+		}
+		ips = append(ips, member.IpInner)
+	}
+	return ips
+}
+
+type apiResponse struct {
+	Code int         `json:"code"`
+	Msg  string      `json:"msg"`
+	Data *Membership `json:"data"`
+}
+
+//Client 通过BridgX的长轮询接口维护集群成员的本地副本, 可嵌入服务发现客户端使用
+type Client struct {
+	endpoint      string
+	token         string
+	clusterName   string
+	waitTimeout   time.Duration
+	retryInterval time.Duration
+	httpClient    *http.Client
+	onChange      func(*Membership)
+
+	lock    sync.RWMutex
+	current *Membership
+}
+
+type Option func(*Client)
+
+//WithWaitTimeout 每次长轮询在服务端的最长等待时间, 服务端上限为60秒
+func WithWaitTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.waitTimeout = timeout
+	}
+}
+
+//WithRetryInterval 请求失败后重试的间隔
+func WithRetryInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.retryInterval = interval
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+//WithOnChange 成员变化时的回调, 在Run所在的goroutine中执行
+func WithOnChange(fn func(*Membership)) Option {
+	return func(c *Client) {
+		c.onChange = fn
+	}
+}
+
+//NewClient endpoint为BridgX API地址, 如http://127.0.0.1:9090, token为登录获取的jwt token
+func NewClient(endpoint, token, clusterName string, opts ...Option) *Client {
+	c := &Client{
+		endpoint:      strings.TrimRight(endpoint, "/"),
+		token:         token,
+		clusterName:   clusterName,
+		waitTimeout:   defaultWaitTimeout,
+		retryInterval: defaultRetryInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: c.waitTimeout + 10*time.Second}
+	}
+	return c
+}
+
+//Get 获取当前的集群成员
+func (c *Client) Get(ctx context.Context) (*Membership, error) {
+	return c.request(ctx, c.endpoint+"/api/v1/cluster/"+url.PathEscape(c.clusterName)+"/members")
+}
+
+//Watch 等待成员文档的版本与revision不同后返回, 超时未变化时返回的文档版本仍为revision
+func (c *Client) Watch(ctx context.Context, revision int64) (*Membership, error) {
+	query := url.Values{}
+	query.Set("revision", fmt.Sprint(revision))
+	query.Set("timeout", fmt.Sprint(int(c.waitTimeout/time.Second)))
+	return c.request(ctx, c.endpoint+"/api/v1/cluster/"+url.PathEscape(c.clusterName)+"/members/watch?"+query.Encode())
+}
+
+//Run 持续长轮询直到ctx结束, 成员变化时更新本地副本并回调, 请求失败时保留原有成员并按间隔重试
+func (c *Client) Run(ctx context.Context) error {
+	for {
+		var m *Membership
+		var err error
+		if current := c.Current(); current == nil {
+			m, err = c.Get(ctx)
+		} else {
+			m, err = c.Watch(ctx, current.Revision)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(c.retryInterval):
+			}
+			continue
+		}
+		c.update(m)
+	}
+}
+
+//Current 返回最近一次获取到的成员, 尚未获取时为nil
+func (c *Client) Current() *Membership {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.current
+}
+
+//WorkingIPs 返回本地副本中可用的成员IP
+func (c *Client) WorkingIPs() []string {
+	m := c.Current()
+	if m == nil {
+		return nil
+	}
+	return m.WorkingIPs()
+}
+
+func (c *Client) update(m *Membership) {
+	c.lock.Lock()
+	changed := c.current == nil || c.current.Revision != m.Revision
+	c.current = m
+	c.lock.Unlock()
+	if changed && c.onChange != nil {
+		c.onChange(m)
+	}
+}
+
+func (c *Client) request(ctx context.Context, u string) (*Membership, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	res := apiResponse{}
+	if err = jsoniter.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("invalid response, status: %v, body: %s", resp.StatusCode, body)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request failed, status: %v, msg: %v", resp.StatusCode, res.Msg)
+	}
+	if res.Data == nil {
+		return nil, errors.New("empty membership in response")
+	}
+	return res.Data, nil
+}
@@ -0,0 +1,71 @@
+package membership
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRun(t *testing.T) {
+	var revision int64 = 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":401,"msg":"token auth fail","data":null}`))
+			return
+		}
+		current := atomic.LoadInt64(&revision)
+		switch r.URL.Path {
+		case "/api/v1/cluster/c1/members":
+		case "/api/v1/cluster/c1/members/watch":
+			//第一次长轮询时模拟一次扩容, 之后成员不再变化
+			if current == 1 && r.URL.Query().Get("revision") == "1" {
+				current = atomic.AddInt64(&revision, 1)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		members := `{"instance_id":"i-1","ip_inner":"10.0.0.1","status":"RUNNING","health":"HEALTHY"}`
+		if current > 1 {
+			members += `,{"instance_id":"i-2","ip_inner":"10.0.0.2","status":"RUNNING"},{"instance_id":"i-3","ip_inner":"10.0.0.3","status":"RUNNING","health":"UNHEALTHY"}`
+		}
+		_, _ = fmt.Fprintf(w, `{"code":200,"msg":"success","data":{"cluster_name":"c1","revision":%d,"members":[%s]}}`, current, members)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	changes := make(chan *Membership, 10)
+	c := NewClient(server.URL, "token", "c1", WithOnChange(func(m *Membership) {
+		changes <- m
+	}))
+	go func() {
+		_ = c.Run(ctx)
+	}()
+	first := <-changes
+	if first.Revision != 1 || !reflect.DeepEqual(first.WorkingIPs(), []string{"10.0.0.1"}) {
+		t.Errorf("first membership = %+v", first)
+	}
+	second := <-changes
+	if second.Revision != 2 || !reflect.DeepEqual(second.WorkingIPs(), []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("second membership = %+v", second)
+	}
+	cancel()
+}
+
+func TestClientRequestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"code":500,"msg":"集群: [c1] 不存在","data":null}`))
+	}))
+	defer server.Close()
+	if _, err := NewClient(server.URL, "token", "c1").Get(context.Background()); err == nil {
+		t.Errorf("Get should fail when server returns error")
+	}
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const emailTimeout = 10 * time.Second
+
+type emailSender struct {
+	config Config
+}
+
+//Send 拨号及整个SMTP会话受ctx及emailTimeout限制, 避免SMTP服务无响应时阻塞调用方
+func (s *emailSender) Send(ctx context.Context, msg Message) error {
+	c := s.config
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+	addr := fmt.Sprintf("%s:%d", c.SmtpHost, c.SmtpPort)
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	//ctx提前取消时关闭连接以中断阻塞中的读写
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	client, err := smtp.NewClient(conn, c.SmtpHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: c.SmtpHost}); err != nil {
+			return err
+		}
+	}
+	if c.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.SmtpHost)); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, to := range c.To {
+		if err = client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(buildMail(c.From, c.To, msg)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildMail(from string, to []string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ",") + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Title) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Content, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestEmailSendTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	//只接受连接, 不发送SMTP问候
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	port := ln.Addr().(*net.TCPAddr).Port
+	s := &emailSender{config: Config{SmtpHost: "127.0.0.1", SmtpPort: port, From: "a@example.com", To: []string{"b@example.com"}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err = s.Send(ctx, Message{Title: "t", Content: "c"}); err == nil {
+		t.Errorf("Send to a hung smtp server should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send took %v, should be bounded by ctx", elapsed)
+	}
+	(<-accepted).Close()
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+const (
+	ChannelLark     = "lark"
+	ChannelDingTalk = "dingtalk"
+	ChannelWeCom    = "wecom"
+	ChannelWebhook  = "webhook" //兼容Slack Incoming Webhook格式
+	ChannelEmail    = "email"
+)
+
+const defaultTimeout = 3
+
+type Message struct {
+	Title   string
+	Content string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+//Config 各渠道共用的配置, 不同渠道只使用其中部分字段
+type Config struct {
+	HookId   string   `json:"hook_id,omitempty"` //lark
+	Webhook  string   `json:"webhook,omitempty"` //dingtalk, wecom, webhook
+	Secret   string   `json:"secret,omitempty"`  //dingtalk加签密钥
+	SmtpHost string   `json:"smtp_host,omitempty"`
+	SmtpPort int      `json:"smtp_port,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+func ParseConfig(s string) (Config, error) {
+	c := Config{}
+	if s == "" {
+		return c, nil
+	}
+	err := jsoniter.UnmarshalFromString(s, &c)
+	return c, err
+}
+
+//Validate 检查渠道必填配置
+func (c Config) Validate(channelType string) error {
+	switch channelType {
+	case ChannelLark:
+		if c.HookId == "" {
+			return fmt.Errorf("%v channel need hook_id", channelType)
+		}
+	case ChannelDingTalk, ChannelWeCom, ChannelWebhook:
+		if c.Webhook == "" {
+			return fmt.Errorf("%v channel need webhook", channelType)
+		}
+	case ChannelEmail:
+		if c.SmtpHost == "" || c.SmtpPort == 0 || c.From == "" || len(c.To) == 0 {
+			return fmt.Errorf("%v channel need smtp_host, smtp_port, from and to", channelType)
+		}
+	default:
+		return fmt.Errorf("unsupported notify channel type: %v", channelType)
+	}
+	return nil
+}
+
+func NewSender(channelType string, c Config) (Sender, error) {
+	if err := c.Validate(channelType); err != nil {
+		return nil, err
+	}
+	switch channelType {
+	case ChannelLark:
+		return &larkSender{hookId: c.HookId}, nil
+	case ChannelDingTalk:
+		return &dingTalkSender{webhook: c.Webhook, secret: c.Secret}, nil
+	case ChannelWeCom:
+		return &weComSender{webhook: c.Webhook}, nil
+	case ChannelWebhook:
+		return &webhookSender{webhook: c.Webhook}, nil
+	default:
+		return &emailSender{config: c}, nil
+	}
+}
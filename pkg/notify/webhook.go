@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/galaxy-future/BridgX/pkg/utils"
+	jsoniter "github.com/json-iterator/go"
+)
+
+type larkSender struct {
+	hookId string
+}
+
+func (s *larkSender) Send(ctx context.Context, msg Message) error {
+	return utils.LarkAlarm(ctx, s.hookId, msg.Title, msg.Content)
+}
+
+//robotResponse 钉钉及企业微信机器人的返回结构
+type robotResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+type dingTalkSender struct {
+	webhook string
+	secret  string
+}
+
+func (s *dingTalkSender) Send(ctx context.Context, msg Message) error {
+	addr := s.webhook
+	if s.secret != "" {
+		timestamp := time.Now().UnixNano() / int64(time.Millisecond)
+		addr = fmt.Sprintf("%s&timestamp=%d&sign=%s", addr, timestamp, url.QueryEscape(dingTalkSign(timestamp, s.secret)))
+	}
+	body := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": msg.Title,
+			"text":  "### " + msg.Title + "\n" + msg.Content,
+		},
+	}
+	return postRobot(addr, body)
+}
+
+//dingTalkSign 钉钉机器人加签: base64(hmac_sha256(timestamp+"\n"+secret))
+func dingTalkSign(timestamp int64, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+type weComSender struct {
+	webhook string
+}
+
+func (s *weComSender) Send(ctx context.Context, msg Message) error {
+	body := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": "### " + msg.Title + "\n" + msg.Content,
+		},
+	}
+	return postRobot(s.webhook, body)
+}
+
+func postRobot(addr string, body interface{}) error {
+	data, err := jsoniter.Marshal(body)
+	if err != nil {
+		return err
+	}
+	ret, err := utils.HttpPostJsonDataT(addr, data, defaultTimeout)
+	if err != nil {
+		return err
+	}
+	res := robotResponse{}
+	if err = jsoniter.Unmarshal(ret, &res); err != nil {
+		return fmt.Errorf("unmarshal robot response failed: %v, body: %s", err, ret)
+	}
+	if res.ErrCode != 0 {
+		return fmt.Errorf("robot response errcode: %d, errmsg: %s", res.ErrCode, res.ErrMsg)
+	}
+	return nil
+}
+
+type webhookSender struct {
+	webhook string
+}
+
+func (s *webhookSender) Send(ctx context.Context, msg Message) error {
+	data, err := jsoniter.Marshal(map[string]string{"text": "*" + msg.Title + "*\n" + msg.Content})
+	if err != nil {
+		return err
+	}
+	ret, err := utils.HttpPostJsonDataT(s.webhook, data, defaultTimeout)
+	if err != nil {
+		return err
+	}
+	//Slack成功时返回纯文本ok, 其他兼容实现可能返回空或JSON
+	if r := strings.TrimSpace(string(ret)); r != "" && r != "ok" && !strings.HasPrefix(r, "{") {
+		return fmt.Errorf("webhook response: %s", r)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestDingTalkSend(t *testing.T) {
+	secret := "SEC-test"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp, _ := strconv.ParseInt(r.URL.Query().Get("timestamp"), 10, 64)
+		h := hmac.New(sha256.New, []byte(secret))
+		h.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+		if r.URL.Query().Get("sign") != base64.StdEncoding.EncodeToString(h.Sum(nil)) {
+			_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if jsoniter.Get(body, "msgtype").ToString() != "markdown" {
+			_, _ = w.Write([]byte(`{"errcode":40035,"errmsg":"invalid msgtype"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(ChannelDingTalk, Config{Webhook: server.URL + "/robot/send?access_token=t", Secret: secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sender.Send(context.Background(), Message{Title: "title", Content: "content"}); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+
+	sender, _ = NewSender(ChannelDingTalk, Config{Webhook: server.URL + "/robot/send?access_token=t", Secret: "wrong"})
+	if err = sender.Send(context.Background(), Message{Title: "title", Content: "content"}); err == nil {
+		t.Errorf("Send() with wrong secret want error")
+	}
+}
+
+func TestWebhookSend(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = jsoniter.Get(body, "text").ToString()
+		if got == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid_payload"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	sender, err := NewSender(ChannelWebhook, Config{Webhook: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sender.Send(context.Background(), Message{Title: "title", Content: "content"}); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+	if want := "*title*\ncontent"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestNewSenderValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		channelType string
+		config      Config
+		wantErr     bool
+	}{
+		{name: "lark", channelType: ChannelLark, config: Config{HookId: "hook"}},
+		{name: "lark without hook id", channelType: ChannelLark, wantErr: true},
+		{name: "wecom without webhook", channelType: ChannelWeCom, wantErr: true},
+		{name: "email without receiver", channelType: ChannelEmail, config: Config{SmtpHost: "smtp", SmtpPort: 25, From: "a@b.c"}, wantErr: true},
+		{name: "email", channelType: ChannelEmail, config: Config{SmtpHost: "smtp", SmtpPort: 25, From: "a@b.c", To: []string{"d@e.f"}}},
+		{name: "unknown", channelType: "sms", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSender(tt.channelType, tt.config); (err != nil) != tt.wantErr {
+				t.Errorf("NewSender() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
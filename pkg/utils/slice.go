@@ -83,3 +83,12 @@ func Intersect(lists [][]string) []string {
 	}
 	return inter
 }
+
+func ContainsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,3 +30,55 @@ func SshCheck(ip, user, pwd string) bool {
 	session.Close()
 	return true
 }
+
+// SshRun 登录目的机器执行命令, 命令退出码非0时返回错误. privateKey不为空时使用秘钥认证
+func SshRun(ip string, port int, user, pwd, privateKey, cmd string, timeout time.Duration) (string, error) {
+	var auth ssh.AuthMethod
+	if privateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return "", err
+		}
+		auth = ssh.PublicKeys(signer)
+	} else if pwd != "" {
+		auth = ssh.Password(strings.TrimSpace(pwd))
+	} else {
+		return "", errors.New("missing ssh password or private key")
+	}
+	addr := net.JoinHostPort(strings.TrimSpace(ip), strconv.Itoa(port))
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            strings.TrimSpace(user),
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	//命令本身没有超时, 到期后关闭session使CombinedOutput返回, 避免卡住的机器阻塞调用方
+	type result struct {
+		out []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := session.CombinedOutput(cmd)
+		done <- result{out: out, err: err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return string(r.out), r.err
+	case <-timer.C:
+		_ = session.Close()
+		_ = client.Close()
+		return "", fmt.Errorf("run command on %v timeout after %v", addr, timeout)
+	}
+}
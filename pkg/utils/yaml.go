@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+	"gopkg.in/yaml.v2"
+)
+
+//JSONToYAML 将JSON转换为YAML, 保留字段顺序
+func JSONToYAML(j []byte) ([]byte, error) {
+	var obj yaml.MapSlice
+	if err := yaml.Unmarshal(j, &obj); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(obj)
+}
+
+//YAMLToJSON 将YAML转换为JSON, 便于复用结构体的json tag解析
+func YAMLToJSON(y []byte) ([]byte, error) {
+	var obj interface{}
+	if err := yaml.Unmarshal(y, &obj); err != nil {
+		return nil, err
+	}
+	return jsoniter.Marshal(convertYAMLValue(obj))
+}
+
+//convertYAMLValue yaml.v2解析出的map key为interface{}, 需转换为string才能序列化为JSON
+func convertYAMLValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprintf("%v", k)] = convertYAMLValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = convertYAMLValue(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
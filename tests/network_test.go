@@ -437,7 +437,6 @@ func TestGetSecurityGroup(t *testing.T) {
 	}
 }
 
-//使用本函数前需要将 updateOrCreateSecurityGroupRules 改为同步调用
 func TestSyncNetwork(t *testing.T) {
 	accounts := make([]model.Account, 0)
 	if err := model.QueryAll(map[string]interface{}{}, &accounts, ""); err != nil {
@@ -446,9 +445,9 @@ func TestSyncNetwork(t *testing.T) {
 	}
 
 	for _, account := range accounts {
-		err := service.RefreshAccount(&service.SimpleTask{
-			ProviderName: account.Provider,
-			AccountKey:   account.AccountKey,
+		err := service.RefreshAccount(context.Background(), service.JobPayload{
+			Provider:   account.Provider,
+			AccountKey: account.AccountKey,
 		})
 		if err != nil {
 			t.Log(account.AccountKey, err)